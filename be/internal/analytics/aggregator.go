@@ -0,0 +1,382 @@
+package analytics
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"biomu/backend/internal/firebase"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+const (
+	GranularityHour = "hour"
+	GranularityDay  = "day"
+
+	topN = 10
+
+	// Event yang datang terlambat tetap masuk karena beberapa jam terakhir selalu dihitung ulang.
+	lateEventWindow = 2 * time.Hour
+	// Bucket harian yang masih terbuka dihitung ulang paling sering sekali per dayRefresh; setelah
+	// hari (plus lateEventWindow) lewat, bucket itu dihitung sekali lagi sebagai hasil final.
+	dayRefresh = time.Hour
+	stateDocID = "aggregator"
+)
+
+// Rollup is one aggregated bucket for a profile (LinkID == "") or a single link.
+type Rollup struct {
+	ProfileID      string                 `firestore:"profileId"`
+	LinkID         string                 `firestore:"linkId"`
+	Granularity    string                 `firestore:"granularity"`
+	Bucket         time.Time              `firestore:"bucket"`
+	Views          int64                  `firestore:"views"`
+	Clicks         int64                  `firestore:"clicks"`
+	UniqueVisitors int64                  `firestore:"uniqueVisitors"`
+	CTR            float64                `firestore:"ctr"`
+	Referrers      map[string]int64       `firestore:"referrers"`
+	Sources        map[string]int64       `firestore:"sources,omitempty"`
+	Devices        map[string]int64       `firestore:"devices"`
+	Countries      map[string]int64       `firestore:"countries"`
+	Cities         map[string]int64       `firestore:"cities"`
+	Browsers       map[string]int64       `firestore:"browsers"`
+	OS             map[string]int64       `firestore:"os"`
+	Links          map[string]LinkSummary `firestore:"links,omitempty"`
+	// Hit bot/suspicious tidak dihitung di views/clicks, hanya dicatat jumlahnya per class
+	Filtered  map[string]int64 `firestore:"filtered,omitempty"`
+	UpdatedAt time.Time        `firestore:"updatedAt"`
+}
+
+type LinkSummary struct {
+	Clicks         int64 `firestore:"clicks" json:"clicks"`
+	UniqueVisitors int64 `firestore:"uniqueVisitors" json:"uniqueVisitors"`
+}
+
+// Aggregator menggulung raw event menjadi bucket per jam dan per hari (UTC).
+// Setiap bucket selalu dihitung ulang penuh dari raw event lalu ditimpa dengan
+// ID dokumen deterministik, jadi menjalankan ulang (atau backfill) aman/idempotent.
+// Run hanya menghitung bucket yang masih bisa berubah (lihat plan), bukan seluruh hari.
+type Aggregator struct {
+	fb          *firebase.App
+	eventsColl  string
+	rollupsColl string
+	interval    time.Duration
+	now         func() time.Time
+}
+
+func NewAggregator(fb *firebase.App, eventsColl, rollupsColl string, interval time.Duration) *Aggregator {
+	return &Aggregator{fb: fb, eventsColl: eventsColl, rollupsColl: rollupsColl, interval: interval, now: time.Now}
+}
+
+// aggregatorState is the checkpoint stored between ticks.
+type aggregatorState struct {
+	// LastRun: watermark; event sebelum LastRun - lateEventWindow sudah masuk bucket final
+	LastRun time.Time `firestore:"lastRun"`
+	// DayBuiltAt: kapan bucket harian yang masih terbuka terakhir dihitung
+	DayBuiltAt time.Time `firestore:"dayBuiltAt,omitempty"`
+}
+
+func (a *Aggregator) RollupsCollection() string { return a.rollupsColl }
+
+// Run aggregates periodically until ctx is cancelled, resuming from the stored checkpoint.
+func (a *Aggregator) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for {
+		if err := a.tick(ctx); err != nil {
+			log.Printf("analytics aggregator: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *Aggregator) tick(ctx context.Context) error {
+	now := a.now().UTC()
+	stateRef := a.fb.DB.Collection(a.rollupsColl + "_state").Doc(stateDocID)
+	var st aggregatorState
+	if snap, err := stateRef.Get(ctx); err == nil {
+		if err := snap.DataTo(&st); err != nil {
+			log.Printf("analytics aggregator state: %v", err)
+		}
+	}
+	hours, days, next := plan(st, now)
+	for _, h := range hours {
+		if err := a.rollupBucket(ctx, GranularityHour, h, h.Add(time.Hour)); err != nil {
+			return err
+		}
+	}
+	for _, d := range days {
+		if err := a.rollupBucket(ctx, GranularityDay, d, d.AddDate(0, 0, 1)); err != nil {
+			return err
+		}
+	}
+	_, err := stateRef.Set(ctx, next)
+	return err
+}
+
+// plan returns the hourly and daily buckets a tick at now has to rebuild, and the next state.
+// Jam dihitung ulang mulai dari watermark dikurangi lateEventWindow (jadi setelah downtime jam
+// yang terlewat ikut dikejar). Hari yang baru saja ditutup selalu dihitung final; hari yang
+// masih terbuka (termasuk kemarin selama lateEventWindow) hanya jika sudah dayRefresh berlalu.
+func plan(st aggregatorState, now time.Time) (hours, days []time.Time, next aggregatorState) {
+	now = now.UTC()
+	from := now.Add(-lateEventWindow)
+	if !st.LastRun.IsZero() && st.LastRun.Add(-lateEventWindow).Before(from) {
+		from = st.LastRun.Add(-lateEventWindow)
+	}
+	for h := from.Truncate(time.Hour); h.Before(now); h = h.Add(time.Hour) {
+		hours = append(hours, h)
+	}
+
+	next = aggregatorState{LastRun: now, DayBuiltAt: st.DayBuiltAt}
+	refresh := st.DayBuiltAt.IsZero() || now.Sub(st.DayBuiltAt) >= dayRefresh
+	if refresh {
+		next.DayBuiltAt = now
+	}
+	for d := dayStart(from); d.Before(now); d = d.AddDate(0, 0, 1) {
+		closesAt := d.AddDate(0, 0, 1).Add(lateEventWindow)
+		switch {
+		case !now.Before(closesAt):
+			// Sudah final: hitung sekali, pada tick pertama setelah hari ditutup
+			if st.LastRun.IsZero() || st.LastRun.Before(closesAt) {
+				days = append(days, d)
+			}
+		case refresh:
+			days = append(days, d)
+		}
+	}
+	return hours, days, next
+}
+
+// Rollup recomputes every hourly bucket touching [from, to) and the daily buckets containing them.
+func (a *Aggregator) Rollup(ctx context.Context, from, to time.Time) error {
+	from = from.UTC().Truncate(time.Hour)
+	to = to.UTC()
+	for h := from; h.Before(to); h = h.Add(time.Hour) {
+		if err := a.rollupBucket(ctx, GranularityHour, h, h.Add(time.Hour)); err != nil {
+			return err
+		}
+	}
+	for d := dayStart(from); d.Before(to); d = d.AddDate(0, 0, 1) {
+		if err := a.rollupBucket(ctx, GranularityDay, d, d.AddDate(0, 0, 1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type rollupKey struct {
+	profileID string
+	linkID    string
+}
+
+type accumulator struct {
+	rollup   Rollup
+	visitors map[string]struct{}
+	// per link di dalam rollup profil
+	linkVisitors map[string]map[string]struct{}
+}
+
+func (a *Aggregator) rollupBucket(ctx context.Context, granularity string, start, end time.Time) error {
+	it := a.fb.DB.Collection(a.eventsColl).
+		Where("ts", ">=", start).
+		Where("ts", "<", end).
+		Documents(ctx)
+	defer it.Stop()
+
+	accs := map[rollupKey]*accumulator{}
+	get := func(k rollupKey) *accumulator {
+		acc, ok := accs[k]
+		if !ok {
+			acc = &accumulator{
+				rollup: Rollup{
+					ProfileID:   k.profileID,
+					LinkID:      k.linkID,
+					Granularity: granularity,
+					Bucket:      start,
+					Referrers:   map[string]int64{},
+					Sources:     map[string]int64{},
+					Devices:     map[string]int64{},
+					Countries:   map[string]int64{},
+					Cities:      map[string]int64{},
+					Browsers:    map[string]int64{},
+					OS:          map[string]int64{},
+				},
+				visitors:     map[string]struct{}{},
+				linkVisitors: map[string]map[string]struct{}{},
+			}
+			accs[k] = acc
+		}
+		return acc
+	}
+
+	for {
+		doc, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		var e Event
+		if err := doc.DataTo(&e); err != nil || e.ProfileID == "" {
+			continue
+		}
+		add(get(rollupKey{profileID: e.ProfileID}), e, true)
+		if e.LinkID != "" && e.Type == EventClick {
+			add(get(rollupKey{profileID: e.ProfileID, linkID: e.LinkID}), e, false)
+		}
+	}
+	if len(accs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	bw := a.fb.DB.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, 0, len(accs))
+	for k, acc := range accs {
+		r := acc.rollup
+		r.UniqueVisitors = int64(len(acc.visitors))
+		if k.linkID != "" {
+			// Link tampil di setiap view profil, jadi impresi link = view profil pada bucket yang sama
+			if p, ok := accs[rollupKey{profileID: k.profileID}]; ok {
+				r.Views = p.rollup.Views
+			}
+		} else if len(acc.linkVisitors) > 0 {
+			r.Links = make(map[string]LinkSummary, len(acc.linkVisitors))
+			for linkID, visitors := range acc.linkVisitors {
+				s := acc.rollup.Links[linkID]
+				s.UniqueVisitors = int64(len(visitors))
+				r.Links[linkID] = s
+			}
+		}
+		if r.Views > 0 {
+			r.CTR = float64(r.Clicks) / float64(r.Views)
+		}
+		r.Referrers = topKeys(r.Referrers, topN)
+		r.Sources = topKeys(r.Sources, topN)
+		r.Devices = topKeys(r.Devices, topN)
+		r.Countries = topKeys(r.Countries, topN)
+		r.Cities = topKeys(r.Cities, topN)
+		r.Browsers = topKeys(r.Browsers, topN)
+		r.OS = topKeys(r.OS, topN)
+		r.UpdatedAt = now
+		ref := a.fb.DB.Collection(a.rollupsColl).Doc(RollupID(k.profileID, k.linkID, granularity, start))
+		job, err := bw.Set(ref, r)
+		if err != nil {
+			bw.End()
+			return err
+		}
+		jobs = append(jobs, job)
+	}
+	bw.End()
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func add(acc *accumulator, e Event, trackLinks bool) {
+	r := &acc.rollup
+	if !e.Human() {
+		if r.Filtered == nil {
+			r.Filtered = map[string]int64{}
+		}
+		r.Filtered[e.Class]++
+		return
+	}
+	switch e.Type {
+	case EventView:
+		r.Views++
+	case EventClick:
+		r.Clicks++
+		if trackLinks && e.LinkID != "" {
+			if r.Links == nil {
+				r.Links = map[string]LinkSummary{}
+			}
+			s := r.Links[e.LinkID]
+			s.Clicks++
+			r.Links[e.LinkID] = s
+			if acc.linkVisitors[e.LinkID] == nil {
+				acc.linkVisitors[e.LinkID] = map[string]struct{}{}
+			}
+			if e.VisitorID != "" {
+				acc.linkVisitors[e.LinkID][e.VisitorID] = struct{}{}
+			}
+		}
+	default:
+		return
+	}
+	if e.VisitorID != "" {
+		acc.visitors[e.VisitorID] = struct{}{}
+	}
+	if e.Referrer != "" {
+		r.Referrers[e.Referrer]++
+	}
+	if e.Source != "" {
+		r.Sources[e.Source]++
+	}
+	if e.UAClass != "" {
+		r.Devices[e.UAClass]++
+	}
+	if e.Country != "" {
+		r.Countries[e.Country]++
+	}
+	if e.City != "" {
+		// Nama kota bisa sama di negara berbeda, jadi diberi prefix kode negara
+		r.Cities[e.Country+"/"+e.City]++
+	}
+	if e.Browser != "" {
+		r.Browsers[e.Browser]++
+	}
+	if e.OS != "" {
+		r.OS[e.OS]++
+	}
+}
+
+// RollupID is the deterministic document ID of a bucket.
+func RollupID(profileID, linkID, granularity string, bucket time.Time) string {
+	id := profileID
+	if linkID != "" {
+		id += "_" + linkID
+	}
+	layout := "2006010215"
+	if granularity == GranularityDay {
+		layout = "20060102"
+	}
+	return id + "_" + granularity + "_" + bucket.UTC().Format(layout)
+}
+
+func dayStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func topKeys(m map[string]int64, n int) map[string]int64 {
+	if len(m) <= n {
+		return m
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if m[keys[i]] != m[keys[j]] {
+			return m[keys[i]] > m[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	out := make(map[string]int64, n)
+	for _, k := range keys[:n] {
+		out[k] = m[k]
+	}
+	return out
+}
@@ -0,0 +1,106 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlan(t *testing.T) {
+	at := func(day, hour, min int) time.Time { return time.Date(2026, 3, day, hour, min, 0, 0, time.UTC) }
+	tests := []struct {
+		name      string
+		state     aggregatorState
+		now       time.Time
+		wantHours []time.Time
+		wantDays  []time.Time
+		refreshed bool
+	}{
+		{
+			name:      "first run",
+			now:       at(2, 10, 30),
+			wantHours: []time.Time{at(2, 8, 0), at(2, 9, 0), at(2, 10, 0)},
+			wantDays:  []time.Time{at(2, 0, 0)},
+			refreshed: true,
+		},
+		{
+			name:      "next tick skips the open day",
+			state:     aggregatorState{LastRun: at(2, 10, 30), DayBuiltAt: at(2, 10, 30)},
+			now:       at(2, 10, 35),
+			wantHours: []time.Time{at(2, 8, 0), at(2, 9, 0), at(2, 10, 0)},
+		},
+		{
+			name:      "open day refreshed after dayRefresh",
+			state:     aggregatorState{LastRun: at(2, 11, 25), DayBuiltAt: at(2, 10, 30)},
+			now:       at(2, 11, 30),
+			wantHours: []time.Time{at(2, 9, 0), at(2, 10, 0), at(2, 11, 0)},
+			wantDays:  []time.Time{at(2, 0, 0)},
+			refreshed: true,
+		},
+		{
+			name:      "yesterday still open for late events",
+			state:     aggregatorState{LastRun: at(3, 0, 55), DayBuiltAt: at(3, 0, 5)},
+			now:       at(3, 1, 0),
+			wantHours: []time.Time{at(2, 22, 0), at(2, 23, 0), at(3, 0, 0)},
+		},
+		{
+			name:      "yesterday closed once",
+			state:     aggregatorState{LastRun: at(3, 1, 55), DayBuiltAt: at(3, 1, 30)},
+			now:       at(3, 2, 0),
+			wantHours: []time.Time{at(2, 23, 0), at(3, 0, 0), at(3, 1, 0)},
+			wantDays:  []time.Time{at(2, 0, 0)},
+		},
+		{
+			name:      "closed day not rebuilt again",
+			state:     aggregatorState{LastRun: at(3, 2, 0), DayBuiltAt: at(3, 1, 30)},
+			now:       at(3, 2, 5),
+			wantHours: []time.Time{at(3, 0, 0), at(3, 1, 0), at(3, 2, 0)},
+		},
+		{
+			name:      "catch up after downtime",
+			state:     aggregatorState{LastRun: at(1, 23, 0), DayBuiltAt: at(1, 22, 30)},
+			now:       at(2, 0, 10),
+			wantHours: []time.Time{at(1, 21, 0), at(1, 22, 0), at(1, 23, 0), at(2, 0, 0)},
+			wantDays:  []time.Time{at(1, 0, 0), at(2, 0, 0)},
+			refreshed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hours, days, next := plan(tt.state, tt.now)
+			if !equalTimes(hours, tt.wantHours) {
+				t.Errorf("hours = %v, want %v", hours, tt.wantHours)
+			}
+			if !equalTimes(days, tt.wantDays) {
+				t.Errorf("days = %v, want %v", days, tt.wantDays)
+			}
+			if !next.LastRun.Equal(tt.now) {
+				t.Errorf("lastRun = %v, want %v", next.LastRun, tt.now)
+			}
+			if got := next.DayBuiltAt.Equal(tt.now); got != tt.refreshed {
+				t.Errorf("dayBuiltAt = %v, refreshed %v", next.DayBuiltAt, tt.refreshed)
+			}
+		})
+	}
+}
+
+func equalTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRollupID(t *testing.T) {
+	bucket := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	if got := RollupID("p1", "", GranularityHour, bucket); got != "p1_hour_2026030210" {
+		t.Errorf("hour = %s", got)
+	}
+	if got := RollupID("p1", "l1", GranularityDay, bucket.In(time.FixedZone("WIB", 7*3600))); got != "p1_l1_day_20260302" {
+		t.Errorf("day = %s", got)
+	}
+}
@@ -0,0 +1,552 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"biomu/backend/internal/botfilter"
+	"biomu/backend/internal/experiment"
+	"biomu/backend/internal/firebase"
+	"biomu/backend/internal/profile"
+	"biomu/backend/internal/ratelimit"
+	"biomu/backend/internal/visitor"
+
+	"cloud.google.com/go/firestore"
+)
+
+const (
+	beaconMaxBytes = 2 << 10
+	maxHourRange   = 31 * 24 * time.Hour
+	maxDayRange    = 366 * 24 * time.Hour
+
+	// Beacon publik: per pengunjung (IP client) per profil, dan total per profil
+	beaconVisitorLimit = 60
+	beaconProfileLimit = 6000
+	beaconPeriod       = time.Minute
+
+	// Daftar link per profil untuk validasi klik dan impression A/B test
+	linksCacheTTL        = time.Minute
+	linksCacheMaxEntries = 10000
+)
+
+// Sessions resolves the signed-in caller (implemented by auth.Handler).
+type Sessions interface {
+	SessionUID(r *http.Request) string
+}
+
+type Handler struct {
+	fb         *firebase.App
+	profiles   *profile.Store
+	sessions   Sessions
+	recorder   *Recorder
+	aggregator *Aggregator
+	visitors   *visitor.Identifier
+	bots       *botfilter.Classifier
+	counters   *experiment.Counters
+
+	byVisitor *ratelimit.Limiter
+	byProfile *ratelimit.Limiter
+
+	mu    sync.Mutex
+	links map[string]linksEntry
+	now   func() time.Time
+}
+
+type linksEntry struct {
+	links []profile.Link
+	exp   time.Time
+}
+
+func NewHandler(fb *firebase.App, profiles *profile.Store, sessions Sessions, recorder *Recorder, aggregator *Aggregator, visitors *visitor.Identifier, bots *botfilter.Classifier, counters *experiment.Counters) *Handler {
+	return &Handler{
+		fb:         fb,
+		profiles:   profiles,
+		sessions:   sessions,
+		recorder:   recorder,
+		aggregator: aggregator,
+		visitors:   visitors,
+		bots:       bots,
+		counters:   counters,
+		byVisitor:  ratelimit.New(beaconVisitorLimit, beaconPeriod),
+		byProfile:  ratelimit.New(beaconProfileLimit, beaconPeriod),
+		links:      map[string]linksEntry{},
+		now:        time.Now,
+	}
+}
+
+// Run garbage-collects the beacon rate limit windows and expired link lists until ctx is done.
+func (h *Handler) Run(ctx context.Context) {
+	ticker := time.NewTicker(beaconPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := h.now()
+			h.byVisitor.GC(now)
+			h.byProfile.GC(now)
+			h.mu.Lock()
+			for id, e := range h.links {
+				if !now.Before(e.exp) {
+					delete(h.links, id)
+				}
+			}
+			h.mu.Unlock()
+		}
+	}
+}
+
+// publicLinks returns the visible links of a profile, cached for linksCacheTTL.
+func (h *Handler) publicLinks(ctx context.Context, profileID string) ([]profile.Link, error) {
+	now := h.now()
+	h.mu.Lock()
+	e, ok := h.links[profileID]
+	h.mu.Unlock()
+	if ok && now.Before(e.exp) {
+		return profile.PublicLinks(e.links, now), nil
+	}
+	links, err := h.profiles.Links(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	h.mu.Lock()
+	if len(h.links) >= linksCacheMaxEntries {
+		h.links = make(map[string]linksEntry)
+	}
+	h.links[profileID] = linksEntry{links: links, exp: now.Add(linksCacheTTL)}
+	h.mu.Unlock()
+	return profile.PublicLinks(links, now), nil
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// POST /api/public/events — beacon view/click dari halaman bio (navigator.sendBeacon, tanpa session)
+func (h *Handler) Beacon(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var body struct {
+		Type      string `json:"type"`
+		Handle    string `json:"handle"`
+		ProfileID string `json:"profileId"`
+		LinkID    string `json:"linkId"`
+		// Source: utm_source dari URL halaman (mis. "qr")
+		Source string `json:"source"`
+	}
+	// sendBeacon mengirim text/plain, jadi Content-Type tidak diperiksa
+	if err := json.NewDecoder(io.LimitReader(r.Body, beaconMaxBytes)).Decode(&body); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+	if body.Type != EventView && body.Type != EventClick {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "type must be view or click"})
+		return
+	}
+	if body.Type == EventClick && body.LinkID == "" {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "linkId is required for click"})
+		return
+	}
+
+	target := "id:" + body.ProfileID
+	if body.ProfileID == "" {
+		target = "handle:" + strings.ToLower(body.Handle)
+	}
+	if body.ProfileID == "" && body.Handle == "" {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "handle or profileId is required"})
+		return
+	}
+	limited := func(wait time.Duration) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		h.writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "too many events"})
+	}
+	// Dibatasi sebelum membaca Firestore
+	if ok, wait := h.byVisitor.Take(visitor.RateKey(r)+"\x00"+target, h.now()); !ok {
+		limited(wait)
+		return
+	}
+
+	ctx := r.Context()
+	var (
+		p   *profile.Profile
+		err error
+	)
+	if body.ProfileID != "" {
+		p, err = h.profiles.FindByID(ctx, body.ProfileID)
+	} else {
+		p, err = h.profiles.FindByHandle(ctx, body.Handle)
+	}
+	if err != nil {
+		log.Printf("analytics beacon profile: %v", err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to record event"})
+		return
+	}
+	if p == nil {
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "profile not found"})
+		return
+	}
+	if ok, wait := h.byProfile.Take(p.ID, h.now()); !ok {
+		limited(wait)
+		return
+	}
+	if body.LinkID != "" {
+		links, err := h.publicLinks(ctx, p.ID)
+		if err != nil {
+			log.Printf("analytics beacon links: %v", err)
+			h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to record event"})
+			return
+		}
+		if !hasLink(links, body.LinkID) {
+			h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "link not found"})
+			return
+		}
+	}
+
+	visitorID := h.visitors.VisitorID(r, p.ID)
+	verdict := h.bots.Classify(botfilter.Hit{Request: r, ProfileID: p.ID, VisitorID: visitorID})
+	if verdict.Human() && body.Type == EventView {
+		// Beacon hanya terkirim jika JavaScript jalan; dipakai heuristik klik di /r/{linkId}
+		h.bots.ObserveBeacon(visitorID, p.ID)
+		h.countImpressions(ctx, p.ID, visitorID)
+	}
+	e := Event{
+		Type:      body.Type,
+		ProfileID: p.ID,
+		LinkID:    body.LinkID,
+		Timestamp: time.Now(),
+		Referrer:  ReferrerHost(r),
+		Source:    NormalizeSource(body.Source),
+		VisitorID: visitorID,
+		Class:     verdict.Class,
+		Reason:    verdict.Reason,
+	}
+	e.Enrich(r)
+	h.recorder.Record(e)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// countImpressions counts one impression for the variant this visitor sees on every link
+// with a running experiment. The assignment is recomputed server-side, not taken from the client.
+func (h *Handler) countImpressions(ctx context.Context, profileID, visitorID string) {
+	if h.counters == nil || visitorID == "" {
+		return
+	}
+	links, err := h.publicLinks(ctx, profileID)
+	if err != nil {
+		log.Printf("analytics beacon impressions %s: %v", profileID, err)
+		return
+	}
+	for _, l := range links {
+		exp, ok := experiment.FromLink(l)
+		if !ok {
+			continue
+		}
+		if v := exp.Assign(l.ID, visitorID); v != nil {
+			h.counters.Impression(l.ID, v.ID)
+		}
+	}
+}
+
+func hasLink(links []profile.Link, id string) bool {
+	for _, l := range links {
+		if l.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+type bucketResponse struct {
+	Bucket         int64                  `json:"bucket"`
+	Views          int64                  `json:"views"`
+	Clicks         int64                  `json:"clicks"`
+	UniqueVisitors int64                  `json:"uniqueVisitors"`
+	CTR            float64                `json:"ctr"`
+	Referrers      map[string]int64       `json:"referrers,omitempty"`
+	Sources        map[string]int64       `json:"sources,omitempty"`
+	Devices        map[string]int64       `json:"devices,omitempty"`
+	Countries      map[string]int64       `json:"countries,omitempty"`
+	Cities         map[string]int64       `json:"cities,omitempty"`
+	Browsers       map[string]int64       `json:"browsers,omitempty"`
+	OS             map[string]int64       `json:"os,omitempty"`
+	Links          map[string]LinkSummary `json:"links,omitempty"`
+	Filtered       map[string]int64       `json:"filtered,omitempty"`
+}
+
+type totalsResponse struct {
+	Views     int64                  `json:"views"`
+	Clicks    int64                  `json:"clicks"`
+	CTR       float64                `json:"ctr"`
+	Referrers map[string]int64       `json:"referrers"`
+	Sources   map[string]int64       `json:"sources"`
+	Devices   map[string]int64       `json:"devices"`
+	Countries map[string]int64       `json:"countries"`
+	Cities    map[string]int64       `json:"cities"`
+	Browsers  map[string]int64       `json:"browsers"`
+	OS        map[string]int64       `json:"os"`
+	Links     map[string]LinkSummary `json:"links,omitempty"`
+	Filtered  map[string]int64       `json:"filtered,omitempty"`
+}
+
+// GET /api/analytics/{profileId}?from=&to=&granularity=hour|day[&linkId=]
+func (h *Handler) Query(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	profileID := r.PathValue("profileId")
+	if profileID == "" {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "profileId is required"})
+		return
+	}
+	if status, msg := h.authorize(r, profileID); status != 0 {
+		h.writeJSON(w, status, map[string]string{"error": msg})
+		return
+	}
+
+	q := r.URL.Query()
+	granularity := q.Get("granularity")
+	if granularity == "" {
+		granularity = GranularityDay
+	}
+	if granularity != GranularityHour && granularity != GranularityDay {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "granularity must be hour or day"})
+		return
+	}
+	step, maxRange := 24*time.Hour, maxDayRange
+	if granularity == GranularityHour {
+		step, maxRange = time.Hour, maxHourRange
+	}
+	now := time.Now().UTC()
+	from, to, err := parseRange(q.Get("from"), q.Get("to"), now.Add(-30*24*time.Hour), now)
+	if err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if to.Sub(from) > maxRange {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "range too large for granularity"})
+		return
+	}
+	from = from.Truncate(step)
+	if granularity == GranularityDay {
+		from = dayStart(from)
+	}
+
+	linkID := q.Get("linkId")
+	coll := h.fb.DB.Collection(h.aggregator.RollupsCollection())
+	var refs []*firestore.DocumentRef
+	var buckets []time.Time
+	for b := from; b.Before(to); b = b.Add(step) {
+		refs = append(refs, coll.Doc(RollupID(profileID, linkID, granularity, b)))
+		buckets = append(buckets, b)
+	}
+	snaps, err := h.fb.DB.GetAll(ctx, refs)
+	if err != nil {
+		log.Printf("analytics query %s: %v", profileID, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load analytics"})
+		return
+	}
+
+	totals := totalsResponse{
+		Referrers: map[string]int64{},
+		Sources:   map[string]int64{},
+		Devices:   map[string]int64{},
+		Countries: map[string]int64{},
+		Cities:    map[string]int64{},
+		Browsers:  map[string]int64{},
+		OS:        map[string]int64{},
+	}
+	series := make([]bucketResponse, 0, len(snaps))
+	for i, snap := range snaps {
+		br := bucketResponse{Bucket: buckets[i].UnixMilli()}
+		if snap.Exists() {
+			var ru Rollup
+			if err := snap.DataTo(&ru); err == nil {
+				br = bucketResponse{
+					Bucket:         buckets[i].UnixMilli(),
+					Views:          ru.Views,
+					Clicks:         ru.Clicks,
+					UniqueVisitors: ru.UniqueVisitors,
+					CTR:            ru.CTR,
+					Referrers:      ru.Referrers,
+					Sources:        ru.Sources,
+					Devices:        ru.Devices,
+					Countries:      ru.Countries,
+					Cities:         ru.Cities,
+					Browsers:       ru.Browsers,
+					OS:             ru.OS,
+					Links:          ru.Links,
+					Filtered:       ru.Filtered,
+				}
+				mergeTotals(&totals, ru)
+			}
+		}
+		series = append(series, br)
+	}
+	if totals.Views > 0 {
+		totals.CTR = float64(totals.Clicks) / float64(totals.Views)
+	}
+	totals.Referrers = topKeys(totals.Referrers, topN)
+	totals.Sources = topKeys(totals.Sources, topN)
+	totals.Devices = topKeys(totals.Devices, topN)
+	totals.Countries = topKeys(totals.Countries, topN)
+	totals.Cities = topKeys(totals.Cities, topN)
+	totals.Browsers = topKeys(totals.Browsers, topN)
+	totals.OS = topKeys(totals.OS, topN)
+
+	h.writeJSON(w, http.StatusOK, map[string]any{
+		"profileId":   profileID,
+		"linkId":      linkID,
+		"granularity": granularity,
+		"from":        from.UnixMilli(),
+		"to":          to.UnixMilli(),
+		"totals":      totals,
+		"buckets":     series,
+	})
+}
+
+// POST /api/analytics/backfill?from=&to= — hitung ulang rollup untuk rentang waktu (admin)
+func (h *Handler) Backfill(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if status, msg := h.authorize(r, ""); status != 0 {
+		h.writeJSON(w, status, map[string]string{"error": msg})
+		return
+	}
+	now := time.Now().UTC()
+	from, to, err := parseRange(r.URL.Query().Get("from"), r.URL.Query().Get("to"), now.Add(-24*time.Hour), now)
+	if err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if to.Sub(from) > maxDayRange {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "range too large"})
+		return
+	}
+	go func() {
+		// Jalan di background: request sudah selesai, jadi jangan pakai r.Context()
+		if err := h.aggregator.Rollup(context.Background(), from, to); err != nil {
+			log.Printf("analytics backfill %s..%s: %v", from.Format(time.RFC3339), to.Format(time.RFC3339), err)
+			return
+		}
+		log.Printf("analytics backfill %s..%s done", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}()
+	h.writeJSON(w, http.StatusAccepted, map[string]any{"from": from.UnixMilli(), "to": to.UnixMilli()})
+}
+
+// authorize allows the profile owner or an admin. profileID "" means admin only.
+// Returns status 0 when the caller is allowed.
+func (h *Handler) authorize(r *http.Request, profileID string) (int, string) {
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		return http.StatusUnauthorized, "unauthorized"
+	}
+	if profileID != "" && uid == profileID {
+		return 0, ""
+	}
+	admin, err := h.profiles.IsAdmin(r.Context(), uid)
+	if err != nil {
+		log.Printf("analytics authorize %s: %v", uid, err)
+		return http.StatusInternalServerError, "failed to load account"
+	}
+	if admin {
+		return 0, ""
+	}
+	return http.StatusForbidden, "forbidden"
+}
+
+func mergeTotals(t *totalsResponse, r Rollup) {
+	t.Views += r.Views
+	t.Clicks += r.Clicks
+	for k, v := range r.Referrers {
+		t.Referrers[k] += v
+	}
+	for k, v := range r.Sources {
+		t.Sources[k] += v
+	}
+	for k, v := range r.Devices {
+		t.Devices[k] += v
+	}
+	for k, v := range r.Countries {
+		t.Countries[k] += v
+	}
+	for k, v := range r.Cities {
+		t.Cities[k] += v
+	}
+	for k, v := range r.Browsers {
+		t.Browsers[k] += v
+	}
+	for k, v := range r.OS {
+		t.OS[k] += v
+	}
+	for k, v := range r.Filtered {
+		if t.Filtered == nil {
+			t.Filtered = map[string]int64{}
+		}
+		t.Filtered[k] += v
+	}
+	for id, s := range r.Links {
+		if t.Links == nil {
+			t.Links = map[string]LinkSummary{}
+		}
+		cur := t.Links[id]
+		cur.Clicks += s.Clicks
+		cur.UniqueVisitors += s.UniqueVisitors
+		t.Links[id] = cur
+	}
+}
+
+type rangeError string
+
+func (e rangeError) Error() string { return string(e) }
+
+// parseRange accepts RFC 3339 timestamps, YYYY-MM-DD dates or unix milliseconds.
+func parseRange(fromStr, toStr string, defFrom, defTo time.Time) (time.Time, time.Time, error) {
+	from, to := defFrom, defTo
+	var ok bool
+	if fromStr != "" {
+		if from, ok = parseTime(fromStr); !ok {
+			return time.Time{}, time.Time{}, rangeError("invalid from")
+		}
+	}
+	if toStr != "" {
+		if to, ok = parseTime(toStr); !ok {
+			return time.Time{}, time.Time{}, rangeError("invalid to")
+		}
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, rangeError("from must be before to")
+	}
+	return from.UTC(), to.UTC(), nil
+}
+
+func parseTime(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, true
+	}
+	var ms int64
+	if err := json.Unmarshal([]byte(s), &ms); err == nil && ms > 0 {
+		return time.UnixMilli(ms), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,79 @@
+package analytics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"biomu/backend/internal/profile"
+	"biomu/backend/internal/visitor"
+)
+
+func TestBeaconRateLimit(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	h := NewHandler(nil, nil, nil, nil, nil, nil, nil, nil)
+	h.now = func() time.Time { return now }
+	beacon := func(remote, forwardedFor string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/public/events", strings.NewReader(`{"type":"view","handle":"Aether"}`))
+		r.RemoteAddr = remote
+		if forwardedFor != "" {
+			r.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		w := httptest.NewRecorder()
+		var proxies *visitor.Proxies
+		proxies.Middleware(http.HandlerFunc(h.Beacon)).ServeHTTP(w, r)
+		return w
+	}
+	// Jatah habis tanpa Firestore: isi limiter dengan key yang sama seperti Beacon
+	for i := 0; i < beaconVisitorLimit; i++ {
+		h.byVisitor.Take("198.51.100.9\x00handle:aether", now)
+	}
+
+	w := beacon("198.51.100.9:1000", "203.0.113.1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "60" {
+		t.Fatalf("status %d, Retry-After %q; want 429 after %d beacons", w.Code, w.Header().Get("Retry-After"), beaconVisitorLimit)
+	}
+	if ok, _ := h.byVisitor.Take("198.51.100.10\x00handle:aether", now); !ok {
+		t.Fatal("limit of one visitor applied to another")
+	}
+	if ok, _ := h.byVisitor.Take("198.51.100.9\x00handle:other", now); !ok {
+		t.Fatal("limit on one profile applied to another")
+	}
+	if w := beacon("198.51.100.9:1000", ""); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status %d, want 429", w.Code)
+	}
+}
+
+func TestPublicLinksCache(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	// profiles nil: setiap cache miss akan panic, jadi test ini membuktikan Firestore tidak dibaca
+	h := NewHandler(nil, nil, nil, nil, nil, nil, nil, nil)
+	h.now = func() time.Time { return now }
+	h.links["p1"] = linksEntry{
+		links: []profile.Link{
+			{ID: "l1", ProfileID: "p1", URL: "https://example.com/1"},
+			{ID: "l2", ProfileID: "p1", URL: "https://example.com/2", Hidden: true},
+			{ID: "l3", ProfileID: "p1", URL: "https://example.com/3", StartsAt: now.Add(30 * time.Second)},
+		},
+		exp: now.Add(linksCacheTTL),
+	}
+	for i := 0; i < 3; i++ {
+		links, err := h.publicLinks(context.Background(), "p1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !hasLink(links, "l1") || hasLink(links, "l2") || hasLink(links, "l3") {
+			t.Fatalf("links = %+v, want only the visible link", links)
+		}
+	}
+
+	// Jadwal dievaluasi saat dibaca, bukan saat di-cache
+	now = now.Add(30 * time.Second)
+	links, _ := h.publicLinks(context.Background(), "p1")
+	if !hasLink(links, "l3") {
+		t.Fatal("scheduled link not shown once it starts")
+	}
+}
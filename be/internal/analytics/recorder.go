@@ -0,0 +1,210 @@
+package analytics
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"biomu/backend/internal/firebase"
+
+	"cloud.google.com/go/firestore"
+)
+
+const (
+	EventView  = "view"
+	EventClick = "click"
+)
+
+const (
+	defaultQueueSize     = 4096
+	defaultBatchSize     = 200
+	defaultFlushInterval = 2 * time.Second
+)
+
+// Event is a single raw analytics event. Raw events are append-only.
+type Event struct {
+	Type      string    `firestore:"type"`
+	ProfileID string    `firestore:"profileId"`
+	LinkID    string    `firestore:"linkId,omitempty"`
+	Timestamp time.Time `firestore:"ts"`
+	Referrer  string    `firestore:"referrer,omitempty"`
+	UAClass   string    `firestore:"uaClass,omitempty"`
+	Browser   string    `firestore:"browser,omitempty"`
+	OS        string    `firestore:"os,omitempty"`
+	Country   string    `firestore:"country,omitempty"`
+	City      string    `firestore:"city,omitempty"`
+	VisitorID string    `firestore:"visitorId,omitempty"`
+	// Source: utm_source kunjungan (mis. "qr" untuk scan QR code), lihat SourceFromRequest
+	Source string `firestore:"source,omitempty"`
+	// Variant: varian A/B test yang dilihat pengunjung saat klik (lihat experiment)
+	Variant string `firestore:"variant,omitempty"`
+	// Class: human, bot, atau suspicious (lihat botfilter). Event lama tanpa class dianggap human.
+	Class  string `firestore:"class,omitempty"`
+	Reason string `firestore:"reason,omitempty"`
+}
+
+// Human reports whether the event counts towards analytics totals.
+func (e Event) Human() bool {
+	return e.Class == "" || e.Class == "human"
+}
+
+// Recorder menulis event ke Firestore secara asynchronous dan dalam batch,
+// supaya handler (mis. redirect) tidak pernah menunggu Firestore.
+type Recorder struct {
+	fb         *firebase.App
+	eventsColl string
+	linksColl  string
+	queue      chan Event
+	batchSize  int
+	interval   time.Duration
+
+	mu      sync.Mutex
+	dropped int64
+	// klik per link yang belum ditulis ke field "clicks" dokumen link
+	clicks map[string]int64
+	done   chan struct{}
+	stop   chan struct{}
+	once   sync.Once
+}
+
+func NewRecorder(fb *firebase.App, eventsColl, linksColl string) *Recorder {
+	return &Recorder{
+		fb:         fb,
+		eventsColl: eventsColl,
+		linksColl:  linksColl,
+		clicks:     map[string]int64{},
+		queue:      make(chan Event, defaultQueueSize),
+		batchSize:  defaultBatchSize,
+		interval:   defaultFlushInterval,
+		done:       make(chan struct{}),
+		stop:       make(chan struct{}),
+	}
+}
+
+func (rec *Recorder) EventsCollection() string { return rec.eventsColl }
+
+// Record enqueues e without blocking. When the queue is full the event is dropped.
+func (rec *Recorder) Record(e Event) {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	select {
+	case rec.queue <- e:
+	default:
+		rec.mu.Lock()
+		rec.dropped++
+		n := rec.dropped
+		rec.mu.Unlock()
+		if n == 1 || n%1000 == 0 {
+			log.Printf("analytics: queue full, dropped %d events", n)
+		}
+	}
+}
+
+// CountClick increments the "clicks" counter of the link document on the next flush.
+func (rec *Recorder) CountClick(linkID string) {
+	rec.mu.Lock()
+	rec.clicks[linkID]++
+	rec.mu.Unlock()
+}
+
+// Run consumes the queue until Close is called. It should be started once in its own goroutine.
+func (rec *Recorder) Run(ctx context.Context) {
+	defer close(rec.done)
+	ticker := time.NewTicker(rec.interval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, rec.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := rec.write(ctx, batch); err != nil {
+			log.Printf("analytics: write %d events: %v", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case e := <-rec.queue:
+			batch = append(batch, e)
+			if len(batch) >= rec.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+			rec.flushClicks(ctx)
+		case <-rec.stop:
+			for {
+				select {
+				case e := <-rec.queue:
+					batch = append(batch, e)
+					if len(batch) >= rec.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					rec.flushClicks(ctx)
+					return
+				}
+			}
+		}
+	}
+}
+
+// Close stops Run after flushing events that are still queued.
+func (rec *Recorder) Close() {
+	rec.once.Do(func() { close(rec.stop) })
+	<-rec.done
+}
+
+func (rec *Recorder) flushClicks(ctx context.Context) {
+	rec.mu.Lock()
+	pending := rec.clicks
+	rec.clicks = map[string]int64{}
+	rec.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+	bw := rec.fb.DB.BulkWriter(ctx)
+	coll := rec.fb.DB.Collection(rec.linksColl)
+	jobs := make(map[string]*firestore.BulkWriterJob, len(pending))
+	for linkID, n := range pending {
+		job, err := bw.Update(coll.Doc(linkID), []firestore.Update{{Path: "clicks", Value: firestore.Increment(n)}})
+		if err != nil {
+			log.Printf("analytics: click counter %s: %v", linkID, err)
+			continue
+		}
+		jobs[linkID] = job
+	}
+	bw.End()
+	for linkID, job := range jobs {
+		// Link yang sudah dihapus wajar gagal (NotFound); cukup dicatat
+		if _, err := job.Results(); err != nil {
+			log.Printf("analytics: click counter %s: %v", linkID, err)
+		}
+	}
+}
+
+func (rec *Recorder) write(ctx context.Context, events []Event) error {
+	bw := rec.fb.DB.BulkWriter(ctx)
+	coll := rec.fb.DB.Collection(rec.eventsColl)
+	jobs := make([]*firestore.BulkWriterJob, 0, len(events))
+	for _, e := range events {
+		job, err := bw.Create(coll.NewDoc(), e)
+		if err != nil {
+			bw.End()
+			return err
+		}
+		jobs = append(jobs, job)
+	}
+	bw.End()
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
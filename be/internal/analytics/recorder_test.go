@@ -0,0 +1,71 @@
+package analytics
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRecordDropsWhenFull(t *testing.T) {
+	rec := NewRecorder(nil, "events", "links")
+	rec.queue = make(chan Event, 2)
+	for i := 0; i < 5; i++ {
+		rec.Record(Event{Type: EventClick, ProfileID: "p1"})
+	}
+	if len(rec.queue) != 2 || rec.dropped != 3 {
+		t.Fatalf("queued %d, dropped %d; want 2 and 3", len(rec.queue), rec.dropped)
+	}
+	if e := <-rec.queue; e.Timestamp.IsZero() || time.Since(e.Timestamp) > time.Minute {
+		t.Fatalf("timestamp not set: %v", e.Timestamp)
+	}
+}
+
+func TestCountClick(t *testing.T) {
+	rec := NewRecorder(nil, "events", "links")
+	rec.CountClick("l1")
+	rec.CountClick("l1")
+	rec.CountClick("l2")
+	if rec.clicks["l1"] != 2 || rec.clicks["l2"] != 1 {
+		t.Fatalf("clicks = %v", rec.clicks)
+	}
+}
+
+func TestReferrerHost(t *testing.T) {
+	tests := map[string]string{
+		"":                                     "",
+		"https://www.Instagram.com/p/x?y=1":    "instagram.com",
+		"https://t.co/abc":                     "t.co",
+		"http://localhost:8080/":               "localhost",
+		"not a url":                            "",
+		"android-app://com.google.android.gm/": "com.google.android.gm",
+	}
+	for ref, want := range tests {
+		r := httptest.NewRequest("GET", "/r/l1", nil)
+		if ref != "" {
+			r.Header.Set("Referer", ref)
+		}
+		if got := ReferrerHost(r); got != want {
+			t.Errorf("ReferrerHost(%q) = %q, want %q", ref, got, want)
+		}
+	}
+}
+
+func TestNormalizeSource(t *testing.T) {
+	tests := map[string]string{
+		"QR":                                   "qr",
+		" news letter ":                        "newsletter",
+		"ig.story":                             "igstory",
+		"a<script>":                            "ascript",
+		"x_y-z":                                "x_y-z",
+		"abcdefghijklmnopqrstuvwxyz0123456789": "abcdefghijklmnopqrstuvwxyz012345",
+	}
+	for in, want := range tests {
+		if got := NormalizeSource(in); got != want {
+			t.Errorf("NormalizeSource(%q) = %q, want %q", in, got, want)
+		}
+	}
+	r := httptest.NewRequest("GET", "/r/l1?utm_source=QR", nil)
+	if got := SourceFromRequest(r); got != "qr" {
+		t.Errorf("SourceFromRequest = %q", got)
+	}
+}
@@ -0,0 +1,57 @@
+package analytics
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"biomu/backend/internal/enrich"
+)
+
+// ReferrerHost keeps only the host of the Referer header (tanpa path/query agar tidak membocorkan data).
+func ReferrerHost(r *http.Request) string {
+	ref := r.Header.Get("Referer")
+	if ref == "" {
+		return ""
+	}
+	u, err := url.Parse(ref)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// SourceFromRequest returns the normalized utm_source query parameter of r.
+func SourceFromRequest(r *http.Request) string {
+	return NormalizeSource(r.URL.Query().Get("utm_source"))
+}
+
+// NormalizeSource keeps a utm_source value short and safe to use as a rollup map key:
+// lowercase letters, digits, "-" and "_", at most 32 characters.
+func NormalizeSource(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	var b strings.Builder
+	for _, c := range s {
+		if b.Len() >= 32 {
+			break
+		}
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// Enrich copies the request enrichment (GeoIP + User-Agent) onto e. Without the
+// enrich middleware only the User-Agent and CDN country header are used.
+func (e *Event) Enrich(r *http.Request) {
+	info, ok := enrich.FromContext(r.Context())
+	if !ok {
+		info = (*enrich.Enricher)(nil).Enrich(r)
+	}
+	e.Country = info.Country
+	e.City = info.City
+	e.UAClass = info.Device
+	e.Browser = info.Browser
+	e.OS = info.OS
+}
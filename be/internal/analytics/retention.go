@@ -0,0 +1,72 @@
+package analytics
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"biomu/backend/internal/firebase"
+)
+
+const purgeBatchSize = 400
+
+// Purger menghapus raw event yang lebih tua dari masa retensi. Rollup tidak ikut
+// dihapus karena tidak berisi data per pengunjung (hanya angka agregat).
+type Purger struct {
+	fb         *firebase.App
+	eventsColl string
+	retention  time.Duration
+	interval   time.Duration
+}
+
+func NewPurger(fb *firebase.App, eventsColl string, retention, interval time.Duration) *Purger {
+	return &Purger{fb: fb, eventsColl: eventsColl, retention: retention, interval: interval}
+}
+
+// Run purges expired raw events every interval until ctx is cancelled.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		n, err := p.Purge(ctx, time.Now().Add(-p.retention))
+		if err != nil {
+			log.Printf("analytics retention: %v", err)
+		} else if n > 0 {
+			log.Printf("analytics retention: purged %d raw events", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge deletes raw events with ts < before and returns how many were deleted.
+func (p *Purger) Purge(ctx context.Context, before time.Time) (int, error) {
+	total := 0
+	for {
+		docs, err := p.fb.DB.Collection(p.eventsColl).
+			Where("ts", "<", before).
+			Limit(purgeBatchSize).
+			Documents(ctx).GetAll()
+		if err != nil {
+			return total, err
+		}
+		if len(docs) == 0 {
+			return total, nil
+		}
+		bw := p.fb.DB.BulkWriter(ctx)
+		for _, doc := range docs {
+			if _, err := bw.Delete(doc.Ref); err != nil {
+				bw.End()
+				return total, err
+			}
+		}
+		bw.End()
+		total += len(docs)
+		if len(docs) < purgeBatchSize {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"firebase.google.com/go/v4/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	maxEmailLen       = 254
+	emailChangeExpiry = 10 * time.Minute
+	// maxEmailChangeAttempts: setelah sekian OTP salah, permintaan ganti email dibatalkan
+	maxEmailChangeAttempts = 5
+)
+
+var (
+	// handleRe: huruf kecil, angka, "_" dan "-"; tanpa titik supaya tidak bentrok dengan file
+	// seperti /embed.js
+	handleRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{2,29}$`)
+	// reservedHandles adalah segmen path pertama yang dipakai route server dan frontend
+	reservedHandles = map[string]bool{
+		"api": true, "d": true, "embed": true, "go": true, "media": true, "newsletter": true,
+		"oembed": true, "orders": true, "r": true, "unlock": true, "profile": true,
+		"signin": true, "signup": true, "verification": true, "admin": true,
+	}
+
+	errHandleTaken = errors.New("handle is taken")
+	errEmailTaken  = errors.New("email is used by another account")
+	errNoPending   = errors.New("no pending email change")
+	errOTPExpired  = errors.New("otp expired")
+	errOTPInvalid  = errors.New("otp invalid")
+)
+
+// validHandle normalizes raw like profile.NormalizeHandle and checks it is usable as /{handle}.
+func validHandle(raw string) (string, bool) {
+	handle := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(raw)), "@")
+	if !handleRe.MatchString(handle) || reservedHandles[handle] {
+		return "", false
+	}
+	return handle, true
+}
+
+func validEmail(raw string) (string, bool) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	if raw == "" || len(raw) > maxEmailLen {
+		return "", false
+	}
+	addr, err := mail.ParseAddress(raw)
+	if err != nil || addr.Address != raw {
+		return "", false
+	}
+	return raw, true
+}
+
+// PUT /api/account/handle — ganti handle akun. Field "handle" tidak bisa ditulis lewat /api/db;
+// keunikan dicek di dalam transaksi.
+func (h *Handler) UpdateHandle(w http.ResponseWriter, r *http.Request) {
+	uid := h.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	var body struct {
+		Handle string `json:"handle"`
+	}
+	if err := h.readJSON(r, &body); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+		return
+	}
+	handle, ok := validHandle(body.Handle)
+	if !ok {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "handle must be 3-30 characters: a-z, 0-9, _ or -"})
+		return
+	}
+	ctx := r.Context()
+	coll := h.fb.DB.Collection(h.accountsColl)
+	ref := coll.Doc(uid)
+	err := h.fb.DB.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		docs, err := tx.Documents(coll.Where("handle", "==", handle).Limit(2)).GetAll()
+		if err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			if doc.Ref.ID != uid {
+				return errHandleTaken
+			}
+		}
+		return tx.Update(ref, []firestore.Update{
+			{Path: "handle", Value: handle},
+			{Path: "updatedAt", Value: time.Now()},
+		})
+	})
+	switch {
+	case errors.Is(err, errHandleTaken):
+		h.writeJSON(w, http.StatusConflict, map[string]string{"error": "Handle sudah dipakai"})
+		return
+	case status.Code(err) == codes.NotFound:
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "Account not found"})
+		return
+	case err != nil:
+		log.Printf("update handle %s: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An unexpected error occurred"})
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]string{"handle": handle})
+}
+
+// POST /api/account/email — minta ganti email: OTP dikirim ke alamat baru dan field "email"
+// baru berubah setelah OTP dikonfirmasi lewat /api/account/email/verify.
+func (h *Handler) RequestEmailChange(w http.ResponseWriter, r *http.Request) {
+	uid := h.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	var body struct {
+		Email string `json:"email"`
+	}
+	if err := h.readJSON(r, &body); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request"})
+		return
+	}
+	emailLower, ok := validEmail(body.Email)
+	if !ok {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Email tidak valid"})
+		return
+	}
+	if h.email == nil {
+		h.writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "Email is not configured"})
+		return
+	}
+	ctx := r.Context()
+	snap, _, err := h.findAccountByEmail(ctx, emailLower)
+	if err != nil {
+		log.Printf("email change %s find: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An unexpected error occurred"})
+		return
+	}
+	if snap != nil {
+		h.writeJSON(w, http.StatusConflict, map[string]string{"error": "Email sudah dipakai akun lain"})
+		return
+	}
+	otp := generateOTP()
+	_, err = h.fb.DB.Collection(h.accountsColl).Doc(uid).Update(ctx, []firestore.Update{
+		{Path: "pendingEmail", Value: emailLower},
+		{Path: "pendingEmailOtp", Value: otp},
+		{Path: "pendingEmailExpiry", Value: time.Now().Add(emailChangeExpiry)},
+		{Path: "pendingEmailAttempts", Value: 0},
+	})
+	if status.Code(err) == codes.NotFound {
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "Account not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("email change %s update: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An unexpected error occurred"})
+		return
+	}
+	if err := h.email.SendEmailChangeOTP(emailLower, otp); err != nil {
+		log.Printf("email change %s send: %v", uid, err)
+	}
+	h.writeJSON(w, http.StatusAccepted, map[string]string{"status": "pending"})
+}
+
+// POST /api/account/email/verify — konfirmasi ganti email dengan OTP yang dikirim ke alamat
+// baru. Email di Firebase Auth ikut diganti dan ditandai terverifikasi.
+func (h *Handler) VerifyEmailChange(w http.ResponseWriter, r *http.Request) {
+	uid := h.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	var body struct {
+		OTP string `json:"otp"`
+	}
+	if err := h.readJSON(r, &body); err != nil || len(strings.TrimSpace(body.OTP)) != 6 {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "OTP harus 6 digit"})
+		return
+	}
+	otp := strings.TrimSpace(body.OTP)
+	ctx := r.Context()
+	coll := h.fb.DB.Collection(h.accountsColl)
+	ref := coll.Doc(uid)
+	clearPending := []firestore.Update{
+		{Path: "pendingEmail", Value: firestore.Delete},
+		{Path: "pendingEmailOtp", Value: firestore.Delete},
+		{Path: "pendingEmailExpiry", Value: firestore.Delete},
+		{Path: "pendingEmailAttempts", Value: firestore.Delete},
+	}
+	var newEmail string
+	// Hasil verifikasi (OTP salah, kedaluwarsa) tetap di-commit supaya percobaan tercatat
+	var result error
+	err := h.fb.DB.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		result = nil
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		data := doc.Data()
+		newEmail, _ = data["pendingEmail"].(string)
+		stored, _ := data["pendingEmailOtp"].(string)
+		if newEmail == "" || stored == "" {
+			result = errNoPending
+			return nil
+		}
+		expiryMs, ok := getExpiryMillis(data, "pendingEmailExpiry")
+		if !ok || expiryMs < time.Now().UnixMilli() {
+			result = errOTPExpired
+			return tx.Update(ref, clearPending)
+		}
+		if stored != otp {
+			result = errOTPInvalid
+			attempts, _ := data["pendingEmailAttempts"].(int64)
+			if attempts+1 >= maxEmailChangeAttempts {
+				return tx.Update(ref, clearPending)
+			}
+			return tx.Update(ref, []firestore.Update{{Path: "pendingEmailAttempts", Value: attempts + 1}})
+		}
+		taken, err := tx.Documents(coll.Where("email", "==", newEmail).Limit(2)).GetAll()
+		if err != nil {
+			return err
+		}
+		for _, other := range taken {
+			if other.Ref.ID != uid {
+				result = errEmailTaken
+				return tx.Update(ref, clearPending)
+			}
+		}
+		return tx.Update(ref, append(clearPending,
+			firestore.Update{Path: "email", Value: newEmail},
+			firestore.Update{Path: "updatedAt", Value: time.Now()},
+		))
+	})
+	if status.Code(err) == codes.NotFound {
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "Account not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("email change %s verify: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An unexpected error occurred"})
+		return
+	}
+	switch {
+	case errors.Is(result, errNoPending):
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Tidak ada permintaan ganti email. Silakan minta OTP baru"})
+		return
+	case errors.Is(result, errOTPExpired):
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "OTP sudah kadaluarsa. Silakan minta OTP baru"})
+		return
+	case errors.Is(result, errOTPInvalid):
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "OTP tidak valid. Silakan periksa kembali kode yang Anda masukkan"})
+		return
+	case errors.Is(result, errEmailTaken):
+		h.writeJSON(w, http.StatusConflict, map[string]string{"error": "Email sudah dipakai akun lain"})
+		return
+	}
+
+	// Email login (Firebase Auth) mengikuti email akun; relay email hanya memakai alamat terverifikasi
+	_, err = h.fb.Auth.UpdateUser(ctx, uid, (&auth.UserToUpdate{}).Email(newEmail).EmailVerified(true))
+	if err != nil && !auth.IsUserNotFound(err) {
+		log.Printf("email change %s auth user: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An unexpected error occurred"})
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]string{"email": newEmail})
+}
+
+// VerifiedEmail returns the login email of uid when Firebase Auth marks it verified (OTP signup,
+// OAuth, atau ganti email lewat OTP), or "" otherwise. Relay email ke pemilik akun hanya memakai
+// alamat ini, bukan field "email" di dokumen akun.
+func (h *Handler) VerifiedEmail(ctx context.Context, uid string) (string, error) {
+	u, err := h.fb.Auth.GetUser(ctx, uid)
+	if auth.IsUserNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if !u.EmailVerified {
+		return "", nil
+	}
+	return u.Email, nil
+}
@@ -0,0 +1,51 @@
+package auth
+
+import "testing"
+
+func TestValidHandle(t *testing.T) {
+	tests := []struct {
+		in, want string
+		ok       bool
+	}{
+		{"aether", "aether", true},
+		{" @Aether_Studio ", "aether_studio", true},
+		{"dj-42", "dj-42", true},
+		{"ab", "", false},
+		{"abcdefghijklmnopqrstuvwxyz012345", "", false},
+		{"-aether", "", false},
+		{"aether.bio", "", false},
+		{"aether/links", "", false},
+		{"аether", "", false}, // "а" Cyrillic
+		{"api", "", false},
+		{"embed", "", false},
+		{"oembed", "", false},
+		{"orders", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := validHandle(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("validHandle(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestValidEmail(t *testing.T) {
+	tests := []struct {
+		in, want string
+		ok       bool
+	}{
+		{"Fan@Example.com", "fan@example.com", true},
+		{" fan@example.com ", "fan@example.com", true},
+		{"Fan <fan@example.com>", "", false},
+		{"fan@example.com\r\nBcc: victim@example.com", "", false},
+		{"fan@example.com, victim@example.com", "", false},
+		{"not-an-email", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := validEmail(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("validEmail(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -375,6 +375,24 @@ func (h *Handler) getUIDFromSessionCookie(r *http.Request) string {
 	return claims.UID
 }
 
+// SessionUID returns the uid of the signed-in caller, or "" when the request has no valid session.
+func (h *Handler) SessionUID(r *http.Request) string {
+	// 1) Coba session JWT (backend session dari verify-otp)
+	if uid := h.getUIDFromSessionCookie(r); uid != "" {
+		return uid
+	}
+	// 2) Fallback: Firebase session cookie (backward compat)
+	cookie, err := r.Cookie(h.sessionCookie)
+	if err != nil || cookie == nil || cookie.Value == "" {
+		return ""
+	}
+	tok, err := h.fb.Auth.VerifySessionCookieAndCheckRevoked(r.Context(), cookie.Value)
+	if err != nil {
+		return ""
+	}
+	return tok.UID
+}
+
 func (h *Handler) docToUserResponse(doc *firestore.DocumentSnapshot) map[string]interface{} {
 	data := doc.Data()
 	if data == nil {
@@ -512,23 +530,10 @@ func (h *Handler) SessionGet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	ctx := r.Context()
-	var uid string
-
-	// 1) Coba session JWT (backend session dari verify-otp)
-	uid = h.getUIDFromSessionCookie(r)
+	uid := h.SessionUID(r)
 	if uid == "" {
-		// 2) Fallback: Firebase session cookie (backward compat)
-		cookie, err := r.Cookie(h.sessionCookie)
-		if err != nil || cookie == nil || cookie.Value == "" {
-			h.writeJSON(w, http.StatusOK, map[string]any{"authenticated": false})
-			return
-		}
-		tok, err := h.fb.Auth.VerifySessionCookieAndCheckRevoked(ctx, cookie.Value)
-		if err != nil {
-			h.writeJSON(w, http.StatusOK, map[string]any{"authenticated": false})
-			return
-		}
-		uid = tok.UID
+		h.writeJSON(w, http.StatusOK, map[string]any{"authenticated": false})
+		return
 	}
 
 	doc, err := h.fb.DB.Collection(h.accountsColl).Doc(uid).Get(ctx)
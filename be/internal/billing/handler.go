@@ -0,0 +1,208 @@
+package billing
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"biomu/backend/internal/profile"
+)
+
+const webhookMaxBytes = 256 << 10
+
+// Sessions resolves the signed-in caller (implemented by auth.Handler).
+type Sessions interface {
+	SessionUID(r *http.Request) string
+}
+
+type Handler struct {
+	store     *Store
+	provider  Provider
+	profiles  *profile.Store
+	sessions  Sessions
+	returnURL string
+}
+
+// NewHandler creates the billing endpoints. returnURL is the frontend page checkout returns to
+// (dengan query checkout=success atau checkout=cancel).
+func NewHandler(store *Store, provider Provider, profiles *profile.Store, sessions Sessions, returnURL string) *Handler {
+	return &Handler{store: store, provider: provider, profiles: profiles, sessions: sessions, returnURL: returnURL}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// POST /api/billing/checkout — buat sesi checkout langganan membership untuk caller
+func (h *Handler) Checkout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	ctx := r.Context()
+	sub, err := h.store.Get(ctx, uid)
+	if err != nil {
+		log.Printf("billing checkout %s: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to start checkout"})
+		return
+	}
+	if sub != nil && sub.Entitled && sub.Status != StatusCanceled {
+		h.writeJSON(w, http.StatusConflict, map[string]string{"error": "already_subscribed"})
+		return
+	}
+	p, err := h.profiles.FindByID(ctx, uid)
+	if err != nil {
+		log.Printf("billing checkout %s: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to start checkout"})
+		return
+	}
+	req := CheckoutRequest{
+		AccountID:  uid,
+		SuccessURL: withQuery(h.returnURL, "checkout", "success"),
+		CancelURL:  withQuery(h.returnURL, "checkout", "cancel"),
+	}
+	if p != nil {
+		req.Email, _ = p.Data["email"].(string)
+	}
+	checkout, err := h.provider.CreateCheckout(ctx, req)
+	if err != nil {
+		log.Printf("billing checkout %s: %v", uid, err)
+		h.writeJSON(w, http.StatusBadGateway, map[string]string{"error": "payment provider unavailable"})
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, checkout)
+}
+
+// GET /api/billing/subscription — state langganan caller (null jika belum pernah berlangganan)
+func (h *Handler) Subscription(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	sub, err := h.store.Get(r.Context(), uid)
+	if err != nil {
+		log.Printf("billing subscription %s: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load subscription"})
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{"subscription": sub})
+}
+
+// POST /api/billing/webhook — event dari payment provider (tanpa session, diverifikasi HMAC)
+func (h *Handler) Webhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, webhookMaxBytes))
+	if err != nil {
+		h.writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "body too large"})
+		return
+	}
+	ev, err := h.provider.ParseWebhook(r.Header, body, time.Now())
+	switch {
+	case errors.Is(err, ErrBadSignature):
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("billing webhook: %v", err)
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": ErrBadPayload.Error()})
+		return
+	case ev == nil:
+		// Tipe event yang tidak dipakai tetap dibalas 2xx supaya provider tidak mengirim ulang
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err := h.store.Apply(r.Context(), h.provider.Name(), ev); err != nil {
+		// 5xx: provider akan mengirim ulang event ini
+		log.Printf("billing webhook %s %s: %v", ev.ID, ev.AccountID, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to apply event"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /api/billing/fake/events — hanya dengan provider fake (dev/test): kirim event langganan
+// untuk caller lewat jalur webhook yang sama. Body {"status": "active", "periodDays": 30}
+func (h *Handler) FakeEvent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	fake, ok := h.provider.(*Fake)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	var body struct {
+		Status     string `json:"status"`
+		PeriodDays int    `json:"periodDays"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10)).Decode(&body); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body: " + err.Error()})
+		return
+	}
+	if body.PeriodDays <= 0 {
+		body.PeriodDays = 30
+	}
+	now := time.Now().UTC()
+	payload, header, err := fake.Webhook(Event{
+		AccountID:        uid,
+		SubscriptionID:   "fake_sub_" + uid,
+		Status:           body.Status,
+		CurrentPeriodEnd: now.AddDate(0, 0, body.PeriodDays),
+	}, now)
+	if err != nil {
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	ev, err := fake.ParseWebhook(header, payload, now)
+	if err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := h.store.Apply(r.Context(), fake.Name(), ev); err != nil {
+		log.Printf("billing fake event %s: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to apply event"})
+		return
+	}
+	sub, err := h.store.Get(r.Context(), uid)
+	if err != nil {
+		log.Printf("billing fake event %s: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load subscription"})
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{"subscription": sub})
+}
+
+func withQuery(raw, key, value string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	q := u.Query()
+	q.Set(key, value)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,19 @@
+package billing
+
+import "testing"
+
+func TestWithQuery(t *testing.T) {
+	tests := []struct {
+		raw, want string
+	}{
+		{"https://aether.bio/billing", "https://aether.bio/billing?checkout=success"},
+		{"https://aether.bio/billing?tab=plan", "https://aether.bio/billing?checkout=success&tab=plan"},
+		{"https://aether.bio/billing?checkout=old", "https://aether.bio/billing?checkout=success"},
+		{"://bad", "://bad"},
+	}
+	for _, tt := range tests {
+		if got := withQuery(tt.raw, "checkout", "success"); got != tt.want {
+			t.Errorf("withQuery(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
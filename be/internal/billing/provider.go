@@ -0,0 +1,116 @@
+// Package billing sells the "membership" status as a subscription: checkout lewat payment
+// provider, webhook yang ditandatangani HMAC, state langganan per akun, dan transisi status akun
+// otomatis (renew, cancel, past due) dengan masa tenggang.
+package billing
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Normalized subscription states (dipetakan dari status masing-masing provider).
+const (
+	StatusActive     = "active"
+	StatusPastDue    = "past_due"
+	StatusCanceled   = "canceled"
+	StatusIncomplete = "incomplete"
+)
+
+// signatureTolerance bounds the age of a webhook timestamp (anti replay).
+const signatureTolerance = 5 * time.Minute
+
+var (
+	ErrBadSignature = errors.New("invalid webhook signature")
+	ErrBadPayload   = errors.New("invalid webhook payload")
+)
+
+// Provider is a payment provider. Implementations: Stripe and Fake (lokal/test).
+type Provider interface {
+	Name() string
+	// CreateCheckout starts a hosted checkout for a new subscription.
+	CreateCheckout(ctx context.Context, req CheckoutRequest) (*Checkout, error)
+	// ParseWebhook verifies the signature of a webhook request and converts it to an Event.
+	// Event types the provider sends but billing does not need return (nil, nil).
+	ParseWebhook(header http.Header, body []byte, now time.Time) (*Event, error)
+}
+
+type CheckoutRequest struct {
+	AccountID  string
+	Email      string
+	SuccessURL string
+	CancelURL  string
+}
+
+type Checkout struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// Event is a subscription snapshot delivered by a webhook.
+type Event struct {
+	ID        string
+	CreatedAt time.Time
+	// AccountID: uid akun yang dititipkan saat checkout
+	AccountID         string
+	SubscriptionID    string
+	Status            string
+	CurrentPeriodEnd  time.Time
+	CancelAtPeriodEnd bool
+}
+
+// Sign returns a signature header value "t=<unix>,v1=<hex hmac>" over "<unix>.<body>", the
+// scheme used by Stripe-Signature.
+func Sign(secret []byte, body []byte, t time.Time) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac(secret, ts, body))
+}
+
+// VerifySignature checks a "t=...,v1=..." header against body. Several v1 entries (rotasi
+// secret di sisi provider) are accepted; the timestamp must be within signatureTolerance.
+func VerifySignature(secret []byte, header string, body []byte, now time.Time) error {
+	var ts string
+	var sigs [][]byte
+	for _, part := range strings.Split(header, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			if b, err := hex.DecodeString(v); err == nil {
+				sigs = append(sigs, b)
+			}
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || len(sigs) == 0 {
+		return ErrBadSignature
+	}
+	if d := now.Sub(time.Unix(unix, 0)); d > signatureTolerance || d < -signatureTolerance {
+		return ErrBadSignature
+	}
+	want := mac(secret, ts, body)
+	for _, sig := range sigs {
+		if hmac.Equal(sig, want) {
+			return nil
+		}
+	}
+	return ErrBadSignature
+}
+
+func mac(secret []byte, ts string, body []byte) []byte {
+	m := hmac.New(sha256.New, secret)
+	m.Write([]byte(ts))
+	m.Write([]byte("."))
+	m.Write(body)
+	return m.Sum(nil)
+}
@@ -0,0 +1,170 @@
+package billing
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+	secret := []byte("whsec_test")
+	body := []byte(`{"id":"evt_1"}`)
+	signedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	valid := Sign(secret, body, signedAt)
+	ts, sig, _ := strings.Cut(valid, ",")
+
+	tests := []struct {
+		name   string
+		secret []byte
+		header string
+		body   []byte
+		now    time.Time
+		ok     bool
+	}{
+		{"valid", secret, valid, body, signedAt, true},
+		{"within tolerance", secret, valid, body, signedAt.Add(signatureTolerance), true},
+		{"clock skew", secret, valid, body, signedAt.Add(-signatureTolerance), true},
+		{"replayed", secret, valid, body, signedAt.Add(signatureTolerance + time.Second), false},
+		{"from the future", secret, valid, body, signedAt.Add(-signatureTolerance - time.Second), false},
+		{"tampered body", secret, valid, []byte(`{"id":"evt_2"}`), signedAt, false},
+		{"other secret", []byte("whsec_other"), valid, body, signedAt, false},
+		// Rotasi secret: salah satu v1 cocok sudah cukup
+		{"rotated secret", secret, ts + ",v1=" + strings.Repeat("00", 32) + "," + sig, body, signedAt, true},
+		{"spaces", secret, ts + ", " + sig, body, signedAt, true},
+		{"missing timestamp", secret, sig, body, signedAt, false},
+		{"missing signature", secret, ts, body, signedAt, false},
+		{"non-hex signature", secret, ts + ",v1=zz", body, signedAt, false},
+		{"bad timestamp", secret, "t=abc," + sig, body, signedAt, false},
+		{"empty", secret, "", body, signedAt, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifySignature(tt.secret, tt.header, tt.body, tt.now)
+			if tt.ok && err != nil {
+				t.Fatalf("err = %v, want nil", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrBadSignature) {
+				t.Fatalf("err = %v, want ErrBadSignature", err)
+			}
+		})
+	}
+}
+
+func TestFakeWebhook(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	f := NewFake([]byte("fake_secret"))
+	ev := Event{
+		AccountID:         "u1",
+		SubscriptionID:    "sub_1",
+		Status:            StatusActive,
+		CurrentPeriodEnd:  now.AddDate(0, 1, 0),
+		CancelAtPeriodEnd: true,
+	}
+	body, header, err := f.Webhook(ev, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := f.ParseWebhook(header, body, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID == "" || !got.CreatedAt.Equal(now) {
+		t.Fatalf("ID/CreatedAt not filled in: %+v", got)
+	}
+	if got.AccountID != ev.AccountID || got.SubscriptionID != ev.SubscriptionID || got.Status != ev.Status ||
+		!got.CurrentPeriodEnd.Equal(ev.CurrentPeriodEnd) || got.CancelAtPeriodEnd != ev.CancelAtPeriodEnd {
+		t.Fatalf("round trip = %+v, want %+v", got, ev)
+	}
+
+	if _, err := NewFake([]byte("other")).ParseWebhook(header, body, now); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("other secret: err = %v, want ErrBadSignature", err)
+	}
+
+	invalid := []struct {
+		name string
+		ev   Event
+	}{
+		{"unknown status", Event{AccountID: "u1", SubscriptionID: "sub_1", Status: "trialing"}},
+		{"no account", Event{SubscriptionID: "sub_1", Status: StatusActive}},
+		{"no subscription", Event{AccountID: "u1", Status: StatusActive}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			body, header, err := f.Webhook(tt.ev, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.ParseWebhook(header, body, now); !errors.Is(err, ErrBadPayload) {
+				t.Fatalf("err = %v, want ErrBadPayload", err)
+			}
+		})
+	}
+}
+
+func TestStripeWebhook(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s, err := NewStripe("sk_test", "whsec_test", "price_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const subscription = `{"id":"evt_1","type":%q,"created":1772366400,"data":{"object":{` +
+		`"id":"sub_1","status":%q,"current_period_end":1775044800,"cancel_at_period_end":false,` +
+		`"metadata":{"uid":"u1"}}}}`
+
+	tests := []struct {
+		name   string
+		body   string
+		status string
+		ignore bool
+		err    error
+	}{
+		{"created", fmt.Sprintf(subscription, "customer.subscription.created", "active"), StatusActive, false, nil},
+		{"trialing", fmt.Sprintf(subscription, "customer.subscription.updated", "trialing"), StatusActive, false, nil},
+		{"past due", fmt.Sprintf(subscription, "customer.subscription.updated", "past_due"), StatusPastDue, false, nil},
+		{"unpaid", fmt.Sprintf(subscription, "customer.subscription.updated", "unpaid"), StatusPastDue, false, nil},
+		{"incomplete", fmt.Sprintf(subscription, "customer.subscription.created", "incomplete"), StatusIncomplete, false, nil},
+		{"expired", fmt.Sprintf(subscription, "customer.subscription.updated", "incomplete_expired"), StatusCanceled, false, nil},
+		// Event deleted selalu canceled, apa pun status objeknya
+		{"deleted", fmt.Sprintf(subscription, "customer.subscription.deleted", "active"), StatusCanceled, false, nil},
+		{"other event", `{"id":"evt_2","type":"invoice.paid","created":1772366400}`, "", true, nil},
+		{"no uid", `{"id":"evt_3","type":"customer.subscription.updated","data":{"object":{"id":"sub_1"}}}`, "", false, ErrBadPayload},
+		{"not json", `{`, "", false, ErrBadPayload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(tt.body)
+			header := http.Header{}
+			header.Set("Stripe-Signature", Sign([]byte(s.WebhookSecret), body, now))
+			ev, err := s.ParseWebhook(header, body, now)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if tt.ignore {
+				if ev != nil {
+					t.Fatalf("event = %+v, want ignored", ev)
+				}
+				return
+			}
+			if ev.Status != tt.status {
+				t.Fatalf("status = %q, want %q", ev.Status, tt.status)
+			}
+			if ev.ID != "evt_1" || ev.AccountID != "u1" || ev.SubscriptionID != "sub_1" ||
+				!ev.CreatedAt.Equal(time.Unix(1772366400, 0)) || !ev.CurrentPeriodEnd.Equal(time.Unix(1775044800, 0)) {
+				t.Fatalf("event = %+v", ev)
+			}
+		})
+	}
+
+	body := []byte(fmt.Sprintf(subscription, "customer.subscription.created", "active"))
+	header := http.Header{}
+	header.Set("Stripe-Signature", Sign([]byte("whsec_other"), body, now))
+	if _, err := s.ParseWebhook(header, body, now); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("foreign signature: err = %v, want ErrBadSignature", err)
+	}
+}
@@ -0,0 +1,255 @@
+package billing
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"biomu/backend/internal/firebase"
+	"biomu/backend/internal/profile"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const sweepInterval = 10 * time.Minute
+
+// Subscription is the billing state of one account (document ID = uid).
+type Subscription struct {
+	Provider          string    `json:"provider" firestore:"provider"`
+	SubscriptionID    string    `json:"subscriptionId" firestore:"subscriptionId"`
+	Status            string    `json:"status" firestore:"status"`
+	CurrentPeriodEnd  time.Time `json:"currentPeriodEnd,omitempty" firestore:"currentPeriodEnd,omitempty"`
+	CancelAtPeriodEnd bool      `json:"cancelAtPeriodEnd" firestore:"cancelAtPeriodEnd"`
+	PastDueSince      time.Time `json:"pastDueSince,omitempty" firestore:"pastDueSince,omitempty"`
+	// AccessUntil: akhir akses membership jika tidak ada event baru (periode + masa tenggang)
+	AccessUntil time.Time `json:"accessUntil,omitempty" firestore:"accessUntil,omitempty"`
+	// Entitled: status akun saat ini "membership" karena langganan ini
+	Entitled bool `json:"entitled" firestore:"entitled"`
+	// DowngradeAt hanya diisi selama Entitled, supaya sweeper cukup query satu field
+	DowngradeAt time.Time `json:"-" firestore:"downgradeAt,omitempty"`
+	LastEventAt time.Time `json:"-" firestore:"lastEventAt"`
+	UpdatedAt   time.Time `json:"updatedAt" firestore:"updatedAt"`
+}
+
+// Store applies webhook events to subscription documents and keeps the account "status"
+// field in sync.
+type Store struct {
+	fb           *firebase.App
+	coll         string
+	eventsColl   string
+	accountsColl string
+	grace        time.Duration
+	now          func() time.Time
+}
+
+// NewStore creates a billing store. grace is how long membership survives a past-due payment
+// and a late renewal webhook.
+func NewStore(fb *firebase.App, coll, eventsColl, accountsColl string, grace time.Duration) *Store {
+	return &Store{fb: fb, coll: coll, eventsColl: eventsColl, accountsColl: accountsColl, grace: grace, now: time.Now}
+}
+
+// Get returns the subscription of uid, or nil.
+func (s *Store) Get(ctx context.Context, uid string) (*Subscription, error) {
+	doc, err := s.fb.DB.Collection(s.coll).Doc(uid).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sub Subscription
+	if err := doc.DataTo(&sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// Apply records a webhook event exactly once and moves the subscription to the state it
+// describes. Events older than the last applied one (provider mengirim ulang/tidak berurutan)
+// are recorded but do not change the state.
+func (s *Store) Apply(ctx context.Context, provider string, ev *Event) error {
+	ref := s.fb.DB.Collection(s.coll).Doc(ev.AccountID)
+	eventRef := s.fb.DB.Collection(s.eventsColl).Doc(provider + "_" + ev.ID)
+	account := s.fb.DB.Collection(s.accountsColl).Doc(ev.AccountID)
+	return s.fb.DB.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if _, err := tx.Get(eventRef); err == nil {
+			return nil
+		} else if status.Code(err) != codes.NotFound {
+			return err
+		}
+		var sub Subscription
+		doc, err := tx.Get(ref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			if err := doc.DataTo(&sub); err != nil {
+				return err
+			}
+		}
+		acc, err := tx.Get(account)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		accountExists := err == nil
+
+		stale := ev.CreatedAt.Before(sub.LastEventAt)
+		if err := tx.Create(eventRef, map[string]any{
+			"provider":       provider,
+			"accountId":      ev.AccountID,
+			"subscriptionId": ev.SubscriptionID,
+			"status":         ev.Status,
+			"createdAt":      ev.CreatedAt,
+			"receivedAt":     s.now().UTC(),
+			"stale":          stale,
+		}); err != nil {
+			return err
+		}
+		if stale {
+			return nil
+		}
+
+		wasEntitled := sub.Entitled
+		s.transition(&sub, provider, ev)
+		if err := tx.Set(ref, sub); err != nil {
+			return err
+		}
+		// Status akun hanya diturunkan jika langganan ini yang menaikkannya (membership manual
+		// oleh admin tidak tersentuh)
+		var next string
+		switch {
+		case !accountExists:
+		case sub.Entitled && currentStatus(acc) != profile.StatusMembership:
+			next = profile.StatusMembership
+		case wasEntitled && !sub.Entitled:
+			next = profile.StatusRegular
+		}
+		if next == "" {
+			return nil
+		}
+		return tx.Update(account, []firestore.Update{
+			{Path: "status", Value: next},
+			{Path: "updatedAt", Value: firestore.ServerTimestamp},
+		})
+	})
+}
+
+// transition computes the new state of sub from ev:
+//   - active: akses sampai akhir periode + masa tenggang (renewal yang terlambat tidak memutus akses)
+//   - past_due: akses sampai masa tenggang sejak pembayaran pertama gagal
+//   - canceled: akses sampai akhir periode yang sudah dibayar, tanpa tenggang
+//   - incomplete: tanpa akses
+func (s *Store) transition(sub *Subscription, provider string, ev *Event) {
+	now := s.now().UTC()
+	if sub.SubscriptionID != ev.SubscriptionID {
+		// Langganan baru (mis. berlangganan lagi setelah cancel): state lama tidak dibawa
+		sub.CurrentPeriodEnd, sub.PastDueSince = time.Time{}, time.Time{}
+	}
+	sub.Provider, sub.SubscriptionID, sub.Status = provider, ev.SubscriptionID, ev.Status
+	sub.CancelAtPeriodEnd, sub.LastEventAt, sub.UpdatedAt = ev.CancelAtPeriodEnd, ev.CreatedAt, now
+	if !ev.CurrentPeriodEnd.IsZero() {
+		sub.CurrentPeriodEnd = ev.CurrentPeriodEnd
+	}
+	if ev.Status != StatusPastDue {
+		sub.PastDueSince = time.Time{}
+	}
+	switch ev.Status {
+	case StatusActive:
+		sub.AccessUntil = sub.CurrentPeriodEnd.Add(s.grace)
+	case StatusPastDue:
+		if sub.PastDueSince.IsZero() {
+			sub.PastDueSince = ev.CreatedAt
+		}
+		sub.AccessUntil = sub.PastDueSince.Add(s.grace)
+	case StatusCanceled:
+		sub.AccessUntil = sub.CurrentPeriodEnd
+	default:
+		sub.AccessUntil = time.Time{}
+	}
+	sub.Entitled = sub.AccessUntil.After(now)
+	sub.DowngradeAt = time.Time{}
+	if sub.Entitled {
+		sub.DowngradeAt = sub.AccessUntil
+	}
+}
+
+// Run downgrades accounts whose access ended without a newer event until ctx is cancelled.
+func (s *Store) Run(ctx context.Context) {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.sweep(ctx); err != nil {
+			log.Printf("billing sweep: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Store) sweep(ctx context.Context) error {
+	it := s.fb.DB.Collection(s.coll).Where("downgradeAt", "<=", s.now().UTC()).Limit(200).Documents(ctx)
+	defer it.Stop()
+	for {
+		doc, err := it.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.expire(ctx, doc.Ref.ID); err != nil {
+			log.Printf("billing sweep %s: %v", doc.Ref.ID, err)
+		}
+	}
+}
+
+// expire ends access for uid if it is still due (dicek ulang di dalam transaksi).
+func (s *Store) expire(ctx context.Context, uid string) error {
+	ref := s.fb.DB.Collection(s.coll).Doc(uid)
+	account := s.fb.DB.Collection(s.accountsColl).Doc(uid)
+	return s.fb.DB.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		var sub Subscription
+		if err := doc.DataTo(&sub); err != nil {
+			return err
+		}
+		now := s.now().UTC()
+		if !sub.Entitled || sub.DowngradeAt.IsZero() || sub.DowngradeAt.After(now) {
+			return nil
+		}
+		_, err = tx.Get(account)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		accountExists := err == nil
+		sub.Entitled, sub.DowngradeAt, sub.UpdatedAt = false, time.Time{}, now
+		if err := tx.Set(ref, sub); err != nil {
+			return err
+		}
+		if !accountExists {
+			return nil
+		}
+		log.Printf("billing: membership of %s ended (%s)", uid, sub.Status)
+		return tx.Update(account, []firestore.Update{
+			{Path: "status", Value: profile.StatusRegular},
+			{Path: "updatedAt", Value: firestore.ServerTimestamp},
+		})
+	})
+}
+
+func currentStatus(acc *firestore.DocumentSnapshot) string {
+	if acc == nil || !acc.Exists() {
+		return ""
+	}
+	s, _ := acc.Data()["status"].(string)
+	return s
+}
@@ -0,0 +1,124 @@
+package billing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTransition(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	grace := 72 * time.Hour
+	periodEnd := now.AddDate(0, 0, 20)
+	lapsed := now.Add(-24 * time.Hour)
+
+	tests := []struct {
+		name        string
+		sub         Subscription
+		ev          Event
+		accessUntil time.Time
+		entitled    bool
+		pastDue     time.Time
+	}{
+		{
+			name:        "active",
+			ev:          Event{SubscriptionID: "sub_1", Status: StatusActive, CurrentPeriodEnd: periodEnd, CreatedAt: now},
+			accessUntil: periodEnd.Add(grace),
+			entitled:    true,
+		},
+		{
+			// Renewal terlambat: periode sudah lewat tapi masih dalam tenggang
+			name:        "active, late renewal",
+			ev:          Event{SubscriptionID: "sub_1", Status: StatusActive, CurrentPeriodEnd: lapsed, CreatedAt: now},
+			accessUntil: lapsed.Add(grace),
+			entitled:    true,
+		},
+		{
+			name:        "active keeps the known period end",
+			sub:         Subscription{SubscriptionID: "sub_1", CurrentPeriodEnd: periodEnd},
+			ev:          Event{SubscriptionID: "sub_1", Status: StatusActive, CreatedAt: now},
+			accessUntil: periodEnd.Add(grace),
+			entitled:    true,
+		},
+		{
+			name:        "first past due",
+			sub:         Subscription{SubscriptionID: "sub_1", Status: StatusActive, CurrentPeriodEnd: periodEnd},
+			ev:          Event{SubscriptionID: "sub_1", Status: StatusPastDue, CreatedAt: now.Add(-time.Hour)},
+			accessUntil: now.Add(-time.Hour).Add(grace),
+			entitled:    true,
+			pastDue:     now.Add(-time.Hour),
+		},
+		{
+			// Retry pembayaran yang gagal lagi tidak memperpanjang tenggang
+			name:        "repeated past due",
+			sub:         Subscription{SubscriptionID: "sub_1", Status: StatusPastDue, PastDueSince: now.Add(-100 * time.Hour)},
+			ev:          Event{SubscriptionID: "sub_1", Status: StatusPastDue, CreatedAt: now},
+			accessUntil: now.Add(-100 * time.Hour).Add(grace),
+			entitled:    false,
+			pastDue:     now.Add(-100 * time.Hour),
+		},
+		{
+			name:        "recovered from past due",
+			sub:         Subscription{SubscriptionID: "sub_1", Status: StatusPastDue, PastDueSince: now.Add(-time.Hour)},
+			ev:          Event{SubscriptionID: "sub_1", Status: StatusActive, CurrentPeriodEnd: periodEnd, CreatedAt: now},
+			accessUntil: periodEnd.Add(grace),
+			entitled:    true,
+		},
+		{
+			name:        "canceled at period end",
+			sub:         Subscription{SubscriptionID: "sub_1", Status: StatusActive, CurrentPeriodEnd: periodEnd},
+			ev:          Event{SubscriptionID: "sub_1", Status: StatusCanceled, CreatedAt: now},
+			accessUntil: periodEnd,
+			entitled:    true,
+		},
+		{
+			// Cancel tidak mendapat masa tenggang
+			name:        "canceled after period end",
+			ev:          Event{SubscriptionID: "sub_1", Status: StatusCanceled, CurrentPeriodEnd: lapsed, CreatedAt: now},
+			accessUntil: lapsed,
+			entitled:    false,
+		},
+		{
+			name:     "incomplete",
+			ev:       Event{SubscriptionID: "sub_1", Status: StatusIncomplete, CurrentPeriodEnd: periodEnd, CreatedAt: now},
+			entitled: false,
+		},
+		{
+			// Langganan baru tidak mewarisi periode dan past due langganan lama
+			name:     "new subscription resets state",
+			sub:      Subscription{SubscriptionID: "sub_old", Status: StatusPastDue, CurrentPeriodEnd: periodEnd, PastDueSince: now.Add(-time.Hour)},
+			ev:       Event{SubscriptionID: "sub_new", Status: StatusIncomplete, CreatedAt: now},
+			entitled: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStore(nil, "subscriptions", "billing_events", "users", grace)
+			s.now = func() time.Time { return now }
+			sub := tt.sub
+			ev := tt.ev
+			s.transition(&sub, "fake", &ev)
+
+			if !sub.AccessUntil.Equal(tt.accessUntil) {
+				t.Fatalf("AccessUntil = %v, want %v", sub.AccessUntil, tt.accessUntil)
+			}
+			if sub.Entitled != tt.entitled {
+				t.Fatalf("Entitled = %v, want %v", sub.Entitled, tt.entitled)
+			}
+			if !sub.PastDueSince.Equal(tt.pastDue) {
+				t.Fatalf("PastDueSince = %v, want %v", sub.PastDueSince, tt.pastDue)
+			}
+			// Sweeper hanya melihat DowngradeAt selama akun masih entitled
+			wantDowngrade := time.Time{}
+			if tt.entitled {
+				wantDowngrade = tt.accessUntil
+			}
+			if !sub.DowngradeAt.Equal(wantDowngrade) {
+				t.Fatalf("DowngradeAt = %v, want %v", sub.DowngradeAt, wantDowngrade)
+			}
+			if sub.Provider != "fake" || sub.SubscriptionID != ev.SubscriptionID || sub.Status != ev.Status ||
+				!sub.LastEventAt.Equal(ev.CreatedAt) || !sub.UpdatedAt.Equal(now) {
+				t.Fatalf("subscription = %+v", sub)
+			}
+		})
+	}
+}
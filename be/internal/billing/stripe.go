@@ -0,0 +1,149 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const stripeAPIDefault = "https://api.stripe.com"
+
+// Stripe creates subscription Checkout Sessions and reads customer.subscription.* webhooks.
+// The account uid travels as client_reference_id and subscription metadata "uid".
+type Stripe struct {
+	SecretKey     string
+	WebhookSecret string
+	PriceID       string
+	// APIBase defaults to https://api.stripe.com (bisa diarahkan ke stripe-mock untuk test)
+	APIBase string
+	Client  *http.Client
+}
+
+func NewStripe(secretKey, webhookSecret, priceID string) (*Stripe, error) {
+	if secretKey == "" || webhookSecret == "" || priceID == "" {
+		return nil, fmt.Errorf("stripe: secret key, webhook secret and price ID are required")
+	}
+	return &Stripe{
+		SecretKey:     secretKey,
+		WebhookSecret: webhookSecret,
+		PriceID:       priceID,
+		APIBase:       stripeAPIDefault,
+		Client:        &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+func (s *Stripe) Name() string { return "stripe" }
+
+func (s *Stripe) CreateCheckout(ctx context.Context, req CheckoutRequest) (*Checkout, error) {
+	form := url.Values{
+		"mode":                             {"subscription"},
+		"line_items[0][price]":             {s.PriceID},
+		"line_items[0][quantity]":          {"1"},
+		"success_url":                      {req.SuccessURL},
+		"cancel_url":                       {req.CancelURL},
+		"client_reference_id":              {req.AccountID},
+		"subscription_data[metadata][uid]": {req.AccountID},
+	}
+	if req.Email != "" {
+		form.Set("customer_email", req.Email)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(s.APIBase, "/")+"/v1/checkout/sessions", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+s.SecretKey)
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// Request yang diulang (mis. double click) tidak membuat session ganda dalam window Stripe
+	httpReq.Header.Set("Idempotency-Key", "checkout-"+req.AccountID+"-"+time.Now().UTC().Format("200601021504"))
+	resp, err := s.Client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(body, &e)
+		return nil, fmt.Errorf("stripe: checkout: %s: %s", resp.Status, e.Error.Message)
+	}
+	var out Checkout
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("stripe: checkout: %w", err)
+	}
+	return &out, nil
+}
+
+type stripeEvent struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		Object struct {
+			ID                string            `json:"id"`
+			Status            string            `json:"status"`
+			CurrentPeriodEnd  int64             `json:"current_period_end"`
+			CancelAtPeriodEnd bool              `json:"cancel_at_period_end"`
+			Metadata          map[string]string `json:"metadata"`
+		} `json:"object"`
+	} `json:"data"`
+}
+
+func (s *Stripe) ParseWebhook(header http.Header, body []byte, now time.Time) (*Event, error) {
+	if err := VerifySignature([]byte(s.WebhookSecret), header.Get("Stripe-Signature"), body, now); err != nil {
+		return nil, err
+	}
+	var ev stripeEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return nil, ErrBadPayload
+	}
+	switch ev.Type {
+	case "customer.subscription.created", "customer.subscription.updated", "customer.subscription.deleted":
+	default:
+		return nil, nil
+	}
+	obj := ev.Data.Object
+	if ev.ID == "" || obj.ID == "" || obj.Metadata["uid"] == "" {
+		return nil, ErrBadPayload
+	}
+	out := &Event{
+		ID:                ev.ID,
+		CreatedAt:         time.Unix(ev.Created, 0).UTC(),
+		AccountID:         obj.Metadata["uid"],
+		SubscriptionID:    obj.ID,
+		Status:            stripeStatus(obj.Status),
+		CancelAtPeriodEnd: obj.CancelAtPeriodEnd,
+	}
+	if obj.CurrentPeriodEnd > 0 {
+		out.CurrentPeriodEnd = time.Unix(obj.CurrentPeriodEnd, 0).UTC()
+	}
+	if ev.Type == "customer.subscription.deleted" {
+		out.Status = StatusCanceled
+	}
+	return out, nil
+}
+
+// stripeStatus maps Stripe subscription statuses to the normalized states.
+func stripeStatus(s string) string {
+	switch s {
+	case "active", "trialing":
+		return StatusActive
+	case "past_due", "unpaid":
+		return StatusPastDue
+	case "canceled", "incomplete_expired":
+		return StatusCanceled
+	default:
+		return StatusIncomplete
+	}
+}
@@ -0,0 +1,52 @@
+// Package blob menyimpan file biner (media upload) di balik satu interface: disk lokal untuk
+// dev/test dan Firebase Storage untuk produksi.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+var (
+	// ErrInvalidKey is returned for keys that are empty, absolute or contain "..".
+	ErrInvalidKey = errors.New("invalid blob key")
+	ErrNotFound   = errors.New("blob not found")
+)
+
+// Store keeps immutable objects addressed by key (mis. "media/{uid}/{id}/256.webp").
+type Store interface {
+	// Put writes data under key and returns the URL clients use to fetch it.
+	Put(ctx context.Context, key, contentType string, data []byte) (string, error)
+	// Delete removes key; deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Opener reads objects back through the backend, for files that are never linked publicly
+// (mis. produk digital yang hanya bisa diunduh lewat URL bertanda tangan).
+type Opener interface {
+	// Open returns the object's content and size; a missing key returns ErrNotFound.
+	Open(ctx context.Context, key string) (io.ReadCloser, int64, error)
+}
+
+// CacheControl is sent for every stored object: keys are never reused, so objects can be
+// cached forever.
+const CacheControl = "public, max-age=31536000, immutable"
+
+// CleanKey validates a key: relative, slash-separated, without "." or ".." segments.
+func CleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || strings.ContainsRune(key, 0) {
+		return "", ErrInvalidKey
+	}
+	if path.Clean(key) != key {
+		return "", ErrInvalidKey
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "." || seg == ".." || seg == "" {
+			return "", ErrInvalidKey
+		}
+	}
+	return key, nil
+}
@@ -0,0 +1,92 @@
+package blob
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/url"
+
+	"biomu/backend/internal/firebase"
+
+	"cloud.google.com/go/storage"
+)
+
+// Firebase stores objects in a Firebase Storage (GCS) bucket. URLs use the Firebase download
+// token scheme, so the bucket itself does not have to be public.
+type Firebase struct {
+	bucket     *storage.BucketHandle
+	bucketName string
+}
+
+func NewFirebase(fb *firebase.App, bucketName string) (*Firebase, error) {
+	if bucketName == "" {
+		return nil, errors.New("storage bucket is required")
+	}
+	b, err := fb.Storage.Bucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+	return &Firebase{bucket: b, bucketName: bucketName}, nil
+}
+
+func (f *Firebase) Put(ctx context.Context, key, contentType string, data []byte) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	token, err := downloadToken()
+	if err != nil {
+		return "", err
+	}
+	// DoesNotExist: key tidak pernah ditimpa (objek dianggap immutable)
+	w := f.bucket.Object(key).If(storage.Conditions{DoesNotExist: true}).NewWriter(ctx)
+	w.ContentType = contentType
+	w.CacheControl = CacheControl
+	w.Metadata = map[string]string{"firebaseStorageDownloadTokens": token}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return "https://firebasestorage.googleapis.com/v0/b/" + url.PathEscape(f.bucketName) + "/o/" +
+		url.PathEscape(key) + "?alt=media&token=" + token, nil
+}
+
+func (f *Firebase) Delete(ctx context.Context, key string) error {
+	key, err := CleanKey(key)
+	if err != nil {
+		return err
+	}
+	err = f.bucket.Object(key).Delete(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (f *Firebase) Open(ctx context.Context, key string) (io.ReadCloser, int64, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	r, err := f.bucket.Object(key).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, 0, ErrNotFound
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return r, r.Attrs.Size, nil
+}
+
+func downloadToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
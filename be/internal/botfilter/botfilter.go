@@ -0,0 +1,288 @@
+// Package botfilter mengklasifikasikan hit analytics sebagai human, bot, atau suspicious
+// berdasarkan daftar pola User-Agent plus heuristik perilaku (HEAD request, burst per
+// visitor ter-hash, dan klik tanpa beacon JavaScript maupun referrer).
+package botfilter
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"biomu/backend/internal/firebase"
+)
+
+const (
+	ClassHuman      = "human"
+	ClassBot        = "bot"
+	ClassSuspicious = "suspicious"
+)
+
+const (
+	burstWindow    = time.Minute
+	burstThreshold = 10
+	beaconTTL      = 30 * time.Minute
+	maxPatterns    = 1000
+	maxPatternLen  = 200
+	settingsDocID  = "botPatterns"
+	refreshDefault = 5 * time.Minute
+)
+
+//go:embed patterns.txt
+var bundledPatterns string
+
+// Verdict is the classification result for one hit.
+type Verdict struct {
+	Class  string
+	Reason string
+}
+
+func (v Verdict) Human() bool { return v.Class == ClassHuman }
+
+// Hit describes one request to classify.
+type Hit struct {
+	Request   *http.Request
+	ProfileID string
+	VisitorID string
+	// Click menandai klik lewat redirect (heuristik "tanpa beacon JS" hanya berlaku untuk klik).
+	Click    bool
+	Referrer string
+}
+
+// Classifier is safe for concurrent use. The pattern set can be replaced at runtime.
+type Classifier struct {
+	fb           *firebase.App
+	settingsColl string
+
+	mu       sync.RWMutex
+	patterns []string
+	re       *regexp.Regexp
+	custom   bool
+
+	stateMu sync.Mutex
+	bursts  map[string][]time.Time
+	beacons map[string]time.Time
+	now     func() time.Time
+}
+
+// NewClassifier loads the bundled pattern list. If fb is non-nil, Reload/Run read the
+// admin-managed override from settingsColl/botPatterns.
+func NewClassifier(fb *firebase.App, settingsColl string) *Classifier {
+	c := &Classifier{
+		fb:           fb,
+		settingsColl: settingsColl,
+		bursts:       map[string][]time.Time{},
+		beacons:      map[string]time.Time{},
+		now:          time.Now,
+	}
+	if err := c.setPatterns(ParsePatterns(bundledPatterns), false); err != nil {
+		panic("botfilter: bundled patterns: " + err.Error())
+	}
+	return c
+}
+
+// ParsePatterns splits a pattern list: one regex per line, blank lines and # comments ignored.
+func ParsePatterns(text string) []string {
+	var out []string
+	sc := bufio.NewScanner(strings.NewReader(text))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+// Compile validates patterns and combines them into one case-insensitive regexp.
+func Compile(patterns []string) (*regexp.Regexp, error) {
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("pattern list is empty")
+	}
+	if len(patterns) > maxPatterns {
+		return nil, fmt.Errorf("too many patterns (max %d)", maxPatterns)
+	}
+	parts := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		if len(p) > maxPatternLen {
+			return nil, fmt.Errorf("pattern too long: %.40q", p)
+		}
+		if _, err := regexp.Compile(p); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", p, err)
+		}
+		parts = append(parts, "(?:"+p+")")
+	}
+	return regexp.Compile("(?i)" + strings.Join(parts, "|"))
+}
+
+func (c *Classifier) setPatterns(patterns []string, custom bool) error {
+	re, err := Compile(patterns)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.patterns, c.re, c.custom = patterns, re, custom
+	c.mu.Unlock()
+	return nil
+}
+
+// Patterns returns the active pattern list and whether it comes from the admin override.
+func (c *Classifier) Patterns() ([]string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]string(nil), c.patterns...), c.custom
+}
+
+// IsBotUA reports whether ua matches the active pattern list. An empty UA counts as a bot.
+func (c *Classifier) IsBotUA(ua string) bool {
+	if strings.TrimSpace(ua) == "" {
+		return true
+	}
+	c.mu.RLock()
+	re := c.re
+	c.mu.RUnlock()
+	return re.MatchString(ua)
+}
+
+// Classify tags a hit. Order: HEAD and UA patterns are bots; burst rate per visitor and
+// clicks without a prior JS beacon nor referrer are suspicious; everything else is human.
+func (c *Classifier) Classify(h Hit) Verdict {
+	r := h.Request
+	if r.Method == http.MethodHead {
+		return Verdict{Class: ClassBot, Reason: "head_request"}
+	}
+	if c.IsBotUA(r.UserAgent()) {
+		return Verdict{Class: ClassBot, Reason: "user_agent"}
+	}
+	now := c.now()
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	if h.VisitorID != "" && c.burstLocked(h.VisitorID, now) {
+		return Verdict{Class: ClassSuspicious, Reason: "burst"}
+	}
+	if h.Click && h.Referrer == "" && !c.sawBeaconLocked(h.VisitorID, h.ProfileID, now) {
+		return Verdict{Class: ClassSuspicious, Reason: "no_beacon"}
+	}
+	return Verdict{Class: ClassHuman}
+}
+
+// ObserveBeacon records that visitorID loaded profileID with JavaScript enabled.
+func (c *Classifier) ObserveBeacon(visitorID, profileID string) {
+	if visitorID == "" {
+		return
+	}
+	c.stateMu.Lock()
+	c.beacons[visitorID+"|"+profileID] = c.now()
+	c.stateMu.Unlock()
+}
+
+func (c *Classifier) sawBeaconLocked(visitorID, profileID string, now time.Time) bool {
+	if visitorID == "" {
+		return false
+	}
+	t, ok := c.beacons[visitorID+"|"+profileID]
+	return ok && now.Sub(t) < beaconTTL
+}
+
+func (c *Classifier) burstLocked(visitorID string, now time.Time) bool {
+	hits := c.bursts[visitorID]
+	kept := hits[:0]
+	for _, t := range hits {
+		if now.Sub(t) < burstWindow {
+			kept = append(kept, t)
+		}
+	}
+	kept = append(kept, now)
+	c.bursts[visitorID] = kept
+	return len(kept) > burstThreshold
+}
+
+// gc drops expired burst and beacon state so memory stays bounded.
+func (c *Classifier) gc() {
+	now := c.now()
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	for k, hits := range c.bursts {
+		if len(hits) == 0 || now.Sub(hits[len(hits)-1]) >= burstWindow {
+			delete(c.bursts, k)
+		}
+	}
+	for k, t := range c.beacons {
+		if now.Sub(t) >= beaconTTL {
+			delete(c.beacons, k)
+		}
+	}
+}
+
+// Reload reads the admin override; without one the bundled list is used.
+func (c *Classifier) Reload(ctx context.Context) error {
+	if c.fb == nil {
+		return nil
+	}
+	snap, err := c.fb.DB.Collection(c.settingsColl).Doc(settingsDocID).Get(ctx)
+	if err != nil {
+		if !snap.Exists() {
+			return c.setPatterns(ParsePatterns(bundledPatterns), false)
+		}
+		return err
+	}
+	raw, _ := snap.Data()["patterns"].([]any)
+	patterns := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+			patterns = append(patterns, strings.TrimSpace(s))
+		}
+	}
+	if len(patterns) == 0 {
+		return c.setPatterns(ParsePatterns(bundledPatterns), false)
+	}
+	return c.setPatterns(patterns, true)
+}
+
+// Save validates and persists an override, then activates it immediately.
+// A nil/empty list resets to the bundled patterns.
+func (c *Classifier) Save(ctx context.Context, patterns []string, updatedBy string) error {
+	if len(patterns) == 0 {
+		if _, err := c.fb.DB.Collection(c.settingsColl).Doc(settingsDocID).Delete(ctx); err != nil {
+			return err
+		}
+		return c.setPatterns(ParsePatterns(bundledPatterns), false)
+	}
+	if _, err := Compile(patterns); err != nil {
+		return err
+	}
+	_, err := c.fb.DB.Collection(c.settingsColl).Doc(settingsDocID).Set(ctx, map[string]any{
+		"patterns":  patterns,
+		"updatedBy": updatedBy,
+		"updatedAt": time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	return c.setPatterns(patterns, true)
+}
+
+// Run periodically reloads the override (so every instance picks up admin changes) and
+// garbage-collects heuristic state, until ctx is cancelled.
+func (c *Classifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(refreshDefault)
+	defer ticker.Stop()
+	for {
+		if err := c.Reload(ctx); err != nil {
+			log.Printf("botfilter reload: %v", err)
+		}
+		c.gc()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package botfilter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestClassifier(now *time.Time) *Classifier {
+	c := NewClassifier(nil, "settings")
+	c.now = func() time.Time { return *now }
+	return c
+}
+
+func request(method, ua string) *http.Request {
+	r := httptest.NewRequest(method, "/r/l1", nil)
+	r.Header.Del("User-Agent")
+	if ua != "" {
+		r.Header.Set("User-Agent", ua)
+	}
+	return r
+}
+
+func TestBundledPatterns(t *testing.T) {
+	c := NewClassifier(nil, "settings")
+	bots := []string{
+		"",
+		"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)",
+		"meta-externalagent/1.1 (+https://developers.facebook.com/docs/sharing/webmasters/crawler)",
+		"Twitterbot/1.0",
+		"WhatsApp/2.23.20.0 A",
+		"TelegramBot (like TwitterBot)",
+		"Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)",
+		"Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)",
+		"LinkedInBot/1.0 (compatible; Mozilla/5.0; Apache-HttpClient +http://www.linkedin.com)",
+		"Pinterest/0.2 (+https://www.pinterest.com/bot.html)",
+		"Mozilla/5.0 (compatible; Pinterestbot/1.0; +http://www.pinterest.com/bot.html)",
+		"Mozilla/5.0 (compatible; Snap URL Preview Service; bot; snapchat_preview@snap.com)",
+		"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+		"Mozilla/5.0 (Linux; Android 6.0.1; Nexus 5X Build/MMB29P) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.199 Mobile Safari/537.36 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+		"Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm)",
+		"Mozilla/5.0 (compatible; YandexBot/3.0; +http://yandex.com/bots)",
+		"Mozilla/5.0 (compatible; AhrefsBot/7.0; +http://ahrefs.com/robot/)",
+		"Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; GPTBot/1.2; +https://openai.com/gptbot)",
+		"Mozilla/5.0 (compatible; UptimeRobot/2.0; http://www.uptimerobot.com/)",
+		"curl/8.4.0",
+		"Wget/1.21.4",
+		"python-requests/2.31.0",
+		"Go-http-client/2.0",
+		"okhttp/4.12.0",
+		"axios/1.6.2",
+		"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/120.0.6099.71 Safari/537.36",
+		"Mozilla/5.0 (Unknown; Linux x86_64) AppleWebKit/538.1 (KHTML, like Gecko) PhantomJS/2.1.1 Safari/538.1",
+		"Mozilla/5.0 (Linux; Android 11; moto g power (2022)) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/109.0.0.0 Mobile Safari/537.36 Chrome-Lighthouse",
+		"Mozilla/5.0 (compatible; SomeNewCrawler/0.1)",
+	}
+	for _, ua := range bots {
+		if !c.IsBotUA(ua) {
+			t.Errorf("not flagged as bot: %q", ua)
+		}
+	}
+
+	// Browser biasa, termasuk in-app browser yang banyak membuka link bio, tidak boleh kena
+	humans := []string{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+		"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0",
+		"Mozilla/5.0 (Linux; Android 13; SM-A546E) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36",
+		"Mozilla/5.0 (Linux; Android 12; 2201117TG) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36 OPR/79.0.4195.76505",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 Instagram 311.0.2.23.108 (iPhone14,5; iOS 17_1; id_ID; id; scale=3.00; 1170x2532; 545049127)",
+		"Mozilla/5.0 (Linux; Android 13; RMX3630 Build/TP1A.220905.001; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/120.0.6099.144 Mobile Safari/537.36 [FB_IAB/FB4A;FBAV/445.0.0.34.118;]",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 musical_ly_32.5.0 JsSdk/2.0 NetType/WIFI Channel/App Store ByteLocale/id Region/ID",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 16_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 Snapchat/12.40.0.38 (like Safari/8615.2.9.10.4, panda)",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 [Pinterest/iOS]",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 Twitter for iPhone/10.18",
+		"Mozilla/5.0 (Linux; Android 12; M2101K6G) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.6045.193 Mobile Safari/537.36 Line/13.21.1",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 LinkedInApp/9.29.1",
+		// "bot" dan "spider" hanya cocok sebagai kata utuh atau bagian nama crawler
+		"Mozilla/5.0 (Linux; Android 13; Robot X) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+	}
+	for _, ua := range humans {
+		if c.IsBotUA(ua) {
+			t.Errorf("browser flagged as bot: %q", ua)
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	re, err := Compile([]string{"mybot", "^acme-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !re.MatchString("Mozilla/5.0 (compatible; MyBot/1.0)") || !re.MatchString("ACME-checker") || re.MatchString("x acme-checker") {
+		t.Fatalf("combined pattern %q matched wrongly", re)
+	}
+
+	errs := map[string][]string{
+		"empty":    nil,
+		"too long": {strings.Repeat("a", maxPatternLen+1)},
+		"invalid":  {"ok", "(unclosed"},
+		"too many": make([]string, maxPatterns+1),
+	}
+	for name, patterns := range errs {
+		if _, err := Compile(patterns); err == nil {
+			t.Errorf("%s: Compile accepted %d patterns", name, len(patterns))
+		}
+	}
+}
+
+func TestParsePatterns(t *testing.T) {
+	got := ParsePatterns("# komentar\n\n  googlebot  \n^curl/\n")
+	if len(got) != 2 || got[0] != "googlebot" || got[1] != "^curl/" {
+		t.Fatalf("ParsePatterns = %q", got)
+	}
+}
+
+func TestCustomPatterns(t *testing.T) {
+	c := NewClassifier(nil, "settings")
+	if err := c.setPatterns([]string{"acmebot"}, true); err != nil {
+		t.Fatal(err)
+	}
+	if patterns, custom := c.Patterns(); len(patterns) != 1 || !custom {
+		t.Fatalf("Patterns() = %v, %v", patterns, custom)
+	}
+	// Override menggantikan daftar bawaan sepenuhnya
+	if c.IsBotUA("Googlebot/2.1") || !c.IsBotUA("AcmeBot/1.0") {
+		t.Fatal("override not active")
+	}
+	// UA kosong tetap bot apa pun daftarnya
+	if !c.IsBotUA("  ") {
+		t.Fatal("empty UA not flagged")
+	}
+}
+
+const chromeUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+func TestClassify(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := newTestClassifier(&now)
+	c.ObserveBeacon("v-beacon", "p1")
+
+	tests := []struct {
+		name   string
+		hit    Hit
+		class  string
+		reason string
+	}{
+		{"head", Hit{Request: request(http.MethodHead, chromeUA), VisitorID: "v1"}, ClassBot, "head_request"},
+		{"crawler", Hit{Request: request(http.MethodGet, "Googlebot/2.1"), VisitorID: "v1"}, ClassBot, "user_agent"},
+		{"empty ua", Hit{Request: request(http.MethodGet, ""), VisitorID: "v1"}, ClassBot, "user_agent"},
+		{"page view", Hit{Request: request(http.MethodGet, chromeUA), ProfileID: "p1", VisitorID: "v2"}, ClassHuman, ""},
+		{"click without beacon", Hit{Request: request(http.MethodGet, chromeUA), ProfileID: "p1", VisitorID: "v3", Click: true}, ClassSuspicious, "no_beacon"},
+		{"click without visitor", Hit{Request: request(http.MethodGet, chromeUA), ProfileID: "p1", Click: true}, ClassSuspicious, "no_beacon"},
+		{"click with referrer", Hit{Request: request(http.MethodGet, chromeUA), ProfileID: "p1", VisitorID: "v4", Click: true, Referrer: "instagram.com"}, ClassHuman, ""},
+		{"click after beacon", Hit{Request: request(http.MethodGet, chromeUA), ProfileID: "p1", VisitorID: "v-beacon", Click: true}, ClassHuman, ""},
+		// beacon berlaku per profil
+		{"beacon other profile", Hit{Request: request(http.MethodGet, chromeUA), ProfileID: "p2", VisitorID: "v-beacon", Click: true}, ClassSuspicious, "no_beacon"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := c.Classify(tt.hit)
+			if v.Class != tt.class || v.Reason != tt.reason {
+				t.Fatalf("Classify = %+v, want %s %s", v, tt.class, tt.reason)
+			}
+			if v.Human() != (tt.class == ClassHuman) {
+				t.Fatalf("Human() = %v", v.Human())
+			}
+		})
+	}
+}
+
+func TestClassifyBeaconExpires(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := newTestClassifier(&now)
+	c.ObserveBeacon("v1", "p1")
+	hit := Hit{Request: request(http.MethodGet, chromeUA), ProfileID: "p1", VisitorID: "v1", Click: true}
+
+	now = now.Add(beaconTTL - time.Second)
+	if v := c.Classify(hit); !v.Human() {
+		t.Fatalf("before TTL: %+v", v)
+	}
+	now = now.Add(time.Second)
+	if v := c.Classify(hit); v.Reason != "no_beacon" {
+		t.Fatalf("after TTL: %+v", v)
+	}
+}
+
+func TestClassifyBurst(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := newTestClassifier(&now)
+	hit := Hit{Request: request(http.MethodGet, chromeUA), ProfileID: "p1", VisitorID: "v1"}
+
+	for i := 0; i < burstThreshold; i++ {
+		if v := c.Classify(hit); !v.Human() {
+			t.Fatalf("hit %d: %+v", i+1, v)
+		}
+		now = now.Add(time.Second)
+	}
+	if v := c.Classify(hit); v.Class != ClassSuspicious || v.Reason != "burst" {
+		t.Fatalf("hit %d: %+v, want burst", burstThreshold+1, v)
+	}
+	// Visitor lain tidak ikut terhitung
+	other := hit
+	other.VisitorID = "v2"
+	if v := c.Classify(other); !v.Human() {
+		t.Fatalf("other visitor: %+v", v)
+	}
+	// Tanpa visitor ID tidak ada hitungan burst
+	anon := hit
+	anon.VisitorID = ""
+	for i := 0; i <= burstThreshold; i++ {
+		if v := c.Classify(anon); !v.Human() {
+			t.Fatalf("anonymous hit %d: %+v", i+1, v)
+		}
+	}
+	// Setelah jendela lewat, visitor kembali dianggap human
+	now = now.Add(burstWindow)
+	if v := c.Classify(hit); !v.Human() {
+		t.Fatalf("after window: %+v", v)
+	}
+}
+
+func TestGC(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := newTestClassifier(&now)
+	c.ObserveBeacon("v1", "p1")
+	c.Classify(Hit{Request: request(http.MethodGet, chromeUA), VisitorID: "v1"})
+
+	now = now.Add(burstWindow)
+	c.gc()
+	if len(c.bursts) != 0 || len(c.beacons) != 1 {
+		t.Fatalf("after burst window: %d bursts, %d beacons", len(c.bursts), len(c.beacons))
+	}
+	now = now.Add(beaconTTL)
+	c.gc()
+	if len(c.beacons) != 0 {
+		t.Fatalf("after beacon TTL: %d beacons", len(c.beacons))
+	}
+}
@@ -0,0 +1,101 @@
+package botfilter
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"biomu/backend/internal/profile"
+)
+
+// Sessions resolves the signed-in caller (implemented by auth.Handler).
+type Sessions interface {
+	SessionUID(r *http.Request) string
+}
+
+type Handler struct {
+	classifier *Classifier
+	profiles   *profile.Store
+	sessions   Sessions
+}
+
+func NewHandler(classifier *Classifier, profiles *profile.Store, sessions Sessions) *Handler {
+	return &Handler{classifier: classifier, profiles: profiles, sessions: sessions}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// requireAdmin writes the error response and returns "" when the caller is not an admin.
+func (h *Handler) requireAdmin(w http.ResponseWriter, r *http.Request) string {
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return ""
+	}
+	admin, err := h.profiles.IsAdmin(r.Context(), uid)
+	if err != nil {
+		log.Printf("botfilter admin check %s: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load account"})
+		return ""
+	}
+	if !admin {
+		h.writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+		return ""
+	}
+	return uid
+}
+
+// GET /api/admin/bot-patterns
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.requireAdmin(w, r) == "" {
+		return
+	}
+	patterns, custom := h.classifier.Patterns()
+	h.writeJSON(w, http.StatusOK, map[string]any{"patterns": patterns, "custom": custom})
+}
+
+// PUT /api/admin/bot-patterns — ganti daftar pola tanpa redeploy ({"patterns": []} = kembali ke bawaan)
+func (h *Handler) Put(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.requireAdmin(w, r)
+	if uid == "" {
+		return
+	}
+	var body struct {
+		Patterns []string `json:"patterns"`
+		// Text: alternatif format file (satu pola per baris, komentar #)
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+	patterns := body.Patterns
+	if body.Text != "" {
+		patterns = append(patterns, ParsePatterns(body.Text)...)
+	}
+	if len(patterns) > 0 {
+		if _, err := Compile(patterns); err != nil {
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	if err := h.classifier.Save(r.Context(), patterns, uid); err != nil {
+		log.Printf("botfilter save: %v", err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to save patterns"})
+		return
+	}
+	active, custom := h.classifier.Patterns()
+	h.writeJSON(w, http.StatusOK, map[string]any{"patterns": active, "custom": custom})
+}
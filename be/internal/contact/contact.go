@@ -0,0 +1,276 @@
+// Package contact relays messages from a contact form on the bio page to the creator without
+// exposing their email address: pesan disimpan di inbox pemilik, diteruskan ke email akun dengan
+// Reply-To pengunjung, dan pesan yang terdeteksi spam hanya masuk folder spam.
+package contact
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"biomu/backend/internal/firebase"
+	"biomu/backend/internal/profile"
+	"biomu/backend/internal/signing"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Inbox folders.
+const (
+	FolderInbox = "inbox"
+	FolderSpam  = "spam"
+)
+
+const (
+	MaxNameLen    = 100
+	MaxMessageLen = 5000
+	maxTitleLen   = 80
+	listLimit     = 500
+	// spamRetention: pesan spam dihapus otomatis setelah ini
+	spamRetention = 30 * 24 * time.Hour
+	sweepInterval = 10 * time.Minute
+)
+
+var ErrNotFound = errors.New("message not found")
+
+// Settings is the contact form block of an account (field "contactForm" di dokumen akun).
+type Settings struct {
+	Enabled bool
+	Title   string
+}
+
+// FromProfile reads the contact form settings of p.
+func FromProfile(p *profile.Profile) Settings {
+	raw, _ := p.Data["contactForm"].(map[string]any)
+	if raw == nil {
+		return Settings{}
+	}
+	out := Settings{}
+	out.Enabled, _ = raw["enabled"].(bool)
+	if v, ok := raw["title"].(string); ok {
+		out.Title = strings.TrimSpace(v)
+		if utf8.RuneCountInString(out.Title) > maxTitleLen {
+			out.Title = string([]rune(out.Title)[:maxTitleLen])
+		}
+	}
+	return out
+}
+
+// Message is one contact form submission in the owner's inbox.
+type Message struct {
+	ID      string `json:"id" firestore:"-"`
+	OwnerID string `json:"-" firestore:"ownerId"`
+	Name    string `json:"name" firestore:"name"`
+	Email   string `json:"email" firestore:"email"`
+	Body    string `json:"message" firestore:"body"`
+	Spam    bool   `json:"spam" firestore:"spam"`
+	// SpamScore dan SpamReasons dari Scorer saat pesan diterima
+	SpamScore   int       `json:"spamScore" firestore:"spamScore"`
+	SpamReasons []string  `json:"spamReasons,omitempty" firestore:"spamReasons,omitempty"`
+	Read        bool      `json:"read" firestore:"read"`
+	Relayed     bool      `json:"relayed" firestore:"relayed"`
+	CreatedAt   time.Time `json:"createdAt" firestore:"createdAt"`
+	// PurgeAt hanya terisi selama pesan ada di folder spam
+	PurgeAt time.Time `json:"-" firestore:"purgeAt,omitempty"`
+	// Sig: HMAC pemilik dan isi pesan, supaya pesan yang ditulis di luar Store tidak tampil di inbox
+	Sig string `json:"-" firestore:"sig"`
+}
+
+// Store keeps contact messages.
+type Store struct {
+	fb   *firebase.App
+	coll string
+	key  *signing.Key
+	now  func() time.Time
+}
+
+// NewStore creates a message store; keys are derived from secret (SESSION_SECRET).
+func NewStore(fb *firebase.App, coll string, secret []byte) *Store {
+	return &Store{fb: fb, coll: coll, key: signing.NewKey(secret, "biomu contact v1"), now: time.Now}
+}
+
+func (s *Store) messageSig(m *Message) string {
+	return s.key.Sign(messageParts(m)...)
+}
+
+func messageParts(m *Message) []string {
+	return []string{"message", m.ID, m.OwnerID, m.Name, m.Email, m.Body}
+}
+
+func (s *Store) fromDoc(doc *firestore.DocumentSnapshot) (*Message, error) {
+	var m Message
+	if err := doc.DataTo(&m); err != nil {
+		return nil, err
+	}
+	m.ID = doc.Ref.ID
+	if !s.key.Verify(m.Sig, messageParts(&m)...) {
+		return nil, ErrNotFound
+	}
+	return &m, nil
+}
+
+// Create stores a new message; ID, CreatedAt and PurgeAt are filled in.
+func (s *Store) Create(ctx context.Context, m *Message) error {
+	ref := s.fb.DB.Collection(s.coll).NewDoc()
+	s.stamp(m, ref.ID)
+	_, err := ref.Create(ctx, m)
+	return err
+}
+
+// stamp fills in the stored fields of a new message: pesan spam langsung dijadwalkan dihapus.
+func (s *Store) stamp(m *Message, id string) {
+	m.ID, m.CreatedAt = id, s.now().UTC()
+	m.PurgeAt = time.Time{}
+	if m.Spam {
+		m.PurgeAt = m.CreatedAt.Add(spamRetention)
+	}
+	m.Sig = s.messageSig(m)
+}
+
+// MarkRelayed records that m was forwarded to the owner's email.
+func (s *Store) MarkRelayed(ctx context.Context, id string) error {
+	_, err := s.fb.DB.Collection(s.coll).Doc(id).Update(ctx, []firestore.Update{{Path: "relayed", Value: true}})
+	return err
+}
+
+// List returns the messages of ownerID in folder, newest first.
+func (s *Store) List(ctx context.Context, ownerID, folder string) ([]*Message, error) {
+	it := s.fb.DB.Collection(s.coll).Where("ownerId", "==", ownerID).Where("spam", "==", folder == FolderSpam).
+		Limit(listLimit).Documents(ctx)
+	defer it.Stop()
+	out := []*Message{}
+	for {
+		doc, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		m, err := s.fromDoc(doc)
+		if err != nil {
+			continue
+		}
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+// Owned returns message id if it belongs to ownerID, or ErrNotFound.
+func (s *Store) Owned(ctx context.Context, ownerID, id string) (*Message, error) {
+	if id == "" {
+		return nil, ErrNotFound
+	}
+	doc, err := s.fb.DB.Collection(s.coll).Doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	m, err := s.fromDoc(doc)
+	if err != nil || m.OwnerID != ownerID {
+		return nil, ErrNotFound
+	}
+	return m, nil
+}
+
+// Update sets the read and spam flags of a message of ownerID; nil leaves a flag unchanged.
+func (s *Store) Update(ctx context.Context, ownerID, id string, read, spam *bool) (*Message, error) {
+	m, err := s.Owned(ctx, ownerID, id)
+	if err != nil {
+		return nil, err
+	}
+	updates := []firestore.Update{}
+	if read != nil {
+		m.Read = *read
+		updates = append(updates, firestore.Update{Path: "read", Value: m.Read})
+	}
+	if spam != nil && *spam != m.Spam {
+		m.Spam = *spam
+		var purgeAt any = firestore.Delete
+		if m.Spam {
+			m.PurgeAt = s.now().UTC().Add(spamRetention)
+			purgeAt = m.PurgeAt
+		}
+		updates = append(updates, firestore.Update{Path: "spam", Value: m.Spam}, firestore.Update{Path: "purgeAt", Value: purgeAt})
+	}
+	if len(updates) == 0 {
+		return m, nil
+	}
+	if _, err := s.fb.DB.Collection(s.coll).Doc(id).Update(ctx, updates); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Delete removes a message of ownerID.
+func (s *Store) Delete(ctx context.Context, ownerID, id string) error {
+	if _, err := s.Owned(ctx, ownerID, id); err != nil {
+		return err
+	}
+	_, err := s.fb.DB.Collection(s.coll).Doc(id).Delete(ctx)
+	return err
+}
+
+// Run deletes spam past its retention every sweepInterval until ctx is done.
+func (s *Store) Run(ctx context.Context) {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.sweep(ctx); err != nil {
+			log.Printf("contact sweep: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Store) sweep(ctx context.Context) error {
+	it := s.fb.DB.Collection(s.coll).Where("purgeAt", "<=", s.now().UTC()).Limit(200).Documents(ctx)
+	defer it.Stop()
+	for {
+		doc, err := it.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.expire(ctx, doc.Ref); err != nil {
+			log.Printf("contact sweep %s: %v", doc.Ref.ID, err)
+		}
+	}
+}
+
+// expire deletes a message that is still spam past its purge time (dicek ulang di dalam transaksi).
+func (s *Store) expire(ctx context.Context, ref *firestore.DocumentRef) error {
+	return s.fb.DB.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var m Message
+		if err := doc.DataTo(&m); err != nil {
+			return err
+		}
+		if !m.Spam || m.PurgeAt.IsZero() || m.PurgeAt.After(s.now().UTC()) {
+			return nil
+		}
+		return tx.Delete(ref)
+	})
+}
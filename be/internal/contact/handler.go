@@ -0,0 +1,372 @@
+package contact
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log"
+	"math"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"biomu/backend/internal/email"
+	"biomu/backend/internal/profile"
+	"biomu/backend/internal/ratelimit"
+	"biomu/backend/internal/visitor"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+const (
+	contactMaxBytes = 16 << 10
+	updateMaxBytes  = 1 << 10
+	maxEmailLen     = 254
+	// honeypotField: input tersembunyi di form; manusia tidak mengisinya, bot form biasanya iya
+	honeypotField = "website"
+	// Batas pesan per IP (semua kreator) dan per kreator (semua IP) dalam satu jendela waktu
+	ipLimit     = 5
+	ownerLimit  = 50
+	limitPeriod = time.Hour
+)
+
+// Sessions resolves the signed-in caller (implemented by auth.Handler).
+type Sessions interface {
+	SessionUID(r *http.Request) string
+}
+
+// Accounts resolves the verified login email of an account (implemented by auth.Handler).
+type Accounts interface {
+	VerifiedEmail(ctx context.Context, uid string) (string, error)
+}
+
+type Handler struct {
+	store    *Store
+	scorer   *Scorer
+	profiles *profile.Store
+	sessions Sessions
+	accounts Accounts
+	email    email.Sender
+	baseURL  string
+	siteName string
+	byIP     *ratelimit.Limiter
+	byOwner  *ratelimit.Limiter
+	now      func() time.Time
+}
+
+// NewHandler creates the contact form endpoints. sender may be nil: pesan tetap tersimpan di
+// inbox tanpa diteruskan ke email. Pesan hanya diteruskan ke email login terverifikasi pemilik
+// (accounts), tidak pernah ke field "email" dokumen akun.
+func NewHandler(store *Store, scorer *Scorer, profiles *profile.Store, sessions Sessions, accounts Accounts, sender email.Sender, baseURL, siteName string) *Handler {
+	return &Handler{
+		store:    store,
+		scorer:   scorer,
+		profiles: profiles,
+		sessions: sessions,
+		accounts: accounts,
+		email:    sender,
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		siteName: siteName,
+		byIP:     ratelimit.New(ipLimit, limitPeriod),
+		byOwner:  ratelimit.New(ownerLimit, limitPeriod),
+		now:      time.Now,
+	}
+}
+
+// Run garbage-collects the rate limit windows until ctx is done.
+func (h *Handler) Run(ctx context.Context) {
+	ticker := time.NewTicker(limitPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := h.now()
+			h.byIP.GC(now)
+			h.byOwner.GC(now)
+		}
+	}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+type contactInput struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Message string `json:"message"`
+	Website string `json:"website"`
+}
+
+// POST /api/public/{handle}/contact — kirim pesan ke kreator (JSON atau form dari halaman bio).
+// Pesan spam dibalas sama seperti pesan biasa, tapi hanya masuk folder spam.
+func (h *Handler) Contact(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	form := !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json")
+	fail := func(status int, msg string) {
+		if form {
+			http.Error(w, msg, status)
+			return
+		}
+		h.writeJSON(w, status, map[string]string{"error": msg})
+	}
+	limited := func(wait time.Duration) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		fail(http.StatusTooManyRequests, "too many messages, try again later")
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, contactMaxBytes)
+	in, err := readInput(r, form)
+	if err != nil {
+		fail(http.StatusBadRequest, err.Error())
+		return
+	}
+	if ok, wait := h.byIP.Take(visitor.RateKey(r), h.now()); !ok {
+		limited(wait)
+		return
+	}
+	name := strings.Join(strings.Fields(in.Name), " ")
+	body := strings.TrimSpace(strings.ReplaceAll(in.Message, "\r\n", "\n"))
+	addr, ok := validEmail(in.Email)
+	switch {
+	case name == "" || utf8.RuneCountInString(name) > MaxNameLen:
+		fail(http.StatusBadRequest, "name is required (max "+strconv.Itoa(MaxNameLen)+" characters)")
+		return
+	case !ok:
+		fail(http.StatusBadRequest, "a valid email is required")
+		return
+	case body == "" || utf8.RuneCountInString(body) > MaxMessageLen:
+		fail(http.StatusBadRequest, "message is required (max "+strconv.Itoa(MaxMessageLen)+" characters)")
+		return
+	}
+
+	ctx := r.Context()
+	handle := profile.NormalizeHandle(r.PathValue("handle"))
+	var p *profile.Profile
+	if handle != "" {
+		p, err = h.profiles.FindByHandle(ctx, handle)
+	}
+	if err != nil {
+		log.Printf("contact %s: %v", handle, err)
+		fail(http.StatusInternalServerError, "failed to send message")
+		return
+	}
+	// Akun pending signup (belum punya role) atau tanpa form kontak aktif
+	if p == nil || p.Data["role"] == nil || !FromProfile(p).Enabled {
+		fail(http.StatusNotFound, "contact form not found")
+		return
+	}
+	// Banyak IP ke satu kreator (botnet) tetap dibatasi, termasuk yang nanti masuk folder spam
+	if ok, wait := h.byOwner.Take(p.ID, h.now()); !ok {
+		limited(wait)
+		return
+	}
+
+	m := h.newMessage(p.ID, name, addr, body, in.Website != "")
+	if err := h.store.Create(ctx, m); err != nil {
+		log.Printf("contact %s: %v", p.ID, err)
+		fail(http.StatusInternalServerError, "failed to send message")
+		return
+	}
+	if m.Spam {
+		log.Printf("contact %s: message %s marked spam (score %d %v)", p.ID, m.ID, m.SpamScore, m.SpamReasons)
+	} else if h.email != nil {
+		go h.relay(m)
+	}
+	if form {
+		h.notice(w, r, http.StatusCreated, "Pesan terkirim", "Terima kasih! Pesan Anda sudah diteruskan.")
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, map[string]string{"status": "sent"})
+}
+
+// readInput reads the contact form (halaman bio) or JSON body; the honeypot field is read from both.
+func readInput(r *http.Request, form bool) (contactInput, error) {
+	var in contactInput
+	if form {
+		if err := r.ParseForm(); err != nil {
+			return in, errors.New("invalid form")
+		}
+		in.Name, in.Email, in.Message = r.PostForm.Get("name"), r.PostForm.Get("email"), r.PostForm.Get("message")
+		in.Website = r.PostForm.Get(honeypotField)
+		return in, nil
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		return in, errors.New("invalid JSON body: " + err.Error())
+	}
+	return in, nil
+}
+
+// newMessage builds a message for ownerID and scores it. honeypot: field tersembunyi terisi.
+func (h *Handler) newMessage(ownerID, name, addr, body string, honeypot bool) *Message {
+	m := &Message{OwnerID: ownerID, Name: name, Email: addr, Body: body}
+	m.SpamScore, m.SpamReasons = h.scorer.Score(name, body, honeypot)
+	m.Spam = m.SpamScore >= SpamThreshold
+	return m
+}
+
+// relayTo returns the verified login email of ownerID, or "" when there is none.
+func (h *Handler) relayTo(ctx context.Context, ownerID string) string {
+	if h.accounts == nil {
+		return ""
+	}
+	to, err := h.accounts.VerifiedEmail(ctx, ownerID)
+	if err != nil {
+		log.Printf("contact relay %s: %v", ownerID, err)
+		return ""
+	}
+	return to
+}
+
+// relay forwards m to the owner's verified email with Reply-To set to the visitor. Tanpa email
+// terverifikasi pesan hanya ada di inbox.
+func (h *Handler) relay(m *Message) {
+	to := h.relayTo(context.Background(), m.OwnerID)
+	if to == "" {
+		return
+	}
+	if err := h.email.SendContactMessage(to, m.Name, m.Email, m.Body); err != nil {
+		log.Printf("contact relay %s: %v", m.ID, err)
+		return
+	}
+	if err := h.store.MarkRelayed(context.Background(), m.ID); err != nil {
+		log.Printf("contact relay %s: %v", m.ID, err)
+	}
+}
+
+func validEmail(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || len(raw) > maxEmailLen {
+		return "", false
+	}
+	addr, err := mail.ParseAddress(raw)
+	if err != nil || addr.Address != raw {
+		return "", false
+	}
+	return raw, true
+}
+
+func (h *Handler) notice(w http.ResponseWriter, r *http.Request, status int, heading, message string) {
+	var buf bytes.Buffer
+	d := map[string]string{"SiteName": h.siteName, "HomeURL": h.baseURL + "/", "Heading": heading, "Message": message}
+	if err := templates.ExecuteTemplate(&buf, "notice.html", d); err != nil {
+		log.Printf("contact notice render: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// GET /api/contact/messages?folder=inbox|spam — pesan milik caller, terbaru dulu, dengan jumlah
+// belum dibaca di folder itu
+func (h *Handler) Messages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	folder := r.URL.Query().Get("folder")
+	switch folder {
+	case "":
+		folder = FolderInbox
+	case FolderInbox, FolderSpam:
+	default:
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "folder must be inbox or spam"})
+		return
+	}
+	messages, err := h.store.List(r.Context(), uid, folder)
+	if err != nil {
+		log.Printf("contact messages %s: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load messages"})
+		return
+	}
+	unread := 0
+	for _, m := range messages {
+		if !m.Read {
+			unread++
+		}
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{"folder": folder, "messages": messages, "unread": unread})
+}
+
+type updateInput struct {
+	Read *bool `json:"read"`
+	Spam *bool `json:"spam"`
+}
+
+// PATCH /api/contact/messages/{id} — tandai sudah/belum dibaca ({"read": true}) atau pindah
+// folder ({"spam": false}); pesan yang dipindah dari spam tidak diteruskan ulang ke email
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	var in updateInput
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, updateMaxBytes)).Decode(&in); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body: " + err.Error()})
+		return
+	}
+	id := r.PathValue("id")
+	m, err := h.store.Update(r.Context(), uid, id, in.Read, in.Spam)
+	if err != nil {
+		h.writeStoreError(w, "update", uid, id, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, m)
+}
+
+// DELETE /api/contact/messages/{id} — hapus pesan dari inbox caller
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	id := r.PathValue("id")
+	if err := h.store.Delete(r.Context(), uid, id); err != nil {
+		h.writeStoreError(w, "delete", uid, id, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) writeStoreError(w http.ResponseWriter, op, uid, id string, err error) {
+	if errors.Is(err, ErrNotFound) {
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	log.Printf("contact %s %s/%s: %v", op, uid, id, err)
+	h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to " + op + " message"})
+}
@@ -0,0 +1,135 @@
+package contact
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadInput(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        contactInput
+	}{
+		{"form", "application/x-www-form-urlencoded",
+			url.Values{"name": {"Fan"}, "email": {"fan@example.com"}, "message": {"Halo"}}.Encode(),
+			contactInput{Name: "Fan", Email: "fan@example.com", Message: "Halo"}},
+		{"form honeypot", "application/x-www-form-urlencoded",
+			url.Values{"name": {"Bot"}, "message": {"promo"}, honeypotField: {"https://spam.example"}}.Encode(),
+			contactInput{Name: "Bot", Message: "promo", Website: "https://spam.example"}},
+		{"json honeypot", "application/json",
+			`{"name":"Bot","email":"bot@example.com","message":"promo","website":"x"}`,
+			contactInput{Name: "Bot", Email: "bot@example.com", Message: "promo", Website: "x"}},
+		{"json", "application/json",
+			`{"name":"Fan","email":"fan@example.com","message":"Halo"}`,
+			contactInput{Name: "Fan", Email: "fan@example.com", Message: "Halo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/public/aether/contact", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			got, err := readInput(r, tt.contentType != "application/json")
+			if err != nil || got != tt.want {
+				t.Fatalf("readInput = %+v, %v; want %+v", got, err, tt.want)
+			}
+		})
+	}
+	r := httptest.NewRequest(http.MethodPost, "/api/public/aether/contact", strings.NewReader(`{"name":`))
+	if _, err := readInput(r, false); err == nil || !strings.HasPrefix(err.Error(), "invalid JSON body") {
+		t.Fatalf("truncated JSON: err = %v", err)
+	}
+}
+
+// Pesan yang mengisi honeypot langsung masuk folder spam (dan tidak diteruskan), sekalipun isinya
+// bersih; pesan spam dijadwalkan dihapus setelah spamRetention.
+func TestStoredSpamStatus(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := NewStore(nil, "contact_messages", []byte("secret"))
+	store.now = func() time.Time { return now }
+	h := NewHandler(store, NewScorer(DefaultKeywords), nil, nil, nil, nil, "", "")
+
+	tests := []struct {
+		name     string
+		from     string
+		body     string
+		honeypot bool
+		spam     bool
+		reasons  []string
+	}{
+		{"clean", "Fan", "Halo, boleh kolaborasi?", false, false, []string{}},
+		{"honeypot", "Fan", "Halo, boleh kolaborasi?", true, true, []string{"honeypot"}},
+		{"keyword only", "Fan", "Main casino yuk", false, false, []string{"keyword:casino"}},
+		{"link in name and keyword", "www.casino.example", "Halo", false, true, []string{"link_in_name", "keyword:casino"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := h.newMessage("u1", tt.from, "fan@example.com", tt.body, tt.honeypot)
+			store.stamp(m, "m1")
+			if m.Spam != tt.spam || !reflect.DeepEqual(m.SpamReasons, tt.reasons) {
+				t.Fatalf("spam %v %v, want %v %v", m.Spam, m.SpamReasons, tt.spam, tt.reasons)
+			}
+			wantPurge := time.Time{}
+			if tt.spam {
+				wantPurge = now.Add(spamRetention)
+			}
+			if !m.PurgeAt.Equal(wantPurge) || m.ID != "m1" || !m.CreatedAt.Equal(now) {
+				t.Fatalf("stored %+v", m)
+			}
+			// Pesan yang disimpan Store lolos verifikasi; yang dipindah ke inbox lain tidak
+			if !store.key.Verify(m.Sig, messageParts(m)...) {
+				t.Fatal("stored message does not verify")
+			}
+			moved := *m
+			moved.OwnerID = "u2"
+			if store.key.Verify(m.Sig, messageParts(&moved)...) {
+				t.Fatal("message moved to another inbox still verifies")
+			}
+		})
+	}
+}
+
+type accountsFunc func(ctx context.Context, uid string) (string, error)
+
+func (f accountsFunc) VerifiedEmail(ctx context.Context, uid string) (string, error) {
+	return f(ctx, uid)
+}
+
+// Pesan hanya diteruskan ke email login terverifikasi, bukan field "email" dokumen akun.
+func TestRelayTo(t *testing.T) {
+	verified := accountsFunc(func(_ context.Context, uid string) (string, error) {
+		switch uid {
+		case "u1":
+			return "owner@example.com", nil
+		case "down":
+			return "", errors.New("auth unavailable")
+		}
+		return "", nil
+	})
+	tests := []struct {
+		name     string
+		accounts Accounts
+		owner    string
+		want     string
+	}{
+		{"verified", verified, "u1", "owner@example.com"},
+		{"unverified", verified, "u2", ""},
+		{"lookup error", verified, "down", ""},
+		{"no accounts", nil, "u1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(nil, NewScorer(nil), nil, nil, tt.accounts, nil, "", "")
+			if got := h.relayTo(context.Background(), tt.owner); got != tt.want {
+				t.Fatalf("relayTo = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package contact
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SpamThreshold: pesan dengan skor sebesar ini atau lebih masuk folder spam dan tidak diteruskan.
+const SpamThreshold = 5
+
+// DefaultKeywords are phrases typical of contact form spam (dicocokkan tanpa membedakan huruf
+// besar/kecil, per kata utuh).
+var DefaultKeywords = []string{
+	"backlinks", "bitcoin", "btc", "casino", "crypto investment", "forex", "guest post",
+	"loan offer", "lottery", "payday loan", "porn", "seo services", "viagra", "web traffic",
+	"whatsapp me", "work from home",
+}
+
+var (
+	linkRe   = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"]+`)
+	markupRe = regexp.MustCompile(`(?i)<a\s|\[url[=\]]|\[link[=\]]`)
+)
+
+// Scorer scores contact messages: link, markup, keyword list dan honeypot.
+type Scorer struct {
+	keywords []*regexp.Regexp
+	names    []string
+}
+
+// NewScorer creates a scorer matching keywords (kosong diabaikan).
+func NewScorer(keywords []string) *Scorer {
+	s := &Scorer{}
+	for _, k := range keywords {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if k == "" {
+			continue
+		}
+		s.keywords = append(s.keywords, regexp.MustCompile(`(?i)(?:^|\W)`+regexp.QuoteMeta(k)+`(?:$|\W)`))
+		s.names = append(s.names, k)
+	}
+	return s
+}
+
+// Score returns the spam score of a message and the rules that added to it, mis.
+// ["links:3", "keyword:casino"].
+func (s *Scorer) Score(name, body string, honeypot bool) (int, []string) {
+	score, reasons := 0, []string{}
+	if honeypot {
+		score += 10
+		reasons = append(reasons, "honeypot")
+	}
+	// Satu link wajar (mis. portofolio); setelah itu setiap link menambah skor
+	if n := len(linkRe.FindAllString(body, -1)); n > 1 {
+		score += 2 * (n - 1)
+		reasons = append(reasons, "links:"+strconv.Itoa(n))
+	}
+	if markupRe.MatchString(body) {
+		score += 3
+		reasons = append(reasons, "markup")
+	}
+	if linkRe.MatchString(name) {
+		score += 5
+		reasons = append(reasons, "link_in_name")
+	}
+	text := name + "\n" + body
+	for i, re := range s.keywords {
+		if re.MatchString(text) {
+			score += 3
+			reasons = append(reasons, "keyword:"+s.names[i])
+		}
+	}
+	return score, reasons
+}
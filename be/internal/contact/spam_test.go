@@ -0,0 +1,83 @@
+package contact
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScore(t *testing.T) {
+	s := NewScorer(DefaultKeywords)
+	tests := []struct {
+		name     string
+		from     string
+		body     string
+		honeypot bool
+		score    int
+		reasons  []string
+	}{
+		{"clean", "Fan", "Halo, boleh kolaborasi untuk video berikutnya?", false, 0, []string{}},
+		{"honeypot", "Fan", "Halo", true, 10, []string{"honeypot"}},
+		// Satu link (portofolio) tidak menambah skor
+		{"one link", "Fan", "Portofolio saya: https://fan.example.com", false, 0, []string{}},
+		{"two links", "Fan", "https://a.example.com dan www.b.example.com", false, 2, []string{"links:2"}},
+		{"four links", "Fan", "http://a.io http://b.io http://c.io http://d.io", false, 6, []string{"links:4"}},
+		{"html anchor", "Fan", `klik <a href="x">di sini</a>`, false, 3, []string{"markup"}},
+		{"bbcode url", "Fan", "[url=http://x.io]promo[/url]", false, 3, []string{"markup"}},
+		{"bbcode link", "Fan", "[LINK]x[/LINK]", false, 3, []string{"markup"}},
+		{"link in name", "www.promo.example", "Halo", false, 5, []string{"link_in_name"}},
+		{"keyword", "Fan", "Best CASINO bonus", false, 3, []string{"keyword:casino"}},
+		{"keyword phrase", "Fan", "We sell SEO services.", false, 3, []string{"keyword:seo services"}},
+		{"keyword in name", "Forex Pro", "Halo", false, 3, []string{"keyword:forex"}},
+		// Kata utuh saja: "btc" di dalam kata lain tidak cocok
+		{"keyword inside word", "Fan", "subtcontract abtcd", false, 0, []string{}},
+		{"two keywords", "Fan", "bitcoin and forex tips", false, 6, []string{"keyword:bitcoin", "keyword:forex"}},
+		{"combined", "Fan", `<a href="http://x.io">casino</a> http://y.io`, true, 10 + 2 + 3 + 3, []string{"honeypot", "links:2", "markup", "keyword:casino"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, reasons := s.Score(tt.from, tt.body, tt.honeypot)
+			if score != tt.score || !reflect.DeepEqual(reasons, tt.reasons) {
+				t.Fatalf("Score = %d %v, want %d %v", score, reasons, tt.score, tt.reasons)
+			}
+		})
+	}
+}
+
+func TestNewScorerKeywords(t *testing.T) {
+	s := NewScorer([]string{" Promo Code ", "", "  "})
+	if len(s.keywords) != 1 || s.names[0] != "promo code" {
+		t.Fatalf("keywords = %v", s.names)
+	}
+	if score, _ := s.Score("Fan", "pakai PROMO CODE ini", false); score != 3 {
+		t.Fatalf("score = %d, want 3", score)
+	}
+	if score, _ := NewScorer(nil).Score("Fan", "casino", false); score != 0 {
+		t.Fatalf("scorer without keywords: score = %d", score)
+	}
+}
+
+// Pesan tepat di SpamThreshold masuk folder spam; satu di bawahnya tetap di inbox.
+func TestSpamThreshold(t *testing.T) {
+	h := NewHandler(nil, NewScorer([]string{"promo"}), nil, nil, nil, nil, "", "")
+	tests := []struct {
+		name  string
+		body  string
+		score int
+		spam  bool
+	}{
+		// 2 link (+2) + keyword (+3) = 5
+		{"at threshold", "promo http://a.io http://b.io", SpamThreshold, true},
+		// 2 link (+2) = 2; 3 link (+4) = 4
+		{"below threshold", "http://a.io http://b.io http://c.io", SpamThreshold - 1, false},
+		{"above threshold", "promo " + strings.Repeat("http://a.io ", 4), 9, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := h.newMessage("u1", "Fan", "fan@example.com", tt.body, false)
+			if m.SpamScore != tt.score || m.Spam != tt.spam {
+				t.Fatalf("score %d spam %v, want %d %v", m.SpamScore, m.Spam, tt.score, tt.spam)
+			}
+		})
+	}
+}
@@ -2,23 +2,67 @@ package db
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"biomu/backend/internal/enrich"
+	"biomu/backend/internal/entitlement"
+	"biomu/backend/internal/experiment"
 	"biomu/backend/internal/firebase"
+	"biomu/backend/internal/profile"
+	"biomu/backend/internal/protect"
+	"biomu/backend/internal/screen"
+	"biomu/backend/internal/targeting"
 
 	"cloud.google.com/go/firestore"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// Sessions resolves the signed-in uid of a request ("" when anonymous).
+type Sessions interface {
+	SessionUID(r *http.Request) string
+}
+
 type Handler struct {
-	fb *firebase.App
+	fb        *firebase.App
+	sessions  Sessions
+	linksColl string
+	guard     *protect.Guard
+	// screener memeriksa setiap URL yang ditulis ke koleksi yang dikonfigurasi (anti phishing)
+	screener *screen.Screener
+	// entitlements menerapkan batas tier akun (jumlah dokumen, ukuran, kuota) pada Create/Update
+	entitlements *entitlement.Checker
+}
+
+func NewHandler(fb *firebase.App, sessions Sessions, linksColl string, guard *protect.Guard, screener *screen.Screener, entitlements *entitlement.Checker) *Handler {
+	return &Handler{fb: fb, sessions: sessions, linksColl: linksColl, guard: guard, screener: screener, entitlements: entitlements}
 }
 
-func NewHandler(fb *firebase.App) *Handler {
-	return &Handler{fb: fb}
+// readDoc converts a document for a read response. Documents the caller may not read are
+// hidden, and so are links outside their schedule window (or hidden) for everyone but their
+// owner. Documents of the links collection pass through the protection guard: the password
+// hash is never returned and a locked link's destination URLs are redacted until the viewer
+// unlocked it. ok is false when the document must be hidden from this viewer entirely.
+func (h *Handler) readDoc(access *entitlement.Access, viewer *protect.Viewer, doc *firestore.DocumentSnapshot, now time.Time) (map[string]any, bool) {
+	data := doc.Data()
+	if !access.CanRead(doc.Ref.ID, data) {
+		return nil, false
+	}
+	if access.Collection == h.linksColl {
+		link := profile.LinkFromDoc(doc)
+		if !access.Owns(doc.Ref.ID, data) && !link.VisibleAt(now) {
+			return nil, false
+		}
+		viewer.FilterDoc(link, data)
+	}
+	data["id"] = doc.Ref.ID
+	return data, true
 }
 
 func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
@@ -41,6 +85,11 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	access, ok := h.readAccess(w, r, collectionName, "")
+	if !ok {
+		return
+	}
+
 	sortBy := r.URL.Query().Get("sortBy")
 	order := r.URL.Query().Get("order")
 	if order == "" {
@@ -49,6 +98,14 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 
 	col := h.fb.DB.Collection(collectionName)
 	q := col.Query
+	// Koleksi privat hanya mengembalikan dokumen milik pemanggil
+	if access.Scoped() {
+		if access.Rule.Owner == "" {
+			q = q.Where(firestore.DocumentID, "==", col.Doc(access.Account.UID))
+		} else {
+			q = q.Where(access.Rule.Owner, "==", access.Account.UID)
+		}
+	}
 	if sortBy != "" {
 		dir := firestore.Asc
 		if strings.ToLower(order) == "desc" {
@@ -60,6 +117,8 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	it := q.Documents(ctx)
 	defer it.Stop()
 
+	viewer := h.guard.Viewer(r)
+	now := time.Now()
 	var out []map[string]any
 	for {
 		doc, err := it.Next()
@@ -71,8 +130,10 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 			h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load data"})
 			return
 		}
-		data := doc.Data()
-		data["id"] = doc.Ref.ID
+		data, ok := h.readDoc(access, viewer, doc, now)
+		if !ok {
+			continue
+		}
 		out = append(out, data)
 	}
 
@@ -94,14 +155,27 @@ func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	access, ok := h.readAccess(w, r, collectionName, id)
+	if !ok {
+		return
+	}
+
 	doc, err := h.fb.DB.Collection(collectionName).Doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
 	if err != nil {
 		log.Printf("db get %s/%s: %v", collectionName, id, err)
 		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load data"})
 		return
 	}
-	data := doc.Data()
-	data["id"] = doc.Ref.ID
+	// Dokumen milik orang lain dan link terjadwal/kedaluwarsa diperlakukan seperti tidak ada
+	data, ok := h.readDoc(access, h.guard.Viewer(r), doc, time.Now())
+	if !ok {
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
 	h.writeJSON(w, http.StatusOK, data)
 }
 
@@ -124,12 +198,46 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
 		return
 	}
+	if !h.checkEntitlements(w, r, collectionName, "", nil, payload) {
+		return
+	}
+
+	if collectionName == h.linksColl {
+		if _, err := profile.NormalizeSchedule(payload); err != nil {
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if err := targeting.ValidateDoc(payload); err != nil {
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if err := experiment.ValidateDoc(payload); err != nil {
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if err := normalizeProtection(nil, payload); err != nil {
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	outcome, status, resp := h.screenURLs(r, collectionName, "", nil, payload)
+	if resp != nil {
+		h.writeJSON(w, status, resp)
+		return
+	}
 
 	now := time.Now()
 	if _, ok := payload["createdAt"]; !ok {
 		payload["createdAt"] = now
 	}
 	payload["updatedAt"] = now
+	// Lokasi & device pembuat dokumen (dari middleware enrich), selalu ditentukan server
+	delete(payload, "enrichment")
+	if info, ok := enrich.FromContext(ctx); ok {
+		if fields := info.Fields(); len(fields) > 0 {
+			payload["enrichment"] = fields
+		}
+	}
 
 	ref, _, err := h.fb.DB.Collection(collectionName).Add(ctx, payload)
 	if err != nil {
@@ -138,6 +246,10 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if outcome.Verdict.Action == screen.ActionQuarantine {
+		h.writeJSON(w, http.StatusOK, map[string]any{"id": ref.ID, "moderation": profile.ModerationQuarantined, "findings": outcome.Verdict.Findings})
+		return
+	}
 	h.writeJSON(w, http.StatusOK, map[string]string{"id": ref.ID})
 }
 
@@ -161,20 +273,78 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
 		return
 	}
+	// Dokumen dibaca sekali di sini; semua pemeriksaan di bawah memakai snapshot yang sama dan
+	// update hanya berhasil kalau dokumen belum berubah sejak dibaca
+	doc, err := h.fb.DB.Collection(collectionName).Doc(id).Get(ctx)
+	if err != nil && status.Code(err) != codes.NotFound {
+		log.Printf("db update %s/%s: %v", collectionName, id, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update document"})
+		return
+	}
+	var stored map[string]any
+	if doc.Exists() {
+		stored = doc.Data()
+	}
+	if !h.checkEntitlements(w, r, collectionName, id, stored, payload) {
+		return
+	}
+	// enrichment hanya ditulis server saat create (termasuk path bertitik seperti enrichment.country)
+	for k := range payload {
+		if k == "enrichment" || strings.HasPrefix(k, "enrichment.") {
+			delete(payload, k)
+		}
+	}
 	payload["updatedAt"] = time.Now()
 
 	var updates []firestore.Update
+	if collectionName == h.linksColl {
+		if err := targeting.ValidateDoc(payload); err != nil {
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if err := experiment.ValidateDoc(payload); err != nil {
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if err := normalizeProtection(stored, payload); err != nil {
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		delete(payload, profile.FieldStartsAtUTC)
+		delete(payload, profile.FieldEndsAtUTC)
+		if profile.HasScheduleField(payload) {
+			extra, err := scheduleUpdates(stored, payload)
+			if err != nil {
+				h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			updates = append(updates, extra...)
+		}
+	}
+	outcome, code, resp := h.screenURLs(r, collectionName, id, stored, payload)
+	if resp != nil {
+		h.writeJSON(w, code, resp)
+		return
+	}
 	for k, v := range payload {
 		updates = append(updates, firestore.Update{Path: k, Value: v})
 	}
 
-	_, err := h.fb.DB.Collection(collectionName).Doc(id).Update(ctx, updates)
+	_, err = doc.Ref.Update(ctx, updates, firestore.LastUpdateTime(doc.UpdateTime))
+	if status.Code(err) == codes.FailedPrecondition {
+		h.writeJSON(w, http.StatusConflict, map[string]string{"error": "document changed, retry"})
+		return
+	}
 	if err != nil {
 		log.Printf("db update %s/%s: %v", collectionName, id, err)
 		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update document"})
 		return
 	}
 
+	if outcome.Verdict.Action == screen.ActionQuarantine {
+		h.writeJSON(w, http.StatusOK, map[string]any{"moderation": profile.ModerationQuarantined, "findings": outcome.Verdict.Findings})
+		return
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -192,6 +362,10 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "collection and id are required"})
 		return
 	}
+	if err := h.entitlements.CheckDelete(ctx, h.sessions.SessionUID(r), collectionName, id); err != nil {
+		h.entitlementError(w, err, collectionName, id)
+		return
+	}
 
 	_, err := h.fb.DB.Collection(collectionName).Doc(id).Delete(ctx)
 	if err != nil {
@@ -203,3 +377,136 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// readAccess resolves what the caller may read in collectionName. It returns false after
+// writing the error response (403 for collections outside the allowlist).
+func (h *Handler) readAccess(w http.ResponseWriter, r *http.Request, collectionName, id string) (*entitlement.Access, bool) {
+	access, err := h.entitlements.ReadAccess(r.Context(), h.sessions.SessionUID(r), collectionName)
+	if err != nil {
+		h.entitlementError(w, err, collectionName, id)
+		return nil, false
+	}
+	return access, true
+}
+
+// checkEntitlements applies the allowlist, the owner check and the caller's tier limits to a
+// write (id == "" for create). It returns false after writing the error response: 403 for
+// collections outside the allowlist, 404 for documents of other accounts, 402/403
+// upgrade_required, 429 when the hourly write quota is used up.
+func (h *Handler) checkEntitlements(w http.ResponseWriter, r *http.Request, collectionName, id string, stored, payload map[string]any) bool {
+	err := h.entitlements.CheckWrite(r.Context(), h.sessions.SessionUID(r), collectionName, id, stored, payload)
+	if err == nil {
+		return true
+	}
+	h.entitlementError(w, err, collectionName, id)
+	return false
+}
+
+// entitlementError writes the response for an error of the entitlement checker.
+func (h *Handler) entitlementError(w http.ResponseWriter, err error, collectionName, id string) {
+	var denial *entitlement.Denial
+	switch {
+	case errors.As(err, &denial):
+		if denial.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(denial.RetryAfter.Seconds())+1))
+		}
+		h.writeJSON(w, denial.Status, denial.Body())
+	case errors.Is(err, entitlement.ErrUnauthorized):
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	case errors.Is(err, entitlement.ErrForbidden), errors.Is(err, entitlement.ErrServerField), errors.Is(err, entitlement.ErrCollection):
+		h.writeJSON(w, http.StatusForbidden, map[string]string{"error": err.Error()})
+	case errors.Is(err, entitlement.ErrNotFound):
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+	default:
+		log.Printf("db entitlements %s/%s: %v", collectionName, id, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to check entitlements"})
+	}
+}
+
+// scheduleUpdates merges a partial schedule change with the stored link so startsAtUtc/endsAtUtc
+// are always recomputed from the complete startsAt/endsAt/timezone triple.
+func scheduleUpdates(stored, payload map[string]any) ([]firestore.Update, error) {
+	merged := make(map[string]any, len(stored))
+	for k, v := range stored {
+		merged[k] = v
+	}
+	for _, k := range []string{profile.FieldStartsAt, profile.FieldEndsAt, profile.FieldTimezone} {
+		if v, ok := payload[k]; ok {
+			merged[k] = v
+		}
+	}
+	cleared, err := profile.NormalizeSchedule(merged)
+	if err != nil {
+		return nil, err
+	}
+	var updates []firestore.Update
+	for _, k := range []string{profile.FieldStartsAtUTC, profile.FieldEndsAtUTC} {
+		if v, ok := merged[k]; ok {
+			updates = append(updates, firestore.Update{Path: k, Value: v})
+		}
+	}
+	for _, k := range cleared {
+		updates = append(updates, firestore.Update{Path: k, Value: firestore.Delete})
+	}
+	return updates, nil
+}
+
+// normalizeProtection hashes a new link password. On update keeping mode "password" without
+// sending the password again reuses the hash of the stored link (stored is nil on create).
+// Nested protection.* paths are rejected so a plaintext password can never be written field
+// by field.
+func normalizeProtection(stored, payload map[string]any) error {
+	for k := range payload {
+		if strings.HasPrefix(k, protect.FieldProtection+".") {
+			return errors.New("update protection as a whole object")
+		}
+	}
+	return protect.NormalizeDoc(payload, stored)
+}
+
+// screenURLs checks every URL of a write into a screened collection. The client can never
+// set the moderation field itself. Blocked URLs fail the request (resp != nil); quarantined
+// ones are saved with moderation.status "quarantined" so the link stays hidden until an admin
+// approves it. On update the stored document is merged in, so clearing the last flagged URL
+// also lifts the quarantine.
+func (h *Handler) screenURLs(r *http.Request, collectionName, id string, stored, payload map[string]any) (screen.Outcome, int, any) {
+	if h.screener == nil || !h.screener.Applies(collectionName) {
+		return screen.Outcome{}, http.StatusOK, nil
+	}
+	for k := range payload {
+		if k == screen.FieldModeration || strings.HasPrefix(k, screen.FieldModeration+".") {
+			delete(payload, k)
+		}
+	}
+	urls := screen.CollectURLs(payload)
+	if id != "" && len(urls) == 0 {
+		return screen.Outcome{}, http.StatusOK, nil
+	}
+
+	if id != "" {
+		// URL di dokumen tersimpan yang tidak ditimpa payload ikut diperiksa
+		merged := make(map[string]any, len(stored))
+		for k, v := range stored {
+			merged[k] = v
+		}
+		for k, v := range payload {
+			if !strings.Contains(k, ".") {
+				merged[k] = v
+			}
+		}
+		urls = screen.CollectURLs(merged)
+		for k, v := range payload {
+			if strings.Contains(k, ".") {
+				urls = append(urls, screen.CollectURLs(v)...)
+			}
+		}
+	}
+
+	outcome := h.screener.Evaluate(r.Context(), urls, stored, time.Now())
+	if outcome.Blocked() {
+		return outcome, http.StatusUnprocessableEntity, map[string]any{"error": "url_blocked", "findings": outcome.Verdict.Findings}
+	}
+	if outcome.Moderation != nil {
+		payload[screen.FieldModeration] = outcome.Moderation
+	}
+	return outcome, http.StatusOK, nil
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ACMEConfig configures certificate provisioning for verified custom domains.
+type ACMEConfig struct {
+	// DirectoryURL is the ACME directory; kosong = Let's Encrypt production.
+	// Untuk test lokal pakai Pebble, mis. "https://localhost:14000/dir".
+	DirectoryURL string
+	// CACertFile is an optional PEM bundle trusted for the directory's HTTPS endpoint
+	// (Pebble memakai sertifikat dari CA test-nya sendiri).
+	CACertFile string
+	Email      string
+	// CacheDir stores the account key and issued certificates.
+	CacheDir string
+}
+
+// NewCertManager returns an autocert manager that only requests certificates for domains
+// that are verified in store. Challenges: tls-alpn-01 via Manager.TLSConfig and http-01 via
+// Manager.HTTPHandler.
+func NewCertManager(store *Store, cfg ACMEConfig) (*autocert.Manager, error) {
+	if cfg.CacheDir == "" {
+		return nil, errors.New("acme: cache dir is required")
+	}
+	client := &acme.Client{DirectoryURL: cfg.DirectoryURL}
+	if client.DirectoryURL == "" {
+		client.DirectoryURL = autocert.DefaultACMEDirectory
+	}
+	if cfg.CACertFile != "" {
+		pem, err := os.ReadFile(cfg.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("acme: read CA cert: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("acme: no certificates found in %s", cfg.CACertFile)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+		client.HTTPClient = &http.Client{Transport: transport, Timeout: time.Minute}
+	}
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(cfg.CacheDir),
+		HostPolicy: store.HostPolicy,
+		Client:     client,
+		Email:      cfg.Email,
+	}, nil
+}
+
+// HostPolicy implements autocert.HostPolicy: hanya domain terverifikasi yang boleh dibuatkan
+// sertifikat, supaya Host/SNI sembarang tidak menghabiskan rate limit CA.
+func (s *Store) HostPolicy(ctx context.Context, host string) error {
+	route, err := s.Lookup(ctx, host)
+	if err != nil {
+		return err
+	}
+	if route == nil {
+		return fmt.Errorf("acme: host %q is not a verified custom domain", host)
+	}
+	return nil
+}
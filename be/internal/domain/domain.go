@@ -0,0 +1,125 @@
+// Package domain memetakan custom domain (mis. links.brand.com) ke halaman bio pemiliknya:
+// verifikasi kepemilikan lewat DNS TXT, router berdasarkan header Host, dan sertifikat TLS
+// otomatis lewat ACME.
+package domain
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/idna"
+)
+
+const (
+	// TXTLabel: record TXT verifikasi dipasang di _aether-verify.<domain>
+	TXTLabel = "_aether-verify"
+	// TXTPrefix: isi record TXT adalah "aether-verify=<token>"
+	TXTPrefix = "aether-verify="
+
+	StatusPending  = "pending"
+	StatusVerified = "verified"
+
+	maxDomainLen = 253
+)
+
+var (
+	ErrInvalidDomain = errors.New("invalid domain name")
+	ErrReserved      = errors.New("domain is reserved")
+)
+
+// Resolver looks up TXT records. *net.Resolver satisfies it; tests can pass a fake.
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// NewResolver returns the system resolver, or a resolver that sends every query to addr
+// ("host:port", mis. DNS test lokal) when addr is set.
+func NewResolver(addr string) Resolver {
+	if addr == "" {
+		return net.DefaultResolver
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+// Domain is a custom domain claimed by one account (document ID = nama domain).
+type Domain struct {
+	Name    string `json:"domain" firestore:"-"`
+	OwnerID string `json:"ownerId" firestore:"ownerId"`
+	Status  string `json:"status" firestore:"status"`
+	// Token diturunkan dari (domain, ownerId) dengan HMAC, tidak disimpan
+	Token string `json:"-" firestore:"-"`
+	// Proof: HMAC (domain, ownerId) yang ditulis saat verifikasi berhasil. Dokumen yang diubah
+	// di luar Store tanpa proof yang cocok tidak pernah dianggap terverifikasi.
+	Proof string `json:"-" firestore:"proof,omitempty"`
+	// CheckError: hasil pengecekan DNS terakhir yang gagal, untuk ditampilkan ke user
+	CheckError    string    `json:"checkError,omitempty" firestore:"checkError"`
+	CreatedAt     time.Time `json:"createdAt" firestore:"createdAt"`
+	VerifiedAt    time.Time `json:"verifiedAt,omitempty" firestore:"verifiedAt,omitempty"`
+	LastCheckedAt time.Time `json:"lastCheckedAt,omitempty" firestore:"lastCheckedAt,omitempty"`
+	// MissingSince: kapan record TXT domain yang sudah terverifikasi mulai tidak ditemukan
+	MissingSince time.Time `json:"-" firestore:"missingSince,omitempty"`
+}
+
+func (d *Domain) Verified() bool { return d.Status == StatusVerified }
+
+// TXTName is the DNS name of the verification record.
+func (d *Domain) TXTName() string { return TXTLabel + "." + d.Name }
+
+// TXTValue is the expected content of the verification record.
+func (d *Domain) TXTValue() string { return TXTPrefix + d.Token }
+
+// Normalize lowercases a host name, strips a trailing dot and port, converts IDN to
+// punycode and validates it. IP addresses and single-label names are rejected.
+func Normalize(raw string) (string, error) {
+	host := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(raw)), ".")
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "" || net.ParseIP(strings.Trim(host, "[]")) != nil {
+		return "", ErrInvalidDomain
+	}
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil || len(ascii) > maxDomainLen || !strings.Contains(ascii, ".") {
+		return "", ErrInvalidDomain
+	}
+	for _, label := range strings.Split(ascii, ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return "", ErrInvalidDomain
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+				return "", ErrInvalidDomain
+			}
+		}
+	}
+	return ascii, nil
+}
+
+// CheckTXT reports whether the verification record of d is published. A missing record is
+// (false, nil); DNS failures other than NXDOMAIN are returned as errors.
+func CheckTXT(ctx context.Context, resolver Resolver, d *Domain) (bool, error) {
+	records, err := resolver.LookupTXT(ctx, d.TXTName())
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	want := d.TXTValue()
+	for _, r := range records {
+		if strings.TrimSpace(r) == want {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+// fakeResolver answers TXT lookups from a map; names without an entry are NXDOMAIN.
+type fakeResolver struct {
+	records map[string][]string
+	err     error
+}
+
+func (f *fakeResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	records, ok := f.records[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return records, nil
+}
+
+func newTestStore(resolver Resolver) *Store {
+	return NewStore(nil, "domains", nil, resolver, []byte("secret"), []string{"aether.bio"})
+}
+
+func TestCheckTXT(t *testing.T) {
+	s := newTestStore(nil)
+	d := &Domain{Name: "links.brand.com", OwnerID: "u1", Token: s.token("links.brand.com", "u1")}
+	other := s.token("links.brand.com", "u2")
+
+	tests := []struct {
+		name     string
+		resolver *fakeResolver
+		want     bool
+		wantErr  bool
+	}{
+		{"published", &fakeResolver{records: map[string][]string{d.TXTName(): {"v=spf1 -all", d.TXTValue()}}}, true, false},
+		{"surrounding spaces", &fakeResolver{records: map[string][]string{d.TXTName(): {" " + d.TXTValue() + " "}}}, true, false},
+		{"nxdomain", &fakeResolver{}, false, false},
+		{"token of another account", &fakeResolver{records: map[string][]string{d.TXTName(): {TXTPrefix + other}}}, false, false},
+		{"record on the apex", &fakeResolver{records: map[string][]string{d.Name: {d.TXTValue()}}}, false, false},
+		{"dns failure", &fakeResolver{err: &net.DNSError{Err: "server misbehaving", IsTemporary: true}}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CheckTXT(context.Background(), tt.resolver, d)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("found = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenAndProof(t *testing.T) {
+	s := newTestStore(nil)
+	token := s.token("links.brand.com", "u1")
+	if len(token) != 32 {
+		t.Fatalf("token %q is not 32 hex characters", token)
+	}
+	if s.token("links.brand.com", "u1") != token {
+		t.Fatal("token is not stable")
+	}
+	if s.token("links.brand.com", "u2") == token || s.token("shop.brand.com", "u1") == token {
+		t.Fatal("token is not bound to domain and account")
+	}
+	if NewStore(nil, "domains", nil, nil, []byte("other"), nil).token("links.brand.com", "u1") == token {
+		t.Fatal("token does not depend on the secret")
+	}
+
+	proof := s.proof("links.brand.com", "u1")
+	tests := []struct {
+		name string
+		d    Domain
+		want bool
+	}{
+		{"verified with proof", Domain{Name: "links.brand.com", OwnerID: "u1", Status: StatusVerified, Proof: proof}, true},
+		{"pending with proof", Domain{Name: "links.brand.com", OwnerID: "u1", Status: StatusPending, Proof: proof}, false},
+		{"verified without proof", Domain{Name: "links.brand.com", OwnerID: "u1", Status: StatusVerified}, false},
+		{"owner swapped", Domain{Name: "links.brand.com", OwnerID: "u2", Status: StatusVerified, Proof: proof}, false},
+		{"proof of another domain", Domain{Name: "shop.brand.com", OwnerID: "u1", Status: StatusVerified, Proof: proof}, false},
+		{"txt token as proof", Domain{Name: "links.brand.com", OwnerID: "u1", Status: StatusVerified, Proof: token}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.trusted(&tt.d); got != tt.want {
+				t.Fatalf("trusted = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		err  error
+	}{
+		{"Links.Brand.com.", "links.brand.com", nil},
+		{"links.brand.com:443", "links.brand.com", nil},
+		{"bücher.example", "xn--bcher-kva.example", nil},
+		{"localhost", "", ErrInvalidDomain},
+		{"192.0.2.1", "", ErrInvalidDomain},
+		{"[2001:db8::1]:443", "", ErrInvalidDomain},
+		{"-bad.example", "", ErrInvalidDomain},
+		{"a..example", "", ErrInvalidDomain},
+		{"under_score.example", "", ErrInvalidDomain},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.raw)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("Normalize(%q) = %q, %v; want %q, %v", tt.raw, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestIsPlatform(t *testing.T) {
+	s := newTestStore(nil)
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"aether.bio", true},
+		{"AETHER.BIO:443", true},
+		{"www.aether.bio", true},
+		{"localhost:8080", true},
+		{"app.localhost", true},
+		{"192.0.2.1:8080", true},
+		{"[2001:db8::1]:443", true},
+		{"", true},
+		{"links.brand.com", false},
+		{"aether.bio.evil.example", false},
+		{"notaether.bio", false},
+	}
+	for _, tt := range tests {
+		if got := s.IsPlatform(tt.host); got != tt.want {
+			t.Errorf("IsPlatform(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
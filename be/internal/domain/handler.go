@@ -0,0 +1,162 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"biomu/backend/internal/profile"
+)
+
+const claimMaxBytes = 4 << 10
+
+// Sessions resolves the signed-in caller (implemented by auth.Handler).
+type Sessions interface {
+	SessionUID(r *http.Request) string
+}
+
+type Handler struct {
+	store    *Store
+	profiles *profile.Store
+	sessions Sessions
+}
+
+func NewHandler(store *Store, profiles *profile.Store, sessions Sessions) *Handler {
+	return &Handler{store: store, profiles: profiles, sessions: sessions}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// dnsRecord is the record the owner must publish to prove control of the domain.
+type dnsRecord struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// domainView is a domain plus the DNS record that verifies it.
+type domainView struct {
+	*Domain
+	Record dnsRecord `json:"record"`
+}
+
+func view(d *Domain) domainView {
+	return domainView{Domain: d, Record: dnsRecord{Type: "TXT", Name: d.TXTName(), Value: d.TXTValue()}}
+}
+
+// GET /api/domains — domain milik caller beserta record TXT verifikasinya
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	domains, err := h.store.List(r.Context(), uid)
+	if err != nil {
+		log.Printf("domain list %s: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load domains"})
+		return
+	}
+	out := make([]domainView, 0, len(domains))
+	for _, d := range domains {
+		out = append(out, view(d))
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{"domains": out})
+}
+
+// POST /api/domains — klaim domain (status pending) dan dapatkan record TXT yang harus dipasang.
+// Body: {"domain": "links.example.com"}
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	var body struct {
+		Domain string `json:"domain"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, claimMaxBytes)).Decode(&body); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body: " + err.Error()})
+		return
+	}
+	p, err := h.profiles.FindByID(r.Context(), uid)
+	if err != nil {
+		log.Printf("domain create %s: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load account"})
+		return
+	}
+	if p == nil || !p.Member() {
+		h.writeJSON(w, http.StatusForbidden, map[string]string{"error": "membership_required"})
+		return
+	}
+	d, err := h.store.Claim(r.Context(), uid, body.Domain)
+	if err != nil {
+		h.writeStoreError(w, "create", body.Domain, err)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, view(d))
+}
+
+// POST /api/domains/{domain}/verify — cek record TXT sekarang (tanpa menunggu pengecekan berkala)
+func (h *Handler) Verify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	d, err := h.store.Verify(r.Context(), uid, r.PathValue("domain"))
+	if err != nil {
+		h.writeStoreError(w, "verify", r.PathValue("domain"), err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, view(d))
+}
+
+// DELETE /api/domains/{domain}
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	if err := h.store.Delete(r.Context(), uid, r.PathValue("domain")); err != nil {
+		h.writeStoreError(w, "delete", r.PathValue("domain"), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) writeStoreError(w http.ResponseWriter, op, name string, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "domain not found"})
+	case errors.Is(err, ErrInvalidDomain), errors.Is(err, ErrReserved):
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, ErrTaken), errors.Is(err, ErrLimit):
+		h.writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		log.Printf("domain %s %s: %v", op, name, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to " + op + " domain"})
+	}
+}
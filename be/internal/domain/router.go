@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"biomu/backend/internal/profile"
+)
+
+// customPrefixes are the routes a bio page needs on its own domain (redirect link, unlock,
+// beacon analytics, API publik profil termasuk signup newsletter, CSS tema, media, checkout
+// produk/tip); selain itu custom domain hanya melayani "/".
+var customPrefixes = []string{
+	"/r/",
+	"/go/",
+	"/unlock/",
+	"/media/",
+	"/api/public/",
+	"/api/links/",
+	"/api/themes/",
+	"/api/shop/checkout",
+}
+
+// Router serves verified custom domains: "/" renders the owner's bio page and the routes in
+// customPrefixes pass through. Platform hosts and unknown hosts go to next unchanged.
+type Router struct {
+	store *Store
+}
+
+func NewRouter(store *Store) *Router {
+	return &Router{store: store}
+}
+
+func (rt *Router) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rt.store.IsPlatform(r.Host) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		route, err := rt.store.Lookup(r.Context(), r.Host)
+		if err != nil {
+			log.Printf("domain router %s: %v", r.Host, err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		path := r.URL.Path
+		switch {
+		case path == "/":
+			// Tulis ulang ke /{handle} supaya dilayani handler halaman bio yang sama
+			r2 := r.Clone(r.Context())
+			r2.URL.Path, r2.URL.RawPath = "/"+route.Handle, ""
+			next.ServeHTTP(w, r2)
+		case profile.NormalizeHandle(strings.TrimPrefix(path, "/")) == route.Handle:
+			http.Redirect(w, r, "/", http.StatusMovedPermanently)
+		case hasAnyPrefix(path, customPrefixes):
+			next.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRouter(t *testing.T) {
+	s := newTestStore(nil)
+	// Lookup dilayani dari cache, tanpa Firestore
+	exp := time.Now().Add(time.Hour)
+	s.cache["links.brand.com"] = cacheEntry{route: &Route{Domain: "links.brand.com", OwnerID: "u1", Handle: "brand"}, exp: exp}
+	s.cache["unknown.example"] = cacheEntry{exp: exp}
+
+	var gotPath string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusTeapot)
+	})
+	h := NewRouter(s).Middleware(next)
+
+	tests := []struct {
+		name       string
+		host, path string
+		wantStatus int
+		wantPath   string
+		location   string
+	}{
+		{"bio page", "links.brand.com", "/", http.StatusTeapot, "/brand", ""},
+		{"handle redirects home", "links.brand.com", "/brand", http.StatusMovedPermanently, "", "/"},
+		{"handle case-insensitive", "links.brand.com", "/Brand", http.StatusMovedPermanently, "", "/"},
+		{"redirect link", "links.brand.com", "/r/l1", http.StatusTeapot, "/r/l1", ""},
+		{"targeted link", "links.brand.com", "/go/l1", http.StatusTeapot, "/go/l1", ""},
+		{"unlock page", "links.brand.com", "/unlock/l1", http.StatusTeapot, "/unlock/l1", ""},
+		{"media", "links.brand.com", "/media/a.webp", http.StatusTeapot, "/media/a.webp", ""},
+		{"public api", "links.brand.com", "/api/public/brand/beacon", http.StatusTeapot, "/api/public/brand/beacon", ""},
+		{"unlock api", "links.brand.com", "/api/links/l1/unlock", http.StatusTeapot, "/api/links/l1/unlock", ""},
+		{"theme css", "links.brand.com", "/api/themes/t1.css", http.StatusTeapot, "/api/themes/t1.css", ""},
+		{"checkout", "links.brand.com", "/api/shop/checkout", http.StatusTeapot, "/api/shop/checkout", ""},
+		{"generic db api", "links.brand.com", "/api/db/links", http.StatusNotFound, "", ""},
+		{"auth api", "links.brand.com", "/api/auth/session", http.StatusNotFound, "", ""},
+		{"admin api", "links.brand.com", "/api/admin/moderation", http.StatusNotFound, "", ""},
+		{"other handle", "links.brand.com", "/someone", http.StatusNotFound, "", ""},
+		{"prefix without slash", "links.brand.com", "/rx", http.StatusNotFound, "", ""},
+		{"platform host", "aether.bio", "/api/db/links", http.StatusTeapot, "/api/db/links", ""},
+		{"unknown host", "unknown.example", "/api/auth/session", http.StatusTeapot, "/api/auth/session", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPath = ""
+			r := httptest.NewRequest(http.MethodGet, "http://"+tt.host+tt.path, nil)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if gotPath != tt.wantPath {
+				t.Fatalf("next saw path %q, want %q", gotPath, tt.wantPath)
+			}
+			if loc := w.Header().Get("Location"); loc != tt.location {
+				t.Fatalf("Location = %q, want %q", loc, tt.location)
+			}
+		})
+	}
+}
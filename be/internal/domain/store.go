@@ -0,0 +1,488 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"biomu/backend/internal/firebase"
+	"biomu/backend/internal/profile"
+	"biomu/backend/internal/signing"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// MaxPerAccount limits custom domains (pending + verified) per account.
+	MaxPerAccount = 3
+	// claimTTL: klaim pending yang lebih lama dari ini boleh diambil alih akun lain
+	claimTTL = 72 * time.Hour
+	// missingGrace: domain terverifikasi baru dicabut setelah record TXT hilang selama ini
+	missingGrace = 72 * time.Hour
+
+	recheckInterval = 15 * time.Minute
+	cacheTTL        = time.Minute
+	cacheMaxEntries = 10000
+	lookupTimeout   = 10 * time.Second
+)
+
+var (
+	ErrNotFound = errors.New("domain not found")
+	ErrTaken    = errors.New("domain is already claimed by another account")
+	ErrLimit    = errors.New("domain limit reached")
+)
+
+// Route is a verified custom domain resolved to the owner's public handle.
+type Route struct {
+	Domain  string
+	OwnerID string
+	Handle  string
+}
+
+type cacheEntry struct {
+	route *Route
+	exp   time.Time
+}
+
+// Store keeps custom domains in a top-level collection (document ID = domain) and resolves
+// Host headers to verified domains with a short in-memory cache.
+type Store struct {
+	fb       *firebase.App
+	coll     string
+	profiles *profile.Store
+	resolver Resolver
+	key      *signing.Key
+	platform []string
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+	now   func() time.Time
+}
+
+// NewStore creates a domain store. secret (e.g. SESSION_SECRET) derives the key for TXT
+// tokens and verification proofs; platformHosts are the platform's own hosts, which can never
+// be claimed (termasuk subdomain-nya).
+func NewStore(fb *firebase.App, coll string, profiles *profile.Store, resolver Resolver, secret []byte, platformHosts []string) *Store {
+	var platform []string
+	for _, h := range platformHosts {
+		if h, err := Normalize(h); err == nil {
+			platform = append(platform, h)
+		}
+	}
+	return &Store{
+		fb:       fb,
+		coll:     coll,
+		profiles: profiles,
+		resolver: resolver,
+		key:      signing.NewKey(secret, "biomu custom domain v1"),
+		platform: platform,
+		cache:    make(map[string]cacheEntry),
+		now:      time.Now,
+	}
+}
+
+// IsPlatform reports whether host (boleh dengan port) is served as the platform itself:
+// a platform host or its subdomain, localhost, or an IP address.
+func (s *Store) IsPlatform(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if host == "" || host == "localhost" || strings.HasSuffix(host, ".localhost") || net.ParseIP(host) != nil {
+		return true
+	}
+	for _, p := range s.platform {
+		if host == p || strings.HasSuffix(host, "."+p) {
+			return true
+		}
+	}
+	return false
+}
+
+// token is the TXT verification token: stabil per (domain, akun), jadi akun lain yang
+// mengambil alih klaim otomatis mendapat token berbeda.
+func (s *Store) token(name, ownerID string) string {
+	return s.key.Token("txt", name, ownerID)
+}
+
+func (s *Store) proof(name, ownerID string) string {
+	return s.key.Sign("verified", name, ownerID)
+}
+
+// trusted reports whether d is verified and its proof matches its owner.
+func (s *Store) trusted(d *Domain) bool {
+	return d.Verified() && s.key.Verify(d.Proof, "verified", d.Name, d.OwnerID)
+}
+
+// List returns the domains claimed by ownerID.
+func (s *Store) List(ctx context.Context, ownerID string) ([]*Domain, error) {
+	docs, err := s.fb.DB.Collection(s.coll).Where("ownerId", "==", ownerID).Limit(MaxPerAccount).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*Domain, 0, len(docs))
+	for _, doc := range docs {
+		d, err := s.fromDoc(doc)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// Claim registers raw as a pending domain of ownerID. Claiming a domain already owned by the
+// caller returns it unchanged.
+func (s *Store) Claim(ctx context.Context, ownerID, raw string) (*Domain, error) {
+	name, err := Normalize(raw)
+	if err != nil {
+		return nil, err
+	}
+	if s.IsPlatform(name) {
+		return nil, ErrReserved
+	}
+	ref := s.fb.DB.Collection(s.coll).Doc(name)
+	var out *Domain
+	err = s.fb.DB.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			d, err := s.fromDoc(doc)
+			if err != nil {
+				return err
+			}
+			if d.OwnerID == ownerID {
+				out = d
+				return nil
+			}
+			// Domain milik akun lain: hanya klaim pending yang sudah kedaluwarsa yang bisa diambil alih
+			if s.trusted(d) || s.now().Sub(d.CreatedAt) < claimTTL {
+				return ErrTaken
+			}
+		}
+		owned, err := tx.Documents(s.fb.DB.Collection(s.coll).Where("ownerId", "==", ownerID).Limit(MaxPerAccount)).GetAll()
+		if err != nil {
+			return err
+		}
+		if len(owned) >= MaxPerAccount {
+			return ErrLimit
+		}
+		out = &Domain{Name: name, OwnerID: ownerID, Status: StatusPending, CreatedAt: s.now().UTC()}
+		out.Token = s.token(name, ownerID)
+		return tx.Set(ref, out)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.forget(name)
+	return out, nil
+}
+
+// Verify checks the TXT record of a domain owned by ownerID right away and stores the result.
+func (s *Store) Verify(ctx context.Context, ownerID, raw string) (*Domain, error) {
+	name, err := Normalize(raw)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	doc, err := s.fb.DB.Collection(s.coll).Doc(name).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	d, err := s.fromDoc(doc)
+	if err != nil {
+		return nil, err
+	}
+	if d.OwnerID != ownerID {
+		return nil, ErrNotFound
+	}
+	return s.refresh(ctx, d)
+}
+
+// Delete removes a domain owned by ownerID and clears it as the account's primary domain.
+func (s *Store) Delete(ctx context.Context, ownerID, raw string) error {
+	name, err := Normalize(raw)
+	if err != nil {
+		return ErrNotFound
+	}
+	ref := s.fb.DB.Collection(s.coll).Doc(name)
+	account := s.fb.DB.Collection(s.profiles.AccountsCollection()).Doc(ownerID)
+	err = s.fb.DB.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if owner, _ := doc.Data()["ownerId"].(string); owner != ownerID {
+			return ErrNotFound
+		}
+		if err := s.clearPrimary(tx, account, name); err != nil {
+			return err
+		}
+		return tx.Delete(ref)
+	})
+	if err != nil {
+		return err
+	}
+	s.forget(name)
+	return nil
+}
+
+// refresh looks up the TXT record of d and applies the resulting state change: pending →
+// verified when the record is found; verified → pending once the record has been missing for
+// longer than missingGrace. DNS failures are recorded but never unverify a domain.
+func (s *Store) refresh(ctx context.Context, d *Domain) (*Domain, error) {
+	lookupCtx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	found, checkErr := CheckTXT(lookupCtx, s.resolver, d)
+	cancel()
+
+	ref := s.fb.DB.Collection(s.coll).Doc(d.Name)
+	account := s.fb.DB.Collection(s.profiles.AccountsCollection()).Doc(d.OwnerID)
+	var out *Domain
+	err := s.fb.DB.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		cur, err := s.fromDoc(doc)
+		if err != nil {
+			return err
+		}
+		// Dihapus atau diambil alih selama lookup DNS berjalan
+		if cur.OwnerID != d.OwnerID {
+			return ErrNotFound
+		}
+		now := s.now().UTC()
+		cur.LastCheckedAt = now
+		switch {
+		case checkErr != nil:
+			cur.CheckError = "DNS lookup failed, try again later"
+		case found:
+			cur.CheckError = ""
+			cur.MissingSince = time.Time{}
+			if !s.trusted(cur) {
+				cur.Status, cur.Proof, cur.VerifiedAt = StatusVerified, s.proof(cur.Name, cur.OwnerID), now
+				if err := s.setPrimary(tx, account, cur.Name); err != nil {
+					return err
+				}
+			}
+		case s.trusted(cur):
+			cur.CheckError = "TXT record not found"
+			if cur.MissingSince.IsZero() {
+				cur.MissingSince = now
+			}
+			if now.Sub(cur.MissingSince) > missingGrace {
+				// Dicabut: kembali ke pending dengan jendela klaim baru
+				cur.Status, cur.Proof, cur.VerifiedAt, cur.MissingSince, cur.CreatedAt = StatusPending, "", time.Time{}, time.Time{}, now
+				if err := s.clearPrimary(tx, account, cur.Name); err != nil {
+					return err
+				}
+			}
+		default:
+			cur.CheckError = "TXT record not found"
+			cur.Status, cur.Proof = StatusPending, ""
+		}
+		out = cur
+		return tx.Set(ref, cur)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if checkErr != nil {
+		log.Printf("domain %s: txt lookup: %v", d.Name, checkErr)
+	}
+	s.forget(d.Name)
+	return out, nil
+}
+
+// setPrimary makes name the account's customDomain (dipakai untuk canonical URL) unless the
+// account already has one.
+func (s *Store) setPrimary(tx *firestore.Transaction, account *firestore.DocumentRef, name string) error {
+	acc, err := tx.Get(account)
+	if status.Code(err) == codes.NotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if current, _ := acc.Data()["customDomain"].(string); current != "" && current != name {
+		return nil
+	}
+	return tx.Update(account, []firestore.Update{
+		{Path: "customDomain", Value: name},
+		{Path: "updatedAt", Value: firestore.ServerTimestamp},
+	})
+}
+
+func (s *Store) clearPrimary(tx *firestore.Transaction, account *firestore.DocumentRef, name string) error {
+	acc, err := tx.Get(account)
+	if status.Code(err) == codes.NotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if current, _ := acc.Data()["customDomain"].(string); current != name {
+		return nil
+	}
+	return tx.Update(account, []firestore.Update{
+		{Path: "customDomain", Value: firestore.Delete},
+		{Path: "updatedAt", Value: firestore.ServerTimestamp},
+	})
+}
+
+// Lookup resolves host to a verified domain and its owner's handle, or nil. Results (termasuk
+// hasil negatif) are cached for cacheTTL.
+func (s *Store) Lookup(ctx context.Context, host string) (*Route, error) {
+	name, err := Normalize(host)
+	if err != nil {
+		return nil, nil
+	}
+	now := s.now()
+	s.mu.Lock()
+	e, ok := s.cache[name]
+	s.mu.Unlock()
+	if ok && now.Before(e.exp) {
+		return e.route, nil
+	}
+
+	route, err := s.load(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	// Host header bebas diisi client: batasi ukuran cache negatif
+	if len(s.cache) >= cacheMaxEntries {
+		s.cache = make(map[string]cacheEntry)
+	}
+	s.cache[name] = cacheEntry{route: route, exp: now.Add(cacheTTL)}
+	s.mu.Unlock()
+	return route, nil
+}
+
+func (s *Store) load(ctx context.Context, name string) (*Route, error) {
+	doc, err := s.fb.DB.Collection(s.coll).Doc(name).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	d, err := s.fromDoc(doc)
+	if err != nil {
+		return nil, err
+	}
+	if !s.trusted(d) {
+		return nil, nil
+	}
+	p, err := s.profiles.FindByID(ctx, d.OwnerID)
+	if err != nil || p == nil || p.Handle == "" {
+		return nil, err
+	}
+	// Akun pending signup (belum punya role) tidak boleh tampil publik
+	if _, hasRole := p.Data["role"]; !hasRole {
+		return nil, nil
+	}
+	return &Route{Domain: d.Name, OwnerID: d.OwnerID, Handle: p.Handle}, nil
+}
+
+func (s *Store) forget(name string) {
+	s.mu.Lock()
+	delete(s.cache, name)
+	s.mu.Unlock()
+}
+
+// CanonicalURL returns "https://<domain>/" when the profile's primary custom domain is
+// verified and owned by it, or "" otherwise.
+func (s *Store) CanonicalURL(ctx context.Context, p *profile.Profile) string {
+	name, _ := p.Data["customDomain"].(string)
+	if name == "" {
+		return ""
+	}
+	route, err := s.Lookup(ctx, name)
+	if err != nil {
+		log.Printf("domain canonical %s: %v", name, err)
+		return ""
+	}
+	if route == nil || route.OwnerID != p.ID {
+		return ""
+	}
+	return "https://" + route.Domain + "/"
+}
+
+// Run re-checks every claimed domain periodically: pending domains are verified as soon as
+// their record appears, verified domains are revoked after missingGrace, and pending claims
+// older than claimTTL are removed.
+func (s *Store) Run(ctx context.Context) {
+	ticker := time.NewTicker(recheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := s.recheck(ctx); err != nil {
+			log.Printf("domain recheck: %v", err)
+		}
+	}
+}
+
+func (s *Store) recheck(ctx context.Context) error {
+	it := s.fb.DB.Collection(s.coll).Documents(ctx)
+	defer it.Stop()
+	for {
+		doc, err := it.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		d, err := s.fromDoc(doc)
+		if err != nil {
+			log.Printf("domain recheck %s: %v", doc.Ref.ID, err)
+			continue
+		}
+		if !s.trusted(d) && s.now().Sub(d.CreatedAt) > claimTTL {
+			if _, err := doc.Ref.Delete(ctx, firestore.LastUpdateTime(doc.UpdateTime)); err != nil {
+				log.Printf("domain recheck %s: delete expired claim: %v", d.Name, err)
+			}
+			continue
+		}
+		if _, err := s.refresh(ctx, d); err != nil && !errors.Is(err, ErrNotFound) {
+			log.Printf("domain recheck %s: %v", d.Name, err)
+		}
+	}
+}
+
+func (s *Store) fromDoc(doc *firestore.DocumentSnapshot) (*Domain, error) {
+	var d Domain
+	if err := doc.DataTo(&d); err != nil {
+		return nil, err
+	}
+	d.Name = doc.Ref.ID
+	d.Token = s.token(d.Name, d.OwnerID)
+	// Status "verified" tanpa proof yang cocok (ditulis langsung ke Firestore) = pending
+	if d.Status == StatusVerified && !s.trusted(&d) {
+		d.Status = StatusPending
+	}
+	return &d, nil
+}
@@ -3,6 +3,9 @@ package email
 import (
 	"crypto/tls"
 	"fmt"
+	"html"
+	"mime"
+	"net/mail"
 	"net/smtp"
 	"strings"
 )
@@ -10,6 +13,11 @@ import (
 type Sender interface {
 	SendPasswordReset(to, otp string) error
 	SendSignupOTP(to, otp string) error
+	SendEmailChangeOTP(to, otp string) error
+	SendLinkScheduleNotice(to, title, url string, live bool) error
+	SendPurchaseReceipt(to, title, url string, download bool) error
+	SendNewsletterConfirmation(to, creator, confirmURL string) error
+	SendContactMessage(to, name, replyTo, message string) error
 }
 
 type sender struct {
@@ -48,21 +56,12 @@ func NewSender(user, pass, service string) (Sender, error) {
 }
 
 func (s *sender) send(to, subject, bodyText, bodyHTML string) error {
-	headers := map[string]string{
-		"From":         `"SMM Panel Landing" <` + s.from + ">",
-		"To":           to,
-		"Subject":      subject,
-		"MIME-Version": "1.0",
-		"Content-Type": "text/html; charset=UTF-8",
-	}
-	var sb strings.Builder
-	for k, v := range headers {
-		sb.WriteString(k + ": " + v + "\r\n")
-	}
-	sb.WriteString("\r\n")
-	sb.WriteString(bodyHTML)
+	return s.sendReplyTo(to, "", subject, bodyText, bodyHTML)
+}
 
-	msg := []byte(sb.String())
+// sendReplyTo is send with an optional Reply-To header (alamat yang sudah di-format aman).
+func (s *sender) sendReplyTo(to, replyTo, subject, bodyText, bodyHTML string) error {
+	msg := s.message(to, replyTo, subject, bodyHTML)
 	if s.insecure {
 		tlsConfig := &tls.Config{ServerName: s.host, InsecureSkipVerify: true}
 		conn, err := tls.Dial("tcp", s.addr, tlsConfig)
@@ -97,6 +96,39 @@ func (s *sender) send(to, subject, bodyText, bodyHTML string) error {
 	return smtp.SendMail(s.addr, s.auth, s.from, []string{to}, msg)
 }
 
+// message builds the raw message. Subject sering berisi teks dari user (judul link, nama
+// kreator atau pengunjung), jadi dibersihkan dengan headerSafe lalu di-encode RFC 2047.
+func (s *sender) message(to, replyTo, subject, bodyHTML string) []byte {
+	headers := map[string]string{
+		"From":         `"SMM Panel Landing" <` + s.from + ">",
+		"To":           headerSafe(to),
+		"Subject":      mime.QEncoding.Encode("utf-8", headerSafe(subject)),
+		"MIME-Version": "1.0",
+		"Content-Type": "text/html; charset=UTF-8",
+	}
+	if replyTo != "" {
+		headers["Reply-To"] = headerSafe(replyTo)
+	}
+	var sb strings.Builder
+	for k, v := range headers {
+		sb.WriteString(k + ": " + v + "\r\n")
+	}
+	sb.WriteString("\r\n")
+	sb.WriteString(bodyHTML)
+	return []byte(sb.String())
+}
+
+// headerSafe replaces line breaks and other control characters so user text cannot start a
+// new header line.
+func headerSafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
 func (s *sender) SendPasswordReset(to, otp string) error {
 	html := passwordResetHTML(otp)
 	text := "SMM Panel Landing Password Reset Code: " + otp + ". It expires in 10 minutes."
@@ -109,6 +141,51 @@ func (s *sender) SendSignupOTP(to, otp string) error {
 	return s.send(to, "Kode verifikasi pendaftaran akun", text, html)
 }
 
+func (s *sender) SendEmailChangeOTP(to, otp string) error {
+	text := "Kode verifikasi email baru Anda adalah: " + otp + ". Kode berlaku selama 10 menit. Abaikan email ini jika Anda tidak meminta ganti email."
+	return s.send(to, "Kode verifikasi ganti email", text, noticeHTML("Verifikasi email baru", text))
+}
+
+func (s *sender) SendLinkScheduleNotice(to, title, url string, live bool) error {
+	if title == "" {
+		title = url
+	}
+	subject := "Link Anda sudah tayang: " + title
+	text := "Link \"" + title + "\" (" + url + ") sekarang tampil di halaman bio Anda."
+	heading := "Link sudah tayang"
+	if !live {
+		subject = "Link Anda sudah kedaluwarsa: " + title
+		text = "Link \"" + title + "\" (" + url + ") sudah melewati jadwal berakhir dan tidak lagi tampil di halaman bio Anda."
+		heading = "Link sudah kedaluwarsa"
+	}
+	return s.send(to, subject, text, noticeHTML(heading, text))
+}
+
+func (s *sender) SendPurchaseReceipt(to, title, url string, download bool) error {
+	subject := "Terima kasih atas dukungan Anda: " + title
+	text := "Pembayaran untuk \"" + title + "\" sudah kami terima. Detail pembayaran: " + url
+	if download {
+		subject = "Pembelian Anda: " + title
+		text = "Pembayaran untuk \"" + title + "\" sudah kami terima. Unduh file Anda di " + url
+	}
+	return s.send(to, subject, text, noticeHTML("Pembayaran diterima", text))
+}
+
+func (s *sender) SendNewsletterConfirmation(to, creator, confirmURL string) error {
+	subject := "Konfirmasi langganan: " + creator
+	text := "Anda (atau seseorang memakai email ini) mendaftar newsletter " + creator + ". Konfirmasi langganan di " + confirmURL + " — abaikan email ini jika Anda tidak mendaftar."
+	return s.send(to, subject, text, noticeHTML("Konfirmasi langganan", text))
+}
+
+func (s *sender) SendContactMessage(to, name, replyTo, message string) error {
+	// Nama berasal dari pengunjung; net/mail meng-encode nama non-ASCII di Reply-To
+	name = headerSafe(name)
+	reply := (&mail.Address{Name: name, Address: replyTo}).String()
+	subject := "Pesan baru dari " + name
+	intro := name + " <" + replyTo + "> mengirim pesan lewat halaman bio Anda. Balas email ini untuk menjawab langsung."
+	return s.sendReplyTo(to, reply, subject, intro+"\n\n"+message, messageHTML("Pesan baru", intro, message))
+}
+
 func passwordResetHTML(otp string) string {
 	// OTP dalam satu elemen teks agar bisa di-select dan di-copy di semua klien email
 	otpEscaped := strings.ReplaceAll(otp, "<", "&lt;")
@@ -151,3 +228,35 @@ func signupOTPHTML(otp string) string {
 </table>
 </body></html>`
 }
+
+// messageHTML is noticeHTML with a quoted, multi-line message body.
+func messageHTML(heading, intro, message string) string {
+	return `<!DOCTYPE html><html><head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"></head><body style="margin:0; padding:0; background:#0f172a;">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" style="background:#0f172a;">
+<tr><td align="center" style="padding:32px 16px;">
+  <table role="presentation" cellpadding="0" cellspacing="0" style="max-width:520px; width:100%; background:#1e293b; border:1px solid rgba(255,255,255,0.1); border-radius:16px;">
+    <tr><td style="padding:24px 24px 16px; text-align:center;"><h1 style="margin:0; font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,sans-serif; font-size:20px; font-weight:600; color:#f1f5f9;">` + html.EscapeString(heading) + `</h1></td></tr>
+    <tr><td style="padding:8px 24px 24px;">
+      <p style="margin:0 0 12px; font-size:14px; color:#94a3b8;">` + html.EscapeString(intro) + `</p>
+      <div style="padding:16px; background:#0f172a; border:1px solid rgba(255,255,255,0.15); border-radius:12px; font-size:14px; color:#f1f5f9; white-space:pre-wrap; word-break:break-word;">` + html.EscapeString(message) + `</div>
+    </td></tr>
+  </table>
+</td></tr>
+</table>
+</body></html>`
+}
+
+func noticeHTML(heading, message string) string {
+	return `<!DOCTYPE html><html><head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"></head><body style="margin:0; padding:0; background:#0f172a;">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" style="background:#0f172a;">
+<tr><td align="center" style="padding:32px 16px;">
+  <table role="presentation" cellpadding="0" cellspacing="0" style="max-width:420px; width:100%; background:#1e293b; border:1px solid rgba(255,255,255,0.1); border-radius:16px;">
+    <tr><td style="padding:24px 24px 16px; text-align:center;"><h1 style="margin:0; font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,sans-serif; font-size:20px; font-weight:600; color:#f1f5f9;">` + html.EscapeString(heading) + `</h1></td></tr>
+    <tr><td style="padding:8px 24px 24px; text-align:center;">
+      <p style="margin:0; font-size:14px; color:#94a3b8;">` + html.EscapeString(message) + `</p>
+    </td></tr>
+  </table>
+</td></tr>
+</table>
+</body></html>`
+}
@@ -0,0 +1,90 @@
+package email
+
+import (
+	"bufio"
+	"mime"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestHeaderSafe(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Link baru", "Link baru"},
+		{"judul\r\nBcc: korban@example.com", "judul  Bcc: korban@example.com"},
+		{"a\nb\rc\td\x00e\x7f", "a b c d e "},
+		{"Zoë — 日本語 🎉", "Zoë — 日本語 🎉"},
+	}
+	for _, tt := range tests {
+		if got := headerSafe(tt.in); got != tt.want {
+			t.Errorf("headerSafe(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// readMessage parses raw like a mail client would.
+func readMessage(t *testing.T, raw []byte) *mail.Message {
+	t.Helper()
+	msg, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(string(raw))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestMessageHeaders(t *testing.T) {
+	s := &sender{from: "noreply@aether.bio"}
+	dec := new(mime.WordDecoder)
+	tests := []struct {
+		name    string
+		subject string
+		want    string
+	}{
+		{"ascii", "Link Anda sudah tayang: Promo", "Link Anda sudah tayang: Promo"},
+		{"non-ascii handle", "Konfirmasi langganan: Zoë 日本", "Konfirmasi langganan: Zoë 日本"},
+		{"header injection", "Pesan baru dari x\r\nBcc: korban@example.com", "Pesan baru dari x  Bcc: korban@example.com"},
+		{"injection in non-ascii", "Pembelian Anda: é\nBcc: korban@example.com", "Pembelian Anda: é Bcc: korban@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := s.message("owner@example.com", "", tt.subject, "<p>Halo</p>")
+			msg := readMessage(t, raw)
+			if len(msg.Header["Bcc"]) != 0 || len(msg.Header["Subject"]) != 1 {
+				t.Fatalf("headers = %v", msg.Header)
+			}
+			subject := msg.Header.Get("Subject")
+			for _, r := range subject {
+				if r > 0x7e {
+					t.Fatalf("Subject header is not ASCII: %q", subject)
+				}
+			}
+			got, err := dec.DecodeHeader(subject)
+			if err != nil || got != tt.want {
+				t.Fatalf("Subject = %q (%v), want %q", got, err, tt.want)
+			}
+			if msg.Header.Get("To") != "owner@example.com" {
+				t.Fatalf("To = %q", msg.Header.Get("To"))
+			}
+		})
+	}
+}
+
+// Nama pengunjung di Reply-To di-encode net/mail dan tetap terbaca sebagai satu alamat.
+func TestMessageReplyTo(t *testing.T) {
+	s := &sender{from: "noreply@aether.bio"}
+	name := headerSafe("Zoë\r\nBcc: korban@example.com")
+	reply := (&mail.Address{Name: name, Address: "fan@example.com"}).String()
+	msg := readMessage(t, s.message("owner@example.com", reply, "Pesan baru dari "+name, "<p>Halo</p>"))
+	if len(msg.Header["Bcc"]) != 0 {
+		t.Fatalf("injected Bcc header: %v", msg.Header)
+	}
+	addr, err := mail.ParseAddress(msg.Header.Get("Reply-To"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr.Name != name || addr.Address != "fan@example.com" {
+		t.Fatalf("Reply-To = %+v", addr)
+	}
+}
@@ -0,0 +1,108 @@
+// Package enrich melengkapi request dengan data lokasi (GeoIP offline dari file .mmdb)
+// dan User-Agent (browser/OS/kelas device) tanpa memanggil layanan eksternal.
+// Hasilnya disimpan di context request sehingga handler mana pun bisa memakainya.
+package enrich
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"biomu/backend/internal/visitor"
+)
+
+// Info is the enrichment result for one request. IP addresses are never part of it.
+type Info struct {
+	Country        string `json:"country,omitempty" firestore:"country,omitempty"`
+	Region         string `json:"region,omitempty" firestore:"region,omitempty"`
+	City           string `json:"city,omitempty" firestore:"city,omitempty"`
+	Browser        string `json:"browser,omitempty" firestore:"browser,omitempty"`
+	BrowserVersion string `json:"browserVersion,omitempty" firestore:"browserVersion,omitempty"`
+	OS             string `json:"os,omitempty" firestore:"os,omitempty"`
+	OSVersion      string `json:"osVersion,omitempty" firestore:"osVersion,omitempty"`
+	Device         string `json:"device,omitempty" firestore:"device,omitempty"`
+}
+
+// Fields returns the non-empty values as a map, ready to be stamped on a Firestore document.
+func (i Info) Fields() map[string]any {
+	out := map[string]any{}
+	set := func(k, v string) {
+		if v != "" {
+			out[k] = v
+		}
+	}
+	set("country", i.Country)
+	set("region", i.Region)
+	set("city", i.City)
+	set("browser", i.Browser)
+	set("browserVersion", i.BrowserVersion)
+	set("os", i.OS)
+	set("osVersion", i.OSVersion)
+	set("device", i.Device)
+	return out
+}
+
+type ctxKey struct{}
+
+// Enricher builds Info from a request.
+type Enricher struct {
+	geo *GeoDB
+}
+
+// NewEnricher creates an enricher. geo may be nil, in which case the CDN country header is used.
+func NewEnricher(geo *GeoDB) *Enricher {
+	return &Enricher{geo: geo}
+}
+
+// Enrich resolves Info for r. The client IP is only used for the lookup and then discarded.
+// Pengunjung dengan DNT/GPC hanya mendapat negara (dipakai targeting), tanpa region dan kota.
+func (e *Enricher) Enrich(r *http.Request) Info {
+	info := e.Describe(visitor.ClientIP(r), r.UserAgent())
+	if info.Country == "" {
+		info.Country = cdnCountry(r)
+	}
+	if visitor.OptedOut(r) {
+		info.Region, info.City = "", ""
+	}
+	return info
+}
+
+// Describe resolves Info for an arbitrary IP/User-Agent pair (e.g. to preview targeting rules).
+func (e *Enricher) Describe(ip, userAgent string) Info {
+	ua := ParseUA(userAgent)
+	info := Info{
+		Browser:        ua.Browser,
+		BrowserVersion: ua.BrowserVersion,
+		OS:             ua.OS,
+		OSVersion:      ua.OSVersion,
+		Device:         ua.Device,
+	}
+	if e != nil && e.geo != nil {
+		g := e.geo.Lookup(ip)
+		info.Country, info.Region, info.City = g.Country, g.Region, g.City
+	}
+	return info
+}
+
+// Middleware stores the enrichment of every request in its context.
+func (e *Enricher) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := e.Enrich(r)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, info)))
+	})
+}
+
+// FromContext returns the Info stored by Middleware.
+func FromContext(ctx context.Context) (Info, bool) {
+	info, ok := ctx.Value(ctxKey{}).(Info)
+	return info, ok
+}
+
+// cdnCountry membaca header negara dari Cloudflare sebagai fallback tanpa database.
+func cdnCountry(r *http.Request) string {
+	c := strings.ToUpper(strings.TrimSpace(r.Header.Get("CF-IPCountry")))
+	if len(c) != 2 || c == "XX" || c == "T1" {
+		return ""
+	}
+	return c
+}
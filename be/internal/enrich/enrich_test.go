@@ -0,0 +1,87 @@
+package enrich
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+const iphoneUA = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1"
+
+func TestEnrich(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "geo.mmdb")
+	writeGeoDB(t, path, "ID", "JK", "Jakarta")
+	geo, err := OpenGeoDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer geo.Close()
+	device := Info{Browser: "Safari", BrowserVersion: "17", OS: "iOS", OSVersion: "17", Device: DeviceMobile}
+	located := device
+	located.Country, located.Region, located.City = "ID", "JK", "Jakarta"
+	countryOnly := device
+	countryOnly.Country = "ID"
+
+	tests := []struct {
+		name     string
+		enricher *Enricher
+		remote   string
+		headers  map[string]string
+		want     Info
+	}{
+		{"geoip", NewEnricher(geo), "203.0.113.5:1000", nil, located},
+		// DNT/GPC: negara tetap ada untuk targeting, region dan kota tidak
+		{"dnt", NewEnricher(geo), "203.0.113.5:1000", map[string]string{"DNT": "1"}, countryOnly},
+		{"gpc", NewEnricher(geo), "203.0.113.5:1000", map[string]string{"Sec-GPC": "1"}, countryOnly},
+		{"dnt 0", NewEnricher(geo), "203.0.113.5:1000", map[string]string{"DNT": "0"}, located},
+		// GeoIP menang atas header CDN; header dipakai jika alamat tidak dikenal
+		{"geoip over cdn", NewEnricher(geo), "203.0.113.5:1000", map[string]string{"CF-IPCountry": "SG"}, located},
+		{"unknown address", NewEnricher(geo), "10.0.0.1:1000", map[string]string{"CF-IPCountry": "id"}, countryOnly},
+		{"no database", NewEnricher(nil), "203.0.113.5:1000", map[string]string{"CF-IPCountry": "ID"}, countryOnly},
+		{"nil enricher", nil, "203.0.113.5:1000", map[string]string{"CF-IPCountry": "ID", "DNT": "1"}, countryOnly},
+		{"cdn unknown", NewEnricher(nil), "203.0.113.5:1000", map[string]string{"CF-IPCountry": "XX"}, device},
+		{"cdn tor", NewEnricher(nil), "203.0.113.5:1000", map[string]string{"CF-IPCountry": "T1"}, device},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+			r.Header.Set("User-Agent", iphoneUA)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if got := tt.enricher.Enrich(r); got != tt.want {
+				t.Fatalf("Enrich = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var got Info
+	var ok bool
+	h := NewEnricher(nil).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok = FromContext(r.Context())
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("User-Agent", iphoneUA)
+	r.Header.Set("CF-IPCountry", "ID")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if !ok || got.Country != "ID" || got.Device != DeviceMobile {
+		t.Fatalf("FromContext = %+v, %v", got, ok)
+	}
+	if _, ok := FromContext(r.Context()); ok {
+		t.Fatal("Info found outside the middleware")
+	}
+}
+
+func TestFields(t *testing.T) {
+	got := Info{Country: "ID", Device: DeviceMobile}.Fields()
+	if len(got) != 2 || got["country"] != "ID" || got["device"] != DeviceMobile {
+		t.Fatalf("Fields = %v", got)
+	}
+	if got := (Info{}).Fields(); len(got) != 0 {
+		t.Fatalf("empty Info Fields = %v", got)
+	}
+}
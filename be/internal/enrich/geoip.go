@@ -0,0 +1,147 @@
+package enrich
+
+import (
+	"context"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Geo is the location resolved from a local MaxMind-format database.
+type Geo struct {
+	Country string
+	Region  string
+	City    string
+}
+
+// geoRecord mengikuti skema GeoIP2/GeoLite2 City & Country (juga DB-IP lite).
+type geoRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// GeoDB wraps a .mmdb file and reopens it when the file changes on disk, so the
+// database can be updated (e.g. by geoipupdate) without restarting the backend.
+type GeoDB struct {
+	path string
+
+	mu      sync.RWMutex
+	reader  *maxminddb.Reader
+	modTime time.Time
+	size    int64
+}
+
+// OpenGeoDB opens the database at path. An empty path returns (nil, nil): lookups are disabled.
+// Jika file belum bisa dibuka (mis. belum di-deploy), GeoDB tetap dikembalikan bersama error:
+// lookup kosong sampai Watch berhasil membukanya.
+func OpenGeoDB(path string) (*GeoDB, error) {
+	if path == "" {
+		return nil, nil
+	}
+	db := &GeoDB{path: path}
+	return db, db.reload()
+}
+
+func (db *GeoDB) reload() error {
+	st, err := os.Stat(db.path)
+	if err != nil {
+		return err
+	}
+	reader, err := maxminddb.Open(db.path)
+	if err != nil {
+		return err
+	}
+	db.mu.Lock()
+	old := db.reader
+	db.reader, db.modTime, db.size = reader, st.ModTime(), st.Size()
+	db.mu.Unlock()
+	if old != nil {
+		_ = old.Close()
+	}
+	return nil
+}
+
+func (db *GeoDB) changed() bool {
+	st, err := os.Stat(db.path)
+	if err != nil {
+		return false
+	}
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return !st.ModTime().Equal(db.modTime) || st.Size() != db.size
+}
+
+// Watch polls the file every interval and hot-reloads it on change until ctx is cancelled.
+func (db *GeoDB) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !db.changed() {
+			continue
+		}
+		if err := db.reload(); err != nil {
+			// File bisa saja sedang ditulis; coba lagi di tick berikutnya, DB lama tetap dipakai
+			log.Printf("geoip reload %s: %v", db.path, err)
+			continue
+		}
+		log.Printf("geoip reloaded %s", db.path)
+	}
+}
+
+// Lookup resolves ip. Unknown or private addresses return an empty Geo.
+func (db *GeoDB) Lookup(ip string) Geo {
+	if db == nil {
+		return Geo{}
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return Geo{}
+	}
+	var rec geoRecord
+	db.mu.RLock()
+	if db.reader == nil {
+		db.mu.RUnlock()
+		return Geo{}
+	}
+	err := db.reader.Lookup(parsed, &rec)
+	db.mu.RUnlock()
+	if err != nil {
+		return Geo{}
+	}
+	g := Geo{Country: rec.Country.ISOCode, City: rec.City.Names["en"]}
+	if len(rec.Subdivisions) > 0 {
+		g.Region = rec.Subdivisions[0].ISOCode
+	}
+	return g
+}
+
+// Close releases the underlying reader.
+func (db *GeoDB) Close() error {
+	if db == nil {
+		return nil
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.reader == nil {
+		return nil
+	}
+	err := db.reader.Close()
+	db.reader = nil
+	return err
+}
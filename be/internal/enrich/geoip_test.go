@@ -0,0 +1,180 @@
+package enrich
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// mmdb encodes the subset of the MaxMind DB format the tests need.
+type mmdb struct{ bytes.Buffer }
+
+func (m *mmdb) str(s string) {
+	m.WriteByte(2<<5 | byte(len(s)))
+	m.WriteString(s)
+}
+
+func (m *mmdb) mapHeader(n int) { m.WriteByte(7<<5 | byte(n)) }
+
+// Array adalah extended type 11: byte kontrol bertipe 0, lalu 11-7.
+func (m *mmdb) arrayHeader(n int) { m.WriteByte(byte(n)); m.WriteByte(4) }
+
+func (m *mmdb) uint16(v uint16) {
+	m.WriteByte(5<<5 | 2)
+	_ = binary.Write(m, binary.BigEndian, v)
+}
+
+func (m *mmdb) uint32(v uint32) {
+	m.WriteByte(6<<5 | 4)
+	_ = binary.Write(m, binary.BigEndian, v)
+}
+
+// writeGeoDB writes an IPv4 database with a single node: 0.0.0.0/1 is unknown and
+// 128.0.0.0/1 resolves to country/region/city.
+func writeGeoDB(t *testing.T, path, country, region, city string) {
+	t.Helper()
+	const nodeCount = 1
+	var f mmdb
+	// Record 24 bit: kiri = nodeCount (tidak ditemukan), kanan = pointer ke data offset 0
+	f.Write([]byte{0, 0, nodeCount, 0, 0, nodeCount + 16})
+	f.Write(make([]byte, 16))
+
+	f.mapHeader(3)
+	f.str("country")
+	f.mapHeader(1)
+	f.str("iso_code")
+	f.str(country)
+	f.str("subdivisions")
+	f.arrayHeader(1)
+	f.mapHeader(1)
+	f.str("iso_code")
+	f.str(region)
+	f.str("city")
+	f.mapHeader(1)
+	f.str("names")
+	f.mapHeader(1)
+	f.str("en")
+	f.str(city)
+
+	f.WriteString("\xab\xcd\xefMaxMind.com")
+	f.mapHeader(6)
+	f.str("node_count")
+	f.uint32(nodeCount)
+	f.str("record_size")
+	f.uint16(24)
+	f.str("ip_version")
+	f.uint16(4)
+	f.str("database_type")
+	f.str("Test-City")
+	f.str("binary_format_major_version")
+	f.uint16(2)
+	f.str("binary_format_minor_version")
+	f.uint16(0)
+
+	replaceFile(t, path, f.Bytes())
+}
+
+// replaceFile swaps in a new file with a rename, seperti geoipupdate: reader lama (mmap) tetap
+// valid sampai ditutup.
+func replaceFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "geo.mmdb")
+	writeGeoDB(t, path, "ID", "JK", "Jakarta")
+	db, err := OpenGeoDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	tests := []struct {
+		ip   string
+		want Geo
+	}{
+		{"203.0.113.5", Geo{Country: "ID", Region: "JK", City: "Jakarta"}},
+		{"10.0.0.1", Geo{}},
+		{"2001:db8::1", Geo{}},
+		{"not-an-ip", Geo{}},
+		{"", Geo{}},
+	}
+	for _, tt := range tests {
+		if got := db.Lookup(tt.ip); got != tt.want {
+			t.Errorf("Lookup(%q) = %+v, want %+v", tt.ip, got, tt.want)
+		}
+	}
+	var none *GeoDB
+	if got := none.Lookup("203.0.113.5"); got != (Geo{}) {
+		t.Fatalf("nil GeoDB Lookup = %+v", got)
+	}
+}
+
+func TestOpenGeoDB(t *testing.T) {
+	if db, err := OpenGeoDB(""); db != nil || err != nil {
+		t.Fatalf("empty path = %v, %v", db, err)
+	}
+	// File belum ada: GeoDB tetap dibuat supaya Watch bisa membukanya nanti
+	db, err := OpenGeoDB(filepath.Join(t.TempDir(), "missing.mmdb"))
+	if err == nil || db == nil {
+		t.Fatalf("missing file = %v, %v", db, err)
+	}
+	if got := db.Lookup("203.0.113.5"); got != (Geo{}) {
+		t.Fatalf("Lookup before the file exists = %+v", got)
+	}
+}
+
+func waitForCountry(t *testing.T, db *GeoDB, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for db.Lookup("203.0.113.5").Country != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("country = %q, want %q", db.Lookup("203.0.113.5").Country, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "geo.mmdb")
+	db, err := OpenGeoDB(path)
+	if err == nil {
+		t.Fatal("opened a missing file")
+	}
+	defer db.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go db.Watch(ctx, 10*time.Millisecond)
+
+	// Database yang di-deploy setelah start
+	writeGeoDB(t, path, "ID", "JK", "Jakarta")
+	waitForCountry(t, db, "ID")
+
+	// geoipupdate mengganti file: dibuka ulang tanpa restart
+	writeGeoDB(t, path, "SG", "01", "Singapore")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	waitForCountry(t, db, "SG")
+	if got := db.Lookup("203.0.113.5"); got.City != "Singapore" {
+		t.Fatalf("Lookup after reload = %+v", got)
+	}
+
+	// File rusak: DB lama tetap dipakai
+	replaceFile(t, path, []byte("corrupt"))
+	time.Sleep(50 * time.Millisecond)
+	if got := db.Lookup("203.0.113.5"); got.Country != "SG" {
+		t.Fatalf("Lookup after a corrupt update = %+v", got)
+	}
+}
@@ -0,0 +1,121 @@
+package enrich
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceTV      = "tv"
+	DeviceConsole = "console"
+	DeviceBot     = "bot"
+	DeviceUnknown = "unknown"
+)
+
+// UA is a parsed User-Agent.
+type UA struct {
+	Browser        string
+	BrowserVersion string
+	OS             string
+	OSVersion      string
+	Device         string
+}
+
+type uaRule struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// Urutan penting: browser turunan Chromium harus dicek sebelum Chrome, Chrome sebelum Safari.
+var browserRules = []uaRule{
+	{"Instagram", regexp.MustCompile(`Instagram ([\d.]+)`)},
+	{"Facebook", regexp.MustCompile(`(?:FBAV|FB_IAB/FB4A;FBAV)/([\d.]+)`)},
+	{"TikTok", regexp.MustCompile(`(?:musical_ly|BytedanceWebview|TikTok)[_/ ]?([\d.]*)`)},
+	{"Line", regexp.MustCompile(`Line/([\d.]+)`)},
+	{"Samsung Internet", regexp.MustCompile(`SamsungBrowser/([\d.]+)`)},
+	{"UC Browser", regexp.MustCompile(`UCBrowser/([\d.]+)`)},
+	{"Opera", regexp.MustCompile(`(?:OPR|Opera)/([\d.]+)`)},
+	{"Edge", regexp.MustCompile(`Edg(?:e|A|iOS)?/([\d.]+)`)},
+	{"Yandex", regexp.MustCompile(`YaBrowser/([\d.]+)`)},
+	{"Brave", regexp.MustCompile(`Brave/([\d.]+)`)},
+	{"Vivaldi", regexp.MustCompile(`Vivaldi/([\d.]+)`)},
+	{"Firefox", regexp.MustCompile(`(?:Firefox|FxiOS)/([\d.]+)`)},
+	{"Chrome", regexp.MustCompile(`(?:Chrome|CriOS)/([\d.]+)`)},
+	{"Safari", regexp.MustCompile(`Version/([\d.]+).*Safari/`)},
+	{"Internet Explorer", regexp.MustCompile(`(?:MSIE |Trident/.*rv:)([\d.]+)`)},
+}
+
+var osRules = []uaRule{
+	{"iPadOS", regexp.MustCompile(`iPad.*OS ([\d_]+)`)},
+	{"iOS", regexp.MustCompile(`(?:iPhone|iPod).*OS ([\d_]+)`)},
+	{"HarmonyOS", regexp.MustCompile(`HarmonyOS(?:[ /]([\d.]+))?`)},
+	{"Android", regexp.MustCompile(`Android[ /]?([\d.]*)`)},
+	{"Windows", regexp.MustCompile(`Windows NT ([\d.]+)`)},
+	{"ChromeOS", regexp.MustCompile(`CrOS \S+ ([\d.]+)`)},
+	{"macOS", regexp.MustCompile(`Mac OS X ([\d_.]+)`)},
+	{"Linux", regexp.MustCompile(`Linux`)},
+}
+
+var botRe = regexp.MustCompile(`(?i)bot\b|crawler|spider|preview|facebookexternalhit|whatsapp|curl/|wget/|python-|go-http-client|headless`)
+
+// ParseUA parses ua into browser, OS and device class without any external lookup.
+func ParseUA(ua string) UA {
+	out := UA{Device: DeviceUnknown}
+	if strings.TrimSpace(ua) == "" {
+		return out
+	}
+	if botRe.MatchString(ua) {
+		out.Device = DeviceBot
+	}
+	for _, r := range browserRules {
+		if m := r.re.FindStringSubmatch(ua); m != nil {
+			out.Browser, out.BrowserVersion = r.name, majorVersion(m[1])
+			break
+		}
+	}
+	for _, r := range osRules {
+		if m := r.re.FindStringSubmatch(ua); m != nil {
+			out.OS = r.name
+			if len(m) > 1 {
+				out.OSVersion = majorVersion(strings.ReplaceAll(m[1], "_", "."))
+			}
+			break
+		}
+	}
+	if out.Device == DeviceBot {
+		return out
+	}
+	out.Device = deviceClass(ua, out.OS)
+	return out
+}
+
+func deviceClass(ua, os string) string {
+	lower := strings.ToLower(ua)
+	switch {
+	case strings.Contains(lower, "smart-tv") || strings.Contains(lower, "smarttv") || strings.Contains(lower, "tizen") ||
+		strings.Contains(lower, "webos") || strings.Contains(lower, "appletv") || strings.Contains(lower, "crkey") ||
+		strings.Contains(lower, "android tv") || strings.Contains(lower, "bravia"):
+		return DeviceTV
+	case strings.Contains(lower, "playstation") || strings.Contains(lower, "xbox") || strings.Contains(lower, "nintendo"):
+		return DeviceConsole
+	case os == "iPadOS" || strings.Contains(lower, "tablet") ||
+		(os == "Android" && !strings.Contains(lower, "mobile")):
+		return DeviceTablet
+	case os == "iOS" || os == "Android" || strings.Contains(lower, "mobi"):
+		return DeviceMobile
+	case os != "":
+		return DeviceDesktop
+	}
+	return DeviceUnknown
+}
+
+func majorVersion(v string) string {
+	v = strings.Trim(v, ".")
+	if i := strings.IndexByte(v, '.'); i > 0 {
+		return v[:i]
+	}
+	return v
+}
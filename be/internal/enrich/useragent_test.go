@@ -0,0 +1,102 @@
+package enrich
+
+import "testing"
+
+func TestParseUA(t *testing.T) {
+	tests := []struct {
+		name string
+		ua   string
+		want UA
+	}{
+		{"empty", "  ", UA{Device: DeviceUnknown}},
+		{"chrome windows",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			UA{Browser: "Chrome", BrowserVersion: "124", OS: "Windows", OSVersion: "10", Device: DeviceDesktop}},
+		{"edge before chrome",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.2478.51",
+			UA{Browser: "Edge", BrowserVersion: "124", OS: "Windows", OSVersion: "10", Device: DeviceDesktop}},
+		{"safari macos",
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15",
+			UA{Browser: "Safari", BrowserVersion: "17", OS: "macOS", OSVersion: "10", Device: DeviceDesktop}},
+		{"iphone",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+			UA{Browser: "Safari", BrowserVersion: "17", OS: "iOS", OSVersion: "17", Device: DeviceMobile}},
+		{"ipad",
+			"Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1",
+			UA{Browser: "Chrome", BrowserVersion: "120", OS: "iPadOS", OSVersion: "16", Device: DeviceTablet}},
+		// Android tanpa "Mobile" adalah tablet
+		{"android phone",
+			"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Mobile Safari/537.36",
+			UA{Browser: "Chrome", BrowserVersion: "124", OS: "Android", OSVersion: "14", Device: DeviceMobile}},
+		{"android tablet",
+			"Mozilla/5.0 (Linux; Android 13; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			UA{Browser: "Chrome", BrowserVersion: "124", OS: "Android", OSVersion: "13", Device: DeviceTablet}},
+		{"samsung internet",
+			"Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/24.0 Chrome/117.0.0.0 Mobile Safari/537.36",
+			UA{Browser: "Samsung Internet", BrowserVersion: "24", OS: "Android", OSVersion: "14", Device: DeviceMobile}},
+		{"firefox ios",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) FxiOS/125.0 Mobile/15E148 Safari/605.1.15",
+			UA{Browser: "Firefox", BrowserVersion: "125", OS: "iOS", OSVersion: "17", Device: DeviceMobile}},
+		// In-app browser: nama aplikasi menang atas Chrome/Safari di dalamnya
+		{"instagram in-app",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 Instagram 327.1.6.30.88 (iPhone14,5; iOS 17_4; id_ID; id)",
+			UA{Browser: "Instagram", BrowserVersion: "327", OS: "iOS", OSVersion: "17", Device: DeviceMobile}},
+		{"facebook in-app",
+			"Mozilla/5.0 (Linux; Android 14; Pixel 7 Build/UQ1A; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/124.0.6367.82 Mobile Safari/537.36 [FB_IAB/FB4A;FBAV/461.0.0.42.107;]",
+			UA{Browser: "Facebook", BrowserVersion: "461", OS: "Android", OSVersion: "14", Device: DeviceMobile}},
+		{"tiktok in-app",
+			"Mozilla/5.0 (Linux; Android 12; SM-A525F) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/110.0.5481.153 Mobile Safari/537.36 musical_ly_2023401040 BytedanceWebview/d8a21c6",
+			UA{Browser: "TikTok", BrowserVersion: "2023401040", OS: "Android", OSVersion: "12", Device: DeviceMobile}},
+		{"line in-app",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 16_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 Safari Line/13.16.0",
+			UA{Browser: "Line", BrowserVersion: "13", OS: "iOS", OSVersion: "16", Device: DeviceMobile}},
+		{"smart tv",
+			"Mozilla/5.0 (SMART-TV; Linux; Tizen 6.0) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/4.0 Chrome/76.0.3809.146 TV Safari/537.36",
+			UA{Browser: "Samsung Internet", BrowserVersion: "4", OS: "Linux", Device: DeviceTV}},
+		{"console",
+			"Mozilla/5.0 (PlayStation; PlayStation 5/2.26) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/13.0 Safari/605.1.15",
+			UA{Browser: "Safari", BrowserVersion: "13", Device: DeviceConsole}},
+		// Bot tetap diparse browser/OS-nya tetapi kelas device-nya "bot"
+		{"googlebot smartphone",
+			"Mozilla/5.0 (Linux; Android 6.0.1; Nexus 5X Build/MMB29P) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.6367.91 Mobile Safari/537.36 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			UA{Browser: "Chrome", BrowserVersion: "124", OS: "Android", OSVersion: "6", Device: DeviceBot}},
+		{"link preview", "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", UA{Device: DeviceBot}},
+		{"whatsapp preview", "WhatsApp/2.23.20.0", UA{Device: DeviceBot}},
+		{"headless chrome",
+			"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/124.0.0.0 Safari/537.36",
+			UA{Browser: "Chrome", BrowserVersion: "124", OS: "Linux", Device: DeviceBot}},
+		{"curl", "curl/8.5.0", UA{Device: DeviceBot}},
+		{"unknown", "SomeApp", UA{Device: DeviceUnknown}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseUA(tt.ua); got != tt.want {
+				t.Fatalf("ParseUA = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeviceClass(t *testing.T) {
+	tests := []struct {
+		ua, os, want string
+	}{
+		{"Mozilla/5.0 (Linux; Android 12; Chromecast) CrKey/1.56", "Android", DeviceTV},
+		{"Mozilla/5.0 (Linux; Android 9; BRAVIA 4K)", "Android", DeviceTV},
+		{"Mozilla/5.0 (Web0S; Linux/SmartTV)", "Linux", DeviceTV},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64; Xbox; Xbox One)", "Windows", DeviceConsole},
+		{"Mozilla/5.0 (Nintendo Switch; WifiWebAuthApplet)", "", DeviceConsole},
+		{"Mozilla/5.0 (Android 14; Tablet; rv:125.0) Gecko/125.0 Firefox/125.0", "Android", DeviceTablet},
+		{"Mozilla/5.0 (Android 14; Mobile; rv:125.0) Gecko/125.0 Firefox/125.0", "Android", DeviceMobile},
+		{"Mozilla/5.0 (iPad; CPU OS 17_0 like Mac OS X)", "iPadOS", DeviceTablet},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)", "iOS", DeviceMobile},
+		{"Opera/9.80 (J2ME/MIDP; Opera Mini/9.80) Presto/2.5.25 Version/10.54 Mobi", "", DeviceMobile},
+		{"Mozilla/5.0 (X11; CrOS x86_64 14541.0.0)", "ChromeOS", DeviceDesktop},
+		{"SomeApp/1.0", "", DeviceUnknown},
+	}
+	for _, tt := range tests {
+		if got := deviceClass(tt.ua, tt.os); got != tt.want {
+			t.Errorf("deviceClass(%q, %q) = %q, want %q", tt.ua, tt.os, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,437 @@
+// Package entitlement defines what the generic /api/db endpoint may touch (allowlist koleksi
+// beserta aturan pemiliknya) and what each membership tier may write through it (jumlah dokumen
+// per akun, ukuran dokumen, kuota tulis per jam), and enforces both for db.Handler.
+package entitlement
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"biomu/backend/internal/firebase"
+	"biomu/backend/internal/profile"
+	"biomu/backend/internal/ratelimit"
+
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Denial reasons.
+const (
+	ReasonCollection = "collection"
+	ReasonMaxDocs    = "max_docs"
+	ReasonDocSize    = "doc_size"
+	ReasonQuota      = "quota"
+)
+
+var (
+	ErrUnauthorized = errors.New("sign in to access data")
+	ErrForbidden    = errors.New("documents can only be written for your own account")
+	ErrServerField  = errors.New("field is managed by the server")
+	// ErrCollection: koleksi tidak ada di allowlist; data fitur lain (order, subscriber, inbox,
+	// settings, salt, ...) hanya bisa diakses lewat handler fiturnya sendiri
+	ErrCollection = errors.New("collection is not available")
+	ErrNotFound   = errors.New("not found")
+)
+
+// Tier is the set of limits of one membership status.
+type Tier struct {
+	Name string `json:"name"`
+	// MaxDocs: koleksi berpemilik (Collection.Owner diisi) yang boleh ditulis tier ini, beserta
+	// jumlah dokumen maksimal per akun (0 = tanpa batas). Koleksi berpemilik yang tidak ada di
+	// map ini tidak boleh ditulis.
+	MaxDocs       map[string]int `json:"maxDocs"`
+	MaxDocBytes   int            `json:"maxDocBytes"`
+	WritesPerHour int            `json:"writesPerHour"`
+}
+
+// Collection is the /api/db rule of one allowed collection.
+type Collection struct {
+	// Owner: field berisi uid pemilik dokumen. Kosong berarti ID dokumen adalah uid pemilik
+	// (dokumen akun); dokumen seperti itu dibuat dan dihapus oleh server, bukan lewat /api/db.
+	Owner string
+	// PublicRead: dokumen boleh dibaca tanpa session (db.Handler tetap menyembunyikan link
+	// yang tidak tayang dan URL link terkunci)
+	PublicRead bool
+	// ServerFields: field yang hanya ditulis server (mis. status dari billing), ditolak dari
+	// caller selain admin
+	ServerFields []string
+}
+
+// OwnerOf returns the uid owning the stored document id with data.
+func (c Collection) OwnerOf(id string, data map[string]any) string {
+	if c.Owner == "" {
+		return id
+	}
+	v, _ := data[c.Owner].(string)
+	return v
+}
+
+// serverField returns the first ServerFields entry payload writes (langsung atau lewat path
+// bertitik seperti "email.x").
+func (c Collection) serverField(payload map[string]any) (string, bool) {
+	for _, field := range c.ServerFields {
+		for k := range payload {
+			if k == field || strings.HasPrefix(k, field+".") {
+				return field, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Policy lists the tiers from lowest to highest and the collections reachable through /api/db.
+type Policy struct {
+	Tiers []Tier
+	// Collections adalah allowlist: koleksi yang tidak tercantum ditolak untuk semua caller,
+	// termasuk admin (data internal punya endpoint admin sendiri)
+	Collections map[string]Collection
+}
+
+// DefaultPolicy allows only the two collections the dashboard edits directly: accounts
+// (ID dokumen = uid; status/role/customDomain, identitas login dan OTP dikelola server; email
+// dan handle diganti lewat /api/account) and links (pemilik di profileId, dibaca publik oleh
+// halaman bio). Links per account are limited by tier.
+func DefaultPolicy(accountsColl, linksColl string) Policy {
+	return Policy{
+		Tiers: []Tier{
+			{Name: profile.StatusRegular, MaxDocs: map[string]int{linksColl: 25}, MaxDocBytes: 8 << 10, WritesPerHour: 200},
+			{Name: profile.StatusMembership, MaxDocs: map[string]int{linksColl: 500}, MaxDocBytes: 64 << 10, WritesPerHour: 2000},
+		},
+		Collections: map[string]Collection{
+			accountsColl: {ServerFields: []string{
+				"status", "role", "customDomain",
+				"email", "handle", "provider",
+				"signupOtp", "signupOtpExpiry", "resetToken", "resetTokenExpiry",
+				"pendingEmail", "pendingEmailOtp", "pendingEmailExpiry", "pendingEmailAttempts",
+			}},
+			linksColl: {Owner: "profileId", PublicRead: true},
+		},
+	}
+}
+
+// Tier returns the tier named name, falling back to the lowest tier.
+func (p Policy) Tier(name string) Tier {
+	for _, t := range p.Tiers {
+		if t.Name == name {
+			return t
+		}
+	}
+	return p.Tiers[0]
+}
+
+// MaxDocBytes is the largest document any tier may write.
+func (p Policy) MaxDocBytes() int {
+	max := 0
+	for _, t := range p.Tiers {
+		if t.MaxDocBytes > max {
+			max = t.MaxDocBytes
+		}
+	}
+	return max
+}
+
+// upgrade returns the lowest tier above current for which ok reports true, or "".
+func (p Policy) upgrade(current string, ok func(Tier) bool) string {
+	above := false
+	for _, t := range p.Tiers {
+		if above && ok(t) {
+			return t.Name
+		}
+		if t.Name == current {
+			above = true
+		}
+	}
+	return ""
+}
+
+// Denial is a write refused by a tier limit. Status is 402 when a higher tier lifts the limit
+// (RequiredTier), otherwise 403 (atau 429 untuk kuota, yang reset sendiri).
+type Denial struct {
+	Status       int
+	Reason       string
+	Tier         string
+	RequiredTier string
+	Limit        int
+	RetryAfter   time.Duration
+}
+
+func (d *Denial) Error() string {
+	return fmt.Sprintf("%s limit of tier %s reached", d.Reason, d.Tier)
+}
+
+// Body is the standard JSON error body of a denial.
+func (d *Denial) Body() map[string]any {
+	body := map[string]any{"error": "upgrade_required", "reason": d.Reason, "tier": d.Tier, "limit": d.Limit}
+	if d.RequiredTier != "" {
+		body["requiredTier"] = d.RequiredTier
+	}
+	if d.Status == http.StatusTooManyRequests {
+		body["error"] = "quota_exceeded"
+		body["retryAfter"] = int(d.RetryAfter.Seconds())
+	}
+	return body
+}
+
+func (p Policy) deny(reason, tier string, limit int, ok func(Tier) bool) *Denial {
+	d := &Denial{Status: http.StatusForbidden, Reason: reason, Tier: tier, Limit: limit, RequiredTier: p.upgrade(tier, ok)}
+	if d.RequiredTier != "" {
+		d.Status = http.StatusPaymentRequired
+	}
+	return d
+}
+
+// Checker enforces a Policy for signed-in callers. Admin accounts are not limited.
+type Checker struct {
+	fb       *firebase.App
+	profiles *profile.Store
+	policy   Policy
+	quota    *ratelimit.Limiter
+	now      func() time.Time
+}
+
+func NewChecker(fb *firebase.App, profiles *profile.Store, policy Policy) *Checker {
+	return &Checker{fb: fb, profiles: profiles, policy: policy, quota: ratelimit.New(0, time.Hour), now: time.Now}
+}
+
+func (c *Checker) Policy() Policy { return c.policy }
+
+// Account is the tier resolved for a caller.
+type Account struct {
+	UID   string
+	Tier  Tier
+	Admin bool
+}
+
+// Account resolves the tier of uid from the account's status.
+func (c *Checker) Account(ctx context.Context, uid string) (*Account, error) {
+	p, err := c.profiles.FindByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return &Account{UID: uid, Tier: c.policy.Tier(profile.StatusRegular)}, nil
+	}
+	return &Account{UID: uid, Tier: c.policy.Tier(p.Status), Admin: p.Data["role"] == "admin"}, nil
+}
+
+// Access is what one caller may do in one allowed collection.
+type Access struct {
+	Collection string
+	Rule       Collection
+	// Account is nil for anonymous callers.
+	Account *Account
+}
+
+// Owns reports whether the caller owns the stored document id (admin dianggap pemilik semua dokumen).
+func (a *Access) Owns(id string, data map[string]any) bool {
+	return a.Account != nil && (a.Account.Admin || a.Rule.OwnerOf(id, data) == a.Account.UID)
+}
+
+// CanRead reports whether the caller may read the stored document id.
+func (a *Access) CanRead(id string, data map[string]any) bool {
+	return a.Rule.PublicRead || a.Owns(id, data)
+}
+
+// Scoped reports whether a list must be narrowed to the caller's own documents.
+func (a *Access) Scoped() bool {
+	return !a.Rule.PublicRead && !a.Account.Admin
+}
+
+// access resolves the rule of collection and the caller's account. Collections outside the
+// allowlist fail with ErrCollection for everyone, before the session is even looked at.
+func (c *Checker) access(ctx context.Context, uid, collection string, anonymous bool) (*Access, error) {
+	rule, ok := c.policy.Collections[collection]
+	if !ok {
+		return nil, ErrCollection
+	}
+	a := &Access{Collection: collection, Rule: rule}
+	if uid == "" {
+		if !anonymous {
+			return nil, ErrUnauthorized
+		}
+		return a, nil
+	}
+	acc, err := c.Account(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	a.Account = acc
+	return a, nil
+}
+
+// ReadAccess is consulted before every /api/db list or get. Anonymous callers may only read
+// PublicRead collections; per-document visibility is decided with Access.CanRead.
+func (c *Checker) ReadAccess(ctx context.Context, uid, collection string) (*Access, error) {
+	rule, ok := c.policy.Collections[collection]
+	return c.access(ctx, uid, collection, ok && rule.PublicRead)
+}
+
+// owned checks that a owns document id with the stored data (nil when it does not exist).
+// Documents of other accounts are reported as ErrNotFound, like missing ones.
+func (a *Access) owned(id string, data map[string]any) error {
+	if data == nil || !a.Owns(id, data) {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// CheckDelete is consulted before every /api/db delete: only the owner (atau admin) may delete,
+// and account documents are never deleted through /api/db.
+func (c *Checker) CheckDelete(ctx context.Context, uid, collection, id string) error {
+	a, err := c.access(ctx, uid, collection, false)
+	if err != nil {
+		return err
+	}
+	if a.Rule.Owner == "" && !a.Account.Admin {
+		return ErrForbidden
+	}
+	doc, err := c.fb.DB.Collection(collection).Doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return a.owned(id, doc.Data())
+}
+
+// CheckWrite is consulted before every /api/db create (id == "") or update. payload is the
+// decoded request body; on create into an owned collection a missing owner field is set to
+// uid, and an update must target a stored document owned by uid. stored is the data of that
+// document as read by the caller (nil when it does not exist), so the update is checked against
+// the same version the rest of the write sees. Limit violations are returned as *Denial.
+func (c *Checker) CheckWrite(ctx context.Context, uid, collection, id string, stored, payload map[string]any) error {
+	a, err := c.access(ctx, uid, collection, false)
+	if err != nil {
+		return err
+	}
+	acc := a.Account
+	if id != "" {
+		if err := a.owned(id, stored); err != nil {
+			return err
+		}
+	}
+	if acc.Admin {
+		return nil
+	}
+	tier := acc.Tier
+
+	if field, ok := a.Rule.serverField(payload); ok {
+		return fmt.Errorf("%w: %s", ErrServerField, field)
+	}
+
+	ownerField := a.Rule.Owner
+	limited := ownerField != ""
+	if !limited && id == "" {
+		// Dokumen akun (ID = uid) dibuat saat signup, bukan lewat /api/db
+		return ErrForbidden
+	}
+	if limited {
+		if _, ok := tier.MaxDocs[collection]; !ok {
+			return c.policy.deny(ReasonCollection, tier.Name, 0, func(t Tier) bool {
+				_, ok := t.MaxDocs[collection]
+				return ok
+			})
+		}
+		// Jumlah dokumen dihitung per pemilik, jadi pemilik hanya boleh diri sendiri
+		if v, ok := payload[ownerField]; ok && v != uid {
+			return ErrForbidden
+		}
+		for k := range payload {
+			if strings.HasPrefix(k, ownerField+".") {
+				return ErrForbidden
+			}
+		}
+		if id == "" {
+			payload[ownerField] = uid
+		}
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if size := len(encoded); size > tier.MaxDocBytes {
+		return c.policy.deny(ReasonDocSize, tier.Name, tier.MaxDocBytes, func(t Tier) bool { return size <= t.MaxDocBytes })
+	}
+
+	if limited && id == "" {
+		if max := tier.MaxDocs[collection]; max > 0 {
+			n, err := c.count(ctx, collection, ownerField, uid)
+			if err != nil {
+				return err
+			}
+			// Dihitung di luar transaksi: create paralel bisa melewati batas beberapa dokumen
+			if n >= max {
+				return c.policy.deny(ReasonMaxDocs, tier.Name, max, func(t Tier) bool {
+					m, ok := t.MaxDocs[collection]
+					return ok && (m == 0 || n < m)
+				})
+			}
+		}
+	}
+
+	if ok, retry := c.quota.TakeMax(uid, tier.WritesPerHour, c.now()); !ok {
+		d := c.policy.deny(ReasonQuota, tier.Name, tier.WritesPerHour, func(t Tier) bool { return t.WritesPerHour > tier.WritesPerHour })
+		if d.RequiredTier == "" {
+			d.Status = http.StatusTooManyRequests
+		}
+		d.RetryAfter = retry
+		return d
+	}
+	return nil
+}
+
+// Usage counts the caller's documents in every owned collection.
+func (c *Checker) Usage(ctx context.Context, uid string) (map[string]int, error) {
+	out := map[string]int{}
+	for coll, rule := range c.policy.Collections {
+		if rule.Owner == "" {
+			continue
+		}
+		n, err := c.count(ctx, coll, rule.Owner, uid)
+		if err != nil {
+			return nil, err
+		}
+		out[coll] = n
+	}
+	return out, nil
+}
+
+// WritesUsed returns the writes counted in the caller's current quota window.
+func (c *Checker) WritesUsed(uid string) int {
+	return c.quota.Used(uid, c.now())
+}
+
+func (c *Checker) count(ctx context.Context, collection, ownerField, uid string) (int, error) {
+	q := c.fb.DB.Collection(collection).Where(ownerField, "==", uid)
+	res, err := q.NewAggregationQuery().WithCount("n").Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+	v, ok := res["n"].(*firestorepb.Value)
+	if !ok {
+		return 0, errors.New("count: unexpected aggregation result")
+	}
+	return int(v.GetIntegerValue()), nil
+}
+
+// Run garbage-collects quota windows until ctx is cancelled.
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			c.quota.GC(now)
+		}
+	}
+}
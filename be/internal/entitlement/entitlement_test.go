@@ -0,0 +1,114 @@
+package entitlement
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAllowlist(t *testing.T) {
+	c := NewChecker(nil, nil, DefaultPolicy("accounts", "links"))
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		run  func() error
+		want error
+	}{
+		{"read links anonymous", func() error { _, err := c.ReadAccess(ctx, "", "links"); return err }, nil},
+		{"read accounts anonymous", func() error { _, err := c.ReadAccess(ctx, "", "accounts"); return err }, ErrUnauthorized},
+		{"read orders", func() error { _, err := c.ReadAccess(ctx, "", "orders"); return err }, ErrCollection},
+		{"read visitor_salts", func() error { _, err := c.ReadAccess(ctx, "u1", "visitor_salts"); return err }, ErrCollection},
+		{"delete visitor_salts", func() error { return c.CheckDelete(ctx, "u1", "visitor_salts", "2026-03-01") }, ErrCollection},
+		{"read settings", func() error { _, err := c.ReadAccess(ctx, "", "settings"); return err }, ErrCollection},
+		{"create settings", func() error { return c.CheckWrite(ctx, "", "settings", "", nil, map[string]any{}) }, ErrCollection},
+		{"update settings", func() error { return c.CheckWrite(ctx, "", "settings", "botPatterns", nil, map[string]any{}) }, ErrCollection},
+		{"delete settings", func() error { return c.CheckDelete(ctx, "", "settings", "urlBlocklist") }, ErrCollection},
+		// Collections outside the allowlist are refused before the session is resolved, so a
+		// signed-in caller (atau admin) gets the same answer.
+		{"update botPatterns signed in", func() error {
+			return c.CheckWrite(ctx, "u1", "settings", "botPatterns", nil, map[string]any{"patterns": []any{}})
+		}, ErrCollection},
+		{"delete botPatterns signed in", func() error { return c.CheckDelete(ctx, "u1", "settings", "botPatterns") }, ErrCollection},
+		{"read urlBlocklist signed in", func() error { _, err := c.ReadAccess(ctx, "u1", "settings"); return err }, ErrCollection},
+		{"update urlBlocklist signed in", func() error {
+			return c.CheckWrite(ctx, "u1", "settings", "urlBlocklist", nil, map[string]any{"rules": []any{}})
+		}, ErrCollection},
+		{"delete urlBlocklist signed in", func() error { return c.CheckDelete(ctx, "u1", "settings", "urlBlocklist") }, ErrCollection},
+		{"write links anonymous", func() error { return c.CheckWrite(ctx, "", "links", "", nil, map[string]any{}) }, ErrUnauthorized},
+		{"delete links anonymous", func() error { return c.CheckDelete(ctx, "", "links", "l1") }, ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccessOwns(t *testing.T) {
+	policy := DefaultPolicy("accounts", "links")
+	links := policy.Collections["links"]
+	accounts := policy.Collections["accounts"]
+	owned := map[string]any{"profileId": "u1"}
+
+	tests := []struct {
+		name    string
+		access  Access
+		id      string
+		data    map[string]any
+		owns    bool
+		canRead bool
+	}{
+		{"anonymous link", Access{Rule: links}, "l1", owned, false, true},
+		{"own link", Access{Rule: links, Account: &Account{UID: "u1"}}, "l1", owned, true, true},
+		{"other link", Access{Rule: links, Account: &Account{UID: "u2"}}, "l1", owned, false, true},
+		{"link without owner", Access{Rule: links, Account: &Account{UID: "u1"}}, "l1", map[string]any{}, false, true},
+		{"own account", Access{Rule: accounts, Account: &Account{UID: "u1"}}, "u1", map[string]any{}, true, true},
+		{"other account", Access{Rule: accounts, Account: &Account{UID: "u2"}}, "u1", map[string]any{}, false, false},
+		{"admin", Access{Rule: accounts, Account: &Account{UID: "a", Admin: true}}, "u1", map[string]any{}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.access.Owns(tt.id, tt.data); got != tt.owns {
+				t.Errorf("Owns = %v, want %v", got, tt.owns)
+			}
+			if got := tt.access.CanRead(tt.id, tt.data); got != tt.canRead {
+				t.Errorf("CanRead = %v, want %v", got, tt.canRead)
+			}
+		})
+	}
+}
+
+func TestServerFields(t *testing.T) {
+	accounts := DefaultPolicy("accounts", "links").Collections["accounts"]
+	tests := []struct {
+		payload map[string]any
+		field   string
+	}{
+		{map[string]any{"displayName": "Aether", "bio": "Musik"}, ""},
+		{map[string]any{"contactCard": map[string]any{"enabled": true}}, ""},
+		{map[string]any{"status": "membership"}, "status"},
+		{map[string]any{"role": "admin"}, "role"},
+		{map[string]any{"customDomain": "brand.com"}, "customDomain"},
+		// Alamat email dipakai relay kontak, notifikasi dan struk
+		{map[string]any{"bio": "x", "email": "victim@example.com"}, "email"},
+		{map[string]any{"email.address": "victim@example.com"}, "email"},
+		{map[string]any{"handle": "admin"}, "handle"},
+		{map[string]any{"provider": "google"}, "provider"},
+		{map[string]any{"signupOtp": "123456"}, "signupOtp"},
+		{map[string]any{"signupOtpExpiry": "2030-01-01"}, "signupOtpExpiry"},
+		{map[string]any{"resetToken": "123456"}, "resetToken"},
+		{map[string]any{"pendingEmail": "victim@example.com"}, "pendingEmail"},
+		{map[string]any{"pendingEmailAttempts": 0}, "pendingEmailAttempts"},
+		// Prefix yang sama tapi field lain tetap boleh
+		{map[string]any{"emailNotifications": true}, ""},
+	}
+	for _, tt := range tests {
+		field, ok := accounts.serverField(tt.payload)
+		if ok != (tt.field != "") || field != tt.field {
+			t.Errorf("serverField(%v) = %q, %v, want %q", tt.payload, field, ok, tt.field)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package entitlement
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// Sessions resolves the signed-in caller (implemented by auth.Handler).
+type Sessions interface {
+	SessionUID(r *http.Request) string
+}
+
+type Handler struct {
+	checker  *Checker
+	sessions Sessions
+}
+
+func NewHandler(checker *Checker, sessions Sessions) *Handler {
+	return &Handler{checker: checker, sessions: sessions}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// GET /api/entitlements — tier caller, batasnya, pemakaian saat ini, dan semua tier (untuk halaman upgrade)
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	acc, err := h.checker.Account(r.Context(), uid)
+	if err != nil {
+		log.Printf("entitlements %s: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load entitlements"})
+		return
+	}
+	docs, err := h.checker.Usage(r.Context(), uid)
+	if err != nil {
+		log.Printf("entitlements %s usage: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load entitlements"})
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{
+		"tier":      acc.Tier.Name,
+		"unlimited": acc.Admin,
+		"limits":    acc.Tier,
+		"usage": map[string]any{
+			"docs":          docs,
+			"writesPerHour": h.checker.WritesUsed(uid),
+		},
+		"tiers": h.checker.Policy().Tiers,
+	})
+}
@@ -0,0 +1,258 @@
+// Package events keeps the event blocks of a bio page (field "events" di dokumen akun) and
+// renders them as iCalendar (RFC 5545): satu file .ics per event untuk tombol "Tambah ke
+// kalender" dan feed events.ics per profil yang bisa dilanggan aplikasi kalender.
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"biomu/backend/internal/profile"
+)
+
+const (
+	MaxEvents         = 20
+	maxTitleLen       = 120
+	maxDescriptionLen = 1000
+	maxLocationLen    = 200
+	maxURLLen         = 2048
+	defaultDuration   = time.Hour
+	// maxDuration: event lebih panjang dari ini kemungkinan salah input
+	maxDuration = 366 * 24 * time.Hour
+)
+
+const (
+	dateLayout      = "2006-01-02"
+	localTimeLayout = "2006-01-02T15:04"
+)
+
+var (
+	ErrInvalid = errors.New("invalid event")
+	idRe       = regexp.MustCompile(`^[a-z0-9]{1,32}$`)
+)
+
+// Event is one event block. Start/End are wall-clock times ("2006-01-02T15:04") in Timezone,
+// atau tanggal ("2006-01-02") untuk event sehari penuh dengan End = hari terakhir (inklusif).
+type Event struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Location    string `json:"location,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Start       string `json:"start"`
+	End         string `json:"end,omitempty"`
+	Timezone    string `json:"timezone,omitempty"`
+	AllDay      bool   `json:"allDay,omitempty"`
+
+	// Hasil normalisasi: instant (event berjam) atau tanggal 00:00 UTC (sehari penuh, EndAt eksklusif)
+	StartAt time.Time `json:"-"`
+	EndAt   time.Time `json:"-"`
+	loc     *time.Location
+}
+
+// TimeLocation returns the timezone of a timed event (UTC for all-day events).
+func (e *Event) TimeLocation() *time.Location {
+	if e.loc == nil {
+		return time.UTC
+	}
+	return e.loc
+}
+
+// Normalize validates e in place and resolves its times. Wall-clock times inside a DST gap are
+// moved forward like link schedules (profile.ResolveLocal) and written back in their resolved form.
+func (e *Event) Normalize() error {
+	e.Title = strings.TrimSpace(e.Title)
+	e.Description = strings.TrimSpace(e.Description)
+	e.Location = strings.TrimSpace(e.Location)
+	e.URL = strings.TrimSpace(e.URL)
+	e.Start, e.End, e.Timezone = strings.TrimSpace(e.Start), strings.TrimSpace(e.End), strings.TrimSpace(e.Timezone)
+	switch {
+	case e.Title == "" || utf8.RuneCountInString(e.Title) > maxTitleLen:
+		return fmt.Errorf("%w: title is required (max %d characters)", ErrInvalid, maxTitleLen)
+	case utf8.RuneCountInString(e.Description) > maxDescriptionLen:
+		return fmt.Errorf("%w: description is too long", ErrInvalid)
+	case utf8.RuneCountInString(e.Location) > maxLocationLen:
+		return fmt.Errorf("%w: location is too long", ErrInvalid)
+	case e.URL != "" && !validURL(e.URL):
+		return fmt.Errorf("%w: url must be an http(s) URL", ErrInvalid)
+	}
+	if e.AllDay {
+		return e.normalizeDates()
+	}
+	if e.Timezone == "" {
+		return fmt.Errorf("%w: timezone is required", ErrInvalid)
+	}
+	loc, err := profile.LoadTimezone(e.Timezone)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	e.loc = loc
+	if e.StartAt, err = parseLocal(e.Start, loc); err != nil {
+		return fmt.Errorf("%w: start must be a local time like 2006-01-02T15:04", ErrInvalid)
+	}
+	if e.End == "" {
+		e.EndAt = e.StartAt.Add(defaultDuration)
+	} else if e.EndAt, err = parseLocal(e.End, loc); err != nil {
+		return fmt.Errorf("%w: end must be a local time like 2006-01-02T15:04", ErrInvalid)
+	}
+	if err := checkSpan(e.StartAt, e.EndAt); err != nil {
+		return err
+	}
+	e.Start, e.End = e.StartAt.In(loc).Format(localTimeLayout), e.EndAt.In(loc).Format(localTimeLayout)
+	return nil
+}
+
+func (e *Event) normalizeDates() error {
+	start, err := time.Parse(dateLayout, e.Start)
+	if err != nil {
+		return fmt.Errorf("%w: start must be a date like 2006-01-02", ErrInvalid)
+	}
+	last := start
+	if e.End != "" {
+		if last, err = time.Parse(dateLayout, e.End); err != nil {
+			return fmt.Errorf("%w: end must be a date like 2006-01-02", ErrInvalid)
+		}
+	}
+	e.StartAt, e.EndAt = start, last.AddDate(0, 0, 1)
+	if err := checkSpan(e.StartAt, e.EndAt); err != nil {
+		return err
+	}
+	e.End, e.Timezone, e.loc = last.Format(dateLayout), "", nil
+	return nil
+}
+
+func checkSpan(start, end time.Time) error {
+	if !end.After(start) {
+		return fmt.Errorf("%w: end must be after start", ErrInvalid)
+	}
+	if end.Sub(start) > maxDuration {
+		return fmt.Errorf("%w: event is longer than a year", ErrInvalid)
+	}
+	return nil
+}
+
+func parseLocal(s string, loc *time.Location) (time.Time, error) {
+	// Hanya jam lokal; offset/UTC tidak diterima supaya timezone event tetap satu sumber
+	if strings.ContainsAny(s, "Zz+") || strings.Count(s, "-") > 2 {
+		return time.Time{}, ErrInvalid
+	}
+	return profile.ParseScheduleTime(s, loc)
+}
+
+func validURL(raw string) bool {
+	if len(raw) > maxURLLen || strings.ContainsAny(raw, "\r\n") {
+		return false
+	}
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Upcoming reports whether e has not ended at now.
+func (e *Event) Upcoming(now time.Time) bool {
+	if e.AllDay {
+		// Tanggal dibaca sebagai hari kalender; pakai batas paling akhir di dunia (UTC-12)
+		return now.Before(e.EndAt.Add(12 * time.Hour))
+	}
+	return now.Before(e.EndAt)
+}
+
+// Normalize validates a list of events for an account, assigning IDs to new ones.
+func Normalize(in []Event) ([]Event, error) {
+	if len(in) > MaxEvents {
+		return nil, fmt.Errorf("%w: at most %d events", ErrInvalid, MaxEvents)
+	}
+	seen := map[string]bool{}
+	out := make([]Event, 0, len(in))
+	for i, e := range in {
+		if err := e.Normalize(); err != nil {
+			return nil, fmt.Errorf("event %d: %w", i, err)
+		}
+		if !idRe.MatchString(e.ID) || seen[e.ID] {
+			e.ID = newID()
+		}
+		seen[e.ID] = true
+		out = append(out, e)
+	}
+	sortEvents(out)
+	return out, nil
+}
+
+// FromProfile returns the valid events of p sorted by start. Stored values are normalized
+// again because account documents can also be written through /api/db.
+func FromProfile(p *profile.Profile) []Event {
+	raw, _ := p.Data["events"].([]any)
+	out := []Event{}
+	for _, item := range raw {
+		m, ok := item.(map[string]any)
+		if !ok || len(out) >= MaxEvents {
+			continue
+		}
+		str := func(key string) string {
+			v, _ := m[key].(string)
+			return v
+		}
+		e := Event{
+			ID:          str("id"),
+			Title:       str("title"),
+			Description: str("description"),
+			Location:    str("location"),
+			URL:         str("url"),
+			Start:       str("start"),
+			End:         str("end"),
+			Timezone:    str("timezone"),
+		}
+		e.AllDay, _ = m["allDay"].(bool)
+		if !idRe.MatchString(e.ID) || e.Normalize() != nil {
+			continue
+		}
+		out = append(out, e)
+	}
+	sortEvents(out)
+	return out
+}
+
+// Find returns the event id of p.
+func Find(p *profile.Profile, id string) (*Event, bool) {
+	for _, e := range FromProfile(p) {
+		if e.ID == id {
+			return &e, true
+		}
+	}
+	return nil, false
+}
+
+// toData is the stored form of events in the account document.
+func toData(events []Event) []map[string]any {
+	out := make([]map[string]any, 0, len(events))
+	for _, e := range events {
+		m := map[string]any{"id": e.ID, "title": e.Title, "start": e.Start, "end": e.End}
+		for k, v := range map[string]string{"description": e.Description, "location": e.Location, "url": e.URL, "timezone": e.Timezone} {
+			if v != "" {
+				m[k] = v
+			}
+		}
+		if e.AllDay {
+			m["allDay"] = true
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func sortEvents(events []Event) {
+	sort.SliceStable(events, func(i, j int) bool { return events[i].StartAt.Before(events[j].StartAt) })
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,119 @@
+package events
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEventNormalize(t *testing.T) {
+	tests := []struct {
+		name       string
+		in         Event
+		start, end string
+		startAt    time.Time
+		endAt      time.Time
+	}{
+		{
+			name:    "default duration",
+			in:      Event{Title: " Meetup ", Start: "2026-04-01T19:00", Timezone: "Asia/Jakarta"},
+			start:   "2026-04-01T19:00",
+			end:     "2026-04-01T20:00",
+			startAt: time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC),
+			endAt:   time.Date(2026, 4, 1, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			// 02:30 tidak ada di New York pada 8 Maret 2026; maju ke 03:30 EDT
+			name:    "start in DST gap",
+			in:      Event{Title: "Live", Start: "2026-03-08T02:30", End: "2026-03-08T05:00", Timezone: "America/New_York"},
+			start:   "2026-03-08T03:30",
+			end:     "2026-03-08T05:00",
+			startAt: time.Date(2026, 3, 8, 7, 30, 0, 0, time.UTC),
+			endAt:   time.Date(2026, 3, 8, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "all day",
+			in:      Event{Title: "Festival", Start: "2026-06-01", End: "2026-06-03", Timezone: "Asia/Jakarta", AllDay: true},
+			start:   "2026-06-01",
+			end:     "2026-06-03",
+			startAt: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+			endAt:   time.Date(2026, 6, 4, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "single all-day",
+			in:      Event{Title: "Rilis", Start: "2026-06-01", AllDay: true},
+			start:   "2026-06-01",
+			end:     "2026-06-01",
+			startAt: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+			endAt:   time.Date(2026, 6, 2, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := tt.in
+			if err := e.Normalize(); err != nil {
+				t.Fatal(err)
+			}
+			if e.Start != tt.start || e.End != tt.end {
+				t.Fatalf("start/end = %q/%q, want %q/%q", e.Start, e.End, tt.start, tt.end)
+			}
+			if !e.StartAt.Equal(tt.startAt) || !e.EndAt.Equal(tt.endAt) {
+				t.Fatalf("StartAt/EndAt = %v/%v, want %v/%v", e.StartAt, e.EndAt, tt.startAt, tt.endAt)
+			}
+			if e.AllDay && e.Timezone != "" {
+				t.Fatalf("all-day event kept timezone %q", e.Timezone)
+			}
+		})
+	}
+}
+
+func TestEventNormalizeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Event
+	}{
+		{"no title", Event{Start: "2026-04-01T19:00", Timezone: "Asia/Jakarta"}},
+		{"no timezone", Event{Title: "x", Start: "2026-04-01T19:00"}},
+		{"unknown timezone", Event{Title: "x", Start: "2026-04-01T19:00", Timezone: "Mars/Olympus"}},
+		{"offset in start", Event{Title: "x", Start: "2026-04-01T19:00+07:00", Timezone: "Asia/Jakarta"}},
+		{"UTC suffix", Event{Title: "x", Start: "2026-04-01T19:00Z", Timezone: "Asia/Jakarta"}},
+		{"end before start", Event{Title: "x", Start: "2026-04-01T19:00", End: "2026-04-01T18:00", Timezone: "Asia/Jakarta"}},
+		{"longer than a year", Event{Title: "x", Start: "2026-01-01", End: "2027-01-02", AllDay: true}},
+		{"all-day with time", Event{Title: "x", Start: "2026-04-01T19:00", AllDay: true}},
+		{"javascript url", Event{Title: "x", Start: "2026-04-01", AllDay: true, URL: "javascript:alert(1)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := tt.in
+			if err := e.Normalize(); !errors.Is(err, ErrInvalid) {
+				t.Fatalf("err = %v, want ErrInvalid", err)
+			}
+		})
+	}
+}
+
+func TestUpcoming(t *testing.T) {
+	day := Event{Title: "Festival", Start: "2026-06-01", AllDay: true}
+	timed := Event{Title: "Live", Start: "2026-06-01T19:00", Timezone: "Asia/Jakarta"}
+	for _, e := range []*Event{&day, &timed} {
+		if err := e.Normalize(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name string
+		e    *Event
+		now  time.Time
+		want bool
+	}{
+		{"all day, still 1 June somewhere", &day, time.Date(2026, 6, 2, 11, 59, 0, 0, time.UTC), true},
+		{"all day, over everywhere", &day, time.Date(2026, 6, 2, 12, 0, 0, 0, time.UTC), false},
+		{"timed, running", &timed, time.Date(2026, 6, 1, 12, 30, 0, 0, time.UTC), true},
+		{"timed, ended", &timed, time.Date(2026, 6, 1, 13, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		if got := tt.e.Upcoming(tt.now); got != tt.want {
+			t.Errorf("%s: Upcoming = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
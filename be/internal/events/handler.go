@@ -0,0 +1,180 @@
+package events
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"biomu/backend/internal/firebase"
+	"biomu/backend/internal/profile"
+	"biomu/backend/internal/public"
+
+	"cloud.google.com/go/firestore"
+)
+
+const bodyLimit = 64 << 10
+
+// Sessions resolves the signed-in caller (implemented by auth.Handler).
+type Sessions interface {
+	SessionUID(r *http.Request) string
+}
+
+type Handler struct {
+	fb           *firebase.App
+	profiles     *profile.Store
+	sessions     Sessions
+	accountsColl string
+	host         string
+	siteName     string
+}
+
+// NewHandler creates the event endpoints. The host of baseURL makes event UIDs globally unique.
+func NewHandler(fb *firebase.App, profiles *profile.Store, sessions Sessions, baseURL, siteName string) *Handler {
+	host := "localhost"
+	if u, err := url.Parse(baseURL); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	return &Handler{fb: fb, profiles: profiles, sessions: sessions, accountsColl: profiles.AccountsCollection(), host: host, siteName: siteName}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// GET /api/profile/events — blok event milik caller
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	p, ok := h.account(w, r)
+	if !ok {
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{"events": FromProfile(p)})
+}
+
+// PUT /api/profile/events — body {"events": [{"title", "start", "end", "timezone", "allDay", ...}]};
+// mengganti semua event. Event tanpa "id" mendapat ID baru; yang tersimpan adalah hasil normalisasi.
+func (h *Handler) Put(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	p, ok := h.account(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Events []Event `json:"events"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, bodyLimit)).Decode(&body); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	out, err := Normalize(body.Events)
+	if err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	_, err = h.fb.DB.Collection(h.accountsColl).Doc(p.ID).Update(r.Context(), []firestore.Update{
+		{Path: "events", Value: toData(out)},
+		{Path: "updatedAt", Value: firestore.ServerTimestamp},
+	})
+	if err != nil {
+		log.Printf("events %s: %v", p.ID, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to save"})
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{"events": out})
+}
+
+func (h *Handler) account(w http.ResponseWriter, r *http.Request) (*profile.Profile, bool) {
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return nil, false
+	}
+	p, err := h.profiles.FindByID(r.Context(), uid)
+	if err != nil {
+		log.Printf("events account %s: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load account"})
+		return nil, false
+	}
+	if p == nil {
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "account not found"})
+		return nil, false
+	}
+	return p, true
+}
+
+// GET /api/public/{handle}/events.ics — feed kalender semua event profil (bisa dilanggan lewat
+// webcal:// atau "Add calendar from URL")
+func (h *Handler) Feed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	p, ok := h.profile(w, r)
+	if !ok {
+		return
+	}
+	name := p.DisplayName
+	if name == "" {
+		name = "@" + p.Handle
+	}
+	cal := Calendar{Name: name + " · " + h.siteName, Host: h.host, Stamp: p.UpdatedAt, Events: FromProfile(p)}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	public.WriteCached(w, r, "text/calendar; charset=utf-8", cal.Encode())
+}
+
+// GET /api/public/{handle}/events/{eventId}.ics — satu event untuk tombol "Tambah ke kalender"
+func (h *Handler) Event(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, ok := strings.CutSuffix(r.PathValue("file"), ".ics")
+	if !ok {
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "event not found"})
+		return
+	}
+	p, ok := h.profile(w, r)
+	if !ok {
+		return
+	}
+	e, ok := Find(p, id)
+	if !ok {
+		w.Header().Set("Cache-Control", "public, max-age=30")
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "event not found"})
+		return
+	}
+	cal := Calendar{Host: h.host, Stamp: p.UpdatedAt, Events: []Event{*e}}
+	w.Header().Set("Content-Disposition", `attachment; filename="`+e.ID+`.ics"`)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	public.WriteCached(w, r, "text/calendar; charset=utf-8", cal.Encode())
+}
+
+func (h *Handler) profile(w http.ResponseWriter, r *http.Request) (*profile.Profile, bool) {
+	handle := profile.NormalizeHandle(r.PathValue("handle"))
+	if handle == "" {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "handle is required"})
+		return nil, false
+	}
+	p, err := h.profiles.FindByHandle(r.Context(), handle)
+	if err != nil {
+		log.Printf("events %s: %v", handle, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load profile"})
+		return nil, false
+	}
+	if p == nil {
+		w.Header().Set("Cache-Control", "public, max-age=30")
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "profile not found"})
+		return nil, false
+	}
+	return p, true
+}
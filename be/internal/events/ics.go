@@ -0,0 +1,231 @@
+package events
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxLineOctets: panjang baris maksimal sebelum di-fold (RFC 5545 3.1), tanpa CRLF
+	maxLineOctets = 75
+	prodID        = "-//Biomu//Bio Events//ID"
+	// feedRefresh: saran interval sinkronisasi untuk aplikasi kalender yang melanggan feed
+	feedRefresh = "PT6H"
+)
+
+const (
+	icsDateLayout     = "20060102"
+	icsLocalLayout    = "20060102T150405"
+	icsUTCLayout      = "20060102T150405Z"
+	timezoneScanStep  = 24 * time.Hour
+	timezoneScanYears = 1
+)
+
+// Calendar describes an iCalendar object to encode.
+type Calendar struct {
+	// Name diisi untuk feed (NAME/X-WR-CALNAME); kosong untuk file satu event
+	Name string
+	// Host membuat UID event unik secara global: "<id>@<host>"
+	Host   string
+	Stamp  time.Time
+	Events []Event
+}
+
+// Encode returns the iCalendar text of c with CRLF line endings and folded lines. Timed events
+// keep their own timezone (DTSTART;TZID=...) with a VTIMEZONE built from the tz database for
+// the years the events span.
+func (c Calendar) Encode() []byte {
+	var b strings.Builder
+	line := func(name, value string) {
+		writeFolded(&b, name+":"+value)
+	}
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", prodID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("NAME", escape(c.Name))
+		line("X-WR-CALNAME", escape(c.Name))
+		line("REFRESH-INTERVAL;VALUE=DURATION", feedRefresh)
+		line("X-PUBLISHED-TTL", feedRefresh)
+	}
+
+	// Satu VTIMEZONE per timezone yang dipakai, mencakup rentang semua event di timezone itu
+	type span struct {
+		loc      *time.Location
+		from, to time.Time
+	}
+	zones := map[string]*span{}
+	order := []string{}
+	for i := range c.Events {
+		e := &c.Events[i]
+		if e.AllDay || e.Timezone == "UTC" {
+			continue
+		}
+		z, ok := zones[e.Timezone]
+		if !ok {
+			z = &span{loc: e.TimeLocation(), from: e.StartAt, to: e.EndAt}
+			zones[e.Timezone] = z
+			order = append(order, e.Timezone)
+		}
+		if e.StartAt.Before(z.from) {
+			z.from = e.StartAt
+		}
+		if e.EndAt.After(z.to) {
+			z.to = e.EndAt
+		}
+	}
+	for _, name := range order {
+		z := zones[name]
+		writeTimezone(line, name, z.loc, z.from, z.to)
+	}
+
+	// DTSTAMP wajib; profil lama tanpa updatedAt memakai waktu sekarang
+	if c.Stamp.IsZero() {
+		c.Stamp = time.Now()
+	}
+	stamp := c.Stamp.UTC().Format(icsUTCLayout)
+	for i := range c.Events {
+		e := &c.Events[i]
+		line("BEGIN", "VEVENT")
+		line("UID", e.ID+"@"+c.Host)
+		line("DTSTAMP", stamp)
+		switch {
+		case e.AllDay:
+			line("DTSTART;VALUE=DATE", e.StartAt.Format(icsDateLayout))
+			line("DTEND;VALUE=DATE", e.EndAt.Format(icsDateLayout))
+		case e.Timezone == "UTC":
+			line("DTSTART", e.StartAt.UTC().Format(icsUTCLayout))
+			line("DTEND", e.EndAt.UTC().Format(icsUTCLayout))
+		default:
+			line("DTSTART;TZID="+paramValue(e.Timezone), e.StartAt.In(e.TimeLocation()).Format(icsLocalLayout))
+			line("DTEND;TZID="+paramValue(e.Timezone), e.EndAt.In(e.TimeLocation()).Format(icsLocalLayout))
+		}
+		line("SUMMARY", escape(e.Title))
+		if e.Description != "" {
+			line("DESCRIPTION", escape(e.Description))
+		}
+		if e.Location != "" {
+			line("LOCATION", escape(e.Location))
+		}
+		if e.URL != "" {
+			line("URL", e.URL)
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return []byte(b.String())
+}
+
+// writeTimezone writes a VTIMEZONE for loc valid from the start of from's year to the end of
+// to's year (plus timezoneScanYears). Go tidak membuka data transisi tz database, jadi offset
+// dipindai per hari dan setiap perubahan dicari sampai detiknya; setiap transisi menjadi satu
+// komponen STANDARD/DAYLIGHT tanpa RRULE.
+func writeTimezone(line func(name, value string), name string, loc *time.Location, from, to time.Time) {
+	start := time.Date(from.In(loc).Year(), time.January, 1, 0, 0, 0, 0, loc)
+	end := time.Date(to.In(loc).Year()+timezoneScanYears, time.January, 1, 0, 0, 0, 0, loc)
+	line("BEGIN", "VTIMEZONE")
+	line("TZID", name)
+	abbr, offset := start.Zone()
+	// Komponen awal: offset yang berlaku di awal rentang
+	writeObservance(line, start.IsDST(), start, offset, offset, abbr)
+	for t := start; t.Before(end); {
+		next := t.Add(timezoneScanStep)
+		_, off := t.Zone()
+		if _, nextOff := next.Zone(); nextOff != off {
+			at := transition(t, next)
+			newAbbr, newOff := at.Zone()
+			writeObservance(line, at.IsDST(), at, off, newOff, newAbbr)
+			next = at
+		}
+		t = next
+	}
+	line("END", "VTIMEZONE")
+}
+
+// transition returns the first instant in (lo, hi] whose offset differs from lo's.
+func transition(lo, hi time.Time) time.Time {
+	_, off := lo.Zone()
+	for hi.Sub(lo) > time.Second {
+		mid := lo.Add(hi.Sub(lo) / 2).Truncate(time.Second)
+		if _, o := mid.Zone(); o == off {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi
+}
+
+// writeObservance writes one STANDARD/DAYLIGHT component. DTSTART is the onset as local time
+// in the offset before the transition (RFC 5545 3.6.5).
+func writeObservance(line func(name, value string), dst bool, at time.Time, from, to int, abbr string) {
+	kind := "STANDARD"
+	if dst {
+		kind = "DAYLIGHT"
+	}
+	line("BEGIN", kind)
+	line("DTSTART", at.In(time.FixedZone("", from)).Format(icsLocalLayout))
+	line("TZOFFSETFROM", utcOffset(from))
+	line("TZOFFSETTO", utcOffset(to))
+	if abbr != "" {
+		line("TZNAME", escape(abbr))
+	}
+	line("END", kind)
+}
+
+// utcOffset formats seconds east of UTC as ±hhmm[ss].
+func utcOffset(sec int) string {
+	sign := "+"
+	if sec < 0 {
+		sign, sec = "-", -sec
+	}
+	out := sign + pad2(sec/3600) + pad2(sec%3600/60)
+	if sec%60 != 0 {
+		out += pad2(sec % 60)
+	}
+	return out
+}
+
+func pad2(n int) string {
+	if n < 10 {
+		return "0" + strconv.Itoa(n)
+	}
+	return strconv.Itoa(n)
+}
+
+// escape escapes a TEXT value (RFC 5545 3.3.11).
+func escape(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`, "\r", `\n`).Replace(s)
+}
+
+// paramValue quotes a parameter value that contains characters not allowed bare (RFC 5545 3.2).
+func paramValue(s string) string {
+	if strings.ContainsAny(s, ":;,") {
+		return `"` + strings.ReplaceAll(s, `"`, "") + `"`
+	}
+	return s
+}
+
+// writeFolded writes one content line, folded at maxLineOctets octets without splitting a
+// UTF-8 sequence; continuation lines start with a single space (RFC 5545 3.1).
+func writeFolded(b *strings.Builder, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Spasi di awal baris lanjutan ikut dihitung
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
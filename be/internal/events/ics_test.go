@@ -0,0 +1,177 @@
+package events
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Meetup", "Meetup"},
+		{"Jl. Sudirman, Jakarta; Lt. 3", `Jl. Sudirman\, Jakarta\; Lt. 3`},
+		{`C:\temp`, `C:\\temp`},
+		{"baris 1\nbaris 2", `baris 1\nbaris 2`},
+		{"baris 1\r\nbaris 2", `baris 1\nbaris 2`},
+		{"baris 1\rbaris 2", `baris 1\nbaris 2`},
+		// Titik dua tidak di-escape di nilai TEXT
+		{"Sesi: tanya jawab", "Sesi: tanya jawab"},
+	}
+	for _, tt := range tests {
+		if got := escape(tt.in); got != tt.want {
+			t.Errorf("escape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParamValue(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Asia/Jakarta", "Asia/Jakarta"},
+		{"Custom;Zone", `"Custom;Zone"`},
+		{`a:"b"`, `"a:b"`},
+	}
+	for _, tt := range tests {
+		if got := paramValue(tt.in); got != tt.want {
+			t.Errorf("paramValue(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteFolded(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		lines int
+	}{
+		{"short", "SUMMARY:Meetup", 1},
+		{"exactly 75 octets", "SUMMARY:" + strings.Repeat("a", 67), 1},
+		{"76 octets", "SUMMARY:" + strings.Repeat("a", 68), 2},
+		{"long", "DESCRIPTION:" + strings.Repeat("abcdefghij", 30), 5},
+		// Rune 3 byte tidak boleh terpotong di batas lipatan
+		{"multibyte", "SUMMARY:" + strings.Repeat("€", 60), 3},
+		{"emoji", "SUMMARY:x" + strings.Repeat("🎉", 40), 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			writeFolded(&b, tt.line)
+			out := b.String()
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("%q does not end with CRLF", out)
+			}
+			lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			if len(lines) != tt.lines {
+				t.Fatalf("%d lines, want %d: %q", len(lines), tt.lines, out)
+			}
+			for i, l := range lines {
+				if len(l) > maxLineOctets {
+					t.Errorf("line %d has %d octets", i, len(l))
+				}
+				if !utf8.ValidString(l) {
+					t.Errorf("line %d splits a UTF-8 sequence: %q", i, l)
+				}
+				if i > 0 && !strings.HasPrefix(l, " ") {
+					t.Errorf("continuation line %d does not start with a space", i)
+				}
+			}
+			if got := unfold(out); got != tt.line+"\r\n" {
+				t.Fatalf("unfolded = %q, want %q", got, tt.line+"\r\n")
+			}
+		})
+	}
+}
+
+func TestUTCOffset(t *testing.T) {
+	tests := []struct {
+		sec  int
+		want string
+	}{
+		{0, "+0000"},
+		{7 * 3600, "+0700"},
+		{-5 * 3600, "-0500"},
+		{5*3600 + 30*60, "+0530"},
+		{-(3*3600 + 30*60), "-0330"},
+		// Offset LMT lama punya detik
+		{25632, "+070712"},
+	}
+	for _, tt := range tests {
+		if got := utcOffset(tt.sec); got != tt.want {
+			t.Errorf("utcOffset(%d) = %q, want %q", tt.sec, got, tt.want)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	events, err := Normalize([]Event{
+		{ID: "ny", Title: "Live, Q&A; NYC", Start: "2026-03-07T20:00", End: "2026-03-08T04:00", Timezone: "America/New_York", Location: "Brooklyn\nNY"},
+		{ID: "jkt", Title: "Meetup", Start: "2026-04-01T19:00", Timezone: "Asia/Jakarta", URL: "https://aether.bio/meetup"},
+		{ID: "utc", Title: "Webinar", Start: "2026-05-01T10:00", Timezone: "UTC"},
+		{ID: "fest", Title: "Festival", Start: "2026-06-01", End: "2026-06-03", AllDay: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cal := Calendar{Name: "Aether, events", Host: "aether.bio", Stamp: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), Events: events}
+	raw := string(cal.Encode())
+
+	for i, l := range strings.Split(strings.TrimSuffix(raw, "\r\n"), "\r\n") {
+		if len(l) > maxLineOctets {
+			t.Errorf("line %d has %d octets: %q", i, len(l), l)
+		}
+		if strings.Contains(l, "\n") {
+			t.Errorf("line %d contains a bare LF", i)
+		}
+	}
+	ics := unfold(raw)
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"NAME:Aether\\, events\r\n",
+		"X-WR-CALNAME:Aether\\, events\r\n",
+		"UID:ny@aether.bio\r\n",
+		"DTSTAMP:20260301T120000Z\r\n",
+		// Event NY melewati awal DST: DTEND tetap jam lokal di TZID yang sama
+		"DTSTART;TZID=America/New_York:20260307T200000\r\n",
+		"DTEND;TZID=America/New_York:20260308T040000\r\n",
+		"SUMMARY:Live\\, Q&A\\; NYC\r\n",
+		"LOCATION:Brooklyn\\nNY\r\n",
+		"DTSTART;TZID=Asia/Jakarta:20260401T190000\r\n",
+		"DTEND;TZID=Asia/Jakarta:20260401T200000\r\n",
+		"URL:https://aether.bio/meetup\r\n",
+		"DTSTART:20260501T100000Z\r\n",
+		// Sehari penuh: DTEND eksklusif
+		"DTSTART;VALUE=DATE:20260601\r\n",
+		"DTEND;VALUE=DATE:20260604\r\n",
+		// Transisi DST New York 2026: 8 Maret 02:00 EST dan 1 November 02:00 EDT
+		"BEGIN:DAYLIGHT\r\nDTSTART:20260308T020000\r\nTZOFFSETFROM:-0500\r\nTZOFFSETTO:-0400\r\nTZNAME:EDT\r\nEND:DAYLIGHT\r\n",
+		"BEGIN:STANDARD\r\nDTSTART:20261101T020000\r\nTZOFFSETFROM:-0400\r\nTZOFFSETTO:-0500\r\nTZNAME:EST\r\nEND:STANDARD\r\n",
+		"BEGIN:VTIMEZONE\r\nTZID:Asia/Jakarta\r\nBEGIN:STANDARD\r\nDTSTART:20260101T000000\r\nTZOFFSETFROM:+0700\r\nTZOFFSETTO:+0700\r\nTZNAME:WIB\r\nEND:STANDARD\r\nEND:VTIMEZONE\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	// UTC dan event sehari penuh tidak butuh VTIMEZONE
+	if n := strings.Count(ics, "BEGIN:VTIMEZONE"); n != 2 {
+		t.Errorf("%d VTIMEZONE components, want 2", n)
+	}
+	if strings.Count(ics, "BEGIN:VEVENT") != 4 || strings.Count(ics, "END:VEVENT") != 4 {
+		t.Errorf("want 4 events:\n%s", ics)
+	}
+
+	// File satu event tidak membawa properti feed
+	single := string(Calendar{Host: "aether.bio", Stamp: cal.Stamp, Events: events[:1]}.Encode())
+	if strings.Contains(single, "X-WR-CALNAME") || strings.Contains(single, "REFRESH-INTERVAL") {
+		t.Errorf("single event file has feed properties:\n%s", single)
+	}
+}
+
+// unfold reverses writeFolded (RFC 5545 3.1).
+func unfold(s string) string {
+	return strings.ReplaceAll(s, "\r\n ", "")
+}
@@ -0,0 +1,146 @@
+package experiment
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"biomu/backend/internal/firebase"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+const (
+	// Setiap varian punya numShards dokumen counter supaya link populer tidak kena batas
+	// ~1 write/detik per dokumen Firestore.
+	numShards            = 10
+	countersSubcoll      = "variantCounters"
+	defaultCounterFlush  = 5 * time.Second
+	fieldImpressions     = "impressions"
+	fieldClicks          = "clicks"
+	fieldCounterVariant  = "variant"
+	counterFlushMaxDelay = 30 * time.Second
+)
+
+type counterKey struct {
+	linkID    string
+	variantID string
+}
+
+// Counters buffers impressions/clicks in memory and flushes them as Increment writes to a
+// random shard under <links>/{linkId}/variantCounters/{variantId}_{n}.
+type Counters struct {
+	fb        *firebase.App
+	linksColl string
+	interval  time.Duration
+
+	mu      sync.Mutex
+	pending map[counterKey]Count
+}
+
+func NewCounters(fb *firebase.App, linksColl string) *Counters {
+	return &Counters{fb: fb, linksColl: linksColl, interval: defaultCounterFlush, pending: map[counterKey]Count{}}
+}
+
+// Impression counts one view of variantID.
+func (c *Counters) Impression(linkID, variantID string) {
+	c.add(linkID, variantID, Count{Impressions: 1})
+}
+
+// Click counts one click on variantID.
+func (c *Counters) Click(linkID, variantID string) {
+	c.add(linkID, variantID, Count{Clicks: 1})
+}
+
+func (c *Counters) add(linkID, variantID string, d Count) {
+	if linkID == "" || variantID == "" {
+		return
+	}
+	k := counterKey{linkID, variantID}
+	c.mu.Lock()
+	cur := c.pending[k]
+	cur.Impressions += d.Impressions
+	cur.Clicks += d.Clicks
+	c.pending[k] = cur
+	c.mu.Unlock()
+}
+
+// Run flushes pending counts every interval until ctx is cancelled (with a final flush).
+func (c *Counters) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// ctx sudah batal; beri waktu singkat untuk flush terakhir
+			flushCtx, cancel := context.WithTimeout(context.Background(), counterFlushMaxDelay)
+			c.flush(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			c.flush(ctx)
+		}
+	}
+}
+
+func (c *Counters) flush(ctx context.Context) {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = map[counterKey]Count{}
+	c.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+	bw := c.fb.DB.BulkWriter(ctx)
+	jobs := make(map[counterKey]*firestore.BulkWriterJob, len(pending))
+	for k, d := range pending {
+		shard := fmt.Sprintf("%s_%d", k.variantID, rand.IntN(numShards))
+		ref := c.fb.DB.Collection(c.linksColl).Doc(k.linkID).Collection(countersSubcoll).Doc(shard)
+		job, err := bw.Set(ref, map[string]any{
+			fieldCounterVariant: k.variantID,
+			fieldImpressions:    firestore.Increment(d.Impressions),
+			fieldClicks:         firestore.Increment(d.Clicks),
+		}, firestore.MergeAll)
+		if err != nil {
+			log.Printf("experiment: counter %s/%s: %v", k.linkID, k.variantID, err)
+			continue
+		}
+		jobs[k] = job
+	}
+	bw.End()
+	for k, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			log.Printf("experiment: counter %s/%s: %v", k.linkID, k.variantID, err)
+		}
+	}
+}
+
+// Totals sums every shard of linkID per variant.
+func (c *Counters) Totals(ctx context.Context, linkID string) (map[string]Count, error) {
+	it := c.fb.DB.Collection(c.linksColl).Doc(linkID).Collection(countersSubcoll).Documents(ctx)
+	defer it.Stop()
+	out := map[string]Count{}
+	for {
+		doc, err := it.Next()
+		if err == iterator.Done {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		data := doc.Data()
+		variantID, _ := data[fieldCounterVariant].(string)
+		cur := out[variantID]
+		if n, ok := number(data[fieldImpressions]); ok {
+			cur.Impressions += int64(n)
+		}
+		if n, ok := number(data[fieldClicks]); ok {
+			cur.Clicks += int64(n)
+		}
+		out[variantID] = cur
+	}
+}
@@ -0,0 +1,244 @@
+// Package experiment menjalankan A/B test pada link: beberapa varian judul/URL dengan bobot
+// trafik, assignment sticky lewat visitor ID ter-hash, counter impression/klik ber-shard di
+// Firestore, uji signifikansi two-proportion z-test, dan promosi otomatis pemenang.
+package experiment
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"biomu/backend/internal/profile"
+)
+
+// Field di dokumen link.
+const (
+	FieldVariants   = "variants"
+	FieldExperiment = "experiment"
+)
+
+const (
+	StatusRunning  = "running"
+	StatusPaused   = "paused"
+	StatusPromoted = "promoted"
+)
+
+const (
+	maxVariants           = 5
+	maxWeight             = 1000
+	defaultConfidence     = 0.95
+	defaultMinImpressions = 100
+)
+
+var variantIDRe = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,32}$`)
+
+// Variant is one arm of a link experiment. Empty Title/URL keep the link's own value.
+type Variant struct {
+	ID     string  `json:"id"`
+	Title  string  `json:"title,omitempty"`
+	URL    string  `json:"url,omitempty"`
+	Weight float64 `json:"weight"`
+}
+
+// Experiment is the configuration stored on a link document.
+type Experiment struct {
+	Variants []Variant
+	// Status: running, paused, atau promoted. Varian baru dipakai setelah status di-set "running".
+	Status         string
+	Confidence     float64
+	MinImpressions int64
+	AutoPromote    bool
+	Winner         string
+	PromotedAt     time.Time
+}
+
+// FromLink parses the experiment of link. ok is false when the link has no variants.
+func FromLink(link profile.Link) (exp Experiment, ok bool) {
+	variants, err := parseVariants(link.Data[FieldVariants])
+	if err != nil || len(variants) < 2 {
+		return Experiment{}, false
+	}
+	exp = Experiment{
+		Variants:       variants,
+		Confidence:     defaultConfidence,
+		MinImpressions: defaultMinImpressions,
+		AutoPromote:    true,
+	}
+	cfg, _ := link.Data[FieldExperiment].(map[string]any)
+	exp.Status, _ = cfg["status"].(string)
+	if c, ok := number(cfg["confidence"]); ok && c > 0 && c < 1 {
+		exp.Confidence = c
+	}
+	if n, ok := number(cfg["minImpressions"]); ok && n >= 1 {
+		exp.MinImpressions = int64(n)
+	}
+	if a, ok := cfg["autoPromote"].(bool); ok {
+		exp.AutoPromote = a
+	}
+	exp.Winner, _ = cfg["winner"].(string)
+	exp.PromotedAt, _ = cfg["promotedAt"].(time.Time)
+	return exp, true
+}
+
+// Running reports whether visitors are currently split between variants.
+func (e Experiment) Running() bool {
+	return e.Status == StatusRunning && len(e.Variants) >= 2
+}
+
+// Variant returns the variant with id, or nil.
+func (e Experiment) Variant(id string) *Variant {
+	for i := range e.Variants {
+		if e.Variants[i].ID == id {
+			return &e.Variants[i]
+		}
+	}
+	return nil
+}
+
+// Assign picks the variant for visitorID on linkID. The same visitor always lands in the same
+// bucket (visitor ID rotates daily, so stickiness lasts a day). Visitors without an ID (DNT/GPC)
+// are not enrolled and get nil: they see the link as configured and are not counted.
+func (e Experiment) Assign(linkID, visitorID string) *Variant {
+	if !e.Running() || visitorID == "" {
+		return nil
+	}
+	var total float64
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+	if total <= 0 {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(visitorID + "|" + linkID))
+	point := float64(binary.BigEndian.Uint64(sum[:8])>>11) / float64(1<<53) * total
+	var acc float64
+	for i := range e.Variants {
+		acc += e.Variants[i].Weight
+		if point < acc {
+			return &e.Variants[i]
+		}
+	}
+	return &e.Variants[len(e.Variants)-1]
+}
+
+// Apply returns link with the variant's title and URL applied.
+func Apply(link profile.Link, v *Variant) profile.Link {
+	if v == nil {
+		return link
+	}
+	if v.Title != "" {
+		link.Title = v.Title
+	}
+	if v.URL != "" {
+		link.URL = v.URL
+	}
+	return link
+}
+
+// Personalize applies the assigned variant to every link with a running experiment.
+// personalized reports whether the result depends on the visitor (and must not be shared-cached).
+func Personalize(links []profile.Link, visitorID string) (out []profile.Link, personalized bool) {
+	out = make([]profile.Link, len(links))
+	for i, l := range links {
+		out[i] = l
+		exp, ok := FromLink(l)
+		if !ok || !exp.Running() {
+			continue
+		}
+		personalized = true
+		out[i] = Apply(l, exp.Assign(l.ID, visitorID))
+	}
+	return out, personalized
+}
+
+// ValidateDoc validates the experiment fields present in a link create/update payload.
+func ValidateDoc(payload map[string]any) error {
+	if v, ok := payload[FieldVariants]; ok && v != nil {
+		variants, err := parseVariants(v)
+		if err != nil {
+			return err
+		}
+		if len(variants) > maxVariants {
+			return fmt.Errorf("too many variants (max %d)", maxVariants)
+		}
+		seen := map[string]bool{}
+		var total float64
+		for i, variant := range variants {
+			if !variantIDRe.MatchString(variant.ID) {
+				return fmt.Errorf("%s[%d].id must match %s", FieldVariants, i, variantIDRe)
+			}
+			if seen[variant.ID] {
+				return fmt.Errorf("duplicate variant id %q", variant.ID)
+			}
+			seen[variant.ID] = true
+			if variant.Weight < 0 || variant.Weight > maxWeight {
+				return fmt.Errorf("%s[%d].weight must be between 0 and %d", FieldVariants, i, maxWeight)
+			}
+			if variant.URL != "" && !strings.HasPrefix(variant.URL, "https://") && !strings.HasPrefix(variant.URL, "http://") {
+				return fmt.Errorf("%s[%d].url must be an absolute http(s) URL", FieldVariants, i)
+			}
+			total += variant.Weight
+		}
+		if len(variants) > 0 && total <= 0 {
+			return fmt.Errorf("at least one variant needs a positive weight")
+		}
+	}
+	if v, ok := payload[FieldExperiment]; ok && v != nil {
+		cfg, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s must be an object", FieldExperiment)
+		}
+		switch s, _ := cfg["status"].(string); s {
+		case "", StatusRunning, StatusPaused, StatusPromoted:
+		default:
+			return fmt.Errorf("%s.status must be running, paused or promoted", FieldExperiment)
+		}
+		if c, ok := number(cfg["confidence"]); ok && (c <= 0 || c >= 1) {
+			return fmt.Errorf("%s.confidence must be between 0 and 1", FieldExperiment)
+		}
+	}
+	return nil
+}
+
+func parseVariants(v any) ([]Variant, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s must be an array", FieldVariants)
+	}
+	out := make([]Variant, 0, len(raw))
+	for i, item := range raw {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s[%d] must be an object", FieldVariants, i)
+		}
+		str := func(k string) string {
+			s, _ := m[k].(string)
+			return strings.TrimSpace(s)
+		}
+		weight := 1.0
+		if w, ok := number(m["weight"]); ok {
+			weight = w
+		}
+		out = append(out, Variant{ID: str("id"), Title: str("title"), URL: str("url"), Weight: weight})
+	}
+	return out, nil
+}
+
+// number membaca angka dari Firestore (int64/float64) maupun JSON (float64).
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
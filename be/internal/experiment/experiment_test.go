@@ -0,0 +1,140 @@
+package experiment
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"biomu/backend/internal/profile"
+)
+
+func running(variants ...Variant) Experiment {
+	return Experiment{Status: StatusRunning, Variants: variants}
+}
+
+// Assignment harus stabil antar rilis: mengubah hash atau urutan pemetaan akan memindahkan
+// pengunjung yang sedang di tengah test ke varian lain.
+func TestAssignGolden(t *testing.T) {
+	exp := running(Variant{ID: "a", Weight: 1}, Variant{ID: "b", Weight: 1}, Variant{ID: "c", Weight: 2})
+	golden := map[string]string{
+		"v1": "c", "v2": "c", "v3": "c", "v4": "c", "v5": "c", "v6": "b",
+		"9f86d081884c7d65": "c", "visitor": "a",
+	}
+	for visitor, want := range golden {
+		if got := exp.Assign("link1", visitor); got == nil || got.ID != want {
+			t.Errorf("Assign(link1, %s) = %v, want %s", visitor, got, want)
+		}
+	}
+}
+
+func TestAssignStable(t *testing.T) {
+	exp := running(Variant{ID: "a", Weight: 1}, Variant{ID: "b", Weight: 3})
+	// Varian baru berbobot 0 di akhir tidak memindahkan siapa pun
+	extended := running(append(append([]Variant{}, exp.Variants...), Variant{ID: "c", Weight: 0})...)
+	counts := map[string]int{}
+	moved := 0
+	for i := 0; i < 20000; i++ {
+		visitor := fmt.Sprintf("visitor-%d", i)
+		v := exp.Assign("link1", visitor)
+		for j := 0; j < 3; j++ {
+			if again := exp.Assign("link1", visitor); again.ID != v.ID {
+				t.Fatalf("%s: %s then %s", visitor, v.ID, again.ID)
+			}
+		}
+		if extended.Assign("link1", visitor).ID != v.ID {
+			t.Fatalf("%s moved after adding a zero-weight variant", visitor)
+		}
+		if exp.Assign("link2", visitor).ID != v.ID {
+			moved++
+		}
+		counts[v.ID]++
+	}
+	if share := float64(counts["b"]) / 20000; math.Abs(share-0.75) > 0.02 {
+		t.Errorf("variant b got %.3f of visitors, want ~0.75", share)
+	}
+	// Bucket per link independen: ~2·0.25·0.75 = 37.5% pengunjung beda varian di link lain
+	if share := float64(moved) / 20000; math.Abs(share-0.375) > 0.02 {
+		t.Errorf("%.3f of visitors differ across links, want ~0.375", share)
+	}
+}
+
+func TestAssignNotEnrolled(t *testing.T) {
+	variants := []Variant{{ID: "a", Weight: 1}, {ID: "b", Weight: 1}}
+	tests := []struct {
+		name    string
+		exp     Experiment
+		visitor string
+	}{
+		{"no visitor ID", running(variants...), ""},
+		{"paused", Experiment{Status: StatusPaused, Variants: variants}, "v1"},
+		{"promoted", Experiment{Status: StatusPromoted, Variants: variants}, "v1"},
+		{"single variant", running(variants[0]), "v1"},
+		{"zero weights", running(Variant{ID: "a"}, Variant{ID: "b"}), "v1"},
+	}
+	for _, tt := range tests {
+		if v := tt.exp.Assign("link1", tt.visitor); v != nil {
+			t.Errorf("%s: assigned %s", tt.name, v.ID)
+		}
+	}
+}
+
+func TestFromLinkAndApply(t *testing.T) {
+	link := profile.Link{ID: "l1", Title: "Shop", URL: "https://example.com", Data: map[string]any{
+		FieldVariants: []any{
+			map[string]any{"id": "a"},
+			map[string]any{"id": "b", "title": "Diskon!", "url": "https://example.com/sale", "weight": int64(0)},
+		},
+		FieldExperiment: map[string]any{"status": "running", "confidence": 0.99, "minImpressions": int64(50), "autoPromote": false},
+	}}
+	exp, ok := FromLink(link)
+	if !ok || !exp.Running() || exp.Confidence != 0.99 || exp.MinImpressions != 50 || exp.AutoPromote {
+		t.Fatalf("FromLink = %+v, %v", exp, ok)
+	}
+	if v := exp.Assign("l1", "any"); v == nil || v.ID != "a" {
+		t.Fatalf("zero-weight variant assigned: %v", v)
+	}
+	got := Apply(link, exp.Variant("b"))
+	if got.Title != "Diskon!" || got.URL != "https://example.com/sale" || link.Title != "Shop" {
+		t.Fatalf("Apply = %+v", got)
+	}
+	if got := Apply(link, exp.Variant("a")); got.Title != "Shop" || got.URL != "https://example.com" {
+		t.Fatalf("empty variant fields not kept: %+v", got)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	exp := running(Variant{ID: "a", Weight: 1}, Variant{ID: "b", Weight: 1})
+	exp.Confidence, exp.MinImpressions = 0.95, 100
+	tests := []struct {
+		name       string
+		counts     map[string]Count
+		wantLeader string
+		wantWinner string
+	}{
+		{"clear winner", map[string]Count{"a": {1000, 50}, "b": {1000, 100}}, "b", "b"},
+		{"too few impressions", map[string]Count{"a": {99, 1}, "b": {1000, 500}}, "b", ""},
+		{"not significant", map[string]Count{"a": {1000, 50}, "b": {1000, 55}}, "b", ""},
+		{"clicks above impressions capped", map[string]Count{"a": {100, 500}, "b": {100, 99}}, "a", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := Analyze(exp, tt.counts)
+			if res.Leader != tt.wantLeader || res.Winner != tt.wantWinner {
+				t.Fatalf("leader %q winner %q (confidence %.3f), want %q %q", res.Leader, res.Winner, res.Confidence, tt.wantLeader, tt.wantWinner)
+			}
+		})
+	}
+}
+
+func TestZTest(t *testing.T) {
+	z, p := ZTest(Count{1000, 100}, Count{1000, 50})
+	if math.Abs(z-4.2) > 0.05 || p > 0.0001 {
+		t.Errorf("ZTest = %.3f, %.5f", z, p)
+	}
+	if _, p := ZTest(Count{}, Count{1000, 50}); p != 1 {
+		t.Errorf("no impressions: p = %v", p)
+	}
+	if z, p := ZTest(Count{100, 0}, Count{100, 0}); z != 0 || p != 1 {
+		t.Errorf("no clicks: %v, %v", z, p)
+	}
+}
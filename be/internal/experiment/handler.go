@@ -0,0 +1,126 @@
+package experiment
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"biomu/backend/internal/firebase"
+	"biomu/backend/internal/profile"
+)
+
+// Sessions resolves the signed-in caller (implemented by auth.Handler).
+type Sessions interface {
+	SessionUID(r *http.Request) string
+}
+
+type Handler struct {
+	fb       *firebase.App
+	profiles *profile.Store
+	sessions Sessions
+	counters *Counters
+}
+
+func NewHandler(fb *firebase.App, profiles *profile.Store, sessions Sessions, counters *Counters) *Handler {
+	return &Handler{fb: fb, profiles: profiles, sessions: sessions, counters: counters}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// loadLink returns the link when the caller owns it or is an admin; otherwise it writes the error.
+func (h *Handler) loadLink(w http.ResponseWriter, r *http.Request) (*profile.Link, string) {
+	ctx := r.Context()
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return nil, ""
+	}
+	linkID := r.PathValue("linkId")
+	link, err := h.profiles.FindLink(ctx, linkID)
+	if err != nil {
+		log.Printf("experiment %s: %v", linkID, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load link"})
+		return nil, ""
+	}
+	if link == nil {
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return nil, ""
+	}
+	if link.ProfileID != uid {
+		admin, err := h.profiles.IsAdmin(ctx, uid)
+		if err != nil {
+			log.Printf("experiment admin check %s: %v", uid, err)
+			h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load account"})
+			return nil, ""
+		}
+		if !admin {
+			h.writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+			return nil, ""
+		}
+	}
+	return link, uid
+}
+
+// GET /api/links/{linkId}/experiment — hasil A/B test per varian + z-test (pemilik/admin)
+func (h *Handler) Results(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	link, _ := h.loadLink(w, r)
+	if link == nil {
+		return
+	}
+	exp, ok := FromLink(*link)
+	if !ok {
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "link has no variants"})
+		return
+	}
+	counts, err := h.counters.Totals(r.Context(), link.ID)
+	if err != nil {
+		log.Printf("experiment results %s: %v", link.ID, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load counters"})
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	h.writeJSON(w, http.StatusOK, Analyze(exp, counts))
+}
+
+// POST /api/links/{linkId}/experiment/promote — promosikan varian secara manual. Body {"variant": "b"}
+func (h *Handler) Promote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	link, uid := h.loadLink(w, r)
+	if link == nil {
+		return
+	}
+	var body struct {
+		Variant string `json:"variant"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 4<<10)).Decode(&body); err != nil || body.Variant == "" {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "variant is required"})
+		return
+	}
+	err := Promote(r.Context(), h.fb, h.profiles.LinksCollection(), link.ID, body.Variant, uid)
+	switch {
+	case errors.Is(err, ErrNotRunning):
+		h.writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	case errors.Is(err, ErrUnknownVariant):
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("experiment promote %s: %v", link.ID, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to promote variant"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,115 @@
+package experiment
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"biomu/backend/internal/firebase"
+	"biomu/backend/internal/profile"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+var (
+	// ErrNotRunning is returned by Promote when the experiment was already promoted or removed.
+	ErrNotRunning = errors.New("experiment is not running")
+	// ErrUnknownVariant is returned by Promote for a variant id that is not on the link.
+	ErrUnknownVariant = errors.New("unknown variant")
+)
+
+// Promoter periodically evaluates running experiments with autoPromote and promotes winners.
+type Promoter struct {
+	fb        *firebase.App
+	linksColl string
+	counters  *Counters
+	interval  time.Duration
+}
+
+func NewPromoter(fb *firebase.App, linksColl string, counters *Counters, interval time.Duration) *Promoter {
+	return &Promoter{fb: fb, linksColl: linksColl, counters: counters, interval: interval}
+}
+
+// Run evaluates experiments every interval until ctx is cancelled.
+func (p *Promoter) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		p.tick(ctx)
+	}
+}
+
+func (p *Promoter) tick(ctx context.Context) {
+	it := p.fb.DB.Collection(p.linksColl).Where(FieldExperiment+".status", "==", StatusRunning).Documents(ctx)
+	defer it.Stop()
+	for {
+		doc, err := it.Next()
+		if err == iterator.Done {
+			return
+		}
+		if err != nil {
+			log.Printf("experiment promoter: %v", err)
+			return
+		}
+		link := profile.LinkFromDoc(doc)
+		exp, ok := FromLink(link)
+		if !ok || !exp.Running() || !exp.AutoPromote {
+			continue
+		}
+		counts, err := p.counters.Totals(ctx, link.ID)
+		if err != nil {
+			log.Printf("experiment promoter %s: %v", link.ID, err)
+			continue
+		}
+		res := Analyze(exp, counts)
+		if res.Winner == "" {
+			continue
+		}
+		if err := Promote(ctx, p.fb, p.linksColl, link.ID, res.Winner, "auto"); err != nil && !errors.Is(err, ErrNotRunning) {
+			log.Printf("experiment promoter %s: %v", link.ID, err)
+			continue
+		}
+		log.Printf("experiment %s: promoted variant %s (confidence %.3f)", link.ID, res.Winner, res.Confidence)
+	}
+}
+
+// Promote ends the experiment on linkID and copies the winning variant's title/URL onto the link.
+// Variants and counters are kept for history.
+func Promote(ctx context.Context, fb *firebase.App, linksColl, linkID, variantID, promotedBy string) error {
+	ref := fb.DB.Collection(linksColl).Doc(linkID)
+	return fb.DB.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		exp, ok := FromLink(profile.LinkFromDoc(doc))
+		if !ok || exp.Status == StatusPromoted {
+			return ErrNotRunning
+		}
+		winner := exp.Variant(variantID)
+		if winner == nil {
+			return ErrUnknownVariant
+		}
+		updates := []firestore.Update{
+			{Path: FieldExperiment + ".status", Value: StatusPromoted},
+			{Path: FieldExperiment + ".winner", Value: winner.ID},
+			{Path: FieldExperiment + ".promotedAt", Value: time.Now()},
+			{Path: FieldExperiment + ".promotedBy", Value: promotedBy},
+			{Path: "updatedAt", Value: time.Now()},
+		}
+		if winner.Title != "" {
+			updates = append(updates, firestore.Update{Path: "title", Value: winner.Title})
+		}
+		if winner.URL != "" {
+			updates = append(updates, firestore.Update{Path: "url", Value: winner.URL})
+		}
+		return tx.Update(ref, updates)
+	})
+}
@@ -0,0 +1,103 @@
+package experiment
+
+import "math"
+
+// Count holds the totals of one variant.
+type Count struct {
+	Impressions int64 `json:"impressions"`
+	Clicks      int64 `json:"clicks"`
+}
+
+// CTR is clicks per impression (0 without impressions).
+func (c Count) CTR() float64 {
+	if c.Impressions == 0 {
+		return 0
+	}
+	return float64(c.Clicks) / float64(c.Impressions)
+}
+
+// ZTest runs a pooled two-proportion z-test of a against b and returns z and the one-sided
+// p-value for "a converts better than b". Clicks are capped at impressions (clicks can
+// arrive without a counted impression, e.g. from a shared /r link).
+func ZTest(a, b Count) (z, p float64) {
+	if a.Impressions == 0 || b.Impressions == 0 {
+		return 0, 1
+	}
+	ca, cb := min(a.Clicks, a.Impressions), min(b.Clicks, b.Impressions)
+	na, nb := float64(a.Impressions), float64(b.Impressions)
+	pa, pb := float64(ca)/na, float64(cb)/nb
+	pooled := float64(ca+cb) / (na + nb)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/na + 1/nb))
+	if se == 0 {
+		return 0, 1
+	}
+	z = (pa - pb) / se
+	// P(Z > z) untuk distribusi normal standar
+	p = 0.5 * math.Erfc(z/math.Sqrt2)
+	return z, p
+}
+
+// VariantResult is the per-variant part of Results.
+type VariantResult struct {
+	Variant
+	Count
+	CTR float64 `json:"ctr"`
+	// Z dan Confidence dibandingkan dengan varian terbaik (kosong untuk varian terbaik itu sendiri).
+	Z          float64 `json:"z,omitempty"`
+	Confidence float64 `json:"confidence,omitempty"`
+}
+
+// Results summarizes an experiment.
+type Results struct {
+	Status         string          `json:"status"`
+	Variants       []VariantResult `json:"variants"`
+	Leader         string          `json:"leader,omitempty"`
+	Confidence     float64         `json:"confidence"`
+	Threshold      float64         `json:"threshold"`
+	MinImpressions int64           `json:"minImpressions"`
+	// Winner terisi jika leader unggul terhadap semua varian lain dengan confidence ≥ threshold
+	// dan setiap varian sudah mencapai minImpressions.
+	Winner string `json:"winner,omitempty"`
+}
+
+// Analyze compares every variant with the leader (highest CTR). The reported confidence is
+// the weakest of the leader's pairwise wins, so a winner must beat every other variant.
+func Analyze(exp Experiment, counts map[string]Count) Results {
+	res := Results{
+		Status:         exp.Status,
+		Threshold:      exp.Confidence,
+		MinImpressions: exp.MinImpressions,
+		Variants:       make([]VariantResult, 0, len(exp.Variants)),
+	}
+	leader := -1
+	enough := true
+	for i, v := range exp.Variants {
+		c := counts[v.ID]
+		res.Variants = append(res.Variants, VariantResult{Variant: v, Count: c, CTR: c.CTR()})
+		if c.Impressions < exp.MinImpressions {
+			enough = false
+		}
+		if leader < 0 || c.CTR() > res.Variants[leader].CTR {
+			leader = i
+		}
+	}
+	if leader < 0 {
+		return res
+	}
+	res.Leader = exp.Variants[leader].ID
+	res.Confidence = 1
+	for i := range res.Variants {
+		if i == leader {
+			continue
+		}
+		z, p := ZTest(res.Variants[leader].Count, res.Variants[i].Count)
+		res.Variants[i].Z, res.Variants[i].Confidence = z, 1-p
+		res.Confidence = math.Min(res.Confidence, 1-p)
+	}
+	if exp.Status == StatusPromoted {
+		res.Winner = exp.Winner
+	} else if enough && res.Confidence >= exp.Confidence {
+		res.Winner = res.Leader
+	}
+	return res
+}
@@ -9,14 +9,16 @@ import (
 
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/auth"
+	"firebase.google.com/go/v4/storage"
 	"google.golang.org/api/option"
 
 	"cloud.google.com/go/firestore"
 )
 
 type App struct {
-	Auth *auth.Client
-	DB   *firestore.Client
+	Auth    *auth.Client
+	DB      *firestore.Client
+	Storage *storage.Client
 }
 
 func Init(ctx context.Context) (*App, error) {
@@ -76,5 +78,10 @@ func bindApp(ctx context.Context, app *firebase.App) (*App, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &App{Auth: authClient, DB: db}, nil
+	// Storage dipakai blob store "firebase" (upload media); bucket dipilih saat dipakai
+	st, err := app.Storage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &App{Auth: authClient, DB: db, Storage: st}, nil
 }
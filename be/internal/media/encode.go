@@ -0,0 +1,86 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+// Encoder writes one output format of a variant.
+type Encoder interface {
+	Format() string
+	ContentType() string
+	Ext() string
+	Encode(ctx context.Context, img image.Image) ([]byte, error)
+}
+
+// JPEG encodes with the standard library. Transparent pixels are flattened onto white.
+type JPEG struct {
+	Quality int
+}
+
+func (JPEG) Format() string      { return "jpeg" }
+func (JPEG) ContentType() string { return "image/jpeg" }
+func (JPEG) Ext() string         { return "jpg" }
+
+func (e JPEG) Encode(_ context.Context, img image.Image) ([]byte, error) {
+	b := img.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, b.Min, draw.Over)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: e.Quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// CWebP encodes WebP by running libwebp's cwebp binary (Go tidak punya encoder WebP lossy
+// di standard library maupun x/image). Alpha is preserved.
+type CWebP struct {
+	Path    string
+	Quality int
+}
+
+// NewCWebP finds cwebp in PATH; ok is false when it is not installed.
+func NewCWebP(quality int) (*CWebP, bool) {
+	p, err := exec.LookPath("cwebp")
+	if err != nil {
+		return nil, false
+	}
+	return &CWebP{Path: p, Quality: quality}, true
+}
+
+func (*CWebP) Format() string      { return "webp" }
+func (*CWebP) ContentType() string { return "image/webp" }
+func (*CWebP) Ext() string         { return "webp" }
+
+func (e *CWebP) Encode(ctx context.Context, img image.Image) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "cwebp-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	in, out := filepath.Join(dir, "in.png"), filepath.Join(dir, "out.webp")
+	var buf bytes.Buffer
+	if err := (&png.Encoder{CompressionLevel: png.BestSpeed}).Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(in, buf.Bytes(), 0o600); err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, e.Path, "-quiet", "-q", strconv.Itoa(e.Quality), "-metadata", "none", in, "-o", out)
+	if msg, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("cwebp: %v: %s", err, bytes.TrimSpace(msg))
+	}
+	return os.ReadFile(out)
+}
@@ -0,0 +1,326 @@
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"biomu/backend/internal/blob"
+	"biomu/backend/internal/firebase"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// multipartOverhead: ruang untuk boundary dan field kecil (kind, setAvatar) di luar file
+	multipartOverhead = 64 << 10
+	maxFieldBytes     = 256
+	// maxConcurrent membatasi decode/resize paralel; gambar 40MP butuh ~160MB RAM
+	maxConcurrent = 2
+	avatarVariant = "512"
+)
+
+var errFileRequired = errors.New("file is required")
+
+// Sessions resolves the signed-in caller (implemented by auth.Handler).
+type Sessions interface {
+	SessionUID(r *http.Request) string
+}
+
+type Handler struct {
+	fb           *firebase.App
+	sessions     Sessions
+	store        blob.Store
+	processor    *Processor
+	mediaColl    string
+	accountsColl string
+	sem          chan struct{}
+}
+
+func NewHandler(fb *firebase.App, sessions Sessions, store blob.Store, processor *Processor, mediaColl, accountsColl string) *Handler {
+	return &Handler{
+		fb:           fb,
+		sessions:     sessions,
+		store:        store,
+		processor:    processor,
+		mediaColl:    mediaColl,
+		accountsColl: accountsColl,
+		sem:          make(chan struct{}, maxConcurrent),
+	}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// Media is the stored document describing one upload.
+type Media struct {
+	ID         string    `json:"id" firestore:"-"`
+	OwnerID    string    `json:"ownerId" firestore:"ownerId"`
+	Kind       string    `json:"kind" firestore:"kind"`
+	SourceType string    `json:"sourceType" firestore:"sourceType"`
+	Width      int       `json:"width" firestore:"width"`
+	Height     int       `json:"height" firestore:"height"`
+	Blurhash   string    `json:"blurhash" firestore:"blurhash"`
+	Variants   []Variant `json:"variants" firestore:"variants"`
+	CreatedAt  time.Time `json:"createdAt" firestore:"createdAt"`
+}
+
+type upload struct {
+	data      []byte
+	kind      string
+	setAvatar bool
+}
+
+// POST /api/media — multipart/form-data: file, kind (avatar|thumbnail, default thumbnail), setAvatar
+func (h *Handler) Upload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	ctx := r.Context()
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxUploadBytes+multipartOverhead)
+	in, err := readUpload(r)
+	if err != nil {
+		var tooBig *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooBig), errors.Is(err, ErrTooLarge):
+			h.writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "file exceeds " + strconv.Itoa(MaxUploadBytes>>20) + "MB"})
+		default:
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return
+	}
+	if in.setAvatar && in.kind != "avatar" {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "setAvatar requires kind=avatar"})
+		return
+	}
+
+	select {
+	case h.sem <- struct{}{}:
+	case <-ctx.Done():
+		return
+	}
+	res, err := h.processor.Process(ctx, in.data, in.kind)
+	<-h.sem
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		code := processStatus(err)
+		if code == http.StatusInternalServerError {
+			log.Printf("media process %s: %v", uid, err)
+			h.writeJSON(w, code, map[string]string{"error": "failed to process image"})
+			return
+		}
+		h.writeJSON(w, code, map[string]string{"error": err.Error()})
+		return
+	}
+
+	ref := h.fb.DB.Collection(h.mediaColl).NewDoc()
+	m := Media{
+		ID:         ref.ID,
+		OwnerID:    uid,
+		Kind:       in.kind,
+		SourceType: res.SourceType,
+		Width:      res.Width,
+		Height:     res.Height,
+		Blurhash:   res.Blurhash,
+		Variants:   make([]Variant, 0, len(res.Outputs)),
+		CreatedAt:  time.Now().UTC(),
+	}
+	for _, out := range res.Outputs {
+		v := out.Variant
+		v.Key = "media/" + uid + "/" + ref.ID + "/" + v.Name + "." + out.Ext
+		v.URL, err = h.store.Put(ctx, v.Key, v.ContentType, out.Data)
+		if err != nil {
+			log.Printf("media put %s: %v", v.Key, err)
+			h.cleanup(m.Variants)
+			h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to store media"})
+			return
+		}
+		m.Variants = append(m.Variants, v)
+	}
+	if _, err := ref.Create(ctx, m); err != nil {
+		log.Printf("media create %s: %v", ref.ID, err)
+		h.cleanup(m.Variants)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to store media"})
+		return
+	}
+
+	if in.setAvatar {
+		avatar, ok := m.variant(avatarVariant, "jpeg")
+		if ok {
+			_, err = h.fb.DB.Collection(h.accountsColl).Doc(uid).Update(ctx, []firestore.Update{
+				{Path: "image", Value: avatar.URL},
+				{Path: "imageBlurhash", Value: m.Blurhash},
+				{Path: "imageMediaId", Value: m.ID},
+				{Path: "updatedAt", Value: firestore.ServerTimestamp},
+			})
+		}
+		if !ok || err != nil {
+			// Media tetap tersimpan; klien bisa mengulang set avatar lewat update profil biasa
+			log.Printf("media set avatar %s: ok=%v err=%v", uid, ok, err)
+			h.writeJSON(w, http.StatusInternalServerError, map[string]any{"error": "failed to set avatar", "media": m})
+			return
+		}
+	}
+	h.writeJSON(w, http.StatusCreated, m)
+}
+
+// DELETE /api/media/{id} — hanya pemilik; menghapus semua variant dan dokumennya
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	ctx := r.Context()
+	id := r.PathValue("id")
+	if id == "" {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "id is required"})
+		return
+	}
+
+	ref := h.fb.DB.Collection(h.mediaColl).Doc(id)
+	doc, err := ref.Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "media not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("media get %s: %v", id, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load media"})
+		return
+	}
+	var m Media
+	if err := doc.DataTo(&m); err != nil {
+		log.Printf("media decode %s: %v", id, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load media"})
+		return
+	}
+	if m.OwnerID != uid {
+		// Sama dengan not found supaya ID media orang lain tidak bisa ditebak
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "media not found"})
+		return
+	}
+	for _, v := range m.Variants {
+		if err := h.store.Delete(ctx, v.Key); err != nil {
+			log.Printf("media delete %s: %v", v.Key, err)
+			h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete media"})
+			return
+		}
+	}
+	if _, err := ref.Delete(ctx); err != nil {
+		log.Printf("media delete doc %s: %v", id, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete media"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (m *Media) variant(name, format string) (Variant, bool) {
+	for _, v := range m.Variants {
+		if v.Name == name && v.Format == format {
+			return v, true
+		}
+	}
+	// Sumber lebih kecil dari 512px: variant terbesar dengan format yang sama
+	for _, v := range m.Variants {
+		if v.Format == format {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
+
+// cleanup removes blobs already written for an upload that failed halfway.
+func (h *Handler) cleanup(variants []Variant) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for _, v := range variants {
+		if err := h.store.Delete(ctx, v.Key); err != nil {
+			log.Printf("media cleanup %s: %v", v.Key, err)
+		}
+	}
+}
+
+func readUpload(r *http.Request) (*upload, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, errors.New("expected multipart/form-data")
+	}
+	in := &upload{kind: "thumbnail"}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch part.FormName() {
+		case "file":
+			if in.data != nil {
+				return nil, errors.New("only one file per request")
+			}
+			in.data, err = io.ReadAll(io.LimitReader(part, MaxUploadBytes+1))
+			if err != nil {
+				return nil, err
+			}
+			if len(in.data) > MaxUploadBytes {
+				return nil, ErrTooLarge
+			}
+		case "kind", "setAvatar":
+			b, err := io.ReadAll(io.LimitReader(part, maxFieldBytes))
+			if err != nil {
+				return nil, err
+			}
+			if part.FormName() == "kind" {
+				in.kind = string(b)
+			} else {
+				in.setAvatar, _ = strconv.ParseBool(string(b))
+			}
+		}
+		part.Close()
+	}
+	if len(in.data) == 0 {
+		return nil, errFileRequired
+	}
+	if _, ok := Kinds[in.kind]; !ok {
+		return nil, ErrUnknownKind
+	}
+	return in, nil
+}
+
+func processStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrUnsupportedType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, ErrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrTooSmall), errors.Is(err, ErrUnknownKind):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,216 @@
+// Package media memproses upload gambar (avatar, thumbnail link): deteksi tipe dari isi file,
+// batas ukuran, koreksi orientasi EXIF lalu membuang seluruh metadata, resize ke beberapa
+// variant (JPEG dan WebP) dan blurhash untuk placeholder.
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"sort"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	MaxUploadBytes = 10 << 20
+	maxPixels      = 40_000_000
+	maxDimension   = 12000
+	minDimension   = 16
+	blurhashSize   = 32
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type (jpeg, png, gif or webp)")
+	ErrTooLarge        = errors.New("image dimensions too large")
+	ErrTooSmall        = errors.New("image is too small")
+	ErrUnknownKind     = errors.New("unknown media kind")
+)
+
+// allowedTypes: SVG sengaja tidak diterima (bisa berisi script)
+var allowedTypes = map[string]bool{"image/jpeg": true, "image/png": true, "image/gif": true, "image/webp": true}
+
+// Kind describes the variants produced for one upload purpose.
+type Kind struct {
+	// Square: crop tengah menjadi persegi (avatar)
+	Square bool
+	// Sizes: sisi terpanjang tiap variant dalam pixel; tidak pernah di-upscale
+	Sizes []int
+}
+
+var Kinds = map[string]Kind{
+	"avatar":    {Square: true, Sizes: []int{512, 256, 96}},
+	"thumbnail": {Sizes: []int{1200, 600, 240}},
+}
+
+// Variant is one stored rendition.
+type Variant struct {
+	Name        string `json:"name" firestore:"name"`
+	Width       int    `json:"width" firestore:"width"`
+	Height      int    `json:"height" firestore:"height"`
+	Format      string `json:"format" firestore:"format"`
+	ContentType string `json:"contentType" firestore:"contentType"`
+	Bytes       int    `json:"bytes" firestore:"bytes"`
+	URL         string `json:"url" firestore:"url"`
+	Key         string `json:"key" firestore:"key"`
+}
+
+// Output is an encoded variant before it is stored.
+type Output struct {
+	Variant
+	Ext  string
+	Data []byte
+}
+
+// Result is the processed upload.
+type Result struct {
+	SourceType string
+	Width      int
+	Height     int
+	Blurhash   string
+	Outputs    []Output
+}
+
+// Processor turns an uploaded file into encoded variants.
+type Processor struct {
+	encoders []Encoder
+}
+
+func NewProcessor(encoders ...Encoder) *Processor {
+	return &Processor{encoders: encoders}
+}
+
+// Formats lists the output formats in encoder order.
+func (p *Processor) Formats() []string {
+	out := make([]string, 0, len(p.encoders))
+	for _, e := range p.encoders {
+		out = append(out, e.Format())
+	}
+	return out
+}
+
+// Sniff returns the content type detected from the file bytes (the client-declared type is
+// ignored) or ErrUnsupportedType.
+func Sniff(data []byte) (string, error) {
+	ct := http.DetectContentType(data)
+	if !allowedTypes[ct] {
+		return "", ErrUnsupportedType
+	}
+	return ct, nil
+}
+
+// Process decodes data and produces every variant of kind in every encoder format.
+func (p *Processor) Process(ctx context.Context, data []byte, kindName string) (*Result, error) {
+	kind, ok := Kinds[kindName]
+	if !ok {
+		return nil, ErrUnknownKind
+	}
+	ct, err := Sniff(data)
+	if err != nil {
+		return nil, err
+	}
+	// Cek dimensi dari header dulu supaya decompression bomb tidak pernah di-decode
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	if cfg.Width > maxDimension || cfg.Height > maxDimension || cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+	if cfg.Width < minDimension || cfg.Height < minDimension {
+		return nil, ErrTooSmall
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	if ct == "image/jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+	if kind.Square {
+		img = cropSquare(img)
+	}
+	b := img.Bounds()
+	res := &Result{SourceType: ct, Width: b.Dx(), Height: b.Dy()}
+	res.Blurhash = Blurhash(resize(img, blurhashSize), 4, 3)
+
+	sizes := append([]int(nil), kind.Sizes...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+	seen := map[image.Point]bool{}
+	for _, size := range sizes {
+		v := resize(img, size)
+		dim := v.Bounds().Size()
+		// Gambar kecil: beberapa ukuran bisa menghasilkan dimensi yang sama
+		if seen[dim] {
+			continue
+		}
+		seen[dim] = true
+		for _, enc := range p.encoders {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			out, err := enc.Encode(ctx, v)
+			if err != nil {
+				return nil, err
+			}
+			res.Outputs = append(res.Outputs, Output{
+				Variant: Variant{
+					Name:        itoa(size),
+					Width:       dim.X,
+					Height:      dim.Y,
+					Format:      enc.Format(),
+					ContentType: enc.ContentType(),
+					Bytes:       len(out),
+				},
+				Ext:  enc.Ext(),
+				Data: out,
+			})
+		}
+	}
+	return res, nil
+}
+
+// resize scales img so its longest side is at most size (never upscales).
+func resize(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+	if w >= h {
+		h, w = max(1, h*size/w), size
+	} else {
+		w, h = max(1, w*size/h), size
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+func cropSquare(img image.Image) image.Image {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	r := image.Rect(0, 0, side, side).Add(b.Min).Add(image.Pt((b.Dx()-side)/2, (b.Dy()-side)/2))
+	dst := image.NewNRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(dst, dst.Bounds(), img, r.Min, draw.Src)
+	return dst
+}
+
+func itoa(n int) string {
+	var b [20]byte
+	i := len(b)
+	for {
+		i--
+		b[i] = byte('0' + n%10)
+		n /= 10
+		if n == 0 {
+			return string(b[i:])
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"testing"
+)
+
+// fill returns a w×h image with the left half a and the right half b.
+func fill(w, h int, a, b color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := a
+			if x >= w/2 {
+				c = b
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// exifSegment builds an APP1 payload with Orientation, Make and a GPS IFD (big-endian TIFF).
+func exifSegment(orientation uint16) []byte {
+	var t bytes.Buffer
+	be := binary.BigEndian
+	w := func(v any) { _ = binary.Write(&t, be, v) }
+	entry := func(tag, typ uint16, count, value uint32) {
+		w(tag)
+		w(typ)
+		w(count)
+		w(value)
+	}
+	t.WriteString("MM\x00*")
+	w(uint32(8))
+	// IFD0 di offset 8: 3 entry, berakhir di 8+2+36+4 = 50
+	w(uint16(3))
+	entry(0x010F, 2, 10, 50)                     // Make → offset 50
+	entry(0x0112, 3, 1, uint32(orientation)<<16) // Orientation (SHORT, rata kiri)
+	entry(0x8825, 4, 1, 60)                      // GPSInfo → offset 60
+	w(uint32(0))
+	t.WriteString("SecretCam\x00")
+	// GPS IFD di offset 60: 2 entry, berakhir di 60+2+24+4 = 90
+	w(uint16(2))
+	entry(0x0001, 2, 2, uint32('S')<<24)
+	entry(0x0002, 5, 3, 90)
+	w(uint32(0))
+	for _, v := range []uint32{6, 1, 12, 1, 3168, 100} { // 6° 12' 31.68"
+		w(v)
+	}
+	return append([]byte("Exif\x00\x00"), t.Bytes()...)
+}
+
+// jpegWithExif encodes img and inserts the EXIF segment right after SOI, like a camera does.
+func jpegWithExif(t *testing.T, img image.Image, orientation uint16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	seg := exifSegment(orientation)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(seg)+2))
+	data := append([]byte{}, buf.Bytes()[:2]...)
+	data = append(data, app1...)
+	data = append(data, seg...)
+	return append(data, buf.Bytes()[2:]...)
+}
+
+// appMarkers lists the APPn markers before the first scan of a JPEG.
+func appMarkers(data []byte) []byte {
+	var out []byte
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		if marker == 0xDA {
+			break
+		}
+		if marker >= 0xE0 && marker <= 0xEF {
+			out = append(out, marker)
+		}
+		i += 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+	}
+	return out
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	img := fill(32, 16, color.White, color.Black)
+	for o := uint16(1); o <= 8; o++ {
+		if got := jpegOrientation(jpegWithExif(t, img, o)); got != int(o) {
+			t.Errorf("orientation %d read as %d", o, got)
+		}
+	}
+	if got := jpegOrientation(jpegWithExif(t, img, 9)); got != 1 {
+		t.Errorf("invalid orientation read as %d", got)
+	}
+	var plain bytes.Buffer
+	_ = jpeg.Encode(&plain, img, nil)
+	for name, data := range map[string][]byte{"no exif": plain.Bytes(), "png": encodePNG(t, img), "truncated": jpegWithExif(t, img, 6)[:30], "empty": nil} {
+		if got := jpegOrientation(data); got != 1 {
+			t.Errorf("%s: orientation %d", name, got)
+		}
+	}
+}
+
+// EXIF (termasuk GPS dan merek kamera) tidak ikut ke variant, tetapi orientasinya diterapkan.
+func TestProcessStripsExif(t *testing.T) {
+	red, blue := color.NRGBA{255, 0, 0, 255}, color.NRGBA{0, 0, 255, 255}
+	data := jpegWithExif(t, fill(40, 20, red, blue), 6)
+	if !bytes.Contains(data, []byte("SecretCam")) || len(appMarkers(data)) == 0 {
+		t.Fatal("fixture has no EXIF")
+	}
+	res, err := NewProcessor(JPEG{Quality: 90}).Process(context.Background(), data, "thumbnail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Rotate 90° CW: 40×20 menjadi 20×40, merah di atas dan biru di bawah
+	if res.SourceType != "image/jpeg" || res.Width != 20 || res.Height != 40 {
+		t.Fatalf("result %s %dx%d", res.SourceType, res.Width, res.Height)
+	}
+	if len(res.Outputs) != 1 {
+		t.Fatalf("%d outputs, want 1 (all sizes exceed the source)", len(res.Outputs))
+	}
+	out := res.Outputs[0].Data
+	for _, leak := range []string{"Exif", "SecretCam", "MM\x00*"} {
+		if bytes.Contains(out, []byte(leak)) {
+			t.Errorf("output contains %q", leak)
+		}
+	}
+	if m := appMarkers(out); len(m) != 0 {
+		t.Errorf("output has APP markers %x", m)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	top, bottom := img.At(10, 5), img.At(10, 35)
+	if r, _, b, _ := top.RGBA(); r>>8 < 200 || b>>8 > 60 {
+		t.Errorf("top pixel %v, want red", top)
+	}
+	if r, _, b, _ := bottom.RGBA(); b>>8 < 200 || r>>8 > 60 {
+		t.Errorf("bottom pixel %v, want blue", bottom)
+	}
+}
+
+func TestProcessVariants(t *testing.T) {
+	p := NewProcessor(JPEG{Quality: 80})
+	tests := []struct {
+		name   string
+		w, h   int
+		kind   string
+		want   [][3]int // name, width, height
+		result [2]int
+	}{
+		{"avatar crops square", 1000, 800, "avatar", [][3]int{{512, 512, 512}, {256, 256, 256}, {96, 96, 96}}, [2]int{800, 800}},
+		{"thumbnail keeps aspect", 2000, 1000, "thumbnail", [][3]int{{1200, 1200, 600}, {600, 600, 300}, {240, 240, 120}}, [2]int{2000, 1000}},
+		{"portrait thumbnail", 500, 1000, "thumbnail", [][3]int{{1200, 500, 1000}, {600, 300, 600}, {240, 120, 240}}, [2]int{500, 1000}},
+		// Tidak pernah di-upscale; ukuran dengan dimensi sama hanya disimpan sekali
+		{"small thumbnail", 300, 200, "thumbnail", [][3]int{{1200, 300, 200}, {240, 240, 160}}, [2]int{300, 200}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := encodePNG(t, fill(tt.w, tt.h, color.White, color.Black))
+			res, err := p.Process(context.Background(), data, tt.kind)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.SourceType != "image/png" || res.Width != tt.result[0] || res.Height != tt.result[1] {
+				t.Fatalf("result %s %dx%d", res.SourceType, res.Width, res.Height)
+			}
+			if len(res.Outputs) != len(tt.want) {
+				t.Fatalf("%d outputs, want %d", len(res.Outputs), len(tt.want))
+			}
+			for i, out := range res.Outputs {
+				want := tt.want[i]
+				if out.Name != itoa(want[0]) || out.Width != want[1] || out.Height != want[2] {
+					t.Errorf("output %d = %s %dx%d, want %d %dx%d", i, out.Name, out.Width, out.Height, want[0], want[1], want[2])
+				}
+				cfg, err := jpeg.DecodeConfig(bytes.NewReader(out.Data))
+				if err != nil || cfg.Width != out.Width || cfg.Height != out.Height || out.Bytes != len(out.Data) {
+					t.Errorf("output %d encodes %dx%d (%v)", i, cfg.Width, cfg.Height, err)
+				}
+				if out.Format != "jpeg" || out.ContentType != "image/jpeg" || out.Ext != "jpg" {
+					t.Errorf("output %d format %s %s %s", i, out.Format, out.ContentType, out.Ext)
+				}
+			}
+		})
+	}
+}
+
+// pngHeader returns a PNG that claims w×h in its IHDR but carries almost no pixel data:
+// decoding it fully would allocate w*h*4 bytes.
+func pngHeader(t *testing.T, w, h uint32) []byte {
+	t.Helper()
+	data := encodePNG(t, image.NewGray(image.Rect(0, 0, 16, 16)))
+	// Signature (8) + length (4) + "IHDR" (4), lalu width dan height
+	binary.BigEndian.PutUint32(data[16:], w)
+	binary.BigEndian.PutUint32(data[20:], h)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestProcessLimits(t *testing.T) {
+	p := NewProcessor(JPEG{Quality: 80})
+	tests := []struct {
+		name   string
+		data   []byte
+		kind   string
+		err    error
+		status int
+	}{
+		{"too wide", pngHeader(t, maxDimension+1, 16), "avatar", ErrTooLarge, http.StatusRequestEntityTooLarge},
+		{"too tall", pngHeader(t, 16, maxDimension+1), "avatar", ErrTooLarge, http.StatusRequestEntityTooLarge},
+		// 7000×7000 = 49MP: tiap sisi di bawah maxDimension tetapi melewati maxPixels
+		{"decompression bomb", pngHeader(t, 7000, 7000), "thumbnail", ErrTooLarge, http.StatusRequestEntityTooLarge},
+		{"too small", encodePNG(t, image.NewGray(image.Rect(0, 0, minDimension-1, 100))), "thumbnail", ErrTooSmall, http.StatusBadRequest},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg" onload="alert(1)"></svg>`), "avatar", ErrUnsupportedType, http.StatusUnsupportedMediaType},
+		{"corrupt png", encodePNG(t, image.NewGray(image.Rect(0, 0, 32, 32)))[:40], "avatar", ErrUnsupportedType, http.StatusUnsupportedMediaType},
+		{"unknown kind", encodePNG(t, image.NewGray(image.Rect(0, 0, 32, 32))), "banner", ErrUnknownKind, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.Process(context.Background(), tt.data, tt.kind)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if got := processStatus(err); got != tt.status {
+				t.Fatalf("status %d, want %d", got, tt.status)
+			}
+		})
+	}
+}
+
+func TestBlurhash(t *testing.T) {
+	solid := fill(32, 32, color.NRGBA{255, 0, 0, 255}, color.NRGBA{255, 0, 0, 255})
+	// 4×3 komponen: flag ukuran "L", lalu DC (warna rata-rata) merah
+	got := Blurhash(solid, 4, 3)
+	if len(got) != 28 || got[0] != 'L' || got[2:6] != encode83(0xFF0000, 4) {
+		t.Fatalf("Blurhash(solid red) = %q", got)
+	}
+	if again := Blurhash(solid, 4, 3); again != got {
+		t.Fatalf("Blurhash is not deterministic: %q, %q", got, again)
+	}
+
+	split := Blurhash(fill(32, 32, color.White, color.Black), 4, 3)
+	mirrored := Blurhash(fill(32, 32, color.Black, color.White), 4, 3)
+	if len(split) != 28 || split == mirrored || split[2:6] != mirrored[2:6] {
+		t.Fatalf("split %q, mirrored %q", split, mirrored)
+	}
+	if got := Blurhash(solid, 1, 1); len(got) != 6 || got[:2] != "00" {
+		t.Fatalf("Blurhash 1x1 = %q", got)
+	}
+}
@@ -0,0 +1,470 @@
+package newsletter
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log"
+	"math"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"biomu/backend/internal/email"
+	"biomu/backend/internal/profile"
+	"biomu/backend/internal/ratelimit"
+	"biomu/backend/internal/visitor"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+const (
+	subscribeMaxBytes = 4 << 10
+	maxEmailLen       = 254
+	// honeypotField: input tersembunyi di form; manusia tidak mengisinya, bot form biasanya iya
+	honeypotField = "website"
+	// Batas signup per IP dan per kreator (jumlah email konfirmasi yang bisa dipicu)
+	ipLimit     = 5
+	ownerLimit  = 100
+	limitPeriod = time.Hour
+)
+
+// Sessions resolves the signed-in caller (implemented by auth.Handler).
+type Sessions interface {
+	SessionUID(r *http.Request) string
+}
+
+type Handler struct {
+	store    *Store
+	profiles *profile.Store
+	sessions Sessions
+	email    email.Sender
+	baseURL  string
+	siteName string
+	byIP     *ratelimit.Limiter
+	byOwner  *ratelimit.Limiter
+	now      func() time.Time
+}
+
+// NewHandler creates the newsletter endpoints. baseURL is the public origin of confirmation
+// and unsubscribe links; without sender signups fail with 503.
+func NewHandler(store *Store, profiles *profile.Store, sessions Sessions, sender email.Sender, baseURL, siteName string) *Handler {
+	return &Handler{
+		store:    store,
+		profiles: profiles,
+		sessions: sessions,
+		email:    sender,
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		siteName: siteName,
+		byIP:     ratelimit.New(ipLimit, limitPeriod),
+		byOwner:  ratelimit.New(ownerLimit, limitPeriod),
+		now:      time.Now,
+	}
+}
+
+// Run garbage-collects the rate limit windows until ctx is done.
+func (h *Handler) Run(ctx context.Context) {
+	ticker := time.NewTicker(limitPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := h.now()
+			h.byIP.GC(now)
+			h.byOwner.GC(now)
+		}
+	}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+type subscribeInput struct {
+	Email   string `json:"email"`
+	Website string `json:"website"`
+}
+
+// POST /api/public/{handle}/subscribe — daftar newsletter kreator (JSON atau form dari halaman
+// bio). Selalu 202 {"status":"pending"} untuk alamat valid, terdaftar atau belum, supaya
+// endpoint ini tidak bisa dipakai mengecek siapa yang berlangganan.
+func (h *Handler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	form := !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json")
+	fail := func(status int, msg string) {
+		if form {
+			http.Error(w, msg, status)
+			return
+		}
+		h.writeJSON(w, status, map[string]string{"error": msg})
+	}
+	limited := func(wait time.Duration) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		fail(http.StatusTooManyRequests, "too many requests")
+	}
+
+	var in subscribeInput
+	r.Body = http.MaxBytesReader(w, r.Body, subscribeMaxBytes)
+	if form {
+		if err := r.ParseForm(); err != nil {
+			fail(http.StatusBadRequest, "invalid form")
+			return
+		}
+		in.Email, in.Website = r.PostForm.Get("email"), r.PostForm.Get(honeypotField)
+	} else if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		fail(http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	handle := profile.NormalizeHandle(r.PathValue("handle"))
+	if in.Website != "" {
+		// Bot: balas seolah berhasil supaya tidak mencoba cara lain
+		log.Printf("newsletter subscribe %s: honeypot filled from %s", handle, visitor.ClientIP(r))
+		h.subscribed(w, r, form)
+		return
+	}
+	if ok, wait := h.byIP.Take(visitor.RateKey(r), h.now()); !ok {
+		limited(wait)
+		return
+	}
+	addr, ok := validEmail(in.Email)
+	if !ok {
+		fail(http.StatusBadRequest, "a valid email is required")
+		return
+	}
+
+	ctx := r.Context()
+	var p *profile.Profile
+	var err error
+	if handle != "" {
+		p, err = h.profiles.FindByHandle(ctx, handle)
+	}
+	if err != nil {
+		log.Printf("newsletter subscribe %s: %v", handle, err)
+		fail(http.StatusInternalServerError, "failed to subscribe")
+		return
+	}
+	// Akun pending signup (belum punya role) atau tanpa blok newsletter aktif: tidak ada list
+	if p == nil || p.Data["role"] == nil || !FromProfile(p).Enabled {
+		fail(http.StatusNotFound, "newsletter not found")
+		return
+	}
+	if h.email == nil {
+		fail(http.StatusServiceUnavailable, "email is not configured")
+		return
+	}
+	if ok, wait := h.byOwner.Take(p.ID, h.now()); !ok {
+		limited(wait)
+		return
+	}
+
+	sub, send, err := h.store.Subscribe(ctx, p.ID, addr)
+	if err != nil {
+		log.Printf("newsletter subscribe %s: %v", p.ID, err)
+		fail(http.StatusInternalServerError, "failed to subscribe")
+		return
+	}
+	if send {
+		creator := p.DisplayName
+		if creator == "" {
+			creator = "@" + p.Handle
+		}
+		// Dikirim di belakang supaya waktu respons tidak membocorkan status alamat
+		go func(sub *Subscriber) {
+			if err := h.email.SendNewsletterConfirmation(sub.Email, creator, h.confirmURL(sub)); err != nil {
+				log.Printf("newsletter confirmation %s: %v", sub.ID, err)
+			}
+		}(sub)
+	}
+	h.subscribed(w, r, form)
+}
+
+func (h *Handler) subscribed(w http.ResponseWriter, r *http.Request, form bool) {
+	if form {
+		h.notice(w, r, http.StatusAccepted, noticeData{
+			Heading: "Cek email Anda",
+			Message: "Kami mengirim link konfirmasi. Langganan aktif setelah link itu dibuka.",
+		})
+		return
+	}
+	h.writeJSON(w, http.StatusAccepted, map[string]string{"status": StatusPending})
+}
+
+func validEmail(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || len(raw) > maxEmailLen {
+		return "", false
+	}
+	addr, err := mail.ParseAddress(raw)
+	if err != nil || addr.Address != raw {
+		return "", false
+	}
+	return raw, true
+}
+
+func (h *Handler) confirmURL(sub *Subscriber) string {
+	return h.baseURL + "/newsletter/confirm?token=" + url.QueryEscape(h.store.ConfirmToken(sub))
+}
+
+func (h *Handler) unsubscribeURL(sub *Subscriber) string {
+	return h.baseURL + "/newsletter/unsubscribe?token=" + url.QueryEscape(h.store.UnsubscribeToken(sub))
+}
+
+type noticeData struct {
+	SiteName    string
+	HomeURL     string
+	Heading     string
+	Message     string
+	Action      string
+	ActionLabel string
+}
+
+func (h *Handler) notice(w http.ResponseWriter, r *http.Request, status int, d noticeData) {
+	d.SiteName, d.HomeURL = h.siteName, h.baseURL+"/"
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, "notice.html", d); err != nil {
+		log.Printf("newsletter notice render: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	// Token ada di URL; jangan bocor lewat Referer
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(buf.Bytes())
+	}
+}
+
+func (h *Handler) invalidLink(w http.ResponseWriter, r *http.Request) {
+	h.notice(w, r, http.StatusNotFound, noticeData{
+		Heading: "Link tidak valid",
+		Message: "Link ini tidak valid atau sudah kedaluwarsa.",
+	})
+}
+
+// GET|POST /newsletter/confirm?token= — GET menampilkan tombol, POST mengonfirmasi. Pemindai
+// link di klien email hanya melakukan GET, jadi tidak ikut mengonfirmasi.
+func (h *Handler) Confirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token := r.URL.Query().Get("token")
+	id, err := h.store.CheckConfirmToken(token)
+	if err != nil {
+		h.invalidLink(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.notice(w, r, http.StatusOK, noticeData{
+			Heading:     "Konfirmasi langganan",
+			Message:     "Tekan tombol di bawah untuk mulai menerima newsletter.",
+			Action:      "/newsletter/confirm?token=" + url.QueryEscape(token),
+			ActionLabel: "Konfirmasi",
+		})
+		return
+	}
+	if _, err := h.store.Confirm(r.Context(), id); err != nil {
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrInvalidToken) {
+			h.invalidLink(w, r)
+			return
+		}
+		log.Printf("newsletter confirm %s: %v", id, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	h.notice(w, r, http.StatusOK, noticeData{
+		Heading: "Langganan aktif",
+		Message: "Terima kasih! Email Anda sudah terkonfirmasi.",
+	})
+}
+
+// GET|POST /newsletter/unsubscribe?token= — GET menampilkan tombol, POST berhenti berlangganan.
+// POST juga menerima one-click unsubscribe dari klien email (RFC 8058, header List-Unsubscribe-Post).
+func (h *Handler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token := r.URL.Query().Get("token")
+	id, err := h.store.CheckUnsubscribeToken(token)
+	if err != nil {
+		h.invalidLink(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		h.notice(w, r, http.StatusOK, noticeData{
+			Heading:     "Berhenti berlangganan",
+			Message:     "Anda tidak akan menerima newsletter ini lagi.",
+			Action:      "/newsletter/unsubscribe?token=" + url.QueryEscape(token),
+			ActionLabel: "Berhenti berlangganan",
+		})
+		return
+	}
+	if _, err := h.store.Unsubscribe(r.Context(), id); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			h.invalidLink(w, r)
+			return
+		}
+		log.Printf("newsletter unsubscribe %s: %v", id, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	h.notice(w, r, http.StatusOK, noticeData{
+		Heading: "Berhenti berlangganan",
+		Message: "Email Anda sudah dihapus dari daftar newsletter ini.",
+	})
+}
+
+// subscriberView is a subscriber with its unsubscribe link, as listed to the creator.
+type subscriberView struct {
+	*Subscriber
+	UnsubscribeURL string `json:"unsubscribeUrl"`
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request, status string) (string, []*Subscriber, bool) {
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return "", nil, false
+	}
+	switch status {
+	case "", StatusPending, StatusConfirmed, StatusUnsubscribed:
+	default:
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "status must be pending, confirmed or unsubscribed"})
+		return "", nil, false
+	}
+	subs, err := h.store.List(r.Context(), uid, status)
+	if err != nil {
+		log.Printf("newsletter subscribers %s: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load subscribers"})
+		return "", nil, false
+	}
+	return uid, subs, true
+}
+
+// GET /api/newsletter/subscribers?status= — subscriber milik caller, terbaru dulu, dengan
+// jumlah per status
+func (h *Handler) Subscribers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	_, subs, ok := h.list(w, r, r.URL.Query().Get("status"))
+	if !ok {
+		return
+	}
+	counts := map[string]int{StatusPending: 0, StatusConfirmed: 0, StatusUnsubscribed: 0}
+	out := make([]subscriberView, 0, len(subs))
+	for _, sub := range subs {
+		counts[sub.Status]++
+		out = append(out, subscriberView{Subscriber: sub, UnsubscribeURL: h.unsubscribeURL(sub)})
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{"subscribers": out, "counts": counts})
+}
+
+// GET /api/newsletter/subscribers.csv?status= — ekspor CSV (default hanya yang confirmed,
+// ?status=all untuk semua); kolom unsubscribe_url dipasang di setiap email yang dikirim kreator
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = StatusConfirmed
+	case "all":
+		status = ""
+	}
+	uid, subs, ok := h.list(w, r, status)
+	if !ok {
+		return
+	}
+	body, err := h.exportCSV(subs)
+	if err != nil {
+		log.Printf("newsletter export %s: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to export subscribers"})
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="subscribers.csv"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+func (h *Handler) exportCSV(subs []*Subscriber) ([]byte, error) {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	_ = cw.Write([]string{"email", "status", "created_at", "confirmed_at", "unsubscribed_at", "unsubscribe_url"})
+	for _, sub := range subs {
+		_ = cw.Write([]string{
+			csvCell(sub.Email),
+			sub.Status,
+			csvTime(sub.CreatedAt),
+			csvTime(sub.ConfirmedAt),
+			csvTime(sub.UnsubscribedAt),
+			h.unsubscribeURL(sub),
+		})
+	}
+	cw.Flush()
+	return buf.Bytes(), cw.Error()
+}
+
+// csvCell neutralizes values a spreadsheet would evaluate as a formula (CSV injection).
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func csvTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// DELETE /api/newsletter/subscribers/{id} — hapus subscriber dari list caller
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	id := r.PathValue("id")
+	if err := h.store.Delete(r.Context(), uid, id); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			h.writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		log.Printf("newsletter delete %s/%s: %v", uid, id, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete subscriber"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,108 @@
+package newsletter
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCSVCell(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"fan@example.com", "fan@example.com"},
+		{"", ""},
+		{"=HYPERLINK(\"http://x\")@example.com", "'=HYPERLINK(\"http://x\")@example.com"},
+		{"+1@example.com", "'+1@example.com"},
+		{"-2+3@example.com", "'-2+3@example.com"},
+		{"@SUM(A1)@example.com", "'@SUM(A1)@example.com"},
+		{"\t=1@example.com", "'\t=1@example.com"},
+		{"\r=1@example.com", "'\r=1@example.com"},
+		// Hanya karakter pertama yang dievaluasi spreadsheet
+		{"fan=1@example.com", "fan=1@example.com"},
+	}
+	for _, tt := range tests {
+		if got := csvCell(tt.in); got != tt.want {
+			t.Errorf("csvCell(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	store := NewStore(nil, "subscribers", []byte("secret"))
+	h := NewHandler(store, nil, nil, nil, "https://aether.bio", "Aether")
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.FixedZone("WIB", 7*3600))
+	subs := []*Subscriber{
+		{ID: "s1", Email: "fan@example.com", Status: StatusConfirmed, CreatedAt: created, ConfirmedAt: created.Add(time.Hour)},
+		{ID: "s2", Email: "=cmd|'/c calc'!A1@example.com", Status: StatusUnsubscribed, CreatedAt: created, UnsubscribedAt: created.Add(2 * time.Hour)},
+	}
+	body, err := h.exportCSV(subs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(string(body))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || strings.Join(rows[0], ",") != "email,status,created_at,confirmed_at,unsubscribed_at,unsubscribe_url" {
+		t.Fatalf("rows = %q", rows)
+	}
+	want := []string{"fan@example.com", StatusConfirmed, "2026-03-01T05:00:00Z", "2026-03-01T06:00:00Z", ""}
+	if strings.Join(rows[1][:5], "|") != strings.Join(want, "|") {
+		t.Fatalf("row 1 = %q, want %q", rows[1][:5], want)
+	}
+	if rows[2][0] != "'=cmd|'/c calc'!A1@example.com" {
+		t.Fatalf("formula email not neutralized: %q", rows[2][0])
+	}
+	// Link unsubscribe di ekspor valid untuk subscriber yang bersangkutan
+	u, err := url.Parse(rows[2][5])
+	if err != nil || !strings.HasPrefix(rows[2][5], "https://aether.bio/newsletter/unsubscribe?token=") {
+		t.Fatalf("unsubscribe url = %q", rows[2][5])
+	}
+	if id, err := store.CheckUnsubscribeToken(u.Query().Get("token")); err != nil || id != "s2" {
+		t.Fatalf("CheckUnsubscribeToken = %q, %v", id, err)
+	}
+}
+
+// GET hanya menampilkan tombol (pemindai link tidak ikut mengonfirmasi); token tidak valid atau
+// kedaluwarsa berhenti sebelum Firestore.
+func TestConfirmLink(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := NewStore(nil, "subscribers", []byte("secret"))
+	store.now = func() time.Time { return now }
+	h := NewHandler(store, nil, nil, nil, "https://aether.bio", "Aether")
+	sub := &Subscriber{ID: "s1", ConfirmSentAt: now}
+	token := url.QueryEscape(store.ConfirmToken(sub))
+
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		at     time.Time
+		want   int
+		body   string
+	}{
+		{"get shows button", http.MethodGet, token, now, http.StatusOK, `action="/newsletter/confirm?token=`},
+		{"expired", http.MethodPost, token, now.Add(confirmTTL + time.Second), http.StatusNotFound, ""},
+		{"tampered", http.MethodPost, strings.Replace(token, "s1", "s2", 1), now, http.StatusNotFound, ""},
+		{"unsubscribe token", http.MethodPost, url.QueryEscape(store.UnsubscribeToken(sub)), now, http.StatusNotFound, ""},
+		{"put", http.MethodPut, token, now, http.StatusMethodNotAllowed, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = tt.at
+			w := httptest.NewRecorder()
+			h.Confirm(w, httptest.NewRequest(tt.method, "/newsletter/confirm?token="+tt.token, nil))
+			if w.Code != tt.want {
+				t.Fatalf("status %d, want %d", w.Code, tt.want)
+			}
+			if tt.body != "" && !strings.Contains(w.Body.String(), tt.body) {
+				t.Fatalf("body does not contain %q", tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,383 @@
+// Package newsletter collects email subscribers from a signup block on the bio page: double
+// opt-in (konfirmasi lewat email), daftar subscriber per kreator dengan status
+// pending/confirmed/unsubscribed, ekspor CSV, dan token unsubscribe satu klik.
+package newsletter
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"biomu/backend/internal/firebase"
+	"biomu/backend/internal/profile"
+	"biomu/backend/internal/signing"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Subscriber statuses.
+const (
+	StatusPending      = "pending"
+	StatusConfirmed    = "confirmed"
+	StatusUnsubscribed = "unsubscribed"
+)
+
+const (
+	// confirmTTL: masa berlaku link konfirmasi; subscriber pending dihapus setelahnya
+	confirmTTL = 7 * 24 * time.Hour
+	// resendInterval: email konfirmasi tidak dikirim ulang lebih sering dari ini per alamat
+	resendInterval = 10 * time.Minute
+	sweepInterval  = 10 * time.Minute
+	listLimit      = 10000
+	maxTitleLen    = 80
+	maxDescLen     = 200
+)
+
+var (
+	ErrNotFound     = errors.New("subscriber not found")
+	ErrInvalidToken = errors.New("invalid or expired link")
+)
+
+// Settings is the signup block of an account (field "newsletter" di dokumen akun).
+type Settings struct {
+	Enabled     bool
+	Title       string
+	Description string
+}
+
+// FromProfile reads the signup block settings of p.
+func FromProfile(p *profile.Profile) Settings {
+	raw, _ := p.Data["newsletter"].(map[string]any)
+	if raw == nil {
+		return Settings{}
+	}
+	out := Settings{}
+	out.Enabled, _ = raw["enabled"].(bool)
+	if v, ok := raw["title"].(string); ok {
+		out.Title = truncate(strings.TrimSpace(v), maxTitleLen)
+	}
+	if v, ok := raw["description"].(string); ok {
+		out.Description = truncate(strings.TrimSpace(v), maxDescLen)
+	}
+	return out
+}
+
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
+
+// Subscriber is one email address on a creator's list.
+type Subscriber struct {
+	ID             string    `json:"id" firestore:"-"`
+	OwnerID        string    `json:"-" firestore:"ownerId"`
+	Email          string    `json:"email" firestore:"email"`
+	Status         string    `json:"status" firestore:"status"`
+	CreatedAt      time.Time `json:"createdAt" firestore:"createdAt"`
+	ConfirmSentAt  time.Time `json:"-" firestore:"confirmSentAt,omitempty"`
+	ConfirmedAt    time.Time `json:"confirmedAt,omitempty" firestore:"confirmedAt,omitempty"`
+	UnsubscribedAt time.Time `json:"unsubscribedAt,omitempty" firestore:"unsubscribedAt,omitempty"`
+	UpdatedAt      time.Time `json:"updatedAt" firestore:"updatedAt"`
+	// PendingUntil hanya terisi selama pending; dipakai sweeper untuk menghapus yang tidak dikonfirmasi
+	PendingUntil time.Time `json:"-" firestore:"pendingUntil,omitempty"`
+	// Sig: HMAC id/pemilik/email/status, supaya dokumen yang diubah di luar Store diabaikan
+	Sig string `json:"-" firestore:"sig"`
+}
+
+// Store keeps subscribers and signs confirmation and unsubscribe tokens.
+type Store struct {
+	fb   *firebase.App
+	coll string
+	key  *signing.Key
+	now  func() time.Time
+}
+
+// NewStore creates a subscriber store; keys are derived from secret (SESSION_SECRET).
+func NewStore(fb *firebase.App, coll string, secret []byte) *Store {
+	return &Store{fb: fb, coll: coll, key: signing.NewKey(secret, "biomu newsletter v1"), now: time.Now}
+}
+
+// subscriberID is deterministic per (owner, email) so one address is listed only once.
+func (s *Store) subscriberID(ownerID, addr string) string {
+	return s.key.Token("subscriber id", ownerID, strings.ToLower(addr))
+}
+
+func (s *Store) subscriberSig(sub *Subscriber) string {
+	return s.key.Sign(subscriberParts(sub)...)
+}
+
+func subscriberParts(sub *Subscriber) []string {
+	return []string{"subscriber", sub.ID, sub.OwnerID, strings.ToLower(sub.Email), sub.Status}
+}
+
+func (s *Store) fromDoc(doc *firestore.DocumentSnapshot) (*Subscriber, error) {
+	var sub Subscriber
+	if err := doc.DataTo(&sub); err != nil {
+		return nil, err
+	}
+	sub.ID = doc.Ref.ID
+	if !s.signed(&sub) {
+		return nil, ErrNotFound
+	}
+	return &sub, nil
+}
+
+// signed reports whether sub still matches the signature written by Store.
+func (s *Store) signed(sub *Subscriber) bool {
+	return s.key.Verify(sub.Sig, subscriberParts(sub)...)
+}
+
+// Subscribe adds addr to ownerID's list as pending. send reports whether a confirmation email
+// is due: alamat baru, alamat yang pernah berhenti berlangganan, atau pending yang email
+// terakhirnya lebih lama dari resendInterval. Alamat yang sudah confirmed tidak berubah.
+func (s *Store) Subscribe(ctx context.Context, ownerID, addr string) (sub *Subscriber, send bool, err error) {
+	ref := s.fb.DB.Collection(s.coll).Doc(s.subscriberID(ownerID, addr))
+	err = s.fb.DB.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		sub, send = nil, false
+		now := s.now().UTC()
+		doc, err := tx.Get(ref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			// Dokumen rusak/dipalsukan diperlakukan seperti belum ada
+			if sub, err = s.fromDoc(doc); err != nil {
+				sub = nil
+			}
+		}
+		if sub == nil {
+			sub = &Subscriber{ID: ref.ID, OwnerID: ownerID, Email: addr, CreatedAt: now}
+		}
+		if send = subscribe(sub, now); !send {
+			return nil
+		}
+		sub.Sig = s.subscriberSig(sub)
+		return tx.Set(ref, sub)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return sub, send, nil
+}
+
+// subscribe moves sub (baru, pending, atau unsubscribed) to pending and reports whether a
+// confirmation email is due. Confirmed subscribers and pending ones emailed less than
+// resendInterval ago are left unchanged.
+func subscribe(sub *Subscriber, now time.Time) bool {
+	switch {
+	case sub.Status == StatusConfirmed:
+		return false
+	case sub.Status == StatusPending && now.Sub(sub.ConfirmSentAt) < resendInterval:
+		return false
+	}
+	sub.Status, sub.ConfirmSentAt, sub.PendingUntil = StatusPending, now, now.Add(confirmTTL)
+	sub.UnsubscribedAt, sub.UpdatedAt = time.Time{}, now
+	return true
+}
+
+// Confirm marks a pending subscriber as confirmed (idempoten untuk yang sudah confirmed).
+func (s *Store) Confirm(ctx context.Context, id string) (*Subscriber, error) {
+	return s.update(ctx, id, confirm)
+}
+
+func confirm(sub *Subscriber, now time.Time) (bool, error) {
+	switch sub.Status {
+	case StatusConfirmed:
+		return false, nil
+	case StatusPending:
+		sub.Status, sub.ConfirmedAt, sub.PendingUntil = StatusConfirmed, now, time.Time{}
+		return true, nil
+	}
+	// Sudah berhenti berlangganan: link konfirmasi lama tidak boleh mendaftarkan ulang
+	return false, ErrInvalidToken
+}
+
+// Unsubscribe removes a subscriber from the mailing list; the entry is kept so the creator
+// knows not to email the address again.
+func (s *Store) Unsubscribe(ctx context.Context, id string) (*Subscriber, error) {
+	return s.update(ctx, id, unsubscribe)
+}
+
+func unsubscribe(sub *Subscriber, now time.Time) (bool, error) {
+	if sub.Status == StatusUnsubscribed {
+		return false, nil
+	}
+	sub.Status, sub.UnsubscribedAt, sub.PendingUntil = StatusUnsubscribed, now, time.Time{}
+	return true, nil
+}
+
+func (s *Store) update(ctx context.Context, id string, fn func(sub *Subscriber, now time.Time) (bool, error)) (*Subscriber, error) {
+	ref := s.fb.DB.Collection(s.coll).Doc(id)
+	var sub *Subscriber
+	err := s.fb.DB.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if sub, err = s.fromDoc(doc); err != nil {
+			return err
+		}
+		now := s.now().UTC()
+		changed, err := fn(sub, now)
+		if err != nil || !changed {
+			return err
+		}
+		sub.UpdatedAt = now
+		sub.Sig = s.subscriberSig(sub)
+		return tx.Set(ref, sub)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// List returns the subscribers of ownerID, newest first; status "" means all.
+func (s *Store) List(ctx context.Context, ownerID, status string) ([]*Subscriber, error) {
+	q := s.fb.DB.Collection(s.coll).Where("ownerId", "==", ownerID)
+	if status != "" {
+		q = q.Where("status", "==", status)
+	}
+	it := q.Limit(listLimit).Documents(ctx)
+	defer it.Stop()
+	out := []*Subscriber{}
+	for {
+		doc, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		sub, err := s.fromDoc(doc)
+		if err != nil {
+			continue
+		}
+		out = append(out, sub)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+// Delete removes a subscriber of ownerID entirely (mis. permintaan hapus data).
+func (s *Store) Delete(ctx context.Context, ownerID, id string) error {
+	ref := s.fb.DB.Collection(s.coll).Doc(id)
+	doc, err := ref.Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	sub, err := s.fromDoc(doc)
+	if err != nil || sub.OwnerID != ownerID {
+		return ErrNotFound
+	}
+	_, err = ref.Delete(ctx)
+	return err
+}
+
+// ConfirmToken returns the token of the confirmation link sent to sub ("id.exp.sig").
+func (s *Store) ConfirmToken(sub *Subscriber) string {
+	exp := strconv.FormatInt(sub.ConfirmSentAt.Add(confirmTTL).Unix(), 10)
+	return sub.ID + "." + exp + "." + s.key.Token("confirm", sub.ID, exp)
+}
+
+// CheckConfirmToken returns the subscriber ID of a valid, unexpired confirmation token.
+func (s *Store) CheckConfirmToken(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", ErrInvalidToken
+	}
+	if !s.key.VerifyToken(parts[2], "confirm", parts[0], parts[1]) {
+		return "", ErrInvalidToken
+	}
+	exp, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || s.now().Unix() > exp {
+		return "", ErrInvalidToken
+	}
+	return parts[0], nil
+}
+
+// UnsubscribeToken returns the permanent unsubscribe token of sub ("id.sig"), untuk dipasang
+// kreator di setiap email yang dikirim ke alamat ini.
+func (s *Store) UnsubscribeToken(sub *Subscriber) string {
+	return sub.ID + "." + s.key.Token("unsubscribe", sub.ID)
+}
+
+// CheckUnsubscribeToken returns the subscriber ID of a valid unsubscribe token.
+func (s *Store) CheckUnsubscribeToken(token string) (string, error) {
+	id, sig, ok := strings.Cut(token, ".")
+	if !ok || !s.key.VerifyToken(sig, "unsubscribe", id) {
+		return "", ErrInvalidToken
+	}
+	return id, nil
+}
+
+// Run deletes pending subscribers that never confirmed, every sweepInterval until ctx is done.
+func (s *Store) Run(ctx context.Context) {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.sweep(ctx); err != nil {
+			log.Printf("newsletter sweep: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Store) sweep(ctx context.Context) error {
+	it := s.fb.DB.Collection(s.coll).Where("pendingUntil", "<=", s.now().UTC()).Limit(200).Documents(ctx)
+	defer it.Stop()
+	for {
+		doc, err := it.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.expire(ctx, doc.Ref); err != nil {
+			log.Printf("newsletter sweep %s: %v", doc.Ref.ID, err)
+		}
+	}
+}
+
+// expire deletes a subscriber that is still pending past its deadline (dicek ulang di dalam transaksi).
+func (s *Store) expire(ctx context.Context, ref *firestore.DocumentRef) error {
+	return s.fb.DB.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		sub, err := s.fromDoc(doc)
+		if err != nil {
+			// Dokumen tanpa tanda tangan valid tidak pernah tampil; bersihkan saja
+			return tx.Delete(ref)
+		}
+		if sub.Status != StatusPending || sub.PendingUntil.After(s.now().UTC()) {
+			return nil
+		}
+		return tx.Delete(ref)
+	})
+}
@@ -0,0 +1,163 @@
+package newsletter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokens(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := NewStore(nil, "subscribers", []byte("secret"))
+	s.now = func() time.Time { return now }
+	sub := &Subscriber{ID: s.subscriberID("u1", "Fan@Example.com"), OwnerID: "u1", Email: "fan@example.com", ConfirmSentAt: now}
+
+	if sub.ID != s.subscriberID("u1", "fan@example.com") || sub.ID == s.subscriberID("u2", "fan@example.com") {
+		t.Fatal("subscriber ID is not per (owner, lowercase email)")
+	}
+
+	confirm := s.ConfirmToken(sub)
+	unsub := s.UnsubscribeToken(sub)
+	parts := strings.Split(confirm, ".")
+	forged := parts[0] + "." + "9999999999" + "." + parts[2]
+
+	tests := []struct {
+		name  string
+		check func(string) (string, error)
+		token string
+		at    time.Time
+		ok    bool
+	}{
+		{"confirm", s.CheckConfirmToken, confirm, now, true},
+		{"confirm at expiry", s.CheckConfirmToken, confirm, now.Add(confirmTTL), true},
+		{"confirm expired", s.CheckConfirmToken, confirm, now.Add(confirmTTL + time.Second), false},
+		{"confirm with extended expiry", s.CheckConfirmToken, forged, now, false},
+		{"confirm other id", s.CheckConfirmToken, "other." + parts[1] + "." + parts[2], now, false},
+		{"confirm flipped signature", s.CheckConfirmToken, parts[0] + "." + parts[1] + "." + flip(parts[2]), now, false},
+		{"confirm bad expiry", s.CheckConfirmToken, parts[0] + ".soon." + parts[2], now, false},
+		{"confirm extra part", s.CheckConfirmToken, confirm + ".x", now, false},
+		{"unsubscribe as confirm", s.CheckConfirmToken, unsub, now, false},
+		{"unsubscribe", s.CheckUnsubscribeToken, unsub, now.Add(365 * 24 * time.Hour), true},
+		{"unsubscribe other id", s.CheckUnsubscribeToken, "other" + unsub[strings.Index(unsub, "."):], now, false},
+		{"confirm as unsubscribe", s.CheckUnsubscribeToken, parts[0] + "." + parts[2], now, false},
+		{"unsubscribe flipped signature", s.CheckUnsubscribeToken, sub.ID + "." + flip(unsub[strings.Index(unsub, ".")+1:]), now, false},
+		{"garbage", s.CheckUnsubscribeToken, "nope", now, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := tt.at
+			s.now = func() time.Time { return at }
+			id, err := tt.check(tt.token)
+			if tt.ok && (err != nil || id != sub.ID) {
+				t.Fatalf("got %q, %v; want %q", id, err, sub.ID)
+			}
+			if !tt.ok && !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("got %q, %v; want ErrInvalidToken", id, err)
+			}
+		})
+	}
+}
+
+// flip changes the last hex digit of sig.
+func flip(sig string) string {
+	last := sig[len(sig)-1]
+	if last == '0' {
+		return sig[:len(sig)-1] + "1"
+	}
+	return sig[:len(sig)-1] + "0"
+}
+
+// Double opt-in: pending → confirmed → unsubscribed, lalu daftar ulang kembali ke pending.
+func TestTransitions(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	sub := &Subscriber{ID: "s1", OwnerID: "u1", Email: "fan@example.com", CreatedAt: t0}
+
+	if !subscribe(sub, t0) || sub.Status != StatusPending || !sub.PendingUntil.Equal(t0.Add(confirmTTL)) {
+		t.Fatalf("new subscriber: %+v", sub)
+	}
+	// Daftar ulang saat pending: email konfirmasi dibatasi resendInterval
+	if subscribe(sub, t0.Add(resendInterval-time.Second)) {
+		t.Fatal("confirmation resent within resendInterval")
+	}
+	t1 := t0.Add(resendInterval)
+	if !subscribe(sub, t1) || !sub.ConfirmSentAt.Equal(t1) || !sub.PendingUntil.Equal(t1.Add(confirmTTL)) {
+		t.Fatalf("resend after resendInterval: %+v", sub)
+	}
+
+	t2 := t1.Add(time.Hour)
+	if changed, err := confirm(sub, t2); !changed || err != nil {
+		t.Fatalf("confirm = %v, %v", changed, err)
+	}
+	if sub.Status != StatusConfirmed || !sub.ConfirmedAt.Equal(t2) || !sub.PendingUntil.IsZero() {
+		t.Fatalf("confirmed: %+v", sub)
+	}
+	// Idempoten; daftar ulang tidak menurunkan status ke pending
+	if changed, err := confirm(sub, t2.Add(time.Minute)); changed || err != nil || !sub.ConfirmedAt.Equal(t2) {
+		t.Fatalf("second confirm = %v, %v", changed, err)
+	}
+	if subscribe(sub, t2.Add(time.Hour)) || sub.Status != StatusConfirmed {
+		t.Fatal("subscribe changed a confirmed subscriber")
+	}
+
+	t3 := t2.Add(24 * time.Hour)
+	if changed, err := unsubscribe(sub, t3); !changed || err != nil {
+		t.Fatalf("unsubscribe = %v, %v", changed, err)
+	}
+	if sub.Status != StatusUnsubscribed || !sub.UnsubscribedAt.Equal(t3) {
+		t.Fatalf("unsubscribed: %+v", sub)
+	}
+	if changed, err := unsubscribe(sub, t3.Add(time.Minute)); changed || err != nil {
+		t.Fatalf("second unsubscribe = %v, %v", changed, err)
+	}
+	// Link konfirmasi lama tidak boleh mendaftarkan ulang
+	if changed, err := confirm(sub, t3.Add(time.Minute)); changed || !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("confirm after unsubscribe = %v, %v", changed, err)
+	}
+
+	// Daftar ulang lewat form: kembali pending dan butuh konfirmasi baru
+	t4 := t3.Add(time.Hour)
+	if !subscribe(sub, t4) || sub.Status != StatusPending || !sub.UnsubscribedAt.IsZero() {
+		t.Fatalf("resubscribe: %+v", sub)
+	}
+
+	// Pending bisa langsung berhenti berlangganan
+	pending := &Subscriber{ID: "s2"}
+	subscribe(pending, t0)
+	if changed, _ := unsubscribe(pending, t1); !changed || !pending.PendingUntil.IsZero() {
+		t.Fatalf("unsubscribe pending: %+v", pending)
+	}
+}
+
+// Dokumen subscriber yang diubah di luar Store (mis. status atau pemilik) tidak lagi valid.
+func TestSigned(t *testing.T) {
+	s := NewStore(nil, "subscribers", []byte("secret"))
+	sub := &Subscriber{ID: "s1", OwnerID: "u1", Email: "Fan@Example.com", Status: StatusPending}
+	sub.Sig = s.subscriberSig(sub)
+	if !s.signed(sub) {
+		t.Fatal("fresh signature rejected")
+	}
+	tests := []struct {
+		name   string
+		tamper func(sub *Subscriber)
+		ok     bool
+	}{
+		{"email case", func(sub *Subscriber) { sub.Email = "fan@example.com" }, true},
+		{"timestamps", func(sub *Subscriber) { sub.CreatedAt = time.Now() }, true},
+		{"status", func(sub *Subscriber) { sub.Status = StatusConfirmed }, false},
+		{"owner", func(sub *Subscriber) { sub.OwnerID = "u2" }, false},
+		{"email", func(sub *Subscriber) { sub.Email = "other@example.com" }, false},
+		{"id", func(sub *Subscriber) { sub.ID = "s2" }, false},
+		{"signature", func(sub *Subscriber) { sub.Sig = flip(sub.Sig) }, false},
+		{"other key", func(sub *Subscriber) { sub.Sig = NewStore(nil, "subscribers", []byte("other")).subscriberSig(sub) }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := *sub
+			tt.tamper(&c)
+			if got := s.signed(&c); got != tt.ok {
+				t.Fatalf("signed = %v, want %v", got, tt.ok)
+			}
+		})
+	}
+}
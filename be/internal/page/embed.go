@@ -0,0 +1,309 @@
+package page
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"biomu/backend/internal/profile"
+	"biomu/backend/internal/public"
+	"biomu/backend/internal/targeting"
+)
+
+const (
+	LayoutCompact = "compact"
+	LayoutFull    = "full"
+
+	// Ukuran awal iframe sebelum script widget mengirim tinggi sebenarnya
+	embedWidth         = 360
+	embedHeightCompact = 240
+	embedHeightFull    = 560
+	oembedCacheAge     = 3600
+)
+
+// embedResizeScript runs inside the widget and reports its height to the embedding page.
+// Iframe di-sandbox tanpa allow-same-origin, jadi parent hanya bisa tahu tinggi lewat postMessage.
+const embedResizeScript = `(function(){var last=0;function send(){var h=Math.ceil(document.documentElement.getBoundingClientRect().height);if(h!==last){last=h;parent.postMessage({type:"biomu:embed-resize",height:h},"*")}}if(window.ResizeObserver){new ResizeObserver(send).observe(document.documentElement)}addEventListener("load",send);send()})();`
+
+// embedHostScript (/embed.js) runs on the embedding page and applies the reported heights to
+// the iframe that sent them; pesan dari origin lain diabaikan.
+const embedHostScript = `(function(){if(window.__biomuEmbed)return;window.__biomuEmbed=1;var s=document.currentScript,origin=s?new URL(s.src).origin:"";addEventListener("message",function(e){var d=e.data;if(e.origin!==origin||!d||d.type!=="biomu:embed-resize"||typeof d.height!=="number")return;var f=document.getElementsByTagName("iframe");for(var i=0;i<f.length;i++){if(f[i].contentWindow===e.source){f[i].style.height=Math.max(0,Math.min(d.height,4000))+"px";return}}})})();
+`
+
+// embedCSP allows the widget to be framed anywhere while blocking everything it does not
+// need; script inline diizinkan lewat hash, bukan 'unsafe-inline'.
+var embedCSP = func() string {
+	sum := sha256.Sum256([]byte(embedResizeScript))
+	return "default-src 'none'; img-src 'self' https: data:; style-src 'unsafe-inline'; script-src 'sha256-" +
+		base64.StdEncoding.EncodeToString(sum[:]) + "'; base-uri 'none'; form-action 'none'; frame-ancestors *"
+}()
+
+type embedData struct {
+	SiteName   string
+	HomeURL    string
+	ProfileURL string
+	Handle     string
+	Name       string
+	Bio        string
+	Image      string
+	Layout     string
+	Links      []pageLink
+	Theme      Theme
+	Script     template.JS
+}
+
+// GET /embed/{handle}?layout=compact|full — widget profil untuk iframe di situs lain. Compact
+// berisi avatar, nama dan bio singkat; full menambahkan daftar link. Link dibuka di tab baru.
+func (h *Handler) Embed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	handle := profile.NormalizeHandle(r.PathValue("handle"))
+	if handle == "" {
+		http.NotFound(w, r)
+		return
+	}
+	p, err := h.profiles.FindByHandle(ctx, handle)
+	if err != nil {
+		log.Printf("page embed %s: %v", handle, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if p == nil {
+		w.Header().Set("Cache-Control", "public, max-age=30")
+		http.NotFound(w, r)
+		return
+	}
+
+	name := p.DisplayName
+	if name == "" {
+		name = "@" + p.Handle
+	}
+	d := embedData{
+		SiteName:   h.siteName,
+		HomeURL:    h.baseURL + "/",
+		ProfileURL: h.canonicalURL(ctx, p),
+		Handle:     p.Handle,
+		Name:       name,
+		Bio:        p.Bio,
+		Image:      p.Image,
+		Layout:     embedLayout(r.URL.Query().Get("layout")),
+		Theme:      h.themeFor(ctx, p),
+		Script:     template.JS(embedResizeScript),
+	}
+	if d.Layout == LayoutCompact {
+		d.Bio = truncate(strings.Join(strings.Fields(p.Bio), " "), descriptionMaxLen)
+	} else {
+		links, err := h.profiles.Links(ctx, p.ID)
+		if err != nil {
+			log.Printf("page embed %s links: %v", handle, err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		for _, l := range profile.PublicLinks(links, time.Now()) {
+			// Absolut ke origin platform: klik tetap tercatat lewat /r/{id} atau /go/{id}
+			d.Links = append(d.Links, pageLink{Title: l.Title, Href: h.baseURL + targeting.Path(l)})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, "embed.html", d); err != nil {
+		log.Printf("page embed %s render: %v", handle, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Security-Policy", embedCSP)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
+	public.WriteCached(w, r, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// GET /embed.js — script untuk halaman yang memasang widget: menyesuaikan tinggi iframe
+func (h *Handler) EmbedScript(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	public.WriteCached(w, r, "text/javascript; charset=utf-8", []byte(embedHostScript))
+}
+
+func embedLayout(s string) string {
+	if s == LayoutFull {
+		return LayoutFull
+	}
+	return LayoutCompact
+}
+
+// oembedResponse is a "rich" oEmbed response (oEmbed 1.0 section 2.3.4).
+type oembedResponse struct {
+	XMLName      xml.Name `json:"-" xml:"oembed"`
+	Type         string   `json:"type" xml:"type"`
+	Version      string   `json:"version" xml:"version"`
+	Title        string   `json:"title" xml:"title"`
+	AuthorName   string   `json:"author_name" xml:"author_name"`
+	AuthorURL    string   `json:"author_url" xml:"author_url"`
+	ProviderName string   `json:"provider_name" xml:"provider_name"`
+	ProviderURL  string   `json:"provider_url" xml:"provider_url"`
+	CacheAge     int      `json:"cache_age" xml:"cache_age"`
+	HTML         string   `json:"html" xml:"html"`
+	Width        int      `json:"width" xml:"width"`
+	Height       int      `json:"height" xml:"height"`
+}
+
+// GET /oembed?url=&format=json|xml&maxwidth=&maxheight=&layout=compact|full — oEmbed provider
+// untuk URL profil (https://<host platform>/{handle} atau custom domain terverifikasi)
+func (h *Handler) OEmbed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	format := q.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "xml" {
+		// oEmbed 2.3.5: format yang tidak didukung → 501
+		http.Error(w, "Not Implemented", http.StatusNotImplemented)
+		return
+	}
+	maxWidth, okWidth := oembedDimension(q.Get("maxwidth"))
+	maxHeight, okHeight := oembedDimension(q.Get("maxheight"))
+	if q.Get("url") == "" || !okWidth || !okHeight {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	handle, err := h.handleFromURL(ctx, q.Get("url"))
+	if err != nil {
+		log.Printf("page oembed %s: %v", q.Get("url"), err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	var p *profile.Profile
+	if handle != "" {
+		if p, err = h.profiles.FindByHandle(ctx, handle); err != nil {
+			log.Printf("page oembed %s: %v", handle, err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	}
+	if p == nil {
+		w.Header().Set("Cache-Control", "public, max-age=30")
+		http.NotFound(w, r)
+		return
+	}
+
+	layout := embedLayout(q.Get("layout"))
+	width, height := embedWidth, embedHeightCompact
+	if layout == LayoutFull {
+		height = embedHeightFull
+	}
+	// Widget responsif, jadi maxwidth/maxheight cukup memperkecil ukuran awal iframe
+	if maxWidth > 0 && width > maxWidth {
+		width = maxWidth
+	}
+	if maxHeight > 0 && height > maxHeight {
+		height = maxHeight
+	}
+
+	name := p.DisplayName
+	if name == "" {
+		name = "@" + p.Handle
+	}
+	src := h.baseURL + "/embed/" + url.PathEscape(p.Handle)
+	if layout != LayoutCompact {
+		src += "?layout=" + layout
+	}
+	iframe := `<iframe src="` + template.HTMLEscapeString(src) + `" width="` + strconv.Itoa(width) + `" height="` + strconv.Itoa(height) +
+		`" title="` + template.HTMLEscapeString(name+" · "+h.siteName) + `" sandbox="allow-scripts allow-popups allow-popups-to-escape-sandbox" loading="lazy" style="border:0;max-width:100%"></iframe>` +
+		`<script async src="` + template.HTMLEscapeString(h.baseURL+"/embed.js") + `"></script>`
+	resp := oembedResponse{
+		Type:         "rich",
+		Version:      "1.0",
+		Title:        name + " (@" + p.Handle + ")",
+		AuthorName:   name,
+		AuthorURL:    h.canonicalURL(ctx, p),
+		ProviderName: h.siteName,
+		ProviderURL:  h.baseURL + "/",
+		CacheAge:     oembedCacheAge,
+		HTML:         iframe,
+		Width:        width,
+		Height:       height,
+	}
+
+	// Data publik: boleh dibaca dari origin mana pun tanpa cookie
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Del("Access-Control-Allow-Credentials")
+	if format == "xml" {
+		body, err := xml.Marshal(resp)
+		if err != nil {
+			log.Printf("page oembed %s xml: %v", handle, err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		public.WriteCached(w, r, "text/xml; charset=utf-8", append([]byte(`<?xml version="1.0" encoding="utf-8" standalone="yes"?>`+"\n"), body...))
+		return
+	}
+	body, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("page oembed %s json: %v", handle, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	public.WriteCached(w, r, "application/json; charset=utf-8", body)
+}
+
+// handleFromURL resolves a profile URL to a handle, or "" when raw is not a profile URL of
+// this platform: "/{handle}" di host platform, atau "/" di custom domain terverifikasi.
+func (h *Handler) handleFromURL(ctx context.Context, raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", nil
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	if h.domains.IsPlatform(u.Host) {
+		if path == "" || strings.Count(path, "/") != 1 {
+			return "", nil
+		}
+		return profile.NormalizeHandle(path[1:]), nil
+	}
+	if path != "" {
+		return "", nil
+	}
+	route, err := h.domains.Lookup(ctx, u.Host)
+	if err != nil || route == nil {
+		return "", err
+	}
+	return route.Handle, nil
+}
+
+// oembedDimension parses maxwidth/maxheight; kosong berarti tanpa batas.
+func oembedDimension(s string) (int, bool) {
+	if s == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil && n > 0
+}
+
+// oembedURL is the oEmbed discovery URL of profileURL in format ("json" atau "xml").
+func (h *Handler) oembedURL(profileURL, format string) string {
+	return h.baseURL + "/oembed?" + url.Values{"url": {profileURL}, "format": {format}}.Encode()
+}
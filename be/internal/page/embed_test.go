@@ -0,0 +1,119 @@
+package page
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"biomu/backend/internal/domain"
+)
+
+func newEmbedHandler() *Handler {
+	domains := domain.NewStore(nil, "domains", nil, nil, []byte("secret"), []string{"aether.bio"})
+	return NewHandler(nil, nil, nil, domains, nil, "https://aether.bio/", "Aether")
+}
+
+// Kasus custom domain di root butuh lookup domain (Firestore) dan dicakup test router domain.
+func TestHandleFromURL(t *testing.T) {
+	h := newEmbedHandler()
+	tests := []struct {
+		raw, want string
+	}{
+		{"https://aether.bio/aether", "aether"},
+		{"https://aether.bio/Aether/", "aether"},
+		{"https://aether.bio/@aether", "aether"},
+		{" http://www.aether.bio/aether ", "aether"},
+		{"https://aether.bio/aether?utm_source=blog#links", "aether"},
+		{"http://localhost:8080/aether", "aether"},
+		{"https://aether.bio/", ""},
+		{"https://aether.bio", ""},
+		{"https://aether.bio/aether/links", ""},
+		{"https://aether.bio//aether", ""},
+		{"ftp://aether.bio/aether", ""},
+		{"aether.bio/aether", ""},
+		{"/aether", ""},
+		{"javascript:alert(1)", ""},
+		{"https://%zz/aether", ""},
+		// Custom domain hanya melayani profil di "/"
+		{"https://links.brand.com/aether", ""},
+	}
+	for _, tt := range tests {
+		got, err := h.handleFromURL(context.Background(), tt.raw)
+		if err != nil {
+			t.Errorf("handleFromURL(%q): %v", tt.raw, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("handleFromURL(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestOEmbedDimension(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+		ok   bool
+	}{
+		{"", 0, true},
+		{"320", 320, true},
+		{"0", 0, false},
+		{"-10", -10, false},
+		{"12.5", 0, false},
+		{"abc", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := oembedDimension(tt.in)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("oembedDimension(%q) = %d, %v, want %d, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// Semua kasus di sini berhenti sebelum lookup profil.
+func TestOEmbedRejectsEarly(t *testing.T) {
+	h := newEmbedHandler()
+	profileURL := url.QueryEscape("https://aether.bio/aether")
+	tests := []struct {
+		name   string
+		method string
+		query  string
+		want   int
+	}{
+		{"post", http.MethodPost, "url=" + profileURL, http.StatusMethodNotAllowed},
+		{"unsupported format", http.MethodGet, "url=" + profileURL + "&format=yaml", http.StatusNotImplemented},
+		{"missing url", http.MethodGet, "format=json", http.StatusBadRequest},
+		{"zero maxwidth", http.MethodGet, "url=" + profileURL + "&maxwidth=0", http.StatusBadRequest},
+		{"bad maxheight", http.MethodGet, "url=" + profileURL + "&maxheight=tall", http.StatusBadRequest},
+		{"not a profile url", http.MethodGet, "url=" + url.QueryEscape("https://aether.bio/aether/links"), http.StatusNotFound},
+		{"foreign scheme", http.MethodGet, "url=" + url.QueryEscape("ftp://aether.bio/aether") + "&format=xml", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.OEmbed(w, httptest.NewRequest(tt.method, "/oembed?"+tt.query, nil))
+			if w.Code != tt.want {
+				t.Fatalf("status %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbedLayout(t *testing.T) {
+	for in, want := range map[string]string{"": LayoutCompact, "compact": LayoutCompact, "full": LayoutFull, "FULL": LayoutCompact, "wide": LayoutCompact} {
+		if got := embedLayout(in); got != want {
+			t.Errorf("embedLayout(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestOEmbedURL(t *testing.T) {
+	h := newEmbedHandler()
+	got := h.oembedURL("https://aether.bio/aether", "xml")
+	want := "https://aether.bio/oembed?format=xml&url=https%3A%2F%2Faether.bio%2Faether"
+	if got != want {
+		t.Fatalf("oembedURL = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,523 @@
+package page
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"biomu/backend/internal/contact"
+	"biomu/backend/internal/domain"
+	"biomu/backend/internal/events"
+	"biomu/backend/internal/experiment"
+	"biomu/backend/internal/newsletter"
+	"biomu/backend/internal/profile"
+	"biomu/backend/internal/protect"
+	"biomu/backend/internal/public"
+	"biomu/backend/internal/sanitize"
+	"biomu/backend/internal/shop"
+	"biomu/backend/internal/targeting"
+	"biomu/backend/internal/theme"
+	"biomu/backend/internal/vcard"
+	"biomu/backend/internal/visitor"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+const descriptionMaxLen = 200
+
+// Handler merender halaman bio publik langsung dari backend supaya crawler
+// sosial media (yang tidak menjalankan JavaScript) tetap dapat preview.
+type Handler struct {
+	profiles *profile.Store
+	visitors *visitor.Identifier
+	themes   *theme.Store
+	domains  *domain.Store
+	shop     *shop.Store
+	baseURL  string
+	siteName string
+}
+
+// NewHandler creates a bio page renderer. baseURL is the public origin used for
+// canonical URLs (e.g. "https://aether.bio") of profiles without a verified custom domain.
+// shop may be nil when no payment provider is configured (blok produk/tip tidak dirender).
+func NewHandler(profiles *profile.Store, visitors *visitor.Identifier, themes *theme.Store, domains *domain.Store, shop *shop.Store, baseURL, siteName string) *Handler {
+	return &Handler{
+		profiles: profiles,
+		visitors: visitors,
+		themes:   themes,
+		domains:  domains,
+		shop:     shop,
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		siteName: siteName,
+	}
+}
+
+type pageLink struct {
+	Title string
+	Href  string
+}
+
+// pageProduct is a product or tip block with a checkout form.
+type pageProduct struct {
+	ID          string
+	Kind        string
+	Title       string
+	Description string
+	Amounts     []pageAmount
+}
+
+type pageAmount struct {
+	Value int64
+	Label string
+}
+
+// pageNewsletter is the signup block posting to /api/public/{handle}/subscribe.
+type pageNewsletter struct {
+	Title       string
+	Description string
+}
+
+// pageContact is the contact form block posting to /api/public/{handle}/contact.
+type pageContact struct {
+	Title string
+}
+
+// pageEvent is an upcoming event linking to its .ics file.
+type pageEvent struct {
+	ID       string
+	Title    string
+	When     string
+	Location string
+	URL      string
+}
+
+type pageData struct {
+	Lang         string
+	SiteName     string
+	HomeURL      string
+	CanonicalURL string
+	OEmbedJSON   string
+	OEmbedXML    string
+	ProfileID    string
+	Handle       string
+	Name         string
+	Title        string
+	Description  string
+	Bio          string
+	Image        string
+	Links        []pageLink
+	Theme        Theme
+	CustomCSS    template.CSS
+	Blocks       []template.HTML
+	Products     []pageProduct
+	Newsletter   *pageNewsletter
+	Contact      *pageContact
+	Events       []pageEvent
+	ContactCard  bool
+	JSONLD       any
+}
+
+// GET /{handle}
+func (h *Handler) Bio(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	handle := profile.NormalizeHandle(r.PathValue("handle"))
+	if handle == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	p, err := h.profiles.FindByHandle(ctx, handle)
+	if err != nil {
+		log.Printf("page bio %s: %v", handle, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if p == nil {
+		h.notFound(w, handle)
+		return
+	}
+
+	links, err := h.profiles.Links(ctx, p.ID)
+	if err != nil {
+		log.Printf("page bio %s links: %v", handle, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	// Link dengan A/B test berjalan memakai judul varian milik pengunjung ini
+	visible, personalized := experiment.Personalize(profile.PublicLinks(links, time.Now()), h.visitors.VisitorID(r, p.ID))
+	d := h.buildPageData(p, visible, h.canonicalURL(ctx, p))
+	d.Theme = h.themeFor(ctx, p)
+	d.Products = h.productsFor(ctx, p)
+	if nl := newsletter.FromProfile(p); nl.Enabled {
+		d.Newsletter = &pageNewsletter{Title: nl.Title, Description: nl.Description}
+		if d.Newsletter.Title == "" {
+			d.Newsletter.Title = "Berlangganan newsletter"
+		}
+	}
+	if cf := contact.FromProfile(p); cf.Enabled {
+		d.Contact = &pageContact{Title: cf.Title}
+		if d.Contact.Title == "" {
+			d.Contact.Title = "Kirim pesan"
+		}
+	}
+	d.Events = upcomingEvents(p, time.Now())
+	d.ContactCard = vcard.FromProfile(p).Enabled
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, "bio.html", d); err != nil {
+		log.Printf("page bio %s render: %v", handle, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if personalized {
+		public.WritePersonalized(w, r, "text/html; charset=utf-8", buf.Bytes())
+		return
+	}
+	public.WriteCached(w, r, "text/html; charset=utf-8", buf.Bytes())
+}
+
+func (h *Handler) buildPageData(p *profile.Profile, links []profile.Link, canonical string) pageData {
+	name := p.DisplayName
+	if name == "" {
+		name = "@" + p.Handle
+	}
+	description := p.Bio
+	if description == "" {
+		description = "Semua link " + name + " dalam satu halaman."
+	}
+	description = truncate(strings.Join(strings.Fields(description), " "), descriptionMaxLen)
+
+	d := pageData{
+		Lang:         "id",
+		SiteName:     h.siteName,
+		HomeURL:      h.baseURL + "/",
+		CanonicalURL: canonical,
+		OEmbedJSON:   h.oembedURL(canonical, "json"),
+		OEmbedXML:    h.oembedURL(canonical, "xml"),
+		ProfileID:    p.ID,
+		Handle:       p.Handle,
+		Name:         name,
+		Title:        name + " (@" + p.Handle + ") · " + h.siteName,
+		Description:  description,
+		Bio:          p.Bio,
+		Image:        p.Image,
+	}
+	// Custom CSS dan blok rich text: hanya akun membership, sudah disanitasi ulang
+	custom := sanitize.FromProfile(p)
+	d.CustomCSS = template.CSS(custom.CSS)
+	for _, b := range custom.Blocks {
+		d.Blocks = append(d.Blocks, template.HTML(b))
+	}
+	sameAs := make([]string, 0, len(links))
+	for _, l := range links {
+		// Lewat /r/{id} (atau /go/{id} untuk link dengan targeting) supaya klik tercatat di analytics
+		d.Links = append(d.Links, pageLink{Title: l.Title, Href: targeting.Path(l)})
+		if protect.FromLink(l).Locked() {
+			continue
+		}
+		if strings.HasPrefix(l.URL, "https://") || strings.HasPrefix(l.URL, "http://") {
+			sameAs = append(sameAs, l.URL)
+		}
+	}
+
+	person := map[string]any{
+		"@type":         "Person",
+		"name":          name,
+		"alternateName": "@" + p.Handle,
+		"url":           canonical,
+		"identifier":    p.ID,
+	}
+	if p.Bio != "" {
+		person["description"] = p.Bio
+	}
+	if p.Image != "" {
+		person["image"] = p.Image
+	}
+	if len(sameAs) > 0 {
+		person["sameAs"] = sameAs
+	}
+	jsonLD := map[string]any{
+		"@context":   "https://schema.org",
+		"@type":      "ProfilePage",
+		"url":        canonical,
+		"name":       d.Title,
+		"mainEntity": person,
+	}
+	if !p.UpdatedAt.IsZero() {
+		jsonLD["dateModified"] = p.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+	d.JSONLD = jsonLD
+	return d
+}
+
+type unlockData struct {
+	SiteName       string
+	HomeURL        string
+	SignInURL      string
+	Name           string
+	Mode           string
+	Heading        string
+	Message        string
+	UnlockEndpoint string
+	// Next adalah /r atau /go link ini dengan parameter unlock (token ditambahkan oleh script)
+	Next  string
+	Theme Theme
+}
+
+// GET /unlock/{linkId}?via=r|go — interstitial untuk link terkunci (password, konten sensitif,
+// members only). Tujuan link tidak pernah ikut dirender di halaman ini.
+func (h *Handler) Unlock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	linkID := r.PathValue("linkId")
+	link, err := h.profiles.FindLink(ctx, linkID)
+	if err != nil {
+		log.Printf("page unlock %s: %v", linkID, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if link == nil || !link.VisibleAt(time.Now()) || link.ProfileID == "" {
+		http.NotFound(w, r)
+		return
+	}
+	owner, err := h.profiles.FindByID(ctx, link.ProfileID)
+	if err != nil {
+		log.Printf("page unlock %s owner: %v", linkID, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if owner == nil {
+		http.NotFound(w, r)
+		return
+	}
+	via := "r"
+	if r.URL.Query().Get("via") == "go" {
+		via = "go"
+	}
+	next := "/" + via + "/" + url.PathEscape(link.ID)
+	p := protect.FromLink(*link)
+	if !p.Locked() {
+		http.Redirect(w, r, next, http.StatusFound)
+		return
+	}
+
+	name := owner.DisplayName
+	if name == "" {
+		name = "@" + owner.Handle
+	}
+	d := unlockData{
+		SiteName:       h.siteName,
+		HomeURL:        h.baseURL + "/",
+		SignInURL:      h.baseURL + "/signin",
+		Name:           name,
+		Mode:           p.Mode,
+		UnlockEndpoint: "/api/links/" + url.PathEscape(link.ID) + "/unlock",
+		Next:           next + "?" + protect.QueryUnlockToken + "=",
+		Theme:          h.themeFor(ctx, owner),
+	}
+	switch p.Mode {
+	case protect.ModePassword:
+		d.Heading, d.Message = "Link dilindungi password", "Masukkan password dari "+name+" untuk membuka \""+link.Title+"\"."
+	case protect.ModeSensitive:
+		d.Heading, d.Message = "Konten sensitif", "\""+link.Title+"\" mungkin berisi konten dewasa (18+)."
+	default:
+		d.Heading, d.Message = "Khusus member", "Masuk ke "+h.siteName+" untuk membuka \""+link.Title+"\"."
+	}
+
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, "unlock.html", d); err != nil {
+		log.Printf("page unlock %s render: %v", linkID, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(buf.Bytes())
+	}
+}
+
+// productsFor returns the product and tip blocks of p; lookup errors only hide the blocks.
+func (h *Handler) productsFor(ctx context.Context, p *profile.Profile) []pageProduct {
+	if h.shop == nil {
+		return nil
+	}
+	products, err := h.shop.ForSale(ctx, p.ID)
+	if err != nil {
+		log.Printf("page products %s: %v", p.ID, err)
+		return nil
+	}
+	out := make([]pageProduct, 0, len(products))
+	for _, pr := range products {
+		item := pageProduct{ID: pr.ID, Kind: pr.Kind, Title: pr.Title, Description: pr.Description}
+		for _, a := range pr.Amounts() {
+			item.Amounts = append(item.Amounts, pageAmount{Value: a, Label: shop.FormatAmount(a, pr.Currency)})
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+// canonicalURL is the profile's verified custom domain, or its URL on the platform.
+func (h *Handler) canonicalURL(ctx context.Context, p *profile.Profile) string {
+	if u := h.domains.CanonicalURL(ctx, p); u != "" {
+		return u
+	}
+	return h.ProfileURL(p.Handle)
+}
+
+// ProfileURL returns the platform URL for handle.
+func (h *Handler) ProfileURL(handle string) string {
+	return h.baseURL + "/" + handle
+}
+
+func (h *Handler) notFound(w http.ResponseWriter, handle string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=30")
+	w.WriteHeader(http.StatusNotFound)
+	_ = templates.ExecuteTemplate(w, "notfound.html", map[string]string{
+		"SiteName": h.siteName,
+		"HomeURL":  h.baseURL + "/",
+		"Handle":   handle,
+	})
+}
+
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return strings.TrimSpace(string(r[:max-1])) + "…"
+}
+
+// Theme berisi variabel CSS halaman bio. Nilai sudah divalidasi sebelum
+// dijadikan template.CSS supaya tidak bisa keluar dari deklarasi CSS.
+type Theme struct {
+	Background       template.CSS
+	Text             template.CSS
+	Accent           template.CSS
+	ButtonBackground template.CSS
+	ButtonText       template.CSS
+	Radius           template.CSS
+	Font             template.CSS
+	// CSS: tema dari themeId (hasil compile theme.CSS) yang menimpa variabel di atas
+	CSS    template.CSS
+	Layout string
+}
+
+var defaultTheme = Theme{
+	Background:       "#0f172a",
+	Text:             "#f1f5f9",
+	Accent:           "#38bdf8",
+	ButtonBackground: "#1e293b",
+	ButtonText:       "#f1f5f9",
+	Radius:           "12px",
+	Font:             "-apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif",
+	Layout:           "classic",
+}
+
+var (
+	cssColorRe  = regexp.MustCompile(`^(#[0-9a-fA-F]{3,8}|[a-zA-Z]{3,20}|(rgb|rgba|hsl|hsla)\([0-9.,%\s]{5,40}\))$`)
+	cssLengthRe = regexp.MustCompile(`^[0-9]{1,3}(\.[0-9]+)?(px|rem|em|%)$`)
+	cssFontRe   = regexp.MustCompile(`^[a-zA-Z0-9 ,'\-]{1,120}$`)
+)
+
+// themeFor resolves the account's themeId (preset atau tema milik akun) on top of the legacy
+// "theme" map; lookup errors fall back to the legacy theme.
+func (h *Handler) themeFor(ctx context.Context, p *profile.Profile) Theme {
+	t := ThemeFromProfile(p)
+	custom, err := h.themes.ForProfile(ctx, p)
+	if err != nil {
+		log.Printf("page theme %s: %v", p.ID, err)
+		return t
+	}
+	if custom != nil {
+		// Spec sudah divalidasi ketat (warna hex, font dari daftar, URL tanpa kutip)
+		t.CSS = template.CSS(theme.CSS(custom.Spec))
+		t.Layout = custom.Layout
+	}
+	return t
+}
+
+// ThemeFromProfile reads the optional "theme" map on the account document,
+// falling back to the default theme for missing or invalid values.
+func ThemeFromProfile(p *profile.Profile) Theme {
+	t := defaultTheme
+	raw, _ := p.Data["theme"].(map[string]any)
+	if raw == nil {
+		return t
+	}
+	pick := func(key string, re *regexp.Regexp, dst *template.CSS) {
+		if s, ok := raw[key].(string); ok && re.MatchString(strings.TrimSpace(s)) {
+			*dst = template.CSS(strings.TrimSpace(s))
+		}
+	}
+	pick("background", cssColorRe, &t.Background)
+	pick("text", cssColorRe, &t.Text)
+	pick("accent", cssColorRe, &t.Accent)
+	pick("buttonBackground", cssColorRe, &t.ButtonBackground)
+	pick("buttonText", cssColorRe, &t.ButtonText)
+	pick("radius", cssLengthRe, &t.Radius)
+	if s, ok := raw["font"].(string); ok && strings.Count(s, "'")%2 == 0 {
+		pick("font", cssFontRe, &t.Font)
+	}
+	return t
+}
+
+var monthNames = [...]string{"Jan", "Feb", "Mar", "Apr", "Mei", "Jun", "Jul", "Agu", "Sep", "Okt", "Nov", "Des"}
+
+// upcomingEvents returns the events of p that have not ended, dengan waktu dalam timezone event.
+func upcomingEvents(p *profile.Profile, now time.Time) []pageEvent {
+	var out []pageEvent
+	for _, e := range events.FromProfile(p) {
+		if !e.Upcoming(now) {
+			continue
+		}
+		out = append(out, pageEvent{ID: e.ID, Title: e.Title, When: eventWhen(&e), Location: e.Location, URL: e.URL})
+	}
+	return out
+}
+
+func eventWhen(e *events.Event) string {
+	date := func(t time.Time) string {
+		return strconv.Itoa(t.Day()) + " " + monthNames[t.Month()-1] + " " + strconv.Itoa(t.Year())
+	}
+	if e.AllDay {
+		last := e.EndAt.AddDate(0, 0, -1)
+		if last.Equal(e.StartAt) {
+			return date(e.StartAt)
+		}
+		return date(e.StartAt) + " – " + date(last)
+	}
+	start, end := e.StartAt.In(e.TimeLocation()), e.EndAt.In(e.TimeLocation())
+	startTime, endTime := start.Format("15:04"), end.Format("15:04 MST")
+	// Event yang melewati pergantian DST: tiap jam ditulis dengan zonanya sendiri
+	if startZone, _ := start.Zone(); startZone != end.Format("MST") {
+		startTime += " " + startZone
+	}
+	if date(start) == date(end) {
+		return date(start) + ", " + startTime + "–" + endTime
+	}
+	return date(start) + ", " + startTime + " – " + date(end) + ", " + endTime
+}
@@ -0,0 +1,261 @@
+package profile
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"biomu/backend/internal/firebase"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+// Store membaca profil publik (dokumen akun yang punya field "handle") dan link milik profil tersebut.
+type Store struct {
+	fb           *firebase.App
+	accountsColl string
+	linksColl    string
+}
+
+func NewStore(fb *firebase.App, accountsColl, linksColl string) *Store {
+	return &Store{fb: fb, accountsColl: accountsColl, linksColl: linksColl}
+}
+
+func (s *Store) AccountsCollection() string { return s.accountsColl }
+func (s *Store) LinksCollection() string    { return s.linksColl }
+
+type Profile struct {
+	ID          string
+	Handle      string
+	DisplayName string
+	Bio         string
+	Image       string
+	ThemeID     string
+	// Status: "reguler" atau "membership" (diisi saat signup/OAuth)
+	Status    string
+	UpdatedAt time.Time
+	Data      map[string]any
+}
+
+// Status akun.
+const (
+	StatusRegular    = "reguler"
+	StatusMembership = "membership"
+)
+
+// Member reports whether the account has an active membership.
+func (p *Profile) Member() bool {
+	return p.Status == StatusMembership
+}
+
+type Link struct {
+	ID        string
+	ProfileID string
+	Title     string
+	URL       string
+	Icon      string
+	Order     int
+	Hidden    bool
+	// Moderation adalah status screening URL (lihat package screen); "" jika tidak pernah ditandai
+	Moderation string
+	StartsAt   time.Time
+	EndsAt     time.Time
+	UpdatedAt  time.Time
+	Data       map[string]any
+}
+
+// NormalizeHandle menyamakan format handle: lowercase, tanpa spasi dan tanpa prefix "@".
+func NormalizeHandle(handle string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(handle)), "@")
+}
+
+// FindByHandle returns the profile whose account document has handle == handle.
+// If no active account is found, returns (nil, nil).
+func (s *Store) FindByHandle(ctx context.Context, handle string) (*Profile, error) {
+	handle = NormalizeHandle(handle)
+	if handle == "" {
+		return nil, nil
+	}
+	it := s.fb.DB.Collection(s.accountsColl).Where("handle", "==", handle).Limit(1).Documents(ctx)
+	defer it.Stop()
+	doc, err := it.Next()
+	if err == iterator.Done {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := profileFromDoc(doc)
+	// Akun pending signup (belum punya role) tidak boleh tampil publik
+	if _, hasRole := p.Data["role"]; !hasRole {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// FindByID returns the profile for account id. If the document does not exist, returns (nil, nil).
+func (s *Store) FindByID(ctx context.Context, id string) (*Profile, error) {
+	doc, err := s.fb.DB.Collection(s.accountsColl).Doc(id).Get(ctx)
+	if err != nil {
+		if !doc.Exists() {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return profileFromDoc(doc), nil
+}
+
+// IsAdmin reports whether the account uid has role "admin".
+func (s *Store) IsAdmin(ctx context.Context, uid string) (bool, error) {
+	if uid == "" {
+		return false, nil
+	}
+	p, err := s.FindByID(ctx, uid)
+	if err != nil || p == nil {
+		return false, err
+	}
+	return p.Data["role"] == "admin", nil
+}
+
+// Links returns every link owned by profileID, sorted by "order".
+func (s *Store) Links(ctx context.Context, profileID string) ([]Link, error) {
+	it := s.fb.DB.Collection(s.linksColl).Where("profileId", "==", profileID).Documents(ctx)
+	defer it.Stop()
+	var out []Link
+	for {
+		doc, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, LinkFromDoc(doc))
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Order < out[j].Order })
+	return out, nil
+}
+
+// FindLink returns a single link document. If the document does not exist, returns (nil, nil).
+func (s *Store) FindLink(ctx context.Context, id string) (*Link, error) {
+	doc, err := s.fb.DB.Collection(s.linksColl).Doc(id).Get(ctx)
+	if err != nil {
+		if !doc.Exists() {
+			return nil, nil
+		}
+		return nil, err
+	}
+	l := LinkFromDoc(doc)
+	return &l, nil
+}
+
+// VisibleAt reports whether the link may be shown to visitors at time t.
+func (l Link) VisibleAt(t time.Time) bool {
+	if l.Hidden || l.URL == "" {
+		return false
+	}
+	// Link yang dikarantina atau ditolak reviewer tidak tampil sampai disetujui admin
+	if l.Moderation == ModerationQuarantined || l.Moderation == ModerationRejected {
+		return false
+	}
+	if !l.StartsAt.IsZero() && t.Before(l.StartsAt) {
+		return false
+	}
+	if !l.EndsAt.IsZero() && !t.Before(l.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// PublicLinks filters out hidden links and links outside their schedule window.
+func PublicLinks(links []Link, now time.Time) []Link {
+	out := make([]Link, 0, len(links))
+	for _, l := range links {
+		if l.VisibleAt(now) {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+func profileFromDoc(doc *firestore.DocumentSnapshot) *Profile {
+	data := doc.Data()
+	return &Profile{
+		ID:          doc.Ref.ID,
+		Handle:      stringField(data, "handle"),
+		DisplayName: stringField(data, "displayName"),
+		Bio:         stringField(data, "bio"),
+		Image:       stringField(data, "image"),
+		ThemeID:     stringField(data, "themeId"),
+		Status:      stringField(data, "status"),
+		UpdatedAt:   timeField(data, "updatedAt"),
+		Data:        data,
+	}
+}
+
+// LinkFromDoc converts a Firestore link document into a Link.
+func LinkFromDoc(doc *firestore.DocumentSnapshot) Link {
+	data := doc.Data()
+	hidden, _ := data["hidden"].(bool)
+	if active, ok := data["active"].(bool); ok && !active {
+		hidden = true
+	}
+	return Link{
+		ID:         doc.Ref.ID,
+		ProfileID:  stringField(data, "profileId"),
+		Title:      stringField(data, "title"),
+		URL:        stringField(data, "url"),
+		Icon:       stringField(data, "icon"),
+		Order:      intField(data, "order"),
+		Hidden:     hidden,
+		Moderation: moderationStatus(data),
+		StartsAt:   scheduleBound(data, FieldStartsAtUTC, FieldStartsAt),
+		EndsAt:     scheduleBound(data, FieldEndsAtUTC, FieldEndsAt),
+		UpdatedAt:  timeField(data, "updatedAt"),
+		Data:       data,
+	}
+}
+
+// Status moderasi link (map "moderation" di dokumen link, ditulis server).
+const (
+	ModerationQuarantined = "quarantined"
+	ModerationRejected    = "rejected"
+	ModerationApproved    = "approved"
+)
+
+func moderationStatus(data map[string]any) string {
+	m, _ := data["moderation"].(map[string]any)
+	return stringField(m, "status")
+}
+
+func stringField(data map[string]any, key string) string {
+	s, _ := data[key].(string)
+	return strings.TrimSpace(s)
+}
+
+func intField(data map[string]any, key string) int {
+	switch v := data[key].(type) {
+	case int64:
+		return int(v)
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// timeField membaca timestamp Firestore (time.Time) atau string RFC 3339.
+func timeField(data map[string]any, key string) time.Time {
+	switch v := data[key].(type) {
+	case time.Time:
+		return v
+	case string:
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,148 @@
+package profile
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	// Image alpine tidak punya zoneinfo; database timezone di-embed ke binary.
+	_ "time/tzdata"
+)
+
+// Field jadwal link. startsAt/endsAt boleh berupa timestamp absolut (RFC 3339 dengan offset
+// atau timestamp Firestore) atau jam lokal "2006-01-02T15:04[:05]" yang dibaca dalam timezone
+// (nama IANA, mis. "Asia/Jakarta"). Server menyimpan hasil normalisasinya di startsAtUtc/endsAtUtc.
+const (
+	FieldStartsAt    = "startsAt"
+	FieldEndsAt      = "endsAt"
+	FieldTimezone    = "timezone"
+	FieldStartsAtUTC = "startsAtUtc"
+	FieldEndsAtUTC   = "endsAtUtc"
+)
+
+var localLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"}
+
+// LoadTimezone loads an IANA timezone; "" means UTC.
+func LoadTimezone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+	return loc, nil
+}
+
+// ParseScheduleTime converts a schedule value to an instant. Absolute values keep their
+// offset; local wall-clock strings are resolved in loc with ResolveLocal. nil/"" yields zero.
+func ParseScheduleTime(v any, loc *time.Location) (time.Time, error) {
+	switch x := v.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return x, nil
+	case string:
+		s := strings.TrimSpace(x)
+		if s == "" {
+			return time.Time{}, nil
+		}
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t, nil
+		}
+		for _, layout := range localLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return ResolveLocal(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), loc), nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("invalid time %q", s)
+	}
+	return time.Time{}, fmt.Errorf("invalid time value %v", v)
+}
+
+// ResolveLocal returns the instant of a wall-clock time in loc with well-defined DST handling:
+// a time inside a spring-forward gap is shifted forward by the gap length (02:30 → 03:30),
+// and an ambiguous time in a fall-back overlap resolves to the earlier instant.
+func ResolveLocal(year int, month time.Month, day, hour, min, sec int, loc *time.Location) time.Time {
+	wall := time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+	// Offset di sekitar waktu tersebut (transisi DST tidak pernah berdekatan < 1 hari)
+	_, offBefore := wall.Add(-24 * time.Hour).In(loc).Zone()
+	_, offAfter := wall.Add(24 * time.Hour).In(loc).Zone()
+
+	matches := func(t time.Time) bool {
+		l := t.In(loc)
+		return l.Year() == year && l.Month() == month && l.Day() == day &&
+			l.Hour() == hour && l.Minute() == min && l.Second() == sec
+	}
+	a := wall.Add(-time.Duration(offBefore) * time.Second)
+	b := wall.Add(-time.Duration(offAfter) * time.Second)
+	switch {
+	case matches(a) && matches(b):
+		if b.Before(a) {
+			return b.In(loc)
+		}
+		return a.In(loc)
+	case matches(a):
+		return a.In(loc)
+	case matches(b):
+		return b.In(loc)
+	}
+	// Gap: pakai offset sebelum transisi, hasilnya bergeser maju sepanjang gap
+	return a.In(loc)
+}
+
+// NormalizeSchedule validates the schedule fields of a link document and sets
+// startsAtUtc/endsAtUtc (or deletes them via the returned keys when cleared).
+// It returns the UTC fields that must be removed because the schedule was cleared.
+func NormalizeSchedule(data map[string]any) (cleared []string, err error) {
+	loc, err := LoadTimezone(stringField(data, FieldTimezone))
+	if err != nil {
+		return nil, err
+	}
+	start, err := ParseScheduleTime(data[FieldStartsAt], loc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", FieldStartsAt, err)
+	}
+	end, err := ParseScheduleTime(data[FieldEndsAt], loc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", FieldEndsAt, err)
+	}
+	if !start.IsZero() && !end.IsZero() && !end.After(start) {
+		return nil, fmt.Errorf("%s must be after %s", FieldEndsAt, FieldStartsAt)
+	}
+	set := func(field string, t time.Time) {
+		if t.IsZero() {
+			delete(data, field)
+			cleared = append(cleared, field)
+			return
+		}
+		data[field] = t.UTC()
+	}
+	set(FieldStartsAtUTC, start)
+	set(FieldEndsAtUTC, end)
+	return cleared, nil
+}
+
+// HasScheduleField reports whether payload touches any schedule field.
+func HasScheduleField(payload map[string]any) bool {
+	for _, k := range []string{FieldStartsAt, FieldEndsAt, FieldTimezone} {
+		if _, ok := payload[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// scheduleBound prefers the normalized UTC field, falling back to parsing the raw value.
+func scheduleBound(data map[string]any, utcField, rawField string) time.Time {
+	if t, ok := data[utcField].(time.Time); ok {
+		return t
+	}
+	loc, err := LoadTimezone(stringField(data, FieldTimezone))
+	if err != nil {
+		loc = time.UTC
+	}
+	t, _ := ParseScheduleTime(data[rawField], loc)
+	return t
+}
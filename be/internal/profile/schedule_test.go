@@ -0,0 +1,126 @@
+package profile
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveLocal(t *testing.T) {
+	utc := func(y int, mo time.Month, d, h, mi int) time.Time { return time.Date(y, mo, d, h, mi, 0, 0, time.UTC) }
+	tests := []struct {
+		name  string
+		tz    string
+		local [5]int // month, day, hour, minute, second (2026)
+		want  time.Time
+		wall  string
+	}{
+		{"no DST", "Asia/Jakarta", [5]int{3, 8, 2, 30, 0}, utc(2026, 3, 7, 19, 30), "02:30"},
+		{"New York normal", "America/New_York", [5]int{3, 7, 2, 30, 0}, utc(2026, 3, 7, 7, 30), "02:30"},
+		{"New York gap", "America/New_York", [5]int{3, 8, 2, 30, 0}, utc(2026, 3, 8, 7, 30), "03:30"},
+		{"New York gap start", "America/New_York", [5]int{3, 8, 2, 0, 0}, utc(2026, 3, 8, 7, 0), "03:00"},
+		{"New York after gap", "America/New_York", [5]int{3, 8, 3, 0, 0}, utc(2026, 3, 8, 7, 0), "03:00"},
+		{"New York overlap", "America/New_York", [5]int{11, 1, 1, 30, 0}, utc(2026, 11, 1, 5, 30), "01:30"},
+		{"New York after overlap", "America/New_York", [5]int{11, 1, 2, 0, 0}, utc(2026, 11, 1, 7, 0), "02:00"},
+		{"Berlin gap", "Europe/Berlin", [5]int{3, 29, 2, 30, 0}, utc(2026, 3, 29, 1, 30), "03:30"},
+		{"Berlin overlap", "Europe/Berlin", [5]int{10, 25, 2, 30, 0}, utc(2026, 10, 25, 0, 30), "02:30"},
+		// Lord Howe: DST hanya 30 menit
+		{"Lord Howe gap", "Australia/Lord_Howe", [5]int{10, 4, 2, 15, 0}, utc(2026, 10, 3, 15, 45), "02:45"},
+		{"Lord Howe overlap", "Australia/Lord_Howe", [5]int{4, 5, 1, 45, 0}, utc(2026, 4, 4, 14, 45), "01:45"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, err := LoadTimezone(tt.tz)
+			if err != nil {
+				t.Fatal(err)
+			}
+			l := tt.local
+			got := ResolveLocal(2026, time.Month(l[0]), l[1], l[2], l[3], l[4], loc)
+			if !got.Equal(tt.want) {
+				t.Fatalf("ResolveLocal = %v (%v), want %v", got, got.UTC(), tt.want)
+			}
+			if wall := got.Format("15:04"); wall != tt.wall {
+				t.Fatalf("wall clock %s, want %s", wall, tt.wall)
+			}
+		})
+	}
+}
+
+func TestParseScheduleTime(t *testing.T) {
+	jakarta, _ := LoadTimezone("Asia/Jakarta")
+	want := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		v    any
+		want time.Time
+		err  bool
+	}{
+		{nil, time.Time{}, false},
+		{"  ", time.Time{}, false},
+		{"2026-05-01T19:00", want, false},
+		{"2026-05-01 19:00:00", want, false},
+		{"2026-05-01T14:00:00+02:00", want, false},
+		{want, want, false},
+		{"1 Mei 2026", time.Time{}, true},
+		{42, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseScheduleTime(tt.v, jakarta)
+		if (err != nil) != tt.err || !got.Equal(tt.want) {
+			t.Errorf("ParseScheduleTime(%v) = %v, %v; want %v", tt.v, got, err, tt.want)
+		}
+	}
+	if _, err := LoadTimezone("+07:00"); err == nil {
+		t.Error("offset accepted as timezone")
+	}
+}
+
+func TestNormalizeSchedule(t *testing.T) {
+	data := map[string]any{FieldStartsAt: "2026-03-08T02:30", FieldTimezone: "America/New_York", FieldEndsAtUTC: time.Now()}
+	cleared, err := NormalizeSchedule(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := data[FieldStartsAtUTC]; got != time.Date(2026, 3, 8, 7, 30, 0, 0, time.UTC) {
+		t.Fatalf("startsAtUtc = %v", got)
+	}
+	if _, ok := data[FieldEndsAtUTC]; ok || len(cleared) != 1 || cleared[0] != FieldEndsAtUTC {
+		t.Fatalf("endsAtUtc not cleared: %v", cleared)
+	}
+
+	for _, bad := range []map[string]any{
+		{FieldStartsAt: "2026-05-02T10:00", FieldEndsAt: "2026-05-02T10:00"},
+		{FieldStartsAt: "2026-05-02T10:00", FieldTimezone: "Mars/Olympus"},
+		{FieldEndsAt: "besok"},
+	} {
+		if _, err := NormalizeSchedule(bad); err == nil {
+			t.Errorf("NormalizeSchedule(%v) accepted", bad)
+		}
+	}
+}
+
+func TestVisibleAt(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	base := Link{ID: "l1", URL: "https://example.com"}
+	tests := []struct {
+		name   string
+		change func(l *Link)
+		want   bool
+	}{
+		{"plain", func(l *Link) {}, true},
+		{"hidden", func(l *Link) { l.Hidden = true }, false},
+		{"no url", func(l *Link) { l.URL = "" }, false},
+		{"quarantined", func(l *Link) { l.Moderation = ModerationQuarantined }, false},
+		{"starts now", func(l *Link) { l.StartsAt = now }, true},
+		{"starts later", func(l *Link) { l.StartsAt = now.Add(time.Second) }, false},
+		{"ends now", func(l *Link) { l.EndsAt = now }, false},
+		{"ends later", func(l *Link) { l.EndsAt = now.Add(time.Second) }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := base
+			tt.change(&l)
+			if got := l.VisibleAt(now); got != tt.want {
+				t.Fatalf("VisibleAt = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
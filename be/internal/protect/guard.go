@@ -0,0 +1,151 @@
+package protect
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"biomu/backend/internal/profile"
+	"biomu/backend/internal/ratelimit"
+	"biomu/backend/internal/visitor"
+)
+
+// HeaderUnlockToken and QueryUnlockToken carry an unlock token on API reads and redirects.
+const (
+	HeaderUnlockToken = "X-Unlock-Token"
+	QueryUnlockToken  = "unlock"
+)
+
+const (
+	// Password salah: per IP per link, plus batas total per link melawan brute force terdistribusi.
+	attemptsPerVisitor = 5
+	attemptsPerLink    = 100
+	attemptWindow      = 15 * time.Minute
+	gcInterval         = 5 * time.Minute
+)
+
+// Sessions resolves the signed-in caller (implemented by auth.Handler).
+type Sessions interface {
+	SessionUID(r *http.Request) string
+}
+
+// Guard decides whether a request may see the destination of a protected link.
+type Guard struct {
+	profiles *profile.Store
+	sessions Sessions
+	unlocker *Unlocker
+
+	perVisitor *ratelimit.Limiter
+	perLink    *ratelimit.Limiter
+}
+
+func NewGuard(profiles *profile.Store, sessions Sessions, unlocker *Unlocker) *Guard {
+	return &Guard{
+		profiles:   profiles,
+		sessions:   sessions,
+		unlocker:   unlocker,
+		perVisitor: ratelimit.New(attemptsPerVisitor, attemptWindow),
+		perLink:    ratelimit.New(attemptsPerLink, attemptWindow),
+	}
+}
+
+// attemptKey keys the per-visitor limiter on the client IP resolved behind trusted proxies
+// (X-Forwarded-For dari client tidak bisa dipakai untuk reset jatah).
+func attemptKey(r *http.Request, linkID string) string {
+	return visitor.RateKey(r) + "|" + linkID
+}
+
+// takeAttempt reserves one password attempt on linkID for the caller of r, per visitor and per
+// link. It fails with the time until the exhausted window resets.
+func (g *Guard) takeAttempt(r *http.Request, linkID string, now time.Time) (bool, time.Duration) {
+	key := attemptKey(r, linkID)
+	ok, wait := g.perVisitor.Take(key, now)
+	if ok {
+		if ok, wait = g.perLink.Take(linkID, now); !ok {
+			g.perVisitor.Refund(key)
+		}
+	}
+	return ok, wait
+}
+
+// refundAttempt returns the attempt reserved by takeAttempt.
+func (g *Guard) refundAttempt(r *http.Request, linkID string) {
+	g.perVisitor.Refund(attemptKey(r, linkID))
+	g.perLink.Refund(linkID)
+}
+
+// Viewer is the caller of one request. The admin lookup runs at most once and only when needed.
+type Viewer struct {
+	g       *Guard
+	r       *http.Request
+	UID     string
+	once    sync.Once
+	isAdmin bool
+}
+
+// Viewer resolves the caller of r.
+func (g *Guard) Viewer(r *http.Request) *Viewer {
+	return &Viewer{g: g, r: r, UID: g.sessions.SessionUID(r)}
+}
+
+func (v *Viewer) admin() bool {
+	v.once.Do(func() {
+		if v.UID == "" {
+			return
+		}
+		admin, err := v.g.profiles.IsAdmin(v.r.Context(), v.UID)
+		if err != nil {
+			log.Printf("protect admin check %s: %v", v.UID, err)
+		}
+		v.isAdmin = admin
+	})
+	return v.isAdmin
+}
+
+// Allowed reports whether the viewer may open link: unprotected links, the owner and admins
+// always pass; members-only needs a session; password/sensitive need a valid unlock token
+// (query "unlock" or header X-Unlock-Token).
+func (v *Viewer) Allowed(link profile.Link) bool {
+	p := FromLink(link)
+	if !p.Locked() {
+		return true
+	}
+	if v.UID != "" && v.UID == link.ProfileID {
+		return true
+	}
+	if p.Mode == ModeMembers && v.UID != "" {
+		return true
+	}
+	for _, token := range []string{v.r.URL.Query().Get(QueryUnlockToken), v.r.Header.Get(HeaderUnlockToken)} {
+		if token != "" && v.g.unlocker.Verify(token, link.ID, p) {
+			return true
+		}
+	}
+	return v.admin()
+}
+
+// FilterDoc prepares a stored link document for an API read: the password hash is always
+// removed and destination URLs are redacted unless the viewer may open the link.
+func (v *Viewer) FilterDoc(link profile.Link, data map[string]any) {
+	StripSecrets(data)
+	if !v.Allowed(link) {
+		Redact(data)
+	}
+}
+
+// Run garbage-collects rate-limit state until ctx is cancelled.
+func (g *Guard) Run(ctx context.Context) {
+	ticker := time.NewTicker(gcInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			g.perVisitor.GC(now)
+			g.perLink.GC(now)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package protect
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"biomu/backend/internal/visitor"
+)
+
+func unlockRequest(remote, forwardedFor string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/api/links/l1/unlock", nil)
+	r.RemoteAddr = remote
+	if forwardedFor != "" {
+		r.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	return r
+}
+
+// behind resolves the client IP like main.go does, through the proxies middleware.
+func behind(proxies *visitor.Proxies, r *http.Request) *http.Request {
+	var out *http.Request
+	proxies.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) { out = r })).
+		ServeHTTP(httptest.NewRecorder(), r)
+	return out
+}
+
+func TestUnlockLockout(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	g := NewGuard(nil, nil, nil)
+
+	for i := 0; i < attemptsPerVisitor; i++ {
+		if ok, _ := g.takeAttempt(unlockRequest("198.51.100.9:1000", ""), "l1", now); !ok {
+			t.Fatalf("attempt %d refused", i+1)
+		}
+	}
+	ok, wait := g.takeAttempt(unlockRequest("198.51.100.9:1000", ""), "l1", now.Add(time.Minute))
+	if ok {
+		t.Fatal("attempt after the limit accepted")
+	}
+	if wait != attemptWindow-time.Minute {
+		t.Fatalf("wait = %v, want %v", wait, attemptWindow-time.Minute)
+	}
+
+	// Tanpa TRUSTED_PROXIES, X-Forwarded-For palsu tidak membuka jatah baru
+	spoofed := behind(nil, unlockRequest("198.51.100.9:2000", "203.0.113.1"))
+	if ok, _ := g.takeAttempt(spoofed, "l1", now); ok {
+		t.Fatal("spoofed X-Forwarded-For reset the limit")
+	}
+	if ok, _ := g.takeAttempt(unlockRequest("198.51.100.9:1000", ""), "l2", now); !ok {
+		t.Fatal("limit leaked to another link")
+	}
+	if ok, _ := g.takeAttempt(unlockRequest("198.51.100.10:1000", ""), "l1", now); !ok {
+		t.Fatal("limit leaked to another visitor")
+	}
+	if ok, _ := g.takeAttempt(unlockRequest("198.51.100.9:1000", ""), "l1", now.Add(attemptWindow)); !ok {
+		t.Fatal("limit not reset after the window")
+	}
+}
+
+func TestUnlockLockoutIPv6Prefix(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	g := NewGuard(nil, nil, nil)
+	for i := 0; i < attemptsPerVisitor; i++ {
+		r := unlockRequest(fmt.Sprintf("[2001:db8:0:1::%x]:1000", i+1), "")
+		if ok, _ := g.takeAttempt(r, "l1", now); !ok {
+			t.Fatalf("attempt %d refused", i+1)
+		}
+	}
+	if ok, _ := g.takeAttempt(unlockRequest("[2001:db8:0:1:ffff::1]:1000", ""), "l1", now); ok {
+		t.Fatal("rotating addresses inside one /64 reset the limit")
+	}
+	if ok, _ := g.takeAttempt(unlockRequest("[2001:db8:0:2::1]:1000", ""), "l1", now); !ok {
+		t.Fatal("another /64 prefix was locked out")
+	}
+}
+
+func TestUnlockLockoutBehindProxy(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	proxies, err := visitor.ParseProxies("10.0.0.0/8", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGuard(nil, nil, nil)
+
+	for i := 0; i < attemptsPerVisitor; i++ {
+		// Hop kiri dikarang client; yang dihitung hop yang ditambahkan proxy
+		r := behind(proxies, unlockRequest("10.0.0.2:1000", fmt.Sprintf("192.0.2.%d, 203.0.113.7", i)))
+		if ok, _ := g.takeAttempt(r, "l1", now); !ok {
+			t.Fatalf("attempt %d refused", i+1)
+		}
+	}
+	if ok, _ := g.takeAttempt(behind(proxies, unlockRequest("10.0.0.3:1000", "192.0.2.99, 203.0.113.7")), "l1", now); ok {
+		t.Fatal("prepended X-Forwarded-For hop reset the limit")
+	}
+	if ok, _ := g.takeAttempt(behind(proxies, unlockRequest("10.0.0.2:1000", "203.0.113.8")), "l1", now); !ok {
+		t.Fatal("another client behind the proxy was locked out")
+	}
+}
+
+func TestUnlockPerLinkLimit(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	g := NewGuard(nil, nil, nil)
+
+	for i := 0; i < attemptsPerLink; i++ {
+		r := unlockRequest(fmt.Sprintf("198.51.%d.%d:1000", i/250, i%250), "")
+		if ok, _ := g.takeAttempt(r, "l1", now); !ok {
+			t.Fatalf("attempt %d refused", i+1)
+		}
+	}
+	fresh := unlockRequest("203.0.113.1:1000", "")
+	for i := 0; i < attemptsPerVisitor; i++ {
+		if ok, _ := g.takeAttempt(fresh, "l1", now); ok {
+			t.Fatal("attempt above the per-link limit accepted")
+		}
+	}
+	// Percobaan yang ditolak batas per link tidak ikut menghabiskan jatah visitor
+	for i := 0; i < attemptsPerVisitor; i++ {
+		g.perLink.Refund("l1")
+	}
+	for i := 0; i < attemptsPerVisitor; i++ {
+		if ok, _ := g.takeAttempt(fresh, "l1", now); !ok {
+			t.Fatalf("visitor attempt %d refused after per-link refusals", i+1)
+		}
+	}
+}
+
+func TestUnlockRefund(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	g := NewGuard(nil, nil, nil)
+	r := unlockRequest("198.51.100.9:1000", "")
+
+	// Password benar tidak menghabiskan jatah
+	for i := 0; i < 3*attemptsPerVisitor; i++ {
+		if ok, _ := g.takeAttempt(r, "l1", now); !ok {
+			t.Fatalf("attempt %d refused", i+1)
+		}
+		g.refundAttempt(r, "l1")
+	}
+}
@@ -0,0 +1,109 @@
+package protect
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"biomu/backend/internal/profile"
+	"biomu/backend/internal/targeting"
+)
+
+const unlockMaxBytes = 4 << 10
+
+type Handler struct {
+	profiles *profile.Store
+	guard    *Guard
+}
+
+func NewHandler(profiles *profile.Store, guard *Guard) *Handler {
+	return &Handler{profiles: profiles, guard: guard}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+type unlockResponse struct {
+	Token     string `json:"token"`
+	ExpiresAt int64  `json:"expiresAt"`
+	// Redirect adalah URL /r dengan token; tujuan asli tetap tidak dikirim di sini
+	Redirect string `json:"redirect"`
+}
+
+// POST /api/links/{linkId}/unlock — body {"password": "..."} untuk mode password,
+// {"confirm": true} untuk konten sensitif; members only cukup dengan session.
+func (h *Handler) Unlock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	w.Header().Set("Cache-Control", "no-store")
+
+	linkID := r.PathValue("linkId")
+	link, err := h.profiles.FindLink(ctx, linkID)
+	if err != nil {
+		log.Printf("protect unlock %s: %v", linkID, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load link"})
+		return
+	}
+	if link == nil || !link.VisibleAt(time.Now()) {
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	p := FromLink(*link)
+	if !p.Locked() {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "link is not protected"})
+		return
+	}
+
+	var body struct {
+		Password string `json:"password"`
+		Confirm  bool   `json:"confirm"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, unlockMaxBytes)).Decode(&body); err != nil && err != io.EOF {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+
+	switch p.Mode {
+	case ModeMembers:
+		if h.guard.sessions.SessionUID(r) == "" {
+			h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "sign in required"})
+			return
+		}
+	case ModeSensitive:
+		if !body.Confirm {
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "confirm is required"})
+			return
+		}
+	case ModePassword:
+		now := time.Now()
+		if ok, wait := h.guard.takeAttempt(r, link.ID, now); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			h.writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "too many attempts"})
+			return
+		}
+		if !p.CheckPassword(body.Password) {
+			h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "wrong password"})
+			return
+		}
+		// Hanya password salah yang menghabiskan jatah percobaan
+		h.guard.refundAttempt(r, link.ID)
+	}
+
+	token, exp := h.guard.unlocker.Issue(link.ID, p)
+	h.writeJSON(w, http.StatusOK, unlockResponse{
+		Token:     token,
+		ExpiresAt: exp.UnixMilli(),
+		Redirect:  targeting.Path(*link) + "?" + QueryUnlockToken + "=" + url.QueryEscape(token),
+	})
+}
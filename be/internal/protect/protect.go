@@ -0,0 +1,166 @@
+// Package protect mengunci link di balik password (bcrypt), interstitial konten sensitif/18+,
+// atau "members only" (pengunjung harus punya session). Link yang terkunci baru bisa dibuka
+// dengan unlock token bertanda tangan yang berumur pendek.
+package protect
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"biomu/backend/internal/profile"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Field di dokumen link: protection = {mode, passwordHash}. Client mengirim
+// protection.password (plaintext) yang langsung di-hash server dan tidak pernah disimpan.
+const (
+	FieldProtection = "protection"
+)
+
+const (
+	ModeNone      = ""
+	ModePassword  = "password"
+	ModeSensitive = "sensitive"
+	ModeMembers   = "members"
+)
+
+const (
+	minPasswordLen = 4
+	maxPasswordLen = 72 // batas input bcrypt
+	bcryptCost     = 12
+)
+
+// ErrPasswordRequired is returned when mode "password" is set without a password.
+var ErrPasswordRequired = errors.New("protection.password is required for mode password")
+
+// Protection is the protection configuration of a link.
+type Protection struct {
+	Mode         string
+	PasswordHash string
+}
+
+// Locked reports whether the link needs unlocking.
+func (p Protection) Locked() bool { return p.Mode != ModeNone }
+
+// FromLink reads the protection of link. Unknown modes are treated as members-only
+// (fail closed) so a typo never exposes the target.
+func FromLink(link profile.Link) Protection {
+	return fromData(link.Data)
+}
+
+func fromData(data map[string]any) Protection {
+	cfg, _ := data[FieldProtection].(map[string]any)
+	if cfg == nil {
+		return Protection{}
+	}
+	mode, _ := cfg["mode"].(string)
+	hash, _ := cfg["passwordHash"].(string)
+	switch mode {
+	case ModeNone, ModePassword, ModeSensitive, ModeMembers:
+	default:
+		mode = ModeMembers
+	}
+	if mode == ModePassword && hash == "" {
+		mode = ModeMembers
+	}
+	return Protection{Mode: mode, PasswordHash: hash}
+}
+
+// CheckPassword compares password with the stored bcrypt hash.
+func (p Protection) CheckPassword(password string) bool {
+	if p.Mode != ModePassword || p.PasswordHash == "" || len(password) > maxPasswordLen {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(p.PasswordHash), []byte(password)) == nil
+}
+
+// NormalizeDoc validates payload["protection"] and replaces a plaintext password with its
+// bcrypt hash. existing is the stored document on update (nil on create): when the mode stays
+// "password" without a new password, the stored hash is kept.
+func NormalizeDoc(payload, existing map[string]any) error {
+	v, ok := payload[FieldProtection]
+	if !ok || v == nil {
+		return nil
+	}
+	cfg, ok := v.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s must be an object", FieldProtection)
+	}
+	mode, _ := cfg["mode"].(string)
+	password, _ := cfg["password"].(string)
+	out := map[string]any{"mode": mode}
+	switch mode {
+	case ModeNone:
+		payload[FieldProtection] = nil
+		return nil
+	case ModeSensitive, ModeMembers:
+	case ModePassword:
+		switch {
+		case password != "":
+			n := utf8.RuneCountInString(password)
+			if n < minPasswordLen || len(password) > maxPasswordLen {
+				return fmt.Errorf("password must be %d–%d characters", minPasswordLen, maxPasswordLen)
+			}
+			hash, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+			if err != nil {
+				return err
+			}
+			out["passwordHash"] = string(hash)
+		case fromData(existing).PasswordHash != "":
+			out["passwordHash"] = fromData(existing).PasswordHash
+		default:
+			return ErrPasswordRequired
+		}
+	default:
+		return fmt.Errorf("protection.mode must be password, sensitive or members")
+	}
+	payload[FieldProtection] = out
+	return nil
+}
+
+// urlFields are the link fields that reveal a destination.
+var urlFields = []string{"url", "fallbackUrl"}
+
+// Redact removes every destination URL from a link document (url, fallbackUrl, targets[].url,
+// variants[].url) so a locked link can be listed without leaking where it points.
+func Redact(data map[string]any) {
+	for _, f := range urlFields {
+		delete(data, f)
+	}
+	for _, f := range []string{"targets", "variants"} {
+		items, _ := data[f].([]any)
+		redacted := make([]any, 0, len(items))
+		for _, item := range items {
+			m, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			c := make(map[string]any, len(m))
+			for k, v := range m {
+				if !strings.EqualFold(k, "url") {
+					c[k] = v
+				}
+			}
+			redacted = append(redacted, c)
+		}
+		if items != nil {
+			data[f] = redacted
+		}
+	}
+}
+
+// StripSecrets removes the password hash; it is never returned to any client.
+func StripSecrets(data map[string]any) {
+	if cfg, ok := data[FieldProtection].(map[string]any); ok {
+		c := make(map[string]any, len(cfg))
+		for k, v := range cfg {
+			if k != "passwordHash" {
+				c[k] = v
+			}
+		}
+		data[FieldProtection] = c
+	}
+}
@@ -0,0 +1,64 @@
+package protect
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"biomu/backend/internal/signing"
+)
+
+// Unlocker issues and verifies unlock tokens: base64url("linkId.expiryUnix").base64url(hmac).
+// The MAC also covers the link's mode and password hash, so changing the password or the mode
+// invalidates tokens that were already handed out.
+type Unlocker struct {
+	key *signing.Key
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewUnlocker derives the signing key from secret (e.g. SESSION_SECRET) so unlock tokens can
+// never be confused with session tokens.
+func NewUnlocker(secret []byte, ttl time.Duration) *Unlocker {
+	return &Unlocker{key: signing.NewKey(secret, "biomu link unlock v1"), ttl: ttl, now: time.Now}
+}
+
+// Issue returns a token for linkID valid for the unlocker's TTL.
+func (u *Unlocker) Issue(linkID string, p Protection) (string, time.Time) {
+	exp := u.now().Add(u.ttl).Truncate(time.Second)
+	payload := linkID + "." + strconv.FormatInt(exp.Unix(), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(u.sign(payload, p)), exp
+}
+
+// Verify reports whether token unlocks linkID under protection p.
+func (u *Unlocker) Verify(token, linkID string, p Protection) bool {
+	encPayload, encSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encPayload)
+	if err != nil {
+		return false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encSig)
+	if err != nil {
+		return false
+	}
+	payload := string(raw)
+	if !hmac.Equal(sig, u.sign(payload, p)) {
+		return false
+	}
+	id, expStr, ok := strings.Cut(payload, ".")
+	if !ok || id != linkID {
+		return false
+	}
+	exp, err := strconv.ParseInt(expStr, 10, 64)
+	return err == nil && u.now().Unix() < exp
+}
+
+func (u *Unlocker) sign(payload string, p Protection) []byte {
+	return u.key.Sum(payload, p.Mode, p.PasswordHash)
+}
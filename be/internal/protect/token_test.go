@@ -0,0 +1,40 @@
+package protect
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUnlockerToken(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	u := NewUnlocker([]byte("secret"), time.Hour)
+	u.now = func() time.Time { return now }
+	p := Protection{Mode: ModePassword, PasswordHash: "$2a$10$hash"}
+	token, exp := u.Issue("l1", p)
+
+	tests := []struct {
+		name   string
+		token  string
+		linkID string
+		p      Protection
+		at     time.Time
+		want   bool
+	}{
+		{"valid", token, "l1", p, now, true},
+		{"other link", token, "l2", p, now, false},
+		{"password changed", token, "l1", Protection{Mode: ModePassword, PasswordHash: "$2a$10$other"}, now, false},
+		{"mode changed", token, "l1", Protection{Mode: ModeSensitive}, now, false},
+		{"expired", token, "l1", p, exp, false},
+		{"other secret", func() string { t, _ := NewUnlocker([]byte("other"), time.Hour).Issue("l1", p); return t }(), "l1", p, now, false},
+		{"garbage", "nope", "l1", p, now, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := tt.at
+			u.now = func() time.Time { return at }
+			if got := u.Verify(tt.token, tt.linkID, tt.p); got != tt.want {
+				t.Fatalf("Verify = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package public
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"biomu/backend/internal/experiment"
+	"biomu/backend/internal/profile"
+	"biomu/backend/internal/protect"
+	"biomu/backend/internal/sanitize"
+	"biomu/backend/internal/visitor"
+)
+
+const (
+	// CDN boleh menyajikan cache lama sambil revalidate di belakang layar.
+	cacheControlFound    = "public, max-age=60, s-maxage=300, stale-while-revalidate=86400"
+	cacheControlNotFound = "public, max-age=30"
+	// Respons yang berbeda per pengunjung (varian A/B test) tidak boleh disimpan shared cache.
+	cacheControlPersonalized = "private, no-cache"
+	// URL yang memuat versi konten (mis. ?v=<hash>) tidak pernah berubah isinya.
+	cacheControlImmutable = "public, max-age=31536000, immutable"
+)
+
+type Handler struct {
+	profiles *profile.Store
+	visitors *visitor.Identifier
+}
+
+func NewHandler(profiles *profile.Store, visitors *visitor.Identifier) *Handler {
+	return &Handler{profiles: profiles, visitors: visitors}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// PublicProfile is the curated view of a profile returned to anonymous visitors.
+// CustomCSS (selector di bawah class "custom") dan Blocks hanya terisi untuk akun membership.
+type PublicProfile struct {
+	Handle      string       `json:"handle"`
+	DisplayName string       `json:"displayName,omitempty"`
+	Bio         string       `json:"bio,omitempty"`
+	Image       string       `json:"image,omitempty"`
+	ThemeID     string       `json:"themeId,omitempty"`
+	CustomCSS   string       `json:"customCss,omitempty"`
+	Blocks      []string     `json:"blocks,omitempty"`
+	Links       []PublicLink `json:"links"`
+	UpdatedAt   int64        `json:"updatedAt,omitempty"`
+}
+
+type PublicLink struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	// URL kosong untuk link terkunci; buka lewat /r/{id} yang menampilkan interstitial
+	URL       string `json:"url"`
+	Icon      string `json:"icon,omitempty"`
+	Protected string `json:"protected,omitempty"`
+}
+
+// NewPublicProfile builds the public DTO from a profile and its visible links.
+func NewPublicProfile(p *profile.Profile, links []profile.Link) PublicProfile {
+	out := PublicProfile{
+		Handle:      p.Handle,
+		DisplayName: p.DisplayName,
+		Bio:         p.Bio,
+		Image:       p.Image,
+		ThemeID:     p.ThemeID,
+		Links:       make([]PublicLink, 0, len(links)),
+	}
+	if !p.UpdatedAt.IsZero() {
+		out.UpdatedAt = p.UpdatedAt.UnixMilli()
+	}
+	custom := sanitize.FromProfile(p)
+	out.CustomCSS = custom.CSS
+	if len(custom.Blocks) > 0 {
+		out.Blocks = custom.Blocks
+	}
+	for _, l := range links {
+		pl := PublicLink{ID: l.ID, Title: l.Title, URL: l.URL, Icon: l.Icon}
+		if p := protect.FromLink(l); p.Locked() {
+			pl.URL, pl.Protected = "", p.Mode
+		}
+		out.Links = append(out.Links, pl)
+	}
+	return out
+}
+
+// GET /api/public/{handle} — profil publik tanpa session
+func (h *Handler) Profile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	handle := profile.NormalizeHandle(r.PathValue("handle"))
+	if handle == "" {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "handle is required"})
+		return
+	}
+
+	p, err := h.profiles.FindByHandle(ctx, handle)
+	if err != nil {
+		log.Printf("public profile %s: %v", handle, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load profile"})
+		return
+	}
+	if p == nil {
+		w.Header().Set("Cache-Control", cacheControlNotFound)
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "profile not found"})
+		return
+	}
+
+	links, err := h.profiles.Links(ctx, p.ID)
+	if err != nil {
+		log.Printf("public profile %s links: %v", handle, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load profile"})
+		return
+	}
+
+	visible, personalized := experiment.Personalize(profile.PublicLinks(links, time.Now()), h.visitors.VisitorID(r, p.ID))
+	body, err := json.Marshal(NewPublicProfile(p, visible))
+	if err != nil {
+		log.Printf("public profile %s encode: %v", handle, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load profile"})
+		return
+	}
+	if personalized {
+		WritePersonalized(w, r, "application/json", body)
+		return
+	}
+	WriteCached(w, r, "application/json", body)
+}
+
+// WriteCached writes body with an ETag and CDN-friendly Cache-Control header,
+// answering 304 when the client already holds the same representation.
+func WriteCached(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	writeWithETag(w, r, contentType, body, cacheControlFound)
+}
+
+// WritePersonalized is WriteCached for per-visitor responses: the browser may revalidate
+// with the ETag, shared caches must not store it.
+func WritePersonalized(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	writeWithETag(w, r, contentType, body, cacheControlPersonalized)
+}
+
+// WriteImmutable is WriteCached for versioned URLs whose content never changes.
+func WriteImmutable(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	writeWithETag(w, r, contentType, body, cacheControlImmutable)
+}
+
+func writeWithETag(w http.ResponseWriter, r *http.Request, contentType string, body []byte, cacheControl string) {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("Vary", "Accept-Encoding")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(body)
+	}
+}
+
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
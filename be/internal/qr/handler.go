@@ -0,0 +1,251 @@
+package qr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"biomu/backend/internal/analytics"
+	"biomu/backend/internal/profile"
+	"biomu/backend/internal/public"
+	"biomu/backend/internal/unfurl"
+
+	"github.com/skip2/go-qrcode"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	defaultSize   = 512
+	minSize       = 64
+	maxSize       = 2048
+	minContrast   = 3.0
+	logoMaxBytes  = 2 << 20
+	logoMaxPixels = 4096 * 4096
+	logoTimeout   = 4 * time.Second
+	// Nilai UTM untuk membedakan scan QR dari trafik lain di analytics (field "source")
+	utmSource = "qr"
+	utmMedium = "qr_code"
+)
+
+type Handler struct {
+	profiles *profile.Store
+	fetcher  *unfurl.Fetcher
+	baseURL  string
+}
+
+// NewHandler creates the QR handler. baseURL is the public origin encoded in the codes;
+// fetcher downloads avatars for the optional logo.
+func NewHandler(profiles *profile.Store, fetcher *unfurl.Fetcher, baseURL string) *Handler {
+	return &Handler{profiles: profiles, fetcher: fetcher, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// GET /api/qr?target=profile|link&id=&format=png|svg&size=&fg=&bg=&ec=L|M|Q|H&logo=1&utm=0&campaign=
+//
+// target=profile: id adalah handle (atau ID akun); target=link: id adalah ID link dan QR berisi
+// URL redirect /r/{id} supaya scan tercatat sebagai klik. Default URL diberi utm_source=qr.
+func (h *Handler) QR(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	opts := Options{Size: defaultSize, Level: qrcode.Medium, FG: color.NRGBA{A: 0xff}, BG: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}}
+	if v := q.Get("size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < minSize || n > maxSize {
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "size must be between 64 and 2048"})
+			return
+		}
+		opts.Size = n
+	}
+	var err error
+	if v := q.Get("fg"); v != "" {
+		if opts.FG, err = ParseColor(v); err != nil {
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "fg: " + err.Error()})
+			return
+		}
+	}
+	if v := q.Get("bg"); v != "" {
+		if strings.EqualFold(v, "transparent") {
+			opts.BG.A = 0
+		} else if opts.BG, err = ParseColor(v); err != nil {
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bg: " + err.Error()})
+			return
+		}
+	}
+	// Tanpa kontras cukup banyak kamera gagal membaca; latar transparen dianggap putih
+	if Contrast(opts.FG, logoBackground(opts)) < minContrast {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "fg and bg do not have enough contrast"})
+		return
+	}
+	withLogo := q.Get("logo") == "1" || q.Get("logo") == "true"
+	ec := q.Get("ec")
+	switch {
+	case ec != "":
+		level, ok := ParseLevel(ec)
+		if !ok {
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "ec must be L, M, Q or H"})
+			return
+		}
+		if withLogo && level < qrcode.High {
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "logo requires ec Q or H"})
+			return
+		}
+		opts.Level = level
+	case withLogo:
+		opts.Level = qrcode.Highest
+	}
+	format := q.Get("format")
+	if format == "" {
+		format = "png"
+	}
+	if format != "png" && format != "svg" {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "format must be png or svg"})
+		return
+	}
+
+	id := strings.TrimSpace(q.Get("id"))
+	if id == "" {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "id is required"})
+		return
+	}
+	var owner *profile.Profile
+	var target, name string
+	switch q.Get("target") {
+	case "", "profile":
+		owner, err = h.profiles.FindByHandle(ctx, id)
+		if err == nil && owner == nil {
+			if owner, err = h.profiles.FindByID(ctx, id); owner != nil {
+				if _, hasRole := owner.Data["role"]; !hasRole || owner.Handle == "" {
+					owner = nil
+				}
+			}
+		}
+		if owner != nil {
+			target, name = h.baseURL+"/"+url.PathEscape(owner.Handle), owner.Handle
+		}
+	case "link":
+		var link *profile.Link
+		link, err = h.profiles.FindLink(ctx, id)
+		if err == nil && link != nil && link.ProfileID != "" {
+			owner, err = h.profiles.FindByID(ctx, link.ProfileID)
+		}
+		if owner != nil {
+			target, name = h.baseURL+"/r/"+url.PathEscape(link.ID), link.ID
+		}
+	default:
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "target must be profile or link"})
+		return
+	}
+	if err != nil {
+		log.Printf("qr %s: %v", id, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load target"})
+		return
+	}
+	if owner == nil {
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+
+	if q.Get("utm") != "0" && q.Get("utm") != "false" {
+		campaign := analytics.NormalizeSource(q.Get("campaign"))
+		if campaign == "" {
+			campaign = analytics.NormalizeSource(owner.Handle)
+		}
+		target = tagURL(target, campaign)
+	}
+	opts.Content = target
+	if withLogo {
+		opts.Logo = h.loadLogo(ctx, owner)
+	}
+
+	var buf bytes.Buffer
+	contentType := "image/png"
+	if format == "svg" {
+		contentType = "image/svg+xml"
+		err = RenderSVG(&buf, opts)
+	} else {
+		err = RenderPNG(&buf, opts)
+	}
+	if err != nil {
+		log.Printf("qr render %s: %v", id, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to render qr code"})
+		return
+	}
+	w.Header().Set("Content-Disposition", `inline; filename="qr-`+safeFilename(name)+"."+format+`"`)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	public.WriteCached(w, r, contentType, buf.Bytes())
+}
+
+// loadLogo downloads and decodes the owner's avatar; failures only drop the logo.
+func (h *Handler) loadLogo(ctx context.Context, owner *profile.Profile) image.Image {
+	src := strings.TrimSpace(owner.Image)
+	if src == "" {
+		return nil
+	}
+	// Avatar hasil upload disimpan sebagai path relatif terhadap origin publik
+	if strings.HasPrefix(src, "/") && !strings.HasPrefix(src, "//") {
+		src = h.baseURL + src
+	}
+	ctx, cancel := context.WithTimeout(ctx, logoTimeout)
+	defer cancel()
+	body, _, err := h.fetcher.Fetch(ctx, src, "image/*", logoMaxBytes)
+	if err != nil {
+		log.Printf("qr logo %s: %v", owner.ID, err)
+		return nil
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(body))
+	if err != nil || cfg.Width*cfg.Height > logoMaxPixels {
+		log.Printf("qr logo %s: unsupported image", owner.ID)
+		return nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(body))
+	if err != nil {
+		log.Printf("qr logo %s: %v", owner.ID, err)
+		return nil
+	}
+	return img
+}
+
+func tagURL(raw, campaign string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	v := u.Query()
+	v.Set("utm_source", utmSource)
+	v.Set("utm_medium", utmMedium)
+	if campaign != "" {
+		v.Set("utm_campaign", campaign)
+	}
+	u.RawQuery = v.Encode()
+	return u.String()
+}
+
+func safeFilename(s string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, s)
+}
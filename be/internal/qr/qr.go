@@ -0,0 +1,232 @@
+// Package qr membuat QR code (PNG/SVG) untuk URL profil dan link, opsional dengan logo
+// avatar di tengah. Encoding memakai go-qrcode; rendering dilakukan sendiri supaya setiap
+// modul berukuran piksel bulat (tajam saat dicetak).
+package qr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/image/draw"
+)
+
+const (
+	// logoRatio: lebar area logo terhadap lebar simbol (tanpa quiet zone). Dengan level Q/H
+	// (25%/30% data bisa dipulihkan) area ~5% ini aman.
+	logoRatio = 0.22
+	quietZone = 4
+)
+
+// Options describes one QR code rendering.
+type Options struct {
+	Content string
+	Level   qrcode.RecoveryLevel
+	// Size is the output width/height in pixels (PNG) or the width/height attribute (SVG).
+	Size int
+	FG   color.NRGBA
+	// BG with alpha 0 renders a transparent background.
+	BG   color.NRGBA
+	Logo image.Image
+}
+
+// ParseLevel maps L/M/Q/H to a recovery level.
+func ParseLevel(s string) (qrcode.RecoveryLevel, bool) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "L":
+		return qrcode.Low, true
+	case "M":
+		return qrcode.Medium, true
+	case "Q":
+		return qrcode.High, true
+	case "H":
+		return qrcode.Highest, true
+	}
+	return 0, false
+}
+
+// ParseColor parses "#rgb", "rrggbb" or "#rrggbb".
+func ParseColor(s string) (color.NRGBA, error) {
+	h := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(h) == 3 {
+		h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+	}
+	var c color.NRGBA
+	if len(h) != 6 {
+		return c, fmt.Errorf("invalid color %q", s)
+	}
+	if _, err := fmt.Sscanf(h, "%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return c, fmt.Errorf("invalid color %q", s)
+	}
+	c.A = 0xff
+	return c, nil
+}
+
+// Contrast returns the WCAG contrast ratio between two opaque colors.
+func Contrast(a, b color.NRGBA) float64 {
+	la, lb := luminance(a), luminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+func luminance(c color.NRGBA) float64 {
+	ch := func(v uint8) float64 {
+		f := float64(v) / 255
+		if f <= 0.03928 {
+			return f / 12.92
+		}
+		return math.Pow((f+0.055)/1.055, 2.4)
+	}
+	return 0.2126*ch(c.R) + 0.7152*ch(c.G) + 0.0722*ch(c.B)
+}
+
+// layout is the module grid plus pixel geometry shared by the PNG and SVG renderers.
+type layout struct {
+	bitmap [][]bool
+	n      int
+	// logo: area modul (inklusif-eksklusif) yang dikosongkan untuk logo; empty jika tanpa logo
+	logo image.Rectangle
+}
+
+func newLayout(o Options) (*layout, error) {
+	q, err := qrcode.New(o.Content, o.Level)
+	if err != nil {
+		return nil, err
+	}
+	l := &layout{bitmap: q.Bitmap()}
+	l.n = len(l.bitmap)
+	if o.Logo != nil {
+		symbol := l.n - 2*quietZone
+		m := int(math.Round(float64(symbol) * logoRatio))
+		if m%2 != symbol%2 {
+			m++ // simetris terhadap tengah
+		}
+		start := (l.n - m) / 2
+		l.logo = image.Rect(start, start, start+m, start+m)
+	}
+	return l, nil
+}
+
+func (l *layout) dark(x, y int) bool {
+	if image.Pt(x, y).In(l.logo) {
+		return false
+	}
+	return l.bitmap[y][x]
+}
+
+// logoBackground is the plate behind the logo: the background color, or white when the
+// background is transparent.
+func logoBackground(o Options) color.NRGBA {
+	if o.BG.A == 0 {
+		return color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	}
+	return o.BG
+}
+
+// fitLogo scales the logo into a square of side px, preserving the aspect ratio.
+func fitLogo(logo image.Image, px int) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, px, px))
+	b := logo.Bounds()
+	w, h := px, px
+	if b.Dx() > b.Dy() {
+		h = px * b.Dy() / b.Dx()
+	} else if b.Dy() > b.Dx() {
+		w = px * b.Dx() / b.Dy()
+	}
+	r := image.Rect((px-w)/2, (px-h)/2, (px-w)/2+w, (px-h)/2+h)
+	draw.CatmullRom.Scale(dst, r, logo, b, draw.Over, nil)
+	return dst
+}
+
+// RenderPNG writes the QR code as PNG. Modules are whole pixels; leftover pixels become
+// extra (background) margin so the symbol stays centered.
+func RenderPNG(w io.Writer, o Options) error {
+	l, err := newLayout(o)
+	if err != nil {
+		return err
+	}
+	module := max(1, o.Size/l.n)
+	size := max(o.Size, l.n)
+	offset := (size - module*l.n) / 2
+
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(o.BG), image.Point{}, draw.Src)
+	fg := image.NewUniform(o.FG)
+	for y := 0; y < l.n; y++ {
+		for x := 0; x < l.n; x++ {
+			if l.dark(x, y) {
+				r := image.Rect(offset+x*module, offset+y*module, offset+(x+1)*module, offset+(y+1)*module)
+				draw.Draw(img, r, fg, image.Point{}, draw.Src)
+			}
+		}
+	}
+	if o.Logo != nil {
+		plate := image.Rect(offset+l.logo.Min.X*module, offset+l.logo.Min.Y*module, offset+l.logo.Max.X*module, offset+l.logo.Max.Y*module)
+		draw.Draw(img, plate, image.NewUniform(logoBackground(o)), image.Point{}, draw.Src)
+		pad := module
+		inner := plate.Inset(pad)
+		if inner.Dx() > 0 {
+			draw.Draw(img, inner, fitLogo(o.Logo, inner.Dx()), image.Point{}, draw.Over)
+		}
+	}
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	return enc.Encode(w, img)
+}
+
+// RenderSVG writes the QR code as SVG with one path per row-run of dark modules. The logo is
+// embedded as a PNG data URI so the file stays self-contained.
+func RenderSVG(w io.Writer, o Options) error {
+	l, err := newLayout(o)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" shape-rendering="crispEdges">`, l.n, l.n, o.Size, o.Size)
+	if o.BG.A != 0 {
+		fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`, l.n, l.n, hex(o.BG))
+	}
+	fmt.Fprintf(&b, `<path fill="%s" d="`, hex(o.FG))
+	for y := 0; y < l.n; y++ {
+		for x := 0; x < l.n; {
+			if !l.dark(x, y) {
+				x++
+				continue
+			}
+			run := 1
+			for x+run < l.n && l.dark(x+run, y) {
+				run++
+			}
+			fmt.Fprintf(&b, "M%d %dh%dv1h-%dz", x, y, run, run)
+			x += run
+		}
+	}
+	b.WriteString(`"/>`)
+	if o.Logo != nil {
+		r := l.logo
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`, r.Min.X, r.Min.Y, r.Dx(), r.Dy(), hex(logoBackground(o)))
+		var buf bytes.Buffer
+		// Resolusi logo mengikuti ukuran output agar SVG tidak membengkak
+		px := max(32, o.Size*r.Dx()/l.n)
+		if err := png.Encode(&buf, fitLogo(o.Logo, px)); err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, `<image x="%d" y="%d" width="%d" height="%d" href="data:image/png;base64,%s"/>`,
+			r.Min.X+1, r.Min.Y+1, r.Dx()-2, r.Dy()-2, base64.StdEncoding.EncodeToString(buf.Bytes()))
+	}
+	b.WriteString(`</svg>`)
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+func hex(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,190 @@
+package qr
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/skip2/go-qrcode"
+)
+
+var (
+	black = color.NRGBA{A: 0xff}
+	white = color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		in   string
+		want color.NRGBA
+		err  bool
+	}{
+		{"#fff", white, false},
+		{"000000", black, false},
+		{" #1A2b3C ", color.NRGBA{R: 0x1a, G: 0x2b, B: 0x3c, A: 0xff}, false},
+		{"#12345", color.NRGBA{}, true},
+		{"#gggggg", color.NRGBA{}, true},
+		{"red", color.NRGBA{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseColor(tt.in)
+		if (err != nil) != tt.err || (!tt.err && got != tt.want) {
+			t.Errorf("ParseColor(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for in, want := range map[string]qrcode.RecoveryLevel{"l": qrcode.Low, "M": qrcode.Medium, " q ": qrcode.High, "H": qrcode.Highest} {
+		if got, ok := ParseLevel(in); !ok || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v", in, got, ok)
+		}
+	}
+	if _, ok := ParseLevel("X"); ok {
+		t.Error("unknown level accepted")
+	}
+}
+
+func TestContrast(t *testing.T) {
+	if c := Contrast(black, white); math.Abs(c-21) > 0.01 {
+		t.Errorf("black/white = %v, want 21", c)
+	}
+	if c := Contrast(white, black); math.Abs(c-21) > 0.01 {
+		t.Errorf("contrast is not symmetric: %v", c)
+	}
+	grey := color.NRGBA{R: 0x77, G: 0x77, B: 0x77, A: 0xff}
+	if c := Contrast(grey, white); c < 4.4 || c > 4.6 {
+		t.Errorf("#777/white = %v, want ~4.5", c)
+	}
+}
+
+func TestRenderPNG(t *testing.T) {
+	o := Options{Content: "https://aether.bio/r/l1", Level: qrcode.Medium, Size: 300, FG: black, BG: white}
+	var buf bytes.Buffer
+	if err := RenderPNG(&buf, o); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 300 || b.Dy() != 300 {
+		t.Fatalf("size %v, want 300x300", b)
+	}
+	l, _ := newLayout(o)
+	module := 300 / l.n
+	offset := (300 - module*l.n) / 2
+	// Setiap modul berukuran piksel bulat: cek piksel tengah modul terhadap bitmap
+	for y := 0; y < l.n; y++ {
+		for x := 0; x < l.n; x++ {
+			c := color.NRGBAModel.Convert(img.At(offset+x*module+module/2, offset+y*module+module/2)).(color.NRGBA)
+			if (c == black) != l.bitmap[y][x] {
+				t.Fatalf("module (%d,%d) = %v, bitmap %v", x, y, c, l.bitmap[y][x])
+			}
+		}
+	}
+}
+
+func TestLogoArea(t *testing.T) {
+	logo := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	o := Options{Content: "https://aether.bio/aether", Level: qrcode.Highest, Size: 512, FG: black, BG: white, Logo: logo}
+	l, err := newLayout(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	symbol := l.n - 2*quietZone
+	if l.logo.Empty() || l.logo.Dx() != l.logo.Dy() {
+		t.Fatalf("logo area %v", l.logo)
+	}
+	// Simetris terhadap tengah dan tidak melebihi ~25% luas simbol (batas pemulihan level H)
+	if l.logo.Min.X+l.logo.Max.X != l.n {
+		t.Errorf("logo area %v not centered in %d modules", l.logo, l.n)
+	}
+	if area := float64(l.logo.Dx()*l.logo.Dy()) / float64(symbol*symbol); area > 0.07 {
+		t.Errorf("logo covers %.1f%% of the symbol", area*100)
+	}
+	for y := l.logo.Min.Y; y < l.logo.Max.Y; y++ {
+		for x := l.logo.Min.X; x < l.logo.Max.X; x++ {
+			if l.dark(x, y) {
+				t.Fatalf("dark module (%d,%d) under the logo", x, y)
+			}
+		}
+	}
+}
+
+func TestRenderSVG(t *testing.T) {
+	o := Options{Content: "https://aether.bio/aether", Level: qrcode.Highest, Size: 256, FG: black, BG: color.NRGBA{}, Logo: image.NewNRGBA(image.Rect(0, 0, 4, 4))}
+	var buf bytes.Buffer
+	if err := RenderSVG(&buf, o); err != nil {
+		t.Fatal(err)
+	}
+	svg := buf.String()
+	dec := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		if _, err := dec.Token(); err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("invalid SVG: %v", err)
+			}
+			break
+		}
+	}
+	if !strings.Contains(svg, `width="256"`) || !strings.Contains(svg, `fill="#000000" d="M`) {
+		t.Fatalf("unexpected SVG header: %.200s", svg)
+	}
+	// Latar transparen: tidak ada rect latar, hanya plate putih di belakang logo
+	if strings.Count(svg, "<rect") != 1 || !strings.Contains(svg, `fill="#ffffff"`) || !strings.Contains(svg, "data:image/png;base64,") {
+		t.Fatalf("unexpected background/logo in SVG")
+	}
+}
+
+func TestTagURL(t *testing.T) {
+	tests := []struct {
+		raw, campaign, want string
+	}{
+		{"https://aether.bio/aether", "aether", "https://aether.bio/aether?utm_campaign=aether&utm_medium=qr_code&utm_source=qr"},
+		{"https://aether.bio/r/l1?utm_source=ig&x=1", "", "https://aether.bio/r/l1?utm_medium=qr_code&utm_source=qr&x=1"},
+	}
+	for _, tt := range tests {
+		if got := tagURL(tt.raw, tt.campaign); got != tt.want {
+			t.Errorf("tagURL(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+	if got := safeFilename("a/b c.é"); got != "a_b_c__" {
+		t.Errorf("safeFilename = %q", got)
+	}
+}
+
+// Parameter divalidasi sebelum Firestore dibaca.
+func TestQRParams(t *testing.T) {
+	h := NewHandler(nil, nil, "https://aether.bio")
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"size=32&id=x", "size must be between 64 and 2048"},
+		{"size=big&id=x", "size must be between 64 and 2048"},
+		{"fg=nope&id=x", "fg: invalid color"},
+		{"fg=eeeeee&bg=ffffff&id=x", "fg and bg do not have enough contrast"},
+		{"fg=eeeeee&bg=transparent&id=x", "fg and bg do not have enough contrast"},
+		{"ec=Z&id=x", "ec must be L, M, Q or H"},
+		{"logo=1&ec=M&id=x", "logo requires ec Q or H"},
+		{"format=gif&id=x", "format must be png or svg"},
+		{"", "id is required"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.QR(w, httptest.NewRequest(http.MethodGet, "/api/qr?"+tt.query, nil))
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%s: %d %s, want 400 %q", tt.query, w.Code, strings.TrimSpace(w.Body.String()), tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Package ratelimit menghitung request per key dalam fixed window, di memory per instance.
+// Dipakai bersama oleh percobaan password link (protect), beacon analytics, form newsletter
+// dan kontak, serta kuota tulis per tier (entitlement).
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter counts requests per key in a fixed window.
+type Limiter struct {
+	max    int
+	window time.Duration
+
+	mu      sync.Mutex
+	windows map[string]window
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+// New returns a limiter allowing max requests per key in each period. max <= 0 means
+// unlimited, unless the maximum is passed per call with TakeMax.
+func New(max int, period time.Duration) *Limiter {
+	return &Limiter{max: max, window: period, windows: map[string]window{}}
+}
+
+// Take reserves a request for key. It fails when the window is used up and then also
+// returns how long until it resets. Reserving up front keeps concurrent guesses bounded.
+func (l *Limiter) Take(key string, now time.Time) (bool, time.Duration) {
+	return l.TakeMax(key, l.max, now)
+}
+
+// TakeMax is Take with a per-call maximum, for limits that depend on the caller (mis. tier
+// akun). max <= 0 means unlimited.
+func (l *Limiter) TakeMax(key string, max int, now time.Time) (bool, time.Duration) {
+	if max <= 0 {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	w := l.windows[key]
+	if now.Sub(w.start) >= l.window {
+		w = window{start: now}
+	}
+	if w.count >= max {
+		return false, l.window - now.Sub(w.start)
+	}
+	w.count++
+	l.windows[key] = w
+	return true, 0
+}
+
+// Refund returns a reserved request (e.g. after a correct password).
+func (l *Limiter) Refund(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if w, ok := l.windows[key]; ok && w.count > 0 {
+		w.count--
+		l.windows[key] = w
+	}
+}
+
+// Used returns the requests made by key in the current window.
+func (l *Limiter) Used(key string, now time.Time) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.window {
+		return 0
+	}
+	return w.count
+}
+
+// GC drops expired windows.
+func (l *Limiter) GC(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for k, w := range l.windows {
+		if now.Sub(w.start) >= l.window {
+			delete(l.windows, k)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTake(t *testing.T) {
+	l := New(3, time.Hour)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Take("a", now); !ok {
+			t.Fatalf("request %d rejected", i+1)
+		}
+	}
+	ok, wait := l.Take("a", now.Add(20*time.Minute))
+	if ok || wait != 40*time.Minute {
+		t.Fatalf("Take = %v %v, want false 40m", ok, wait)
+	}
+	// Key lain punya window sendiri
+	if ok, _ := l.Take("b", now); !ok {
+		t.Fatal("other key rejected")
+	}
+	// Window baru setelah periode habis
+	if ok, _ := l.Take("a", now.Add(time.Hour)); !ok {
+		t.Fatal("rejected after the window reset")
+	}
+	if got := l.Used("a", now.Add(time.Hour)); got != 1 {
+		t.Fatalf("Used = %d, want 1", got)
+	}
+}
+
+func TestTakeMax(t *testing.T) {
+	l := New(0, time.Hour)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 100; i++ {
+		if ok, _ := l.TakeMax("free", 0, now); !ok {
+			t.Fatal("max 0 must be unlimited")
+		}
+	}
+	if got := l.Used("free", now); got != 0 {
+		t.Fatalf("unlimited requests counted: %d", got)
+	}
+	l.TakeMax("u1", 2, now)
+	l.TakeMax("u1", 2, now)
+	if ok, _ := l.TakeMax("u1", 2, now); ok {
+		t.Fatal("third request allowed with max 2")
+	}
+	// Maksimum lebih tinggi (mis. setelah upgrade tier) langsung berlaku di window yang sama
+	if ok, _ := l.TakeMax("u1", 5, now); !ok {
+		t.Fatal("higher max rejected")
+	}
+}
+
+func TestRefund(t *testing.T) {
+	l := New(1, time.Hour)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	l.Take("a", now)
+	l.Refund("a")
+	l.Refund("a")
+	if got := l.Used("a", now); got != 0 {
+		t.Fatalf("Used = %d after refund", got)
+	}
+	if ok, _ := l.Take("a", now); !ok {
+		t.Fatal("refunded request not available")
+	}
+	l.Refund("missing")
+}
+
+func TestGC(t *testing.T) {
+	l := New(1, time.Hour)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	l.Take("old", now)
+	l.Take("new", now.Add(30*time.Minute))
+	l.GC(now.Add(time.Hour))
+	if _, ok := l.windows["old"]; ok {
+		t.Fatal("expired window kept")
+	}
+	if _, ok := l.windows["new"]; !ok {
+		t.Fatal("current window dropped")
+	}
+}
@@ -0,0 +1,181 @@
+package redirect
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"biomu/backend/internal/analytics"
+	"biomu/backend/internal/botfilter"
+	"biomu/backend/internal/enrich"
+	"biomu/backend/internal/experiment"
+	"biomu/backend/internal/profile"
+	"biomu/backend/internal/protect"
+	"biomu/backend/internal/screen"
+	"biomu/backend/internal/targeting"
+	"biomu/backend/internal/visitor"
+)
+
+type Handler struct {
+	profiles *profile.Store
+	recorder *analytics.Recorder
+	visitors *visitor.Identifier
+	bots     *botfilter.Classifier
+	counters *experiment.Counters
+	guard    *protect.Guard
+	screener *screen.Screener
+	// countBots: jika true, klik bot/suspicious juga menambah counter "clicks" di dokumen link
+	countBots bool
+}
+
+func NewHandler(profiles *profile.Store, recorder *analytics.Recorder, visitors *visitor.Identifier, bots *botfilter.Classifier, counters *experiment.Counters, guard *protect.Guard, screener *screen.Screener, countBots bool) *Handler {
+	return &Handler{profiles: profiles, recorder: recorder, visitors: visitors, bots: bots, counters: counters, guard: guard, screener: screener, countBots: countBots}
+}
+
+// GET /r/{linkId} — catat klik lalu redirect 302 ke URL tujuan link
+func (h *Handler) Link(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, false)
+}
+
+// GET /go/{linkId} — seperti /r, tapi tujuan dipilih lewat aturan targeting di dokumen link
+// (negara, OS, device, bahasa, jendela waktu) dengan fallbackUrl.
+func (h *Handler) Targeted(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, true)
+}
+
+func (h *Handler) serve(w http.ResponseWriter, r *http.Request, targeted bool) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	linkID := r.PathValue("linkId")
+	if linkID == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	link, err := h.profiles.FindLink(ctx, linkID)
+	if err != nil {
+		log.Printf("redirect %s: %v", linkID, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	// Link yang sudah dihapus, dinonaktifkan, atau di luar jadwal tidak boleh jadi open redirect.
+	now := time.Now()
+	if link == nil || !link.VisibleAt(now) || link.ProfileID == "" {
+		http.NotFound(w, r)
+		return
+	}
+	// Link terkunci (password, konten sensitif, members only) → interstitial dulu
+	if !h.guard.Viewer(r).Allowed(*link) {
+		via := "r"
+		if targeted {
+			via = "go"
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		http.Redirect(w, r, "/unlock/"+url.PathEscape(link.ID)+"?via="+via, http.StatusFound)
+		return
+	}
+	visitorID := h.visitors.VisitorID(r, link.ProfileID)
+	// A/B test: varian (judul/URL) dipilih sticky per visitor sebelum aturan targeting
+	var variant *experiment.Variant
+	if exp, ok := experiment.FromLink(*link); ok {
+		variant = exp.Assign(link.ID, visitorID)
+	}
+	resolved := experiment.Apply(*link, variant)
+	destination := resolved.URL
+	if targeted {
+		info, ok := enrich.FromContext(ctx)
+		if !ok {
+			info = enrich.NewEnricher(nil).Enrich(r)
+		}
+		destination = targeting.Resolve(resolved, targeting.VisitorFromRequest(r, info, now)).URL
+	}
+	target, ok := SafeTarget(destination)
+	if !ok {
+		log.Printf("redirect %s: refusing unsafe target %q", linkID, destination)
+		http.NotFound(w, r)
+		return
+	}
+	// Blocklist bisa bertambah setelah link disimpan: tujuan yang kini diblokir tidak di-redirect
+	if h.screener != nil {
+		if f := h.screener.Check(target); f.Action == screen.ActionBlock {
+			log.Printf("redirect %s: blocked target %q (%s)", linkID, target, f.Reason)
+			http.NotFound(w, r)
+			return
+		}
+	}
+	owner, err := h.profiles.FindByID(ctx, link.ProfileID)
+	if err != nil {
+		log.Printf("redirect %s owner: %v", linkID, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if owner == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if h.recorder != nil {
+		referrer := analytics.ReferrerHost(r)
+		verdict := h.bots.Classify(botfilter.Hit{
+			Request:   r,
+			ProfileID: link.ProfileID,
+			VisitorID: visitorID,
+			Click:     true,
+			Referrer:  referrer,
+		})
+		e := analytics.Event{
+			Type:      analytics.EventClick,
+			ProfileID: link.ProfileID,
+			LinkID:    link.ID,
+			Timestamp: now,
+			Referrer:  referrer,
+			Source:    analytics.SourceFromRequest(r),
+			VisitorID: visitorID,
+			Class:     verdict.Class,
+			Reason:    verdict.Reason,
+		}
+		if variant != nil {
+			e.Variant = variant.ID
+		}
+		e.Enrich(r)
+		h.recorder.Record(e)
+		if verdict.Human() || h.countBots {
+			h.recorder.CountClick(link.ID)
+		}
+		// Hasil A/B test hanya memakai klik human, terlepas dari ANALYTICS_COUNT_BOTS
+		if variant != nil && verdict.Human() && h.counters != nil {
+			h.counters.Click(link.ID, variant.ID)
+		}
+	}
+
+	// Tujuan bergantung pada pengunjung; jangan di-cache CDN/browser
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// SafeTarget validates a stored link URL before it is used as a redirect target:
+// only absolute http(s) URLs with a host and without embedded credentials are allowed.
+func SafeTarget(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.ContainsAny(raw, "\r\n\t") {
+		return "", false
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", false
+	}
+	if u.Host == "" || u.User != nil {
+		return "", false
+	}
+	return u.String(), true
+}
@@ -0,0 +1,670 @@
+package sanitize
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxCSSBytes membatasi custom CSS per akun.
+	MaxCSSBytes = 32 << 10
+	maxRules    = 500
+	maxDropped  = 50
+	maxFuncNest = 4
+)
+
+var ErrTooLarge = errors.New("input too large")
+
+var (
+	safeIdentRe = regexp.MustCompile(`^(--|-?[a-zA-Z_])[a-zA-Z0-9_-]*$`)
+	hexColorRe  = regexp.MustCompile(`^([0-9a-fA-F]{3,4}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+	// Hanya file media yang di-host sendiri; url() ke host luar bisa dipakai untuk tracking
+	localURLRe = regexp.MustCompile(`^/media/[A-Za-z0-9._~/-]+$`)
+	anbUnitRe  = regexp.MustCompile(`^[nN](-[0-9]*)?$`)
+)
+
+var allowedProperties = set(
+	"color", "background", "background-color", "background-image", "background-position", "background-size",
+	"background-repeat", "background-attachment", "background-clip", "background-origin", "background-blend-mode",
+	"border", "border-top", "border-right", "border-bottom", "border-left", "border-color", "border-style",
+	"border-width", "border-radius", "border-top-left-radius", "border-top-right-radius",
+	"border-bottom-left-radius", "border-bottom-right-radius", "outline", "outline-color", "outline-offset",
+	"outline-style", "outline-width", "box-shadow", "text-shadow", "opacity", "filter", "backdrop-filter",
+	"font", "font-family", "font-size", "font-style", "font-weight", "font-variant", "letter-spacing", "line-height",
+	"text-align", "text-decoration", "text-decoration-color", "text-decoration-style", "text-decoration-thickness",
+	"text-transform", "text-indent", "text-underline-offset", "white-space", "word-spacing", "word-break",
+	"overflow-wrap", "vertical-align", "list-style", "list-style-type", "list-style-position",
+	"margin", "margin-top", "margin-right", "margin-bottom", "margin-left", "padding", "padding-top",
+	"padding-right", "padding-bottom", "padding-left", "width", "min-width", "max-width", "height", "min-height",
+	"max-height", "aspect-ratio", "box-sizing", "display", "visibility", "overflow", "overflow-x", "overflow-y",
+	"flex", "flex-direction", "flex-wrap", "flex-grow", "flex-shrink", "flex-basis", "justify-content",
+	"align-items", "align-self", "align-content", "order", "gap", "row-gap", "column-gap", "grid-template-columns",
+	"grid-template-rows", "grid-column", "grid-row", "object-fit", "object-position", "transform",
+	"transform-origin", "transition", "transition-property", "transition-duration", "transition-timing-function",
+	"transition-delay", "cursor", "accent-color", "caret-color",
+)
+
+var allowedFunctions = set(
+	"rgb", "rgba", "hsl", "hsla", "hwb", "lab", "lch", "oklab", "oklch", "color-mix",
+	"linear-gradient", "radial-gradient", "conic-gradient", "repeating-linear-gradient",
+	"repeating-radial-gradient", "calc", "min", "max", "clamp", "var", "url",
+	"translate", "translatex", "translatey", "rotate", "scale", "scalex", "scaley", "skew", "skewx", "skewy",
+	"blur", "brightness", "contrast", "grayscale", "saturate", "sepia", "drop-shadow", "hue-rotate", "invert",
+	"cubic-bezier", "steps", "repeat", "minmax",
+)
+
+var allowedUnits = set("px", "em", "rem", "vh", "vw", "vmin", "vmax", "dvh", "svh", "ch", "ex", "deg", "rad",
+	"turn", "grad", "s", "ms", "fr")
+
+var (
+	pseudoClasses = set("hover", "focus", "focus-visible", "focus-within", "active", "visited", "first-child",
+		"last-child", "only-child", "first-of-type", "last-of-type", "empty", "root")
+	pseudoFunctions = set("nth-child", "nth-last-child", "nth-of-type", "nth-last-of-type")
+)
+
+func set(items ...string) map[string]bool {
+	m := make(map[string]bool, len(items))
+	for _, it := range items {
+		m[it] = true
+	}
+	return m
+}
+
+// node is a component value: a token, or a block/function with its contents.
+type node struct {
+	tok      token
+	children []node
+}
+
+func (n node) isBlock(k tokenKind) bool { return n.tok.kind == k && n.children != nil }
+
+func parseNodes(toks []token, i *int, closer tokenKind) []node {
+	out := []node{}
+	for *i < len(toks) {
+		t := toks[*i]
+		*i++
+		if t.kind == closer {
+			return out
+		}
+		switch t.kind {
+		case tkLBrace:
+			out = append(out, node{tok: t, children: parseNodes(toks, i, tkRBrace)})
+		case tkLParen, tkFunction:
+			out = append(out, node{tok: t, children: parseNodes(toks, i, tkRParen)})
+		case tkLBracket:
+			out = append(out, node{tok: t, children: parseNodes(toks, i, tkRBracket)})
+		default:
+			out = append(out, node{tok: t})
+		}
+	}
+	return out
+}
+
+type cssSanitizer struct {
+	scope   string
+	dropped []string
+	seen    map[string]bool
+	rules   int
+}
+
+func (s *cssSanitizer) drop(what string) {
+	if s.seen[what] || len(s.dropped) >= maxDropped {
+		return
+	}
+	s.seen[what] = true
+	s.dropped = append(s.dropped, what)
+}
+
+// CSS sanitizes a user stylesheet. Every selector is scoped under scope (mis. ".custom";
+// html, body dan :root dipetakan ke scope itu sendiri). Only allowlisted properties,
+// functions and units survive; url() is limited to self-hosted /media/ files; @import,
+// @font-face, @keyframes and every at-rule other than @media are removed. dropped lists
+// what was removed, for feedback in the editor.
+func CSS(src, scope string) (out string, dropped []string, err error) {
+	if len(src) > MaxCSSBytes {
+		return "", nil, ErrTooLarge
+	}
+	toks := newTokenizer(src).all()
+	i := 0
+	nodes := parseNodes(toks, &i, tkEOF)
+	s := &cssSanitizer{scope: scope, seen: map[string]bool{}}
+	var b strings.Builder
+	s.rulesList(&b, nodes, false)
+	return b.String(), s.dropped, nil
+}
+
+func (s *cssSanitizer) rulesList(b *strings.Builder, nodes []node, nested bool) {
+	var prelude []node
+	for _, n := range nodes {
+		switch {
+		case len(prelude) == 0 && (n.tok.kind == tkWhitespace || n.tok.kind == tkCDO || n.tok.kind == tkCDC):
+			continue
+		case n.isBlock(tkLBrace):
+			s.rule(b, prelude, n.children, nested)
+			prelude = nil
+		case n.tok.kind == tkSemicolon && len(prelude) > 0 && prelude[0].tok.kind == tkAtKeyword:
+			// At-rule tanpa block (@import, @charset, @namespace)
+			s.drop("@" + strings.ToLower(prelude[0].tok.value))
+			prelude = nil
+		default:
+			prelude = append(prelude, n)
+		}
+	}
+	if len(prelude) > 0 && prelude[0].tok.kind == tkAtKeyword {
+		s.drop("@" + strings.ToLower(prelude[0].tok.value))
+	}
+}
+
+func (s *cssSanitizer) rule(b *strings.Builder, prelude, block []node, nested bool) {
+	if s.rules >= maxRules {
+		s.drop("rules beyond the first " + strconv.Itoa(maxRules))
+		return
+	}
+	s.rules++
+	if len(prelude) == 0 {
+		s.drop("rule without selector")
+		return
+	}
+	if prelude[0].tok.kind == tkAtKeyword {
+		name := strings.ToLower(prelude[0].tok.value)
+		if name != "media" || nested {
+			s.drop("@" + name)
+			return
+		}
+		query, ok := mediaQuery(prelude[1:])
+		if !ok {
+			s.drop("@media query")
+			return
+		}
+		var inner strings.Builder
+		s.rulesList(&inner, block, true)
+		if inner.Len() > 0 {
+			b.WriteString("@media " + query + "{\n" + inner.String() + "}\n")
+		}
+		return
+	}
+	sel, ok := s.selectorList(prelude)
+	if !ok {
+		s.drop("selector " + strconv.Quote(truncate(serializeRaw(prelude), 60)))
+		return
+	}
+	decls := s.declarations(block)
+	if len(decls) > 0 {
+		b.WriteString(sel + "{" + strings.Join(decls, ";") + "}\n")
+	}
+}
+
+func (s *cssSanitizer) declarations(block []node) []string {
+	var out []string
+	var cur []node
+	flush := func() {
+		if d, ok := s.declaration(trimSpace(cur)); ok {
+			out = append(out, d)
+		}
+		cur = nil
+	}
+	for _, n := range block {
+		if n.tok.kind == tkSemicolon {
+			flush()
+			continue
+		}
+		cur = append(cur, n)
+	}
+	flush()
+	return out
+}
+
+func (s *cssSanitizer) declaration(nodes []node) (string, bool) {
+	if len(nodes) == 0 {
+		return "", false
+	}
+	if nodes[0].tok.kind != tkIdent {
+		s.drop("declaration " + strconv.Quote(truncate(serializeRaw(nodes), 60)))
+		return "", false
+	}
+	name := nodes[0].tok.value
+	custom := strings.HasPrefix(name, "--")
+	if !custom {
+		name = strings.ToLower(name)
+	}
+	if !safeIdentRe.MatchString(name) || (!custom && !allowedProperties[name]) {
+		s.drop("property " + strconv.Quote(truncate(name, 60)))
+		return "", false
+	}
+	rest := trimSpace(nodes[1:])
+	if len(rest) == 0 || rest[0].tok.kind != tkColon {
+		return "", false
+	}
+	value := trimSpace(rest[1:])
+	important := false
+	if n := len(value); n >= 2 && value[n-1].tok.kind == tkIdent && strings.EqualFold(value[n-1].tok.value, "important") {
+		bang := trimSpace(value[:n-1])
+		if k := len(bang); k > 0 && bang[k-1].tok.kind == tkDelim && bang[k-1].tok.value == "!" {
+			value, important = trimSpace(bang[:k-1]), true
+		}
+	}
+	v, ok := s.value(value, 0)
+	if !ok || v == "" {
+		s.drop("value of " + name)
+		return "", false
+	}
+	if important {
+		v += " !important"
+	}
+	return name + ":" + v, true
+}
+
+func (s *cssSanitizer) value(nodes []node, depth int) (string, bool) {
+	if depth > maxFuncNest {
+		return "", false
+	}
+	var parts []token
+	var b strings.Builder
+	for _, n := range nodes {
+		t := n.tok
+		var piece string
+		switch t.kind {
+		case tkWhitespace:
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), " ") {
+				b.WriteByte(' ')
+			}
+			continue
+		case tkIdent:
+			if !safeIdentRe.MatchString(t.value) {
+				return "", false
+			}
+			piece = t.value
+		case tkNumber:
+			piece = t.value
+		case tkPercentage:
+			piece = t.value + "%"
+		case tkDimension:
+			unit := strings.ToLower(t.unit)
+			if !allowedUnits[unit] {
+				return "", false
+			}
+			piece = t.value + unit
+		case tkHash:
+			if !hexColorRe.MatchString(t.value) {
+				return "", false
+			}
+			piece = "#" + t.value
+		case tkString:
+			piece = quoteString(t.value)
+		case tkComma:
+			piece = ","
+		case tkDelim:
+			if !strings.Contains("/+-*", t.value) {
+				return "", false
+			}
+			piece = t.value
+		case tkURL:
+			u, ok := localURL(t.value)
+			if !ok {
+				s.drop("url(" + truncate(t.value, 60) + ")")
+				return "", false
+			}
+			piece = u
+		case tkFunction:
+			name := strings.ToLower(t.value)
+			if !allowedFunctions[name] {
+				s.drop(name + "()")
+				return "", false
+			}
+			args := trimSpace(n.children)
+			switch name {
+			case "url":
+				if len(args) != 1 || args[0].tok.kind != tkString {
+					return "", false
+				}
+				u, ok := localURL(args[0].tok.value)
+				if !ok {
+					s.drop("url(" + truncate(args[0].tok.value, 60) + ")")
+					return "", false
+				}
+				piece = u
+			case "var":
+				if len(args) == 0 || args[0].tok.kind != tkIdent || !strings.HasPrefix(args[0].tok.value, "--") {
+					return "", false
+				}
+				fallthrough
+			default:
+				inner, ok := s.value(args, depth+1)
+				if !ok {
+					return "", false
+				}
+				piece = name + "(" + inner + ")"
+			}
+		default:
+			return "", false
+		}
+		// Token yang bersebelahan bisa menyatu saat di-parse ulang (mis. komentar yang dibuang
+		// di antara "a" dan "b", atau "/" diikuti "*" yang membuka komentar): beri spasi.
+		if len(parts) > 0 && !strings.HasSuffix(b.String(), " ") && needsSpace(parts[len(parts)-1], t) {
+			b.WriteByte(' ')
+		}
+		parts = append(parts, t)
+		b.WriteString(piece)
+	}
+	return strings.TrimSpace(b.String()), true
+}
+
+func localURL(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if !localURLRe.MatchString(raw) || strings.Contains(raw, "..") || strings.Contains(raw, "//") {
+		return "", false
+	}
+	return `url("` + raw + `")`, true
+}
+
+// selectorList scopes every selector of a rule prelude; any unsupported selector
+// (atribut, pseudo-element, :not, escape aneh) invalidates the whole rule.
+func (s *cssSanitizer) selectorList(prelude []node) (string, bool) {
+	var out []string
+	var cur []node
+	for _, n := range append(prelude, node{tok: token{kind: tkComma}}) {
+		if n.tok.kind != tkComma {
+			cur = append(cur, n)
+			continue
+		}
+		sel, ok := s.selector(trimSpace(cur))
+		if !ok {
+			return "", false
+		}
+		out = append(out, sel)
+		cur = nil
+	}
+	return strings.Join(out, ","), true
+}
+
+func (s *cssSanitizer) selector(nodes []node) (string, bool) {
+	if len(nodes) == 0 {
+		return "", false
+	}
+	var compounds []string
+	var combinators []string
+	var cur strings.Builder
+	pendingComb := ""
+	endCompound := func() bool {
+		if cur.Len() == 0 {
+			return false
+		}
+		compounds = append(compounds, cur.String())
+		cur.Reset()
+		return true
+	}
+	for i := 0; i < len(nodes); i++ {
+		n := nodes[i]
+		t := n.tok
+		switch {
+		case t.kind == tkWhitespace || (t.kind == tkDelim && (t.value == ">" || t.value == "+" || t.value == "~")):
+			if cur.Len() > 0 {
+				endCompound()
+				pendingComb = " "
+			}
+			if t.kind == tkDelim {
+				if len(compounds) == 0 || (pendingComb != " " && pendingComb != "") {
+					return "", false
+				}
+				pendingComb = t.value
+			}
+			continue
+		}
+		if pendingComb != "" {
+			combinators = append(combinators, pendingComb)
+			pendingComb = ""
+		}
+		switch {
+		case t.kind == tkIdent && cur.Len() == 0:
+			if !safeIdentRe.MatchString(t.value) || strings.HasPrefix(t.value, "-") {
+				return "", false
+			}
+			cur.WriteString(strings.ToLower(t.value))
+		case t.kind == tkDelim && t.value == "*" && cur.Len() == 0:
+			cur.WriteString("*")
+		case t.kind == tkDelim && t.value == ".":
+			if i+1 >= len(nodes) || nodes[i+1].tok.kind != tkIdent || !safeIdentRe.MatchString(nodes[i+1].tok.value) {
+				return "", false
+			}
+			i++
+			cur.WriteString("." + nodes[i].tok.value)
+		case t.kind == tkHash:
+			if !t.idHash || !safeIdentRe.MatchString(t.value) {
+				return "", false
+			}
+			cur.WriteString("#" + t.value)
+		case t.kind == tkColon:
+			if i+1 >= len(nodes) {
+				return "", false
+			}
+			i++
+			p := nodes[i].tok
+			name := strings.ToLower(p.value)
+			switch {
+			case p.kind == tkIdent && pseudoClasses[name]:
+				cur.WriteString(":" + name)
+			case p.kind == tkFunction && pseudoFunctions[name]:
+				anb, ok := anPlusB(trimSpace(nodes[i].children))
+				if !ok {
+					return "", false
+				}
+				cur.WriteString(":" + name + "(" + anb + ")")
+			default:
+				return "", false
+			}
+		default:
+			return "", false
+		}
+	}
+	if pendingComb != "" && pendingComb != " " {
+		return "", false
+	}
+	endCompound()
+
+	// html/body/:root di awal → scope itu sendiri, sisanya di bawah scope
+	var b strings.Builder
+	first := compounds[0]
+	if rest, ok := rootCompound(first, s.scope); ok {
+		b.WriteString(s.scope + rest)
+	} else {
+		b.WriteString(s.scope + " " + first)
+	}
+	for i, c := range compounds[1:] {
+		if combinators[i] == " " {
+			b.WriteString(" " + c)
+		} else {
+			b.WriteString(" " + combinators[i] + " " + c)
+		}
+	}
+	return b.String(), true
+}
+
+// rootCompound strips a leading html, body, :root or the scope itself (CSS yang sudah
+// disanitasi) from a compound selector.
+func rootCompound(c, scope string) (string, bool) {
+	for _, root := range []string{"html", "body", ":root", scope} {
+		rest, ok := strings.CutPrefix(c, root)
+		if ok && (rest == "" || strings.ContainsRune(".:#", rune(rest[0]))) {
+			return rest, true
+		}
+	}
+	return "", false
+}
+
+// anPlusB validates the argument of :nth-child() and friends (odd, even, 2n+1, -n+3, 5).
+func anPlusB(nodes []node) (string, bool) {
+	if len(nodes) == 0 || len(nodes) > 5 {
+		return "", false
+	}
+	var b strings.Builder
+	for _, n := range nodes {
+		t := n.tok
+		switch t.kind {
+		case tkWhitespace:
+			b.WriteByte(' ')
+		case tkIdent:
+			v := strings.ToLower(t.value)
+			if v != "odd" && v != "even" && !anbUnitRe.MatchString(strings.TrimPrefix(v, "-")) {
+				return "", false
+			}
+			b.WriteString(v)
+		case tkNumber:
+			b.WriteString(t.value)
+		case tkDimension:
+			if !anbUnitRe.MatchString(t.unit) || strings.ContainsAny(t.value, ".eE") {
+				return "", false
+			}
+			b.WriteString(t.value + strings.ToLower(t.unit))
+		case tkDelim:
+			if t.value != "+" && t.value != "-" {
+				return "", false
+			}
+			b.WriteString(t.value)
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+// mediaQuery accepts feature queries such as "screen and (max-width: 480px)" and
+// "(prefers-color-scheme: dark)".
+func mediaQuery(nodes []node) (string, bool) {
+	var b strings.Builder
+	prevWord := false
+	for _, n := range trimSpace(nodes) {
+		t := n.tok
+		switch {
+		case t.kind == tkWhitespace:
+			b.WriteByte(' ')
+			prevWord = false
+		case t.kind == tkIdent && safeIdentRe.MatchString(t.value):
+			if prevWord {
+				b.WriteByte(' ')
+			}
+			b.WriteString(strings.ToLower(t.value))
+			prevWord = true
+		case t.kind == tkComma:
+			b.WriteByte(',')
+			prevWord = false
+		case n.isBlock(tkLParen):
+			inner := trimSpace(n.children)
+			if len(inner) == 0 || inner[0].tok.kind != tkIdent || !safeIdentRe.MatchString(inner[0].tok.value) {
+				return "", false
+			}
+			f := strings.ToLower(inner[0].tok.value)
+			rest := trimSpace(inner[1:])
+			if len(rest) > 0 {
+				if rest[0].tok.kind != tkColon {
+					return "", false
+				}
+				v := trimSpace(rest[1:])
+				if len(v) != 1 {
+					return "", false
+				}
+				switch vt := v[0].tok; {
+				case vt.kind == tkIdent && safeIdentRe.MatchString(vt.value):
+					f += ":" + strings.ToLower(vt.value)
+				case vt.kind == tkNumber:
+					f += ":" + vt.value
+				case vt.kind == tkDimension && allowedUnits[strings.ToLower(vt.unit)]:
+					f += ":" + vt.value + strings.ToLower(vt.unit)
+				default:
+					return "", false
+				}
+			}
+			b.WriteString("(" + f + ")")
+			prevWord = false
+		default:
+			return "", false
+		}
+	}
+	q := strings.Join(strings.Fields(b.String()), " ")
+	return q, q != ""
+}
+
+// needsSpace reports whether serializing a directly followed by b could re-tokenize
+// differently.
+func needsSpace(a, b token) bool {
+	if a.kind == tkDelim && a.value == "/" && b.kind == tkDelim && b.value == "*" {
+		return true
+	}
+	return wordLike(a) && wordLike(b)
+}
+
+func wordLike(t token) bool {
+	switch t.kind {
+	case tkIdent, tkFunction, tkNumber, tkPercentage, tkDimension, tkHash, tkURL, tkString:
+		return true
+	case tkDelim:
+		return t.value == "-" || t.value == "+"
+	}
+	return false
+}
+
+func quoteString(v string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range v {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteString(`\` + string(r))
+		case r < 0x20 || r == 0x7f || r == '<' || r == '>' || r == '&':
+			// <, > dan & di-escape supaya aman disisipkan di dalam <style>
+			b.WriteString(`\` + strconv.FormatInt(int64(r), 16) + " ")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func trimSpace(nodes []node) []node {
+	for len(nodes) > 0 && nodes[0].tok.kind == tkWhitespace {
+		nodes = nodes[1:]
+	}
+	for len(nodes) > 0 && nodes[len(nodes)-1].tok.kind == tkWhitespace {
+		nodes = nodes[:len(nodes)-1]
+	}
+	return nodes
+}
+
+// serializeRaw is a rough rendering used only in "dropped" messages.
+func serializeRaw(nodes []node) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		t := n.tok
+		switch t.kind {
+		case tkWhitespace:
+			b.WriteByte(' ')
+		case tkIdent, tkDelim, tkNumber:
+			b.WriteString(t.value)
+		case tkAtKeyword:
+			b.WriteString("@" + t.value)
+		case tkHash:
+			b.WriteString("#" + t.value)
+		case tkColon:
+			b.WriteByte(':')
+		case tkComma:
+			b.WriteByte(',')
+		case tkFunction:
+			b.WriteString(t.value + "(…)")
+		case tkLBracket:
+			b.WriteString("[…]")
+		case tkLParen:
+			b.WriteString("(…)")
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-1]) + "…"
+}
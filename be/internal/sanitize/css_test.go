@@ -0,0 +1,130 @@
+package sanitize
+
+import (
+	"strings"
+	"testing"
+)
+
+const testScope = ".custom"
+
+var cssSeeds = []string{
+	`.card { color: red; background: url("/media/u1/bg.png") }`,
+	`body{background:#fff}:root{--accent:#f0a}a:hover{color:var(--accent)}`,
+	`@media screen and (max-width: 480px) { .links > a + a { margin: 0 } }`,
+	`li:nth-child(2n+1){opacity:.5 !important}`,
+	`</style><script>alert(1)</script>`,
+	`.a{font-family:"</style><script>alert(1)</script>"}`,
+	`.a{content:"<\/style>"}`,
+	`.a{background:u\rl(https://evil.example/x.png)}`,
+	`.a{background:\75 \72 \6c (https://evil.example/x.png)}`,
+	`.a{background:url( "https://evil.example/x.png" )}`,
+	`.a{background:url(/media/../secret)}`,
+	`.a{background:url(//evil.example/x.png)}`,
+	`.a{font-family:"url(https://evil.example/x.png)"}`,
+	`@import url("https://evil.example/x.css"); .a{color:red}`,
+	`@\69mport "https://evil.example/x.css";`,
+	`@IMPORT 'x.css'`,
+	`.a{width:expression(alert(1))}`,
+	`.a{width:ex\70ression(alert(1))}`,
+	`.a{width:EXPRESSION(alert(1))}`,
+	`.a{behavior:url(x.htc);-moz-binding:url(x.xml)}`,
+	`@font-face{font-family:x;src:url(/media/f.woff)}@keyframes k{from{opacity:0}}`,
+	`.a{color:red/**/;width:1px/ *x*/2}`,
+	`<!-- .a{color:red} -->`,
+	`[href^=http]{color:red}.a::before{content:"x"}`,
+	`.a{color:red`,
+	`.a{width:calc(calc(calc(calc(calc(calc(1px)))))))}`,
+	"\x00.a{color:\\0 red}\r\n.b{color:blue}",
+}
+
+// CSS output must be a fixed point (sanitizing it again changes nothing) and must never be
+// able to close the <style> element or load anything outside /media/.
+func FuzzCSS(f *testing.F) {
+	for _, s := range cssSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		out, _, err := CSS(src, testScope)
+		if err != nil {
+			if len(src) <= MaxCSSBytes {
+				t.Fatalf("CSS(%q): %v", src, err)
+			}
+			return
+		}
+		again, dropped, err := CSS(out, testScope)
+		if err != nil || again != out {
+			t.Fatalf("not a fixed point:\n in:  %q\n out: %q\n again: %q (dropped %v, err %v)", src, out, again, dropped, err)
+		}
+		lower := strings.ToLower(out)
+		for _, bad := range []string{"</style", "<!--", "-->"} {
+			if strings.Contains(lower, bad) {
+				t.Fatalf("output of %q contains %q: %q", src, bad, out)
+			}
+		}
+		// Escape dan karakter markup hanya boleh muncul di dalam string (hasil quoteString)
+		code := strings.ToLower(unquoted(out))
+		if strings.ContainsAny(code, `\<&`) {
+			t.Fatalf("escape or markup outside a string in %q", out)
+		}
+		for _, bad := range []string{"@import", "expression(", "javascript:"} {
+			if strings.Contains(code, bad) {
+				t.Fatalf("output of %q contains %q: %q", src, bad, out)
+			}
+		}
+		if strings.Count(code, "url(") != strings.Count(out, `url("/media/`) {
+			t.Fatalf("url() outside /media/ in %q", out)
+		}
+	})
+}
+
+// unquoted returns s with the contents of double-quoted strings removed.
+func unquoted(s string) string {
+	var b strings.Builder
+	in := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case in && c == '\\':
+			i++
+		case c == '"':
+			in = !in
+		case !in:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func TestCSS(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    string
+		dropped bool
+	}{
+		{"scoped", `.card{color:red}`, ".custom .card{color:red}\n", false},
+		{"root", `body,:root{--accent:#f0a}`, ".custom,.custom{--accent:#f0a}\n", false},
+		{"media", `@media (max-width:480px){a{margin:0}}`, "@media (max-width:480px){\n.custom a{margin:0}\n}\n", false},
+		{"local url", `.a{background:url(/media/u1/bg.png)}`, ".custom .a{background:url(\"/media/u1/bg.png\")}\n", false},
+		{"remote url", `.a{background:url(https://evil.example/x.png)}`, "", true},
+		{"escaped url", `.a{background:u\rl(https://evil.example/x.png)}`, "", true},
+		{"import", `@import "x.css";.a{color:red}`, ".custom .a{color:red}\n", true},
+		{"expression", `.a{width:ex\70ression(alert(1))}`, "", true},
+		{"style breakout", `.a{font-family:"</style><script>"}`, ".custom .a{font-family:\"\\3c /style\\3e \\3c script\\3e \"}\n", false},
+		{"attribute selector", `[href^=http]{color:red}`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, dropped, err := CSS(tt.src, testScope)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != tt.want || (len(dropped) > 0) != tt.dropped {
+				t.Fatalf("CSS(%q) = %q, dropped %v", tt.src, out, dropped)
+			}
+		})
+	}
+	if _, _, err := CSS(strings.Repeat("a", MaxCSSBytes+1), testScope); err != ErrTooLarge {
+		t.Fatalf("oversized input: %v", err)
+	}
+}
@@ -0,0 +1,381 @@
+package sanitize
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Tokenizer CSS mengikuti CSS Syntax Module Level 3 secukupnya: sanitizer tidak pernah
+// meneruskan teks input apa adanya, semua output diserialisasi ulang dari token.
+
+type tokenKind int
+
+const (
+	tkEOF tokenKind = iota
+	tkIdent
+	tkFunction // Value = nama fungsi tanpa "("
+	tkAtKeyword
+	tkHash
+	tkString
+	tkBadString
+	tkURL // url(...) tanpa kutip; Value = isi
+	tkBadURL
+	tkDelim
+	tkNumber
+	tkPercentage
+	tkDimension // Value = angka, Unit = satuan
+	tkWhitespace
+	tkCDO
+	tkCDC
+	tkColon
+	tkSemicolon
+	tkComma
+	tkLBracket
+	tkRBracket
+	tkLParen
+	tkRParen
+	tkLBrace
+	tkRBrace
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	unit  string
+	// idHash: hash token yang valid sebagai identifier (#main, bukan #1a)
+	idHash bool
+}
+
+type tokenizer struct {
+	src []rune
+	pos int
+}
+
+func newTokenizer(s string) *tokenizer {
+	// Preprocessing: newline dinormalisasi, NUL dan surrogate diganti U+FFFD
+	s = strings.NewReplacer("\r\n", "\n", "\r", "\n", "\f", "\n", "\x00", "�").Replace(s)
+	return &tokenizer{src: []rune(strings.ToValidUTF8(s, "�"))}
+}
+
+func (t *tokenizer) peek(n int) rune {
+	if t.pos+n < len(t.src) {
+		return t.src[t.pos+n]
+	}
+	return -1
+}
+
+func (t *tokenizer) all() []token {
+	var out []token
+	for {
+		tok := t.next()
+		if tok.kind == tkEOF {
+			return out
+		}
+		out = append(out, tok)
+	}
+}
+
+func (t *tokenizer) next() token {
+	// Komentar dibuang
+	for t.peek(0) == '/' && t.peek(1) == '*' {
+		t.pos += 2
+		for t.pos < len(t.src) && !(t.peek(0) == '*' && t.peek(1) == '/') {
+			t.pos++
+		}
+		t.pos = min(t.pos+2, len(t.src))
+	}
+	c := t.peek(0)
+	switch {
+	case c == -1:
+		return token{kind: tkEOF}
+	case isSpace(c):
+		for isSpace(t.peek(0)) {
+			t.pos++
+		}
+		return token{kind: tkWhitespace}
+	case c == '"' || c == '\'':
+		t.pos++
+		return t.consumeString(c)
+	case c == '#':
+		if isNameChar(t.peek(1)) || validEscape(t.peek(1), t.peek(2)) {
+			t.pos++
+			id := startsIdent(t.peek(0), t.peek(1), t.peek(2))
+			return token{kind: tkHash, value: t.consumeName(), idHash: id}
+		}
+	case c == '(':
+		t.pos++
+		return token{kind: tkLParen}
+	case c == ')':
+		t.pos++
+		return token{kind: tkRParen}
+	case c == '[':
+		t.pos++
+		return token{kind: tkLBracket}
+	case c == ']':
+		t.pos++
+		return token{kind: tkRBracket}
+	case c == '{':
+		t.pos++
+		return token{kind: tkLBrace}
+	case c == '}':
+		t.pos++
+		return token{kind: tkRBrace}
+	case c == ',':
+		t.pos++
+		return token{kind: tkComma}
+	case c == ':':
+		t.pos++
+		return token{kind: tkColon}
+	case c == ';':
+		t.pos++
+		return token{kind: tkSemicolon}
+	case c == '+' || c == '.':
+		if startsNumber(c, t.peek(1), t.peek(2)) {
+			return t.consumeNumeric()
+		}
+	case c == '-':
+		if startsNumber(c, t.peek(1), t.peek(2)) {
+			return t.consumeNumeric()
+		}
+		if t.peek(1) == '-' && t.peek(2) == '>' {
+			t.pos += 3
+			return token{kind: tkCDC}
+		}
+		if startsIdent(c, t.peek(1), t.peek(2)) {
+			return t.consumeIdentLike()
+		}
+	case c == '<':
+		if t.peek(1) == '!' && t.peek(2) == '-' && t.peek(3) == '-' {
+			t.pos += 4
+			return token{kind: tkCDO}
+		}
+	case c == '@':
+		if startsIdent(t.peek(1), t.peek(2), t.peek(3)) {
+			t.pos++
+			return token{kind: tkAtKeyword, value: t.consumeName()}
+		}
+	case c == '\\':
+		if validEscape(c, t.peek(1)) {
+			return t.consumeIdentLike()
+		}
+	case isDigit(c):
+		return t.consumeNumeric()
+	case isNameStart(c):
+		return t.consumeIdentLike()
+	}
+	t.pos++
+	return token{kind: tkDelim, value: string(c)}
+}
+
+func (t *tokenizer) consumeString(quote rune) token {
+	var b strings.Builder
+	for {
+		c := t.peek(0)
+		switch {
+		case c == -1:
+			return token{kind: tkString, value: b.String()}
+		case c == quote:
+			t.pos++
+			return token{kind: tkString, value: b.String()}
+		case c == '\n':
+			return token{kind: tkBadString}
+		case c == '\\':
+			if t.peek(1) == -1 {
+				t.pos++
+				continue
+			}
+			if t.peek(1) == '\n' {
+				t.pos += 2
+				continue
+			}
+			t.pos++
+			b.WriteRune(t.consumeEscape())
+		default:
+			t.pos++
+			b.WriteRune(c)
+		}
+	}
+}
+
+func (t *tokenizer) consumeNumeric() token {
+	start := t.pos
+	if c := t.peek(0); c == '+' || c == '-' {
+		t.pos++
+	}
+	for isDigit(t.peek(0)) {
+		t.pos++
+	}
+	if t.peek(0) == '.' && isDigit(t.peek(1)) {
+		t.pos++
+		for isDigit(t.peek(0)) {
+			t.pos++
+		}
+	}
+	if c := t.peek(0); c == 'e' || c == 'E' {
+		d := 1
+		if s := t.peek(1); s == '+' || s == '-' {
+			d = 2
+		}
+		if isDigit(t.peek(d)) {
+			t.pos += d
+			for isDigit(t.peek(0)) {
+				t.pos++
+			}
+		}
+	}
+	num := string(t.src[start:t.pos])
+	if startsIdent(t.peek(0), t.peek(1), t.peek(2)) {
+		return token{kind: tkDimension, value: num, unit: t.consumeName()}
+	}
+	if t.peek(0) == '%' {
+		t.pos++
+		return token{kind: tkPercentage, value: num}
+	}
+	return token{kind: tkNumber, value: num}
+}
+
+func (t *tokenizer) consumeIdentLike() token {
+	name := t.consumeName()
+	if t.peek(0) != '(' {
+		return token{kind: tkIdent, value: name}
+	}
+	t.pos++
+	if !strings.EqualFold(name, "url") {
+		return token{kind: tkFunction, value: name}
+	}
+	// url( diikuti string → function token biasa; selain itu url token tanpa kutip
+	p := 0
+	for isSpace(t.peek(p)) {
+		p++
+	}
+	if c := t.peek(p); c == '"' || c == '\'' {
+		return token{kind: tkFunction, value: name}
+	}
+	t.pos += p
+	return t.consumeURL()
+}
+
+func (t *tokenizer) consumeURL() token {
+	var b strings.Builder
+	for {
+		c := t.peek(0)
+		switch {
+		case c == -1:
+			return token{kind: tkURL, value: b.String()}
+		case c == ')':
+			t.pos++
+			return token{kind: tkURL, value: b.String()}
+		case isSpace(c):
+			for isSpace(t.peek(0)) {
+				t.pos++
+			}
+			if t.peek(0) == ')' || t.peek(0) == -1 {
+				continue
+			}
+			t.consumeBadURL()
+			return token{kind: tkBadURL}
+		case c == '"' || c == '\'' || c == '(' || c < 0x20 || c == 0x7f:
+			t.consumeBadURL()
+			return token{kind: tkBadURL}
+		case c == '\\':
+			if !validEscape(c, t.peek(1)) {
+				t.consumeBadURL()
+				return token{kind: tkBadURL}
+			}
+			t.pos++
+			b.WriteRune(t.consumeEscape())
+		default:
+			t.pos++
+			b.WriteRune(c)
+		}
+	}
+}
+
+func (t *tokenizer) consumeBadURL() {
+	for {
+		c := t.peek(0)
+		if c == -1 {
+			return
+		}
+		t.pos++
+		if c == ')' {
+			return
+		}
+		if c == '\\' && validEscape(c, t.peek(0)) {
+			t.consumeEscape()
+		}
+	}
+}
+
+func (t *tokenizer) consumeName() string {
+	var b strings.Builder
+	for {
+		c := t.peek(0)
+		switch {
+		case isNameChar(c):
+			t.pos++
+			b.WriteRune(c)
+		case validEscape(c, t.peek(1)):
+			t.pos++
+			b.WriteRune(t.consumeEscape())
+		default:
+			return b.String()
+		}
+	}
+}
+
+// consumeEscape is called after the backslash.
+func (t *tokenizer) consumeEscape() rune {
+	c := t.peek(0)
+	if c == -1 {
+		return utf8.RuneError
+	}
+	if !isHex(c) {
+		t.pos++
+		return c
+	}
+	start := t.pos
+	for t.pos-start < 6 && isHex(t.peek(0)) {
+		t.pos++
+	}
+	n, _ := strconv.ParseUint(string(t.src[start:t.pos]), 16, 32)
+	if isSpace(t.peek(0)) {
+		t.pos++
+	}
+	r := rune(n)
+	if r == 0 || r > utf8.MaxRune || (r >= 0xD800 && r <= 0xDFFF) {
+		return utf8.RuneError
+	}
+	return r
+}
+
+func isSpace(c rune) bool     { return c == ' ' || c == '\t' || c == '\n' }
+func isDigit(c rune) bool     { return c >= '0' && c <= '9' }
+func isHex(c rune) bool       { return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') }
+func isNameStart(c rune) bool { return c >= 0x80 || c == '_' || (c|0x20 >= 'a' && c|0x20 <= 'z') }
+func isNameChar(c rune) bool  { return isNameStart(c) || isDigit(c) || c == '-' }
+
+func validEscape(a, b rune) bool { return a == '\\' && b != '\n' && b != -1 }
+
+func startsIdent(a, b, c rune) bool {
+	switch {
+	case a == '-':
+		return isNameStart(b) || b == '-' || validEscape(b, c)
+	case isNameStart(a):
+		return true
+	default:
+		return validEscape(a, b)
+	}
+}
+
+func startsNumber(a, b, c rune) bool {
+	switch {
+	case a == '+' || a == '-':
+		return isDigit(b) || (b == '.' && isDigit(c))
+	case a == '.':
+		return isDigit(b)
+	default:
+		return isDigit(a)
+	}
+}
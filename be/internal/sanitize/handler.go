@@ -0,0 +1,166 @@
+package sanitize
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"biomu/backend/internal/firebase"
+	"biomu/backend/internal/profile"
+
+	"cloud.google.com/go/firestore"
+)
+
+const (
+	// Scope adalah class di <body> halaman bio; semua selector custom CSS berada di bawahnya.
+	Scope     = ".custom"
+	maxBlocks = 20
+	bodyLimit = MaxCSSBytes + maxBlocks*MaxHTMLBytes + 4<<10
+)
+
+// Sessions resolves the signed-in caller (implemented by auth.Handler).
+type Sessions interface {
+	SessionUID(r *http.Request) string
+}
+
+type Handler struct {
+	fb           *firebase.App
+	profiles     *profile.Store
+	sessions     Sessions
+	accountsColl string
+}
+
+func NewHandler(fb *firebase.App, profiles *profile.Store, sessions Sessions) *Handler {
+	return &Handler{fb: fb, profiles: profiles, sessions: sessions, accountsColl: profiles.AccountsCollection()}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// Custom is the sanitized custom CSS and rich text blocks of an account.
+type Custom struct {
+	CSS    string   `json:"css"`
+	Blocks []string `json:"blocks"`
+}
+
+// FromProfile returns the custom content to render for p: empty unless the account is a
+// member. Stored values are sanitized again because account documents can also be written
+// through /api/db.
+func FromProfile(p *profile.Profile) Custom {
+	out := Custom{Blocks: []string{}}
+	if !p.Member() {
+		return out
+	}
+	if css, ok := p.Data["customCss"].(string); ok {
+		out.CSS, _, _ = CSS(css, Scope)
+	}
+	raw, _ := p.Data["blocks"].([]any)
+	for _, b := range raw {
+		s, ok := b.(string)
+		if !ok || len(out.Blocks) >= maxBlocks {
+			continue
+		}
+		if clean, err := HTML(s); err == nil && clean != "" {
+			out.Blocks = append(out.Blocks, clean)
+		}
+	}
+	return out
+}
+
+// GET /api/profile/custom — custom CSS dan blok rich text milik caller
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	p, ok := h.account(w, r)
+	if !ok {
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{"member": p.Member(), "custom": FromProfile(p)})
+}
+
+// PUT /api/profile/custom — body {"css": "...", "blocks": ["<p>...</p>"]}; hanya status membership.
+// Yang tersimpan adalah hasil sanitasi; "dropped" menjelaskan bagian CSS yang dibuang.
+func (h *Handler) Put(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	p, ok := h.account(w, r)
+	if !ok {
+		return
+	}
+	if !p.Member() {
+		h.writeJSON(w, http.StatusForbidden, map[string]string{"error": "membership_required"})
+		return
+	}
+	var body struct {
+		CSS    string   `json:"css"`
+		Blocks []string `json:"blocks"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, bodyLimit)).Decode(&body); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	if len(body.Blocks) > maxBlocks {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "at most " + strconv.Itoa(maxBlocks) + " blocks"})
+		return
+	}
+
+	css, dropped, err := CSS(body.CSS, Scope)
+	if errors.Is(err, ErrTooLarge) {
+		h.writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "css exceeds " + strconv.Itoa(MaxCSSBytes>>10) + "KB"})
+		return
+	}
+	out := Custom{CSS: css, Blocks: make([]string, 0, len(body.Blocks))}
+	for i, b := range body.Blocks {
+		clean, err := HTML(b)
+		if errors.Is(err, ErrTooLarge) {
+			h.writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "block " + strconv.Itoa(i) + " exceeds " + strconv.Itoa(MaxHTMLBytes>>10) + "KB"})
+			return
+		}
+		if clean != "" {
+			out.Blocks = append(out.Blocks, clean)
+		}
+	}
+
+	_, err = h.fb.DB.Collection(h.accountsColl).Doc(p.ID).Update(r.Context(), []firestore.Update{
+		{Path: "customCss", Value: out.CSS},
+		{Path: "blocks", Value: out.Blocks},
+		{Path: "updatedAt", Value: firestore.ServerTimestamp},
+	})
+	if err != nil {
+		log.Printf("custom content %s: %v", p.ID, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to save"})
+		return
+	}
+	if dropped == nil {
+		dropped = []string{}
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{"custom": out, "dropped": dropped})
+}
+
+func (h *Handler) account(w http.ResponseWriter, r *http.Request) (*profile.Profile, bool) {
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return nil, false
+	}
+	p, err := h.profiles.FindByID(r.Context(), uid)
+	if err != nil {
+		log.Printf("custom content account %s: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load account"})
+		return nil, false
+	}
+	if p == nil {
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "account not found"})
+		return nil, false
+	}
+	return p, true
+}
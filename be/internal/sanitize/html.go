@@ -0,0 +1,216 @@
+package sanitize
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	// MaxHTMLBytes membatasi satu blok rich text.
+	MaxHTMLBytes = 16 << 10
+	maxHTMLDepth = 16
+	maxAttrRunes = 200
+	maxClasses   = 8
+)
+
+// allowedElements maps each element to its allowed attributes (selain class, yang boleh di semua elemen).
+var allowedElements = map[string][]string{
+	"p": nil, "br": nil, "hr": nil, "div": nil, "span": nil,
+	"h2": nil, "h3": nil, "h4": nil,
+	"strong": nil, "b": nil, "em": nil, "i": nil, "u": nil, "s": nil, "small": nil, "mark": nil,
+	"sub": nil, "sup": nil, "code": nil, "pre": nil, "blockquote": nil,
+	"ul": nil, "ol": nil, "li": nil,
+	"a":   {"href", "title"},
+	"img": {"src", "alt", "width", "height"},
+}
+
+var voidElements = set("br", "hr", "img")
+
+// dropContent: elemen yang isinya ikut dibuang, bukan hanya tag-nya
+var dropContent = set("script", "style", "iframe", "object", "embed", "template", "noscript", "noembed",
+	"noframes", "textarea", "title", "svg", "math", "select", "xmp", "plaintext", "head")
+
+var (
+	classRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]{0,39}$`)
+	sizeRe  = regexp.MustCompile(`^[0-9]{1,4}$`)
+)
+
+// HTML sanitizes a rich text block against the element/attribute allowlist. Disallowed
+// elements are unwrapped (teksnya tetap), elements in dropContent are removed with their
+// content, links get rel="nofollow noopener ugc" and target="_blank", and every tag opened is
+// closed. Output is re-serialized from tokens, so sanitizing it again yields the same string.
+func HTML(src string) (string, error) {
+	if len(src) > MaxHTMLBytes {
+		return "", ErrTooLarge
+	}
+	src = strings.ReplaceAll(strings.ToValidUTF8(src, "�"), "\x00", "�")
+	z := html.NewTokenizer(strings.NewReader(src))
+	var b strings.Builder
+	var open []string
+	skip := ""
+	skipDepth := 0
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			// io.EOF atau input rusak: sisa input diabaikan, tag yang terbuka ditutup di bawah
+			break
+		}
+		tok := z.Token()
+		name := tok.Data
+		if skip != "" {
+			// Di dalam elemen yang dibuang: hanya lacak nesting elemen yang sama
+			switch {
+			case tt == html.StartTagToken && name == skip:
+				skipDepth++
+			case tt == html.EndTagToken && name == skip:
+				if skipDepth--; skipDepth == 0 {
+					skip = ""
+				}
+			}
+			continue
+		}
+		switch tt {
+		case html.TextToken:
+			b.WriteString(html.EscapeString(tok.Data))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if dropContent[name] {
+				if tt == html.StartTagToken && !voidElements[name] {
+					skip, skipDepth = name, 1
+				}
+				continue
+			}
+			attrs, ok := allowedElements[name]
+			if !ok || (!voidElements[name] && len(open) >= maxHTMLDepth) {
+				continue
+			}
+			b.WriteString("<" + name + attributes(name, tok.Attr, attrs) + ">")
+			if !voidElements[name] {
+				open = append(open, name)
+			}
+		case html.EndTagToken:
+			// Tutup sampai elemen yang cocok; end tag tanpa pasangan diabaikan
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == name {
+					for j := len(open) - 1; j >= i; j-- {
+						b.WriteString("</" + open[j] + ">")
+					}
+					open = open[:i]
+					break
+				}
+			}
+		}
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+	return b.String(), nil
+}
+
+func attributes(elem string, in []html.Attribute, allowed []string) string {
+	var b strings.Builder
+	seen := map[string]bool{}
+	for _, a := range in {
+		key := a.Key
+		if a.Namespace != "" || seen[key] {
+			continue
+		}
+		val := strings.TrimSpace(a.Val)
+		switch {
+		case key == "class":
+			val = classes(val)
+		case !contains(allowed, key):
+			continue
+		case key == "href":
+			val = safeHref(val)
+		case key == "src":
+			val = safeSrc(val)
+		case key == "width" || key == "height":
+			if !sizeRe.MatchString(val) {
+				val = ""
+			}
+		default:
+			val = truncateRunes(strings.Join(strings.Fields(val), " "), maxAttrRunes)
+		}
+		if val == "" && key != "alt" {
+			continue
+		}
+		seen[key] = true
+		b.WriteString(" " + key + `="` + html.EscapeString(val) + `"`)
+	}
+	if elem == "a" && seen["href"] {
+		b.WriteString(` rel="nofollow noopener ugc" target="_blank"`)
+	}
+	if elem == "img" && !seen["alt"] {
+		b.WriteString(` alt=""`)
+	}
+	return b.String()
+}
+
+func classes(v string) string {
+	var out []string
+	for _, c := range strings.Fields(v) {
+		if classRe.MatchString(c) && !contains(out, c) && len(out) < maxClasses {
+			out = append(out, c)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// safeHref allows absolute http(s), mailto: and tel: links and same-origin paths.
+func safeHref(v string) string {
+	if v == "" || strings.ContainsFunc(v, func(r rune) bool { return r < 0x20 || r == 0x7f || r == '\\' }) {
+		return ""
+	}
+	if strings.HasPrefix(v, "/") {
+		if strings.HasPrefix(v, "//") {
+			return ""
+		}
+		return v
+	}
+	u, err := url.Parse(v)
+	if err != nil {
+		return ""
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		if u.Host == "" || u.User != nil {
+			return ""
+		}
+		return v
+	case "mailto", "tel":
+		return v
+	}
+	return ""
+}
+
+// safeSrc allows https images and self-hosted media only.
+func safeSrc(v string) string {
+	if strings.HasPrefix(v, "/media/") && localURLRe.MatchString(v) && !strings.Contains(v, "..") {
+		return v
+	}
+	u, err := url.Parse(v)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.User != nil || strings.ContainsAny(v, " \t\n\r\\") {
+		return ""
+	}
+	return v
+}
+
+func contains(list []string, v string) bool {
+	for _, it := range list {
+		if it == v {
+			return true
+		}
+	}
+	return false
+}
+
+func truncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
@@ -0,0 +1,104 @@
+package sanitize
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+var htmlSeeds = []string{
+	`<p>Halo <strong>dunia</strong> <a href="https://example.com">link</a></p>`,
+	`<img src="/media/u1/a.png" width="120"><img src="http://example.com/x.png">`,
+	`</style><script>alert(1)</script>`,
+	`<style>.a{color:red}</style></style><p>x</p>`,
+	`<svg><style></style><img src=x onerror=alert(1)></svg>`,
+	`<a href="javascript:alert(1)">x</a><a href="JaVaScRiPt&colon;alert(1)">y</a><a href=" java\nscript:alert(1)">z</a>`,
+	`<a href="//evil.example">x</a><a href="https://user@evil.example">y</a>`,
+	`<p style="background:url(https://evil.example)" onclick="x()" class="a b a">x</p>`,
+	`<div><div><div><div><div><div><div><div><div><div><div><div><div><div><div><div><div><div>deep`,
+	`<b><i>misnested</b></i><ul><li>a<li>b</ul>`,
+	`<!-- <script>x</script> --><![CDATA[<script>]]>`,
+	`<textarea></textarea><script>x</script></textarea>`,
+	`<noscript><p title="</noscript><script>x</script>"></noscript>`,
+	`<math><mi><style><img src=x onerror=alert(1)></style></mi></math>`,
+	"<p>\x00<scr\x00ipt>x</p>",
+	`<p title="a &quot; onmouseover=x()">x</p>`,
+}
+
+var safeURLRe = regexp.MustCompile(`^(/($|[^/])|https?://|mailto:|tel:)`)
+
+// HTML output must be a fixed point and must not contain scripts, event handlers, style
+// elements or links outside http(s), mailto:, tel: and same-origin paths.
+func FuzzHTML(f *testing.F) {
+	for _, s := range htmlSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		out, err := HTML(src)
+		if err != nil {
+			if len(src) <= MaxHTMLBytes {
+				t.Fatalf("HTML(%q): %v", src, err)
+			}
+			return
+		}
+		again, err := HTML(out)
+		if err != nil || again != out {
+			t.Fatalf("not a fixed point:\n in:  %q\n out: %q\n again: %q (err %v)", src, out, again, err)
+		}
+		lower := strings.ToLower(out)
+		for _, bad := range []string{"</style", "<style", "<script", "<!--"} {
+			if strings.Contains(lower, bad) {
+				t.Fatalf("output of %q contains %q: %q", src, bad, out)
+			}
+		}
+		z := html.NewTokenizer(strings.NewReader(out))
+		for tt := z.Next(); tt != html.ErrorToken; tt = z.Next() {
+			tok := z.Token()
+			if tt != html.StartTagToken {
+				continue
+			}
+			allowed, ok := allowedElements[tok.Data]
+			if !ok {
+				t.Fatalf("element %q in %q", tok.Data, out)
+			}
+			for _, a := range tok.Attr {
+				if a.Key != "class" && a.Key != "rel" && a.Key != "target" && !contains(allowed, a.Key) {
+					t.Fatalf("attribute %q on %q in %q", a.Key, tok.Data, out)
+				}
+				if (a.Key == "href" || a.Key == "src") && !safeURLRe.MatchString(strings.ToLower(a.Val)) {
+					t.Fatalf("unsafe %s %q in %q", a.Key, a.Val, out)
+				}
+			}
+		}
+	})
+}
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"allowed", `<p>Hi <b>you</b></p>`, `<p>Hi <b>you</b></p>`},
+		{"unwrap", `<font color=red>text</font>`, `text`},
+		{"drop content", `<p>a<script>alert(1)</script>b</p>`, `<p>ab</p>`},
+		{"style breakout", `</style><script>alert(1)</script><p>x</p>`, `<p>x</p>`},
+		{"link", `<a href="https://example.com" onclick="x()">x</a>`, `<a href="https://example.com" rel="nofollow noopener ugc" target="_blank">x</a>`},
+		{"javascript link", `<a href="javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"protocol-relative link", `<a href="//evil.example">x</a>`, `<a>x</a>`},
+		{"image", `<img src="http://example.com/x.png" width="12px">`, `<img alt="">`},
+		{"local image", `<img src="/media/u1/a.png" alt="A" width="120">`, `<img src="/media/u1/a.png" alt="A" width="120">`},
+		{"unclosed", `<ul><li>a`, `<ul><li>a</li></ul>`},
+		{"classes", `<p class="a b a 1x">x</p>`, `<p class="a b">x</p>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HTML(tt.src)
+			if err != nil || got != tt.want {
+				t.Fatalf("HTML(%q) = %q, %v; want %q", tt.src, got, err, tt.want)
+			}
+		})
+	}
+}
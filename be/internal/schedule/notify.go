@@ -0,0 +1,38 @@
+package schedule
+
+import (
+	"context"
+	"log"
+
+	"biomu/backend/internal/email"
+	"biomu/backend/internal/profile"
+)
+
+// EmailNotifier returns a Listener that emails the link owner. Owners can opt out by setting
+// notifications.linkSchedule = false on their account document.
+func EmailNotifier(sender email.Sender, profiles *profile.Store) Listener {
+	return func(ctx context.Context, e Event) {
+		if sender == nil {
+			return
+		}
+		owner, err := profiles.FindByID(ctx, e.Link.ProfileID)
+		if err != nil || owner == nil {
+			if err != nil {
+				log.Printf("schedule notify %s: %v", e.Link.ID, err)
+			}
+			return
+		}
+		if prefs, ok := owner.Data["notifications"].(map[string]any); ok {
+			if enabled, ok := prefs["linkSchedule"].(bool); ok && !enabled {
+				return
+			}
+		}
+		to, _ := owner.Data["email"].(string)
+		if to == "" {
+			return
+		}
+		if err := sender.SendLinkScheduleNotice(to, e.Link.Title, e.Link.URL, e.Type == EventLinkLive); err != nil {
+			log.Printf("schedule notify %s: %v", e.Link.ID, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Package schedule memantau link terjadwal (startsAt/endsAt) dan memancarkan event saat
+// link mulai tayang atau kedaluwarsa, mis. untuk notifikasi email ke pemilik profil.
+package schedule
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"biomu/backend/internal/firebase"
+	"biomu/backend/internal/profile"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+const (
+	EventLinkLive    = "link.live"
+	EventLinkExpired = "link.expired"
+)
+
+const (
+	// lookback membatasi seberapa jauh ke belakang transisi masih dikirim (mis. setelah downtime).
+	lookback = 24 * time.Hour
+
+	fieldLiveNotified    = "liveNotifiedAt"
+	fieldExpiredNotified = "expiredNotifiedAt"
+)
+
+// Event is emitted once per schedule transition of a link.
+type Event struct {
+	Type string
+	Link profile.Link
+	// At is the scheduled instant (startsAt or endsAt) that was crossed.
+	At time.Time
+}
+
+// Listener receives scheduler events. It runs synchronously on the scheduler goroutine.
+type Listener func(ctx context.Context, e Event)
+
+// Scheduler polls the links collection. Transitions are keyed on the normalized UTC bounds,
+// so wall-clock schedules fire at the right instant across DST changes, and every transition
+// is marked on the link document so it is emitted once even with several backend instances.
+type Scheduler struct {
+	fb        *firebase.App
+	linksColl string
+	interval  time.Duration
+	listeners []Listener
+}
+
+func NewScheduler(fb *firebase.App, linksColl string, interval time.Duration) *Scheduler {
+	return &Scheduler{fb: fb, linksColl: linksColl, interval: interval}
+}
+
+// OnEvent registers l. Call before Run.
+func (s *Scheduler) OnEvent(l Listener) {
+	s.listeners = append(s.listeners, l)
+}
+
+// Run checks for transitions every interval until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.Tick(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick emits every transition in (now-lookback, now] that has not been emitted yet.
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) {
+	s.scan(ctx, now, profile.FieldStartsAtUTC, fieldLiveNotified, EventLinkLive)
+	s.scan(ctx, now, profile.FieldEndsAtUTC, fieldExpiredNotified, EventLinkExpired)
+}
+
+func (s *Scheduler) scan(ctx context.Context, now time.Time, boundField, markField, eventType string) {
+	it := s.fb.DB.Collection(s.linksColl).
+		Where(boundField, ">", now.Add(-lookback)).
+		Where(boundField, "<=", now).
+		Documents(ctx)
+	defer it.Stop()
+	for {
+		doc, err := it.Next()
+		if err == iterator.Done {
+			return
+		}
+		if err != nil {
+			log.Printf("schedule scan %s: %v", boundField, err)
+			return
+		}
+		at, _ := doc.Data()[boundField].(time.Time)
+		// Sudah dikirim untuk jadwal ini; kalau jadwal diubah ke waktu lain, event dikirim lagi
+		if marked, ok := doc.Data()[markField].(time.Time); ok && marked.Equal(at) {
+			continue
+		}
+		link := profile.LinkFromDoc(doc)
+		if eventType == EventLinkLive && !link.VisibleAt(now) {
+			// Tersembunyi atau sudah lewat endsAt: tidak ada yang "tayang"
+			continue
+		}
+		// Precondition update time: instance lain yang lebih dulu menandai akan membuat ini gagal
+		_, err = doc.Ref.Update(ctx, []firestore.Update{{Path: markField, Value: at}}, firestore.LastUpdateTime(doc.UpdateTime))
+		if err != nil {
+			log.Printf("schedule mark %s/%s: %v", s.linksColl, doc.Ref.ID, err)
+			continue
+		}
+		e := Event{Type: eventType, Link: link, At: at}
+		for _, l := range s.listeners {
+			l(ctx, e)
+		}
+	}
+}
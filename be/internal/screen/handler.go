@@ -0,0 +1,360 @@
+package screen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"biomu/backend/internal/firebase"
+	"biomu/backend/internal/profile"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	queueLimit       = 200
+	maxAppealLen     = 1000
+	maxNoteLen       = 1000
+	reviewMaxBytes   = 8 << 10
+	blocklistMaxSize = 512 << 10
+)
+
+var (
+	errNotFlagged    = errors.New("document is not quarantined or rejected")
+	errAppealPending = errors.New("an appeal is already pending")
+	errNotOwner      = errors.New("forbidden")
+)
+
+// Sessions resolves the signed-in caller (implemented by auth.Handler).
+type Sessions interface {
+	SessionUID(r *http.Request) string
+}
+
+type Handler struct {
+	fb       *firebase.App
+	screener *Screener
+	profiles *profile.Store
+	sessions Sessions
+}
+
+func NewHandler(fb *firebase.App, screener *Screener, profiles *profile.Store, sessions Sessions) *Handler {
+	return &Handler{fb: fb, screener: screener, profiles: profiles, sessions: sessions}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// requireAdmin writes the error response and returns "" when the caller is not an admin.
+func (h *Handler) requireAdmin(w http.ResponseWriter, r *http.Request) string {
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return ""
+	}
+	admin, err := h.profiles.IsAdmin(r.Context(), uid)
+	if err != nil {
+		log.Printf("screen admin check %s: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load account"})
+		return ""
+	}
+	if !admin {
+		h.writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+		return ""
+	}
+	return uid
+}
+
+// QueueItem is one entry of the admin review queue.
+type QueueItem struct {
+	Collection string         `json:"collection"`
+	ID         string         `json:"id"`
+	ProfileID  string         `json:"profileId,omitempty"`
+	Title      string         `json:"title,omitempty"`
+	URL        string         `json:"url,omitempty"`
+	Moderation map[string]any `json:"moderation"`
+	flaggedAt  time.Time
+}
+
+// GET /api/admin/moderation?status=quarantined|rejected|approved|appeal&collection= — antrean review
+// (default: quarantined). status=appeal berisi dokumen dengan banding yang belum diputus.
+func (h *Handler) Queue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.requireAdmin(w, r) == "" {
+		return
+	}
+	ctx := r.Context()
+	q := r.URL.Query()
+	path, value := FieldModeration+".status", q.Get("status")
+	switch value {
+	case "":
+		value = profile.ModerationQuarantined
+	case profile.ModerationQuarantined, profile.ModerationRejected, profile.ModerationApproved:
+	case "appeal":
+		path, value = FieldModeration+".appeal.status", AppealPending
+	default:
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid status"})
+		return
+	}
+	collections := h.screener.Collections()
+	if c := q.Get("collection"); c != "" {
+		if !h.screener.Applies(c) {
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "collection is not screened"})
+			return
+		}
+		collections = []string{c}
+	}
+
+	items := []QueueItem{}
+	for _, coll := range collections {
+		it := h.fb.DB.Collection(coll).Where(path, "==", value).Limit(queueLimit).Documents(ctx)
+		for {
+			doc, err := it.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				it.Stop()
+				log.Printf("screen queue %s: %v", coll, err)
+				h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load queue"})
+				return
+			}
+			data := doc.Data()
+			m, _ := data[FieldModeration].(map[string]any)
+			item := QueueItem{Collection: coll, ID: doc.Ref.ID, Moderation: m}
+			item.ProfileID, _ = data["profileId"].(string)
+			item.Title, _ = data["title"].(string)
+			item.URL, _ = data["url"].(string)
+			item.flaggedAt, _ = m["flaggedAt"].(time.Time)
+			items = append(items, item)
+		}
+		it.Stop()
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].flaggedAt.After(items[j].flaggedAt) })
+	h.writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+// POST /api/admin/moderation/{collection}/{id} — body {"decision": "approve"|"reject", "note"}.
+// Approve: URL yang ditandai masuk approvedUrls dan link tampil lagi; reject: link tetap tersembunyi.
+// Banding yang masih pending ikut diputus.
+func (h *Handler) Review(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.requireAdmin(w, r)
+	if uid == "" {
+		return
+	}
+	coll, id := r.PathValue("collection"), r.PathValue("id")
+	if !h.screener.Applies(coll) || id == "" {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "collection is not screened"})
+		return
+	}
+	var body struct {
+		Decision string `json:"decision"`
+		Note     string `json:"note"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, reviewMaxBytes)).Decode(&body); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+	if body.Decision != "approve" && body.Decision != "reject" {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "decision must be approve or reject"})
+		return
+	}
+	note := truncate(strings.TrimSpace(body.Note), maxNoteLen)
+
+	err := h.update(r.Context(), coll, id, func(data map[string]any) (map[string]any, error) {
+		m, _ := data[FieldModeration].(map[string]any)
+		if m == nil {
+			return nil, errNotFlagged
+		}
+		now := time.Now()
+		next := copyMap(m)
+		next["reviewedBy"], next["reviewedAt"], next["note"] = uid, now, note
+		appealStatus := AppealDenied
+		if body.Decision == "approve" {
+			approved := stringSet(m["approvedUrls"])
+			for u := range findingURLs(m["findings"]) {
+				approved[u] = true
+			}
+			list := make([]string, 0, len(approved))
+			for u := range approved {
+				list = append(list, u)
+			}
+			sort.Strings(list)
+			next["status"], next["approvedUrls"] = profile.ModerationApproved, list
+			appealStatus = AppealAccepted
+		} else {
+			next["status"] = profile.ModerationRejected
+		}
+		if a, ok := m["appeal"].(map[string]any); ok && a["status"] == AppealPending {
+			a = copyMap(a)
+			a["status"], a["resolvedAt"] = appealStatus, now
+			next["appeal"] = a
+		}
+		return next, nil
+	})
+	if err != nil {
+		h.writeError(w, "review", coll, id, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{"id": id, "collection": coll, "decision": body.Decision})
+}
+
+// POST /api/moderation/{collection}/{id}/appeal — body {"message": "..."}; hanya pemilik dokumen
+// (field profileId) selama statusnya quarantined/rejected dan belum ada banding yang pending.
+func (h *Handler) Appeal(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	coll, id := r.PathValue("collection"), r.PathValue("id")
+	if !h.screener.Applies(coll) || id == "" {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "collection is not screened"})
+		return
+	}
+	var body struct {
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, reviewMaxBytes)).Decode(&body); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+	message := strings.TrimSpace(body.Message)
+	if message == "" || len([]rune(message)) > maxAppealLen {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "message is required (max 1000 characters)"})
+		return
+	}
+
+	var appeal map[string]any
+	err := h.update(r.Context(), coll, id, func(data map[string]any) (map[string]any, error) {
+		if owner, _ := data["profileId"].(string); owner != uid {
+			return nil, errNotOwner
+		}
+		m, _ := data[FieldModeration].(map[string]any)
+		st, _ := m["status"].(string)
+		if st != profile.ModerationQuarantined && st != profile.ModerationRejected {
+			return nil, errNotFlagged
+		}
+		if a, ok := m["appeal"].(map[string]any); ok && a["status"] == AppealPending {
+			return nil, errAppealPending
+		}
+		next := copyMap(m)
+		appeal = map[string]any{"status": AppealPending, "message": message, "submittedAt": time.Now()}
+		next["appeal"] = appeal
+		return next, nil
+	})
+	if err != nil {
+		h.writeError(w, "appeal", coll, id, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{"id": id, "appeal": appeal})
+}
+
+// GET /api/admin/url-blocklist
+func (h *Handler) GetBlocklist(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.requireAdmin(w, r) == "" {
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{"text": h.screener.CustomRules(), "bundled": bundledRules})
+}
+
+// PUT /api/admin/url-blocklist — body {"text": "domain evil.example\npattern ..."}; aturan admin
+// ditambahkan ke blocklist bawaan ({"text": ""} menghapus aturan admin)
+func (h *Handler) PutBlocklist(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.requireAdmin(w, r)
+	if uid == "" {
+		return
+	}
+	var body struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, blocklistMaxSize)).Decode(&body); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+	if _, err := ParseRules(body.Text); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := h.screener.Save(r.Context(), body.Text, uid); err != nil {
+		log.Printf("screen save blocklist: %v", err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to save blocklist"})
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{"text": h.screener.CustomRules()})
+}
+
+// update rewrites the moderation map of one document inside a transaction.
+func (h *Handler) update(ctx context.Context, coll, id string, fn func(data map[string]any) (map[string]any, error)) error {
+	ref := h.fb.DB.Collection(coll).Doc(id)
+	return h.fb.DB.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		next, err := fn(doc.Data())
+		if err != nil {
+			return err
+		}
+		return tx.Update(ref, []firestore.Update{{Path: FieldModeration, Value: next}})
+	})
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, op, coll, id string, err error) {
+	switch {
+	case status.Code(err) == codes.NotFound:
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+	case errors.Is(err, errNotOwner):
+		h.writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+	case errors.Is(err, errNotFlagged), errors.Is(err, errAppealPending):
+		h.writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		log.Printf("screen %s %s/%s: %v", op, coll, id, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update document"})
+	}
+}
+
+func copyMap(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func truncate(s string, max int) string {
+	if r := []rune(s); len(r) > max {
+		return string(r[:max])
+	}
+	return s
+}
@@ -0,0 +1,115 @@
+package screen
+
+import (
+	"strings"
+	"unicode"
+)
+
+// confusables memetakan huruf non-Latin yang tampak identik dengan huruf Latin (subset dari
+// Unicode confusables yang relevan untuk nama domain).
+var confusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p',
+	'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'i', 'ї': 'i', 'ј': 'j', 'ѕ': 's', 'ԁ': 'd',
+	'һ': 'h', 'ӏ': 'l', 'ԛ': 'q', 'ԝ': 'w', 'ү': 'y',
+	// Armenian
+	'ո': 'n', 'ս': 'u', 'օ': 'o', 'հ': 'h',
+	// Greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't',
+	'υ': 'u', 'χ': 'x', 'ω': 'w',
+	// Latin lookalike
+	'ı': 'i', 'ɑ': 'a', 'ɡ': 'g', 'ł': 'l', 'ƅ': 'b', 'ɩ': 'i', 'ʏ': 'y', 'ѵ': 'v',
+}
+
+// skeleton replaces confusable characters with their Latin counterpart and reports whether
+// anything was replaced.
+func skeleton(host string) (string, bool) {
+	changed := false
+	out := strings.Map(func(r rune) rune {
+		if c, ok := confusables[r]; ok {
+			changed = true
+			return c
+		}
+		return r
+	}, host)
+	return out, changed
+}
+
+// asciiLookalike applies the common ASCII substitutions (paypa1, g00gle, rnicrosoft).
+func asciiLookalike(host string) string {
+	return strings.NewReplacer("0", "o", "1", "l", "3", "e", "5", "s", "rn", "m", "vv", "w").Replace(host)
+}
+
+// mixedScript reports whether a label mixes Latin letters with Cyrillic, Greek or Armenian ones.
+func mixedScript(label string) bool {
+	latin, other := false, false
+	for _, r := range label {
+		switch {
+		case r <= unicode.MaxASCII:
+			if unicode.IsLetter(r) {
+				latin = true
+			}
+		case unicode.In(r, unicode.Cyrillic, unicode.Greek, unicode.Armenian):
+			other = true
+		case unicode.Is(unicode.Latin, r):
+			latin = true
+		}
+	}
+	return latin && other
+}
+
+// brandInHost finds a brand domain embedded in a host that is not the brand itself, e.g.
+// paypal.com.login.example, secure-paypal.com or paypal.com-verify.example.
+func brandInHost(host, brand string) bool {
+	for i := 0; ; {
+		j := strings.Index(host[i:], brand)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(brand)
+		before := start == 0 || host[start-1] == '.' || host[start-1] == '-'
+		switch {
+		case !before:
+		case end < len(host) && (host[end] == '.' || host[end] == '-'):
+			return true
+		case end == len(host) && start > 0 && host[start-1] == '-':
+			return true
+		}
+		i = start + 1
+	}
+}
+
+// checkHost runs the homoglyph and brand impersonation checks on a normalized host.
+func (r *Rules) checkHost(host string) (action, reason, detail string) {
+	for _, b := range r.brands {
+		if hostMatches(host, b) {
+			return ActionAllow, "", ""
+		}
+	}
+	sk, changed := skeleton(host)
+	if changed {
+		for _, b := range r.brands {
+			if hostMatches(sk, b) {
+				return ActionBlock, "homoglyph", b
+			}
+		}
+	}
+	for _, label := range strings.Split(host, ".") {
+		if mixedScript(label) {
+			return ActionQuarantine, "mixed_script", label
+		}
+	}
+	if la := asciiLookalike(sk); la != sk {
+		for _, b := range r.brands {
+			if hostMatches(la, b) {
+				return ActionQuarantine, "lookalike", b
+			}
+		}
+	}
+	for _, b := range r.brands {
+		if brandInHost(sk, b) {
+			return ActionQuarantine, "brand_impersonation", b
+		}
+	}
+	return ActionAllow, "", ""
+}
@@ -0,0 +1,129 @@
+package screen
+
+import "testing"
+
+func TestSkeleton(t *testing.T) {
+	tests := []struct {
+		host    string
+		want    string
+		changed bool
+	}{
+		{"paypal.com", "paypal.com", false},
+		{"раypal.com", "paypal.com", true}, // Cyrillic р, а
+		{"аррӏе.com", "apple.com", true},   // semua huruf Cyrillic
+		{"gοοgle.com", "google.com", true}, // Greek ο
+		{"օvo.id", "ovo.id", true},         // Armenian օ
+		{"ɡoogle.com", "google.com", true}, // Latin script g
+		{"münchen.de", "münchen.de", false},
+	}
+	for _, tt := range tests {
+		got, changed := skeleton(tt.host)
+		if got != tt.want || changed != tt.changed {
+			t.Errorf("skeleton(%q) = %q, %v; want %q, %v", tt.host, got, changed, tt.want, tt.changed)
+		}
+	}
+}
+
+func TestMixedScript(t *testing.T) {
+	tests := []struct {
+		label string
+		want  bool
+	}{
+		{"paypal", false},
+		{"пример", false},
+		{"παράδειγμα", false},
+		{"münchen", false},
+		{"раypal", true},
+		{"gοοgle", true},
+		{"shop-օnline", true},
+		{"123-пример", false},
+	}
+	for _, tt := range tests {
+		if got := mixedScript(tt.label); got != tt.want {
+			t.Errorf("mixedScript(%q) = %v, want %v", tt.label, got, tt.want)
+		}
+	}
+}
+
+func TestASCIILookalike(t *testing.T) {
+	tests := map[string]string{
+		"paypa1.com":     "paypal.com",
+		"g00gle.com":     "google.com",
+		"rnicrosoft.com": "microsoft.com",
+		"vvhatsapp.com":  "whatsapp.com",
+		"netf1ix.com":    "netflix.com",
+		"example.com":    "example.com",
+	}
+	for host, want := range tests {
+		if got := asciiLookalike(host); got != want {
+			t.Errorf("asciiLookalike(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
+
+func TestBrandInHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"paypal.com.login.example", true},
+		{"secure-paypal.com", true},
+		{"paypal.com-verify.example", true},
+		{"login.secure-paypal.com", true},
+		// bukan impersonation: brand hanya bagian dari label lain
+		{"paypal.com", false},
+		{"notpaypal.com", false},
+		{"paypal.community", false},
+		{"mypaypal.com.au", false},
+	}
+	for _, tt := range tests {
+		if got := brandInHost(tt.host, "paypal.com"); got != tt.want {
+			t.Errorf("brandInHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	r, err := ParseRules(bundledRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		host   string
+		action string
+		reason string
+		detail string
+	}{
+		// Domain asli brand dan subdomainnya tidak boleh kena
+		{"paypal.com", ActionAllow, "", ""},
+		{"www.paypal.com", ActionAllow, "", ""},
+		{"mail.google.com", ActionAllow, "", ""},
+		{"klikbca.com", ActionAllow, "", ""},
+		// Domain non-Latin yang sah dan kata yang kebetulan mengandung brand
+		{"пример.рф", ActionAllow, "", ""},
+		{"παράδειγμα.δοκιμή", ActionAllow, "", ""},
+		{"pineapple.com", ActionAllow, "", ""},
+		{"paypal.community", ActionAllow, "", ""},
+		{"rnb.example", ActionAllow, "", ""},
+
+		{"раypal.com", ActionBlock, "homoglyph", "paypal.com"},
+		{"login.раypal.com", ActionBlock, "homoglyph", "paypal.com"},
+		{"аррӏе.com", ActionBlock, "homoglyph", "apple.com"},
+		{"gοοgle.com", ActionBlock, "homoglyph", "google.com"},
+		{"օvo.id", ActionBlock, "homoglyph", "ovo.id"},
+		{"gооdshop.example", ActionQuarantine, "mixed_script", "gооdshop"},
+		{"paypa1.com", ActionQuarantine, "lookalike", "paypal.com"},
+		{"g00gle.com", ActionQuarantine, "lookalike", "google.com"},
+		{"rnicrosoft.com", ActionQuarantine, "lookalike", "microsoft.com"},
+		{"secure-paypal.com", ActionQuarantine, "brand_impersonation", "paypal.com"},
+		{"paypal.com.verify.example", ActionQuarantine, "brand_impersonation", "paypal.com"},
+		// Homoglyph yang tidak persis sama dengan brand tetap dikarantina
+		{"аpple.com-id.example", ActionQuarantine, "mixed_script", "аpple"},
+	}
+	for _, tt := range tests {
+		action, reason, detail := r.checkHost(tt.host)
+		if action != tt.action || reason != tt.reason || detail != tt.detail {
+			t.Errorf("checkHost(%q) = %s %s %s; want %s %s %s", tt.host, action, reason, detail, tt.action, tt.reason, tt.detail)
+		}
+	}
+}
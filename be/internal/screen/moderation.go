@@ -0,0 +1,129 @@
+package screen
+
+import (
+	"context"
+	"time"
+
+	"biomu/backend/internal/profile"
+
+	"cloud.google.com/go/firestore"
+)
+
+// FieldModeration is the server-managed map on a screened document:
+//
+//	status        quarantined | rejected | approved
+//	findings      []Finding yang memicu karantina
+//	flaggedAt     kapan terakhir ditandai
+//	approvedUrls  URL yang sudah disetujui admin (tidak dikarantina lagi)
+//	reviewedBy, reviewedAt, note
+//	appeal        {status: pending|accepted|denied, message, submittedAt, resolvedAt}
+const FieldModeration = "moderation"
+
+const (
+	AppealPending  = "pending"
+	AppealAccepted = "accepted"
+	AppealDenied   = "denied"
+)
+
+// Outcome is what the db layer does with a screened document.
+type Outcome struct {
+	Verdict Verdict
+	// Moderation is the new moderation value: a map to store, firestore.Delete to clear a
+	// previous quarantine, or nil to leave the stored field untouched.
+	Moderation any
+}
+
+// Blocked reports whether the write must be rejected.
+func (o Outcome) Blocked() bool { return o.Verdict.Action == ActionBlock }
+
+// Evaluate screens urls of a document being written. existing is the stored document
+// (nil on create): URLs an admin already approved are not quarantined again, and a rejected
+// document stays rejected while it still carries the rejected URLs.
+func (s *Screener) Evaluate(ctx context.Context, urls []string, existing map[string]any, now time.Time) Outcome {
+	v := s.Screen(ctx, urls)
+	prev, _ := existing[FieldModeration].(map[string]any)
+	approved := stringSet(prev["approvedUrls"])
+
+	kept := v.Findings[:0]
+	v.Action = ActionAllow
+	for _, f := range v.Findings {
+		if f.Action == ActionQuarantine && approved[f.URL] {
+			continue
+		}
+		kept = append(kept, f)
+		v.Action = worse(v.Action, f.Action)
+	}
+	v.Findings = kept
+
+	status, _ := prev["status"].(string)
+	switch v.Action {
+	case ActionBlock:
+		return Outcome{Verdict: v}
+	case ActionQuarantine:
+		if status == profile.ModerationRejected && subset(flaggedURLs(v.Findings), findingURLs(prev["findings"])) {
+			return Outcome{Verdict: v}
+		}
+		m := map[string]any{
+			"status":    profile.ModerationQuarantined,
+			"findings":  v.Findings,
+			"flaggedAt": now,
+		}
+		if len(approved) > 0 {
+			m["approvedUrls"] = prev["approvedUrls"]
+		}
+		return Outcome{Verdict: v, Moderation: m}
+	}
+	// Bersih: karantina/penolakan lama gugur karena URL bermasalah sudah tidak ada
+	if status == profile.ModerationQuarantined || status == profile.ModerationRejected {
+		if len(approved) > 0 {
+			return Outcome{Verdict: v, Moderation: map[string]any{
+				"status":       profile.ModerationApproved,
+				"approvedUrls": prev["approvedUrls"],
+			}}
+		}
+		return Outcome{Verdict: v, Moderation: firestore.Delete}
+	}
+	return Outcome{Verdict: v}
+}
+
+func stringSet(v any) map[string]bool {
+	out := map[string]bool{}
+	list, _ := v.([]any)
+	for _, e := range list {
+		if s, ok := e.(string); ok {
+			out[s] = true
+		}
+	}
+	return out
+}
+
+// findingURLs reads the url of stored findings.
+func findingURLs(v any) map[string]bool {
+	out := map[string]bool{}
+	list, _ := v.([]any)
+	for _, e := range list {
+		if m, ok := e.(map[string]any); ok {
+			if s, ok := m["url"].(string); ok {
+				out[s] = true
+			}
+		}
+	}
+	return out
+}
+
+func flaggedURLs(findings []Finding) []string {
+	out := make([]string, 0, len(findings))
+	for _, f := range findings {
+		out = append(out, f.URL)
+	}
+	return out
+}
+
+func subset(urls []string, set map[string]bool) bool {
+	for _, u := range urls {
+		if !set[u] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,177 @@
+package screen
+
+import (
+	"bufio"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+const (
+	ActionAllow      = "allow"
+	ActionQuarantine = "quarantine"
+	ActionBlock      = "block"
+)
+
+const (
+	maxRules      = 5000
+	maxPatternLen = 300
+)
+
+// Finding is one reason a URL was flagged. Target is set when the problem was found on a
+// hop behind a URL shortener.
+type Finding struct {
+	URL    string `json:"url" firestore:"url"`
+	Target string `json:"target,omitempty" firestore:"target,omitempty"`
+	Action string `json:"action" firestore:"action"`
+	Reason string `json:"reason" firestore:"reason"`
+	Detail string `json:"detail,omitempty" firestore:"detail,omitempty"`
+}
+
+// Rules is a parsed blocklist.
+type Rules struct {
+	domains    map[string]bool
+	patterns   []*regexp.Regexp
+	shorteners map[string]bool
+	brands     []string
+}
+
+// ParseRules parses the blocklist format: "<kind> <value>" per line, blank lines and
+// # comments ignored. Kinds: domain, pattern, shortener, brand.
+func ParseRules(text string) (*Rules, error) {
+	r := &Rules{domains: map[string]bool{}, shorteners: map[string]bool{}}
+	sc := bufio.NewScanner(strings.NewReader(text))
+	n, count := 0, 0
+	for sc.Scan() {
+		n++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if count++; count > maxRules {
+			return nil, fmt.Errorf("too many rules (max %d)", maxRules)
+		}
+		kind, value, ok := strings.Cut(line, " ")
+		value = strings.TrimSpace(value)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("line %d: expected \"<kind> <value>\"", n)
+		}
+		switch strings.ToLower(kind) {
+		case "domain":
+			r.domains[normalizeHost(value)] = true
+		case "shortener":
+			r.shorteners[normalizeHost(value)] = true
+		case "brand":
+			r.brands = append(r.brands, normalizeHost(value))
+		case "pattern":
+			if len(value) > maxPatternLen {
+				return nil, fmt.Errorf("line %d: pattern too long", n)
+			}
+			re, err := regexp.Compile("(?i)" + value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid pattern: %v", n, err)
+			}
+			r.patterns = append(r.patterns, re)
+		default:
+			return nil, fmt.Errorf("line %d: unknown rule kind %q", n, kind)
+		}
+	}
+	return r, nil
+}
+
+// with returns the union of r and o.
+func (r *Rules) with(o *Rules) *Rules {
+	out := &Rules{domains: map[string]bool{}, shorteners: map[string]bool{}}
+	for _, src := range []*Rules{r, o} {
+		if src == nil {
+			continue
+		}
+		for d := range src.domains {
+			out.domains[d] = true
+		}
+		for d := range src.shorteners {
+			out.shorteners[d] = true
+		}
+		out.patterns = append(out.patterns, src.patterns...)
+		out.brands = append(out.brands, src.brands...)
+	}
+	sort.Strings(out.brands)
+	return out
+}
+
+// normalizeHost lowercases host, drops a trailing dot and decodes punycode labels.
+func normalizeHost(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if strings.Contains(host, "xn--") {
+		if u, err := idna.Punycode.ToUnicode(host); err == nil {
+			host = u
+		}
+	}
+	return host
+}
+
+// hostMatches reports whether host equals domain or is one of its subdomains.
+func hostMatches(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+func (r *Rules) isShortener(host string) bool {
+	return r.shorteners[normalizeHost(host)]
+}
+
+// check runs every local rule (no network) against one URL. The returned finding has
+// Action == ActionAllow when nothing matched.
+func (r *Rules) check(raw string) Finding {
+	f := Finding{URL: raw, Action: ActionAllow}
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		f.Action, f.Reason = ActionBlock, "invalid_url"
+		return f
+	}
+	// https://bank.com@evil.example — trik klasik menyamarkan host sebenarnya
+	if u.User != nil {
+		f.Action, f.Reason = ActionBlock, "credentials_in_url"
+		return f
+	}
+	host := normalizeHost(u.Hostname())
+	for d := host; d != ""; {
+		if r.domains[d] {
+			f.Action, f.Reason, f.Detail = ActionBlock, "blocklisted_domain", d
+			return f
+		}
+		_, parent, ok := strings.Cut(d, ".")
+		if !ok {
+			break
+		}
+		d = parent
+	}
+	if action, reason, detail := r.checkHost(host); action != ActionAllow {
+		f.Action, f.Reason, f.Detail = action, reason, detail
+		return f
+	}
+	if _, err := netip.ParseAddr(strings.Trim(u.Hostname(), "[]")); err == nil {
+		f.Action, f.Reason = ActionQuarantine, "ip_address_host"
+		return f
+	}
+	for _, re := range r.patterns {
+		if re.MatchString(u.String()) {
+			f.Action, f.Reason, f.Detail = ActionQuarantine, "suspicious_pattern", re.String()[len("(?i)"):]
+			return f
+		}
+	}
+	return f
+}
+
+// worse returns the more severe of two actions.
+func worse(a, b string) string {
+	rank := map[string]int{ActionAllow: 0, ActionQuarantine: 1, ActionBlock: 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
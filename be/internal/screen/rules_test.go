@@ -0,0 +1,118 @@
+package screen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRules(t *testing.T) {
+	r, err := ParseRules("# komentar\n\ndomain Evil.Example.\nDOMAIN xn--e1afmkfd.xn--p1ai\nshortener Bit.LY\nbrand PayPal.com\npattern /phish\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.domains["evil.example"] || !r.domains["пример.рф"] {
+		t.Errorf("domains = %v", r.domains)
+	}
+	if !r.isShortener("BIT.LY.") {
+		t.Error("bit.ly not recognized as shortener")
+	}
+	if len(r.brands) != 1 || r.brands[0] != "paypal.com" {
+		t.Errorf("brands = %v", r.brands)
+	}
+	if len(r.patterns) != 1 || !r.patterns[0].MatchString("https://x.example/PHISH") {
+		t.Error("pattern is not case-insensitive")
+	}
+
+	errs := map[string]string{
+		"domain":                                   "line 1",
+		"block evil.example":                       "unknown rule kind",
+		"# ok\npattern (unclosed":                  "line 2: invalid pattern",
+		"pattern " + strings.Repeat("a", 301):      "pattern too long",
+		strings.Repeat("domain a.example\n", 5001): "too many rules",
+	}
+	for text, want := range errs {
+		if _, err := ParseRules(text); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseRules(%.30q) err = %v, want %q", text, err, want)
+		}
+	}
+}
+
+func TestBundledRules(t *testing.T) {
+	r, err := ParseRules(bundledRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.domains) == 0 || len(r.shorteners) == 0 || len(r.brands) == 0 || len(r.patterns) == 0 {
+		t.Fatalf("bundled blocklist incomplete: %d domains, %d shorteners, %d brands, %d patterns",
+			len(r.domains), len(r.shorteners), len(r.brands), len(r.patterns))
+	}
+}
+
+func TestCheck(t *testing.T) {
+	bundled, err := ParseRules(bundledRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom, err := ParseRules("domain evil.example\nbrand toko-kita.id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := bundled.with(custom)
+
+	tests := []struct {
+		url    string
+		action string
+		reason string
+		detail string
+	}{
+		{"https://example.com/about", ActionAllow, "", ""},
+		{"https://www.paypal.com/signin", ActionAllow, "", ""},
+		{"https://xn--e1afmkfd.xn--p1ai/", ActionAllow, "", ""}, // пример.рф
+		{"https://toko-kita.id/promo", ActionAllow, "", ""},
+		{"https://notgrabify.link/", ActionAllow, "", ""},
+
+		{"javascript:alert(1)", ActionBlock, "invalid_url", ""},
+		{"ftp://example.com/file", ActionBlock, "invalid_url", ""},
+		{"https:///path", ActionBlock, "invalid_url", ""},
+		{"https://paypal.com@evil.example/", ActionBlock, "credentials_in_url", ""},
+		// blocklist berlaku untuk semua subdomain, huruf besar dan titik di akhir host
+		{"https://grabify.link/abc", ActionBlock, "blocklisted_domain", "grabify.link"},
+		{"https://x.y.GRABIFY.LINK./abc", ActionBlock, "blocklisted_domain", "grabify.link"},
+		{"https://cdn.evil.example/a.png", ActionBlock, "blocklisted_domain", "evil.example"},
+		// homoglyph dalam bentuk punycode
+		{"https://xn--ypal-43d9g.com/login", ActionBlock, "homoglyph", "paypal.com"},
+		{"https://login.xn--ypal-43d9g.com/", ActionBlock, "homoglyph", "paypal.com"},
+		{"https://XN--80AK6AA92E.com/", ActionBlock, "homoglyph", "apple.com"},
+		{"https://xn--ggle-0nda.com/", ActionBlock, "homoglyph", "google.com"},
+		{"https://paypa1.com/", ActionQuarantine, "lookalike", "paypal.com"},
+		{"https://toko-kita.id.promo.example/", ActionQuarantine, "brand_impersonation", "toko-kita.id"},
+		{"http://192.168.1.1/admin", ActionQuarantine, "ip_address_host", ""},
+		{"http://[::1]:8080/", ActionQuarantine, "ip_address_host", ""},
+		{"https://example.com/app.APK", ActionQuarantine, "suspicious_pattern", `\.(exe|scr|apk|msi|bat|cmd|vbs|jar)([?#]|$)`},
+		{"https://blog.example/wp-content/plugins/x/login.php", ActionQuarantine, "suspicious_pattern", `/(wp-content|wp-includes|wp-admin)/[^?#]*(login|signin|verify|account|banking|webscr)`},
+	}
+	for _, tt := range tests {
+		f := r.check(tt.url)
+		if f.Action != tt.action || f.Reason != tt.reason || f.Detail != tt.detail {
+			t.Errorf("check(%q) = %s %s %s; want %s %s %s", tt.url, f.Action, f.Reason, f.Detail, tt.action, tt.reason, tt.detail)
+		}
+		if f.URL != tt.url {
+			t.Errorf("check(%q).URL = %q", tt.url, f.URL)
+		}
+	}
+}
+
+func TestWorse(t *testing.T) {
+	tests := []struct{ a, b, want string }{
+		{ActionAllow, ActionAllow, ActionAllow},
+		{ActionAllow, ActionQuarantine, ActionQuarantine},
+		{ActionQuarantine, ActionAllow, ActionQuarantine},
+		{ActionQuarantine, ActionBlock, ActionBlock},
+		{ActionBlock, ActionQuarantine, ActionBlock},
+	}
+	for _, tt := range tests {
+		if got := worse(tt.a, tt.b); got != tt.want {
+			t.Errorf("worse(%s, %s) = %s, want %s", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+// Package screen memeriksa URL yang disimpan user (blocklist domain, pola URL, homoglyph
+// punycode, brand impersonation, dan URL shortener yang diikuti sampai tujuan akhirnya)
+// supaya layanan link-in-bio tidak dipakai untuk phishing. URL yang pasti berbahaya ditolak;
+// yang meragukan disimpan tapi dikarantina sampai direview admin.
+package screen
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"biomu/backend/internal/firebase"
+	"biomu/backend/internal/unfurl"
+)
+
+const (
+	settingsDocID  = "urlBlocklist"
+	refreshDefault = 5 * time.Minute
+	expandTimeout  = 5 * time.Second
+	// maxURLs membatasi jumlah URL per dokumen (dan jumlah shortener yang diikuti).
+	maxURLs = 50
+)
+
+//go:embed blocklist.txt
+var bundledRules string
+
+// Expander follows the redirect chain of a URL and returns every hop (implemented by
+// unfurl.Fetcher).
+type Expander interface {
+	Expand(ctx context.Context, raw string) ([]string, error)
+}
+
+// Verdict is the combined result for all URLs of a document.
+type Verdict struct {
+	Action   string    `json:"action"`
+	Findings []Finding `json:"findings,omitempty"`
+}
+
+// Screener is safe for concurrent use. Admin rules are merged with the bundled blocklist.
+type Screener struct {
+	fb           *firebase.App
+	settingsColl string
+	collections  map[string]bool
+	expander     Expander
+
+	mu     sync.RWMutex
+	rules  *Rules
+	custom string
+}
+
+// NewScreener screens documents of the given collections. If fb is non-nil, Reload/Run read
+// the admin-managed rules from settingsColl/urlBlocklist. expander may be nil (shortener
+// links are then only checked locally).
+func NewScreener(fb *firebase.App, settingsColl string, collections []string, expander Expander) *Screener {
+	s := &Screener{fb: fb, settingsColl: settingsColl, collections: map[string]bool{}, expander: expander}
+	for _, c := range collections {
+		if c = strings.TrimSpace(c); c != "" {
+			s.collections[c] = true
+		}
+	}
+	if err := s.setCustom(""); err != nil {
+		panic("screen: bundled blocklist: " + err.Error())
+	}
+	return s
+}
+
+// Applies reports whether documents of collection are screened.
+func (s *Screener) Applies(collection string) bool {
+	return s.collections[collection]
+}
+
+// Collections returns the screened collections.
+func (s *Screener) Collections() []string {
+	out := make([]string, 0, len(s.collections))
+	for c := range s.collections {
+		out = append(out, c)
+	}
+	return out
+}
+
+func (s *Screener) setCustom(text string) error {
+	bundled, err := ParseRules(bundledRules)
+	if err != nil {
+		return err
+	}
+	custom, err := ParseRules(text)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.rules, s.custom = bundled.with(custom), text
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Screener) activeRules() *Rules {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rules
+}
+
+// CustomRules returns the admin-managed rules in blocklist format.
+func (s *Screener) CustomRules() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.custom
+}
+
+// Check evaluates one URL against the local rules only (no network). Used at redirect time
+// so links saved before a domain was blocklisted stop working immediately.
+func (s *Screener) Check(raw string) Finding {
+	return s.activeRules().check(raw)
+}
+
+// Screen evaluates urls. Links to known shorteners are expanded and every hop is checked;
+// a chain that cannot be resolved, or that points into an internal network, is quarantined.
+func (s *Screener) Screen(ctx context.Context, urls []string) Verdict {
+	v := Verdict{Action: ActionAllow}
+	if len(urls) > maxURLs {
+		v.Action = ActionBlock
+		v.Findings = []Finding{{Action: ActionBlock, Reason: "too_many_urls"}}
+		return v
+	}
+	rules := s.activeRules()
+	add := func(f Finding) {
+		v.Action = worse(v.Action, f.Action)
+		v.Findings = append(v.Findings, f)
+	}
+	seen := map[string]bool{}
+	for _, raw := range urls {
+		if seen[raw] {
+			continue
+		}
+		seen[raw] = true
+		f := rules.check(raw)
+		if f.Action != ActionAllow {
+			add(f)
+			if f.Action == ActionBlock {
+				continue
+			}
+		}
+		if s.expander == nil || !rules.isShortener(hostOf(raw)) {
+			continue
+		}
+		for _, hf := range s.expandFindings(ctx, rules, raw) {
+			add(hf)
+		}
+	}
+	return v
+}
+
+// expandFindings follows a shortener link and checks every hop behind it.
+func (s *Screener) expandFindings(ctx context.Context, rules *Rules, raw string) []Finding {
+	ctx, cancel := context.WithTimeout(ctx, expandTimeout)
+	defer cancel()
+	chain, err := s.expander.Expand(ctx, raw)
+	var out []Finding
+	for _, hop := range chain[min(1, len(chain)):] {
+		hf := rules.check(hop)
+		if hf.Action == ActionAllow {
+			continue
+		}
+		hf.URL, hf.Target = raw, hop
+		out = append(out, hf)
+	}
+	switch {
+	case errors.Is(err, unfurl.ErrBlocked):
+		out = append(out, Finding{URL: raw, Action: ActionQuarantine, Reason: "shortener_internal_target", Detail: lastHop(chain)})
+	case err != nil:
+		log.Printf("screen expand %s: %v", raw, err)
+		out = append(out, Finding{URL: raw, Action: ActionQuarantine, Reason: "shortener_unresolved"})
+	case len(chain) > 0 && rules.isShortener(hostOf(lastHop(chain))):
+		// Rantai shortener yang tidak berujung (atau berhenti di shortener lain)
+		out = append(out, Finding{URL: raw, Action: ActionQuarantine, Reason: "shortener_chain", Detail: lastHop(chain)})
+	}
+	return out
+}
+
+func lastHop(chain []string) string {
+	if len(chain) == 0 {
+		return ""
+	}
+	return chain[len(chain)-1]
+}
+
+func hostOf(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// CollectURLs returns every http(s) URL string inside a decoded JSON document (nested maps
+// and arrays included). The server-managed moderation field is skipped.
+func CollectURLs(v any) []string {
+	var out []string
+	var walk func(any)
+	walk = func(v any) {
+		switch x := v.(type) {
+		case string:
+			s := strings.TrimSpace(x)
+			lower := strings.ToLower(s)
+			if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+				out = append(out, s)
+			}
+		case map[string]any:
+			for k, e := range x {
+				if k != FieldModeration {
+					walk(e)
+				}
+			}
+		case []any:
+			for _, e := range x {
+				walk(e)
+			}
+		}
+	}
+	walk(v)
+	return out
+}
+
+// Reload reads the admin rules; without them only the bundled blocklist is active.
+func (s *Screener) Reload(ctx context.Context) error {
+	if s.fb == nil {
+		return nil
+	}
+	snap, err := s.fb.DB.Collection(s.settingsColl).Doc(settingsDocID).Get(ctx)
+	if err != nil {
+		if !snap.Exists() {
+			return s.setCustom("")
+		}
+		return err
+	}
+	text, _ := snap.Data()["text"].(string)
+	return s.setCustom(text)
+}
+
+// Save validates and persists the admin rules, then activates them immediately.
+// An empty text removes the override.
+func (s *Screener) Save(ctx context.Context, text, updatedBy string) error {
+	if _, err := ParseRules(text); err != nil {
+		return err
+	}
+	if strings.TrimSpace(text) == "" {
+		if _, err := s.fb.DB.Collection(s.settingsColl).Doc(settingsDocID).Delete(ctx); err != nil {
+			return err
+		}
+		return s.setCustom("")
+	}
+	_, err := s.fb.DB.Collection(s.settingsColl).Doc(settingsDocID).Set(ctx, map[string]any{
+		"text":      text,
+		"updatedBy": updatedBy,
+		"updatedAt": time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	return s.setCustom(text)
+}
+
+// Run periodically reloads the admin rules (so every instance picks up changes) until ctx
+// is cancelled.
+func (s *Screener) Run(ctx context.Context) {
+	ticker := time.NewTicker(refreshDefault)
+	defer ticker.Stop()
+	for {
+		if err := s.Reload(ctx); err != nil {
+			log.Printf("screen reload: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
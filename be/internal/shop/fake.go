@@ -0,0 +1,93 @@
+package shop
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"biomu/backend/internal/billing"
+)
+
+// FakeSignatureHeader carries the webhook signature of the Fake provider.
+const FakeSignatureHeader = "Payment-Signature"
+
+// Fake is a local provider for development and tests: pembayaran langsung kembali ke SuccessURL
+// (order tetap pending sampai ada webhook), and webhooks are JSON events signed like Stripe's.
+type Fake struct {
+	Secret []byte
+}
+
+func NewFake(secret []byte) *Fake {
+	return &Fake{Secret: secret}
+}
+
+func (f *Fake) Name() string { return "fake" }
+
+func (f *Fake) CreatePayment(_ context.Context, req PaymentRequest) (*Payment, error) {
+	id := "fake_pay_" + randomHex(8)
+	u, err := url.Parse(req.SuccessURL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("payment", id)
+	u.RawQuery = q.Encode()
+	return &Payment{ID: id, URL: u.String()}, nil
+}
+
+// fakeEvent is the wire format of Fake webhooks.
+type fakeEvent struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Type      string    `json:"type"`
+	OrderID   string    `json:"orderId"`
+	PaymentID string    `json:"paymentId"`
+	Amount    int64     `json:"amount"`
+	Currency  string    `json:"currency"`
+}
+
+// Webhook returns the body and signature header of a webhook delivering ev. Empty ID and
+// CreatedAt are filled in.
+func (f *Fake) Webhook(ev PaymentEvent, now time.Time) ([]byte, http.Header, error) {
+	if ev.ID == "" {
+		ev.ID = "fake_evt_" + randomHex(8)
+	}
+	if ev.CreatedAt.IsZero() {
+		ev.CreatedAt = now
+	}
+	body, err := json.Marshal(fakeEvent(ev))
+	if err != nil {
+		return nil, nil, err
+	}
+	header := http.Header{}
+	header.Set(FakeSignatureHeader, billing.Sign(f.Secret, body, now))
+	header.Set("Content-Type", "application/json")
+	return body, header, nil
+}
+
+func (f *Fake) ParseWebhook(header http.Header, body []byte, now time.Time) (*PaymentEvent, error) {
+	if err := billing.VerifySignature(f.Secret, header.Get(FakeSignatureHeader), body, now); err != nil {
+		return nil, err
+	}
+	var ev fakeEvent
+	if err := json.Unmarshal(body, &ev); err != nil || ev.ID == "" || (ev.OrderID == "" && ev.PaymentID == "") {
+		return nil, billing.ErrBadPayload
+	}
+	switch ev.Type {
+	case EventPaid, EventRefunded, EventCanceled:
+	default:
+		return nil, billing.ErrBadPayload
+	}
+	out := PaymentEvent(ev)
+	return &out, nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
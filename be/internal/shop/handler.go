@@ -0,0 +1,740 @@
+package shop
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"biomu/backend/internal/billing"
+	"biomu/backend/internal/email"
+	"biomu/backend/internal/profile"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+const (
+	webhookMaxBytes  = 256 << 10
+	productMaxBytes  = 8 << 10
+	checkoutMaxBytes = 4 << 10
+	// multipartOverhead: ruang untuk boundary dan header part di luar file
+	multipartOverhead = 64 << 10
+	maxEmailLen       = 254
+)
+
+// Sessions resolves the signed-in caller (implemented by auth.Handler).
+type Sessions interface {
+	SessionUID(r *http.Request) string
+}
+
+type Handler struct {
+	store    *Store
+	provider Provider
+	profiles *profile.Store
+	sessions Sessions
+	email    email.Sender
+	baseURL  string
+	siteName string
+}
+
+// NewHandler creates the shop endpoints. baseURL is the public origin of receipt and download
+// URLs; sender may be nil (tanpa email tanda terima).
+func NewHandler(store *Store, provider Provider, profiles *profile.Store, sessions Sessions, sender email.Sender, baseURL, siteName string) *Handler {
+	return &Handler{
+		store:    store,
+		provider: provider,
+		profiles: profiles,
+		sessions: sessions,
+		email:    sender,
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		siteName: siteName,
+	}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// productInput is the editable part of a product.
+type productInput struct {
+	Kind             string  `json:"kind"`
+	Title            string  `json:"title"`
+	Description      string  `json:"description"`
+	Price            int64   `json:"price"`
+	Currency         string  `json:"currency"`
+	SuggestedAmounts []int64 `json:"suggestedAmounts"`
+	MaxDownloads     int     `json:"maxDownloads"`
+	Active           bool    `json:"active"`
+	Position         int     `json:"position"`
+}
+
+func (in productInput) apply(p *Product) {
+	p.Kind, p.Title, p.Description = in.Kind, in.Title, in.Description
+	p.Price, p.Currency, p.SuggestedAmounts = in.Price, in.Currency, in.SuggestedAmounts
+	p.MaxDownloads, p.Active, p.Position = in.MaxDownloads, in.Active, in.Position
+}
+
+// GET /api/shop/products — produk dan tip jar milik caller (termasuk yang nonaktif)
+func (h *Handler) Products(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	products, err := h.store.List(r.Context(), uid)
+	if err != nil {
+		log.Printf("shop products %s: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load products"})
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{"products": products, "currency": h.store.DefaultCurrency()})
+}
+
+// POST /api/shop/products — buat produk ({"kind":"product"}, aktif setelah file diunggah) atau
+// tip jar ({"kind":"tip","price":<minimum>,"suggestedAmounts":[...]}); nominal dalam satuan terkecil
+func (h *Handler) CreateProduct(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	var in productInput
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, productMaxBytes)).Decode(&in); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body: " + err.Error()})
+		return
+	}
+	p := &Product{}
+	in.apply(p)
+	if err := p.Normalize(h.store.DefaultCurrency()); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := h.store.Create(r.Context(), uid, p); err != nil {
+		h.writeStoreError(w, "create", uid, err)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, p)
+}
+
+// PUT /api/shop/products/{id} — ubah produk milik caller (jenis produk tidak bisa diganti)
+func (h *Handler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	var in productInput
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, productMaxBytes)).Decode(&in); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body: " + err.Error()})
+		return
+	}
+	ctx := r.Context()
+	p, err := h.store.Owned(ctx, uid, r.PathValue("id"))
+	if err != nil {
+		h.writeStoreError(w, "update", uid, err)
+		return
+	}
+	if in.Kind == "" {
+		in.Kind = p.Kind
+	}
+	if in.Kind != p.Kind {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "kind cannot be changed"})
+		return
+	}
+	in.apply(p)
+	if err := p.Normalize(h.store.DefaultCurrency()); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := h.store.Save(ctx, p); err != nil {
+		h.writeStoreError(w, "update", uid, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, p)
+}
+
+// DELETE /api/shop/products/{id} — hapus produk; pembeli sebelumnya tetap bisa mengunduh
+func (h *Handler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	if err := h.store.Delete(r.Context(), uid, r.PathValue("id")); err != nil {
+		h.writeStoreError(w, "delete", uid, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /api/shop/products/{id}/file — multipart/form-data "file": file yang dijual (maks 50MB)
+func (h *Handler) UploadFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	ctx := r.Context()
+	p, err := h.store.Owned(ctx, uid, r.PathValue("id"))
+	if err != nil {
+		h.writeStoreError(w, "upload", uid, err)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxFileBytes+multipartOverhead)
+	name, contentType, data, err := readFile(r)
+	if err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) || errors.Is(err, errFileTooLarge) {
+			h.writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "file exceeds " + strconv.Itoa(MaxFileBytes>>20) + "MB"})
+			return
+		}
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := h.store.PutFile(ctx, p, name, contentType, data); err != nil {
+		h.writeStoreError(w, "upload", uid, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, p)
+}
+
+var errFileTooLarge = errors.New("file too large")
+
+func readFile(r *http.Request) (name, contentType string, data []byte, err error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return "", "", nil, errors.New("expected multipart/form-data")
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return "", "", nil, ErrFileRequired
+		}
+		if err != nil {
+			return "", "", nil, err
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+		data, err = io.ReadAll(io.LimitReader(part, MaxFileBytes+1))
+		part.Close()
+		if err != nil {
+			return "", "", nil, err
+		}
+		if len(data) > MaxFileBytes {
+			return "", "", nil, errFileTooLarge
+		}
+		if len(data) == 0 {
+			return "", "", nil, ErrFileRequired
+		}
+		contentType = part.Header.Get("Content-Type")
+		if _, _, err := mime.ParseMediaType(contentType); err != nil || contentType == "" {
+			contentType = http.DetectContentType(data)
+		}
+		return part.FileName(), contentType, data, nil
+	}
+}
+
+func (h *Handler) writeStoreError(w http.ResponseWriter, op, uid string, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": ErrNotFound.Error()})
+	case errors.Is(err, ErrInvalid):
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, ErrLimit):
+		h.writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		log.Printf("shop %s %s: %v", op, uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to " + op + " product"})
+	}
+}
+
+// GET /api/shop/orders — penjualan caller, terbaru dulu
+func (h *Handler) Sales(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	orders, err := h.store.Sales(r.Context(), uid)
+	if err != nil {
+		log.Printf("shop sales %s: %v", uid, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load orders"})
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{"orders": orders})
+}
+
+// checkoutInput is the body of a checkout, as JSON or as a form posted from the bio page.
+type checkoutInput struct {
+	ProductID string `json:"productId"`
+	Email     string `json:"email"`
+	// Amount hanya untuk tip (satuan terkecil); kosong = nominal minimum
+	Amount int64 `json:"amount"`
+}
+
+// POST /api/shop/checkout — buat order pending dan sesi pembayaran. Body JSON dibalas 201
+// {orderId, url, receiptUrl}; form dari halaman bio di-redirect (303) ke halaman pembayaran.
+func (h *Handler) Checkout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	form := !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json")
+	fail := func(status int, msg string) {
+		if form {
+			http.Error(w, msg, status)
+			return
+		}
+		h.writeJSON(w, status, map[string]string{"error": msg})
+	}
+
+	var in checkoutInput
+	r.Body = http.MaxBytesReader(w, r.Body, checkoutMaxBytes)
+	if form {
+		if err := r.ParseForm(); err != nil {
+			fail(http.StatusBadRequest, "invalid form")
+			return
+		}
+		in.ProductID, in.Email = r.PostForm.Get("productId"), r.PostForm.Get("email")
+		if v := r.PostForm.Get("amount"); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				fail(http.StatusBadRequest, "invalid amount")
+				return
+			}
+			in.Amount = n
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		fail(http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	buyer, ok := validEmail(in.Email)
+	if !ok {
+		fail(http.StatusBadRequest, "a valid email is required")
+		return
+	}
+
+	ctx := r.Context()
+	p, err := h.store.Get(ctx, in.ProductID)
+	if errors.Is(err, ErrNotFound) || (err == nil && !p.Sellable()) {
+		fail(http.StatusNotFound, ErrNotFound.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("shop checkout %s: %v", in.ProductID, err)
+		fail(http.StatusInternalServerError, "failed to start checkout")
+		return
+	}
+	amount := p.Price
+	if p.Kind == KindTip && in.Amount != 0 {
+		if in.Amount < p.Price || in.Amount > MaxAmount {
+			fail(http.StatusBadRequest, "amount must be between "+FormatAmount(p.Price, p.Currency)+" and "+FormatAmount(MaxAmount, p.Currency))
+			return
+		}
+		amount = in.Amount
+	}
+	seller, err := h.profiles.FindByID(ctx, p.OwnerID)
+	if err != nil {
+		log.Printf("shop checkout %s seller: %v", p.ID, err)
+		fail(http.StatusInternalServerError, "failed to start checkout")
+		return
+	}
+	// Akun pending signup (belum punya role) tidak boleh berjualan
+	if seller == nil || seller.Handle == "" || seller.Data["role"] == nil {
+		fail(http.StatusNotFound, ErrNotFound.Error())
+		return
+	}
+
+	o, err := h.store.CreateOrder(ctx, p, amount, buyer)
+	if err != nil {
+		log.Printf("shop checkout %s: %v", p.ID, err)
+		fail(http.StatusInternalServerError, "failed to start checkout")
+		return
+	}
+	receipt := h.receiptURL(o.ID)
+	payment, err := h.provider.CreatePayment(ctx, PaymentRequest{
+		OrderID:    o.ID,
+		Title:      p.Title,
+		Amount:     amount,
+		Currency:   p.Currency,
+		Email:      buyer,
+		SuccessURL: receipt,
+		CancelURL:  h.baseURL + "/" + url.PathEscape(seller.Handle),
+	})
+	if err != nil {
+		// Order tetap pending dan dibatalkan oleh sweeper
+		log.Printf("shop checkout %s payment: %v", o.ID, err)
+		fail(http.StatusBadGateway, "payment provider unavailable")
+		return
+	}
+	if err := h.store.SetPayment(ctx, o.ID, h.provider.Name(), payment.ID); err != nil {
+		log.Printf("shop checkout %s: %v", o.ID, err)
+	}
+	if form {
+		http.Redirect(w, r, payment.URL, http.StatusSeeOther)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, map[string]string{"orderId": o.ID, "url": payment.URL, "receiptUrl": receipt})
+}
+
+func validEmail(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || len(raw) > maxEmailLen {
+		return "", false
+	}
+	addr, err := mail.ParseAddress(raw)
+	if err != nil || addr.Address != raw {
+		return "", false
+	}
+	return raw, true
+}
+
+// POST /api/shop/webhook — event pembayaran dari provider (tanpa session, diverifikasi HMAC)
+func (h *Handler) Webhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, webhookMaxBytes))
+	if err != nil {
+		h.writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "body too large"})
+		return
+	}
+	ev, err := h.provider.ParseWebhook(r.Header, body, time.Now())
+	switch {
+	case errors.Is(err, billing.ErrBadSignature):
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("shop webhook: %v", err)
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": billing.ErrBadPayload.Error()})
+		return
+	case ev == nil:
+		// Tipe event yang tidak dipakai tetap dibalas 2xx supaya provider tidak mengirim ulang
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if _, err := h.apply(r.Context(), ev); err != nil {
+		// 5xx: provider akan mengirim ulang event ini
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to apply event"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apply applies ev and emails the receipt once an order is fulfilled. Events for unknown orders
+// (mis. pembayaran lain di akun provider yang sama) are ignored.
+func (h *Handler) apply(ctx context.Context, ev *PaymentEvent) (*Order, error) {
+	o, changed, err := h.store.Apply(ctx, h.provider.Name(), ev)
+	if errors.Is(err, ErrOrderNotFound) {
+		log.Printf("shop webhook %s: no order for %q/%q", ev.ID, ev.OrderID, ev.PaymentID)
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("shop webhook %s %s: %v", ev.ID, ev.OrderID, err)
+		return nil, err
+	}
+	if changed && o.Status == OrderFulfilled && h.email != nil {
+		go func(o *Order) {
+			if err := h.email.SendPurchaseReceipt(o.Email, o.Title, h.receiptURL(o.ID), o.Kind == KindProduct); err != nil {
+				log.Printf("shop receipt %s: %v", o.ID, err)
+			}
+		}(o)
+	}
+	return o, nil
+}
+
+// POST /api/shop/fake/events — hanya dengan provider fake (dev/test): kirim event pembayaran
+// untuk satu order lewat jalur webhook yang sama. Body {"orderId": "...", "type": "paid"}
+func (h *Handler) FakeEvent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	fake, ok := h.provider.(*Fake)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	var body struct {
+		OrderID string `json:"orderId"`
+		Type    string `json:"type"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10)).Decode(&body); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body: " + err.Error()})
+		return
+	}
+	ctx := r.Context()
+	o, err := h.store.Order(ctx, body.OrderID)
+	if errors.Is(err, ErrOrderNotFound) {
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("shop fake event %s: %v", body.OrderID, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load order"})
+		return
+	}
+	now := time.Now().UTC()
+	payload, header, err := fake.Webhook(PaymentEvent{
+		Type:      body.Type,
+		OrderID:   o.ID,
+		PaymentID: o.PaymentID,
+		Amount:    o.Amount,
+		Currency:  o.Currency,
+	}, now)
+	if err != nil {
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	ev, err := fake.ParseWebhook(header, payload, now)
+	if err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if o, err = h.apply(ctx, ev); err != nil {
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to apply event"})
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{"order": h.view(o)})
+}
+
+// downloadView is the download offered to the buyer of a fulfilled product order.
+type downloadView struct {
+	URL       string    `json:"url"`
+	FileName  string    `json:"fileName"`
+	Size      int64     `json:"size"`
+	Remaining int       `json:"remaining"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// orderView is what the buyer sees of an order.
+type orderView struct {
+	ID          string        `json:"id"`
+	Kind        string        `json:"kind"`
+	Title       string        `json:"title"`
+	Status      string        `json:"status"`
+	Amount      int64         `json:"amount"`
+	Currency    string        `json:"currency"`
+	AmountLabel string        `json:"amountLabel"`
+	Download    *downloadView `json:"download,omitempty"`
+	// Reason: kenapa unduhan tidak tersedia lagi (access_expired, download_limit)
+	Reason string `json:"reason,omitempty"`
+}
+
+func (h *Handler) view(o *Order) orderView {
+	v := orderView{
+		ID:          o.ID,
+		Kind:        o.Kind,
+		Title:       o.Title,
+		Status:      o.Status,
+		Amount:      o.Amount,
+		Currency:    o.Currency,
+		AmountLabel: FormatAmount(o.Amount, o.Currency),
+	}
+	if o.Kind != KindProduct || o.Status != OrderFulfilled {
+		return v
+	}
+	switch err := o.Downloadable(time.Now()); {
+	case errors.Is(err, ErrAccessExpired):
+		v.Reason = "access_expired"
+	case errors.Is(err, ErrDownloadLimit):
+		v.Reason = "download_limit"
+	case err == nil:
+		q := h.store.DownloadQuery(o)
+		exp, _ := strconv.ParseInt(q.Get("exp"), 10, 64)
+		v.Download = &downloadView{
+			URL:       h.baseURL + "/d/" + url.PathEscape(o.ID) + "?" + q.Encode(),
+			FileName:  o.File.Name,
+			Size:      o.File.Size,
+			Remaining: o.MaxDownloads - o.Downloads,
+			ExpiresAt: time.Unix(exp, 0).UTC(),
+		}
+	}
+	return v
+}
+
+func (h *Handler) receiptURL(id string) string {
+	return h.baseURL + "/orders/" + url.PathEscape(id) + "?t=" + h.store.AccessToken(id)
+}
+
+// buyerOrder loads the order in the path if the request carries its access token (?t=).
+func (h *Handler) buyerOrder(r *http.Request) (*Order, int) {
+	id := r.PathValue("orderId")
+	if !h.store.CheckAccessToken(id, r.URL.Query().Get("t")) {
+		return nil, http.StatusNotFound
+	}
+	o, err := h.store.Order(r.Context(), id)
+	if errors.Is(err, ErrOrderNotFound) {
+		return nil, http.StatusNotFound
+	}
+	if err != nil {
+		log.Printf("shop order %s: %v", id, err)
+		return nil, http.StatusInternalServerError
+	}
+	return o, http.StatusOK
+}
+
+// GET /api/shop/orders/{orderId}?t= — status order untuk pembeli, beserta URL unduhan baru
+func (h *Handler) Order(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	o, code := h.buyerOrder(r)
+	if o == nil {
+		h.writeJSON(w, code, map[string]string{"error": http.StatusText(code)})
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	h.writeJSON(w, http.StatusOK, map[string]any{"order": h.view(o)})
+}
+
+type receiptData struct {
+	SiteName string
+	HomeURL  string
+	Order    orderView
+	// Pending: halaman di-refresh sampai webhook pembayaran masuk
+	Pending bool
+}
+
+// GET /orders/{orderId}?t= — halaman tanda terima pembeli (tujuan setelah pembayaran dan link
+// di email), dengan tombol unduh yang URL-nya dibuat baru setiap kali dibuka
+func (h *Handler) Receipt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	o, code := h.buyerOrder(r)
+	if o == nil {
+		http.Error(w, http.StatusText(code), code)
+		return
+	}
+	d := receiptData{SiteName: h.siteName, HomeURL: h.baseURL + "/", Order: h.view(o), Pending: o.Status == OrderPending}
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, "receipt.html", d); err != nil {
+		log.Printf("shop receipt %s render: %v", o.ID, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	// Token akses ada di URL; jangan bocor lewat Referer
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(buf.Bytes())
+	}
+}
+
+// GET /d/{orderId}?exp=&sig= — unduh file order. URL bertanda tangan HMAC dengan masa berlaku;
+// setiap unduhan dihitung terhadap batas unduhan order.
+func (h *Handler) Download(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := r.PathValue("orderId")
+	if !h.store.CheckDownload(id, r.URL.Query()) {
+		http.Error(w, "Download link is invalid or has expired", http.StatusForbidden)
+		return
+	}
+	ctx := r.Context()
+	o, err := h.store.Order(ctx, id)
+	if err == nil {
+		err = o.Downloadable(time.Now())
+	}
+	if err != nil {
+		h.downloadError(w, id, err)
+		return
+	}
+	// File dibuka sebelum unduhan dihitung, supaya error storage tidak memakan jatah pembeli
+	f, err := h.store.OpenFile(ctx, o)
+	if err != nil {
+		log.Printf("shop download %s: %v", id, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	if r.Method == http.MethodGet {
+		if _, err := h.store.TakeDownload(ctx, id); err != nil {
+			h.downloadError(w, id, err)
+			return
+		}
+	}
+	contentType := f.File.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(f.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": f.File.Name}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		log.Printf("shop download %s stream: %v", id, err)
+	}
+}
+
+func (h *Handler) downloadError(w http.ResponseWriter, id string, err error) {
+	switch {
+	case errors.Is(err, ErrOrderNotFound):
+		http.Error(w, "Not Found", http.StatusNotFound)
+	case errors.Is(err, ErrAccessExpired):
+		http.Error(w, "Download access for this order has expired", http.StatusGone)
+	case errors.Is(err, ErrDownloadLimit):
+		http.Error(w, "Download limit for this order reached", http.StatusGone)
+	case errors.Is(err, ErrNotDownloadable):
+		http.Error(w, "This order has no download", http.StatusGone)
+	default:
+		log.Printf("shop download %s: %v", id, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
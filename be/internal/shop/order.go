@@ -0,0 +1,464 @@
+package shop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Order states.
+const (
+	OrderPending   = "pending"
+	OrderPaid      = "paid"
+	OrderFulfilled = "fulfilled"
+	OrderRefunded  = "refunded"
+	OrderCanceled  = "canceled"
+)
+
+const (
+	// pendingTTL: order yang tidak dibayar dalam waktu ini dibatalkan oleh sweeper
+	pendingTTL    = 24 * time.Hour
+	sweepInterval = 10 * time.Minute
+	salesLimit    = 500
+)
+
+var (
+	ErrOrderNotFound = errors.New("order not found")
+	ErrTransition    = errors.New("order transition not allowed")
+	// Alasan unduhan ditolak (URL valid, tapi order tidak lagi memberi akses)
+	ErrNotDownloadable = errors.New("order has no download")
+	ErrAccessExpired   = errors.New("download access expired")
+	ErrDownloadLimit   = errors.New("download limit reached")
+)
+
+// transitions is the order state machine: refund bisa terjadi setelah dibayar, pembatalan hanya
+// selagi pending.
+var transitions = map[string][]string{
+	OrderPending:   {OrderPaid, OrderCanceled},
+	OrderPaid:      {OrderFulfilled, OrderRefunded},
+	OrderFulfilled: {OrderRefunded},
+}
+
+// CanTransition reports whether an order may move from one state to another.
+func CanTransition(from, to string) bool {
+	return slices.Contains(transitions[from], to)
+}
+
+// Transition is one entry of an order's history.
+type Transition struct {
+	From    string    `json:"from" firestore:"from"`
+	To      string    `json:"to" firestore:"to"`
+	EventID string    `json:"eventId,omitempty" firestore:"eventId,omitempty"`
+	At      time.Time `json:"at" firestore:"at"`
+}
+
+// Order is one purchase of a product or one tip.
+type Order struct {
+	ID        string `json:"id" firestore:"-"`
+	ProductID string `json:"productId" firestore:"productId"`
+	SellerID  string `json:"sellerId" firestore:"sellerId"`
+	Kind      string `json:"kind" firestore:"kind"`
+	Title     string `json:"title" firestore:"title"`
+	Amount    int64  `json:"amount" firestore:"amount"`
+	Currency  string `json:"currency" firestore:"currency"`
+	Email     string `json:"email" firestore:"email"`
+	Status    string `json:"status" firestore:"status"`
+	Provider  string `json:"provider" firestore:"provider"`
+	// PaymentID: ID payment di provider (checkout session, lalu payment intent setelah dibayar)
+	PaymentID string       `json:"-" firestore:"paymentId"`
+	History   []Transition `json:"history" firestore:"history"`
+	// File disalin dari produk saat order dibuat, supaya pembeli menerima file yang dibelinya
+	File         *File `json:"file,omitempty" firestore:"file,omitempty"`
+	MaxDownloads int   `json:"maxDownloads,omitempty" firestore:"maxDownloads,omitempty"`
+	Downloads    int   `json:"downloads" firestore:"downloads"`
+	// AccessUntil: batas pembeli meminta URL unduhan baru
+	AccessUntil time.Time `json:"accessUntil,omitempty" firestore:"accessUntil,omitempty"`
+	// PendingUntil hanya diisi selama pending, supaya sweeper cukup query satu field
+	PendingUntil time.Time `json:"-" firestore:"pendingUntil,omitempty"`
+	// Sig: HMAC state order; order yang diubah di luar Store (langsung ke Firestore) dianggap tidak ada
+	Sig       string    `json:"-" firestore:"sig"`
+	CreatedAt time.Time `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" firestore:"updatedAt"`
+}
+
+// Downloadable reports whether the buyer may download the order's file at now.
+func (o *Order) Downloadable(now time.Time) error {
+	switch {
+	case o.Status != OrderFulfilled || o.File == nil:
+		return ErrNotDownloadable
+	case !now.Before(o.AccessUntil):
+		return ErrAccessExpired
+	case o.Downloads >= o.MaxDownloads:
+		return ErrDownloadLimit
+	}
+	return nil
+}
+
+func (o *Order) transition(to, eventID string, at time.Time) error {
+	if !CanTransition(o.Status, to) {
+		return fmt.Errorf("%w: %s → %s", ErrTransition, o.Status, to)
+	}
+	o.History = append(o.History, Transition{From: o.Status, To: to, EventID: eventID, At: at})
+	o.Status, o.UpdatedAt = to, at
+	if to != OrderPending {
+		o.PendingUntil = time.Time{}
+	}
+	return nil
+}
+
+// CreateOrder starts a pending order for product p. amount is what the buyer chose (tip) and
+// must already be validated by the caller.
+func (s *Store) CreateOrder(ctx context.Context, p *Product, amount int64, email string) (*Order, error) {
+	ref := s.fb.DB.Collection(s.orders).NewDoc()
+	now := s.now().UTC()
+	o := &Order{
+		ID:           ref.ID,
+		ProductID:    p.ID,
+		SellerID:     p.OwnerID,
+		Kind:         p.Kind,
+		Title:        p.Title,
+		Amount:       amount,
+		Currency:     p.Currency,
+		Email:        email,
+		Status:       OrderPending,
+		History:      []Transition{{To: OrderPending, At: now}},
+		File:         p.File,
+		MaxDownloads: p.MaxDownloads,
+		PendingUntil: now.Add(pendingTTL),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	o.Sig = s.orderSig(o)
+	if _, err := ref.Create(ctx, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// SetPayment records the provider payment started for a pending order.
+func (s *Store) SetPayment(ctx context.Context, id, provider, paymentID string) error {
+	_, err := s.fb.DB.Collection(s.orders).Doc(id).Update(ctx, []firestore.Update{
+		{Path: "provider", Value: provider},
+		{Path: "paymentId", Value: paymentID},
+		{Path: "updatedAt", Value: s.now().UTC()},
+	})
+	return err
+}
+
+// Order returns order id, or ErrOrderNotFound.
+func (s *Store) Order(ctx context.Context, id string) (*Order, error) {
+	if id == "" {
+		return nil, ErrOrderNotFound
+	}
+	doc, err := s.fb.DB.Collection(s.orders).Doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.orderFromDoc(doc)
+}
+
+// Sales returns the newest orders of sellerID.
+func (s *Store) Sales(ctx context.Context, sellerID string) ([]*Order, error) {
+	it := s.fb.DB.Collection(s.orders).Where("sellerId", "==", sellerID).Limit(salesLimit).Documents(ctx)
+	defer it.Stop()
+	out := []*Order{}
+	for {
+		doc, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		o, err := s.orderFromDoc(doc)
+		if err != nil {
+			continue
+		}
+		out = append(out, o)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+// Apply records a webhook event exactly once and moves its order through the state machine.
+// changed is true when the order moved; events that do not fit the current state (mis. paid
+// untuk order yang sudah dibatalkan, nominal yang tidak cocok) are recorded and logged only.
+func (s *Store) Apply(ctx context.Context, provider string, ev *PaymentEvent) (o *Order, changed bool, err error) {
+	orderID := ev.OrderID
+	if orderID == "" {
+		if orderID, err = s.orderByPayment(ctx, ev.PaymentID); err != nil {
+			return nil, false, err
+		}
+	}
+	ref := s.fb.DB.Collection(s.orders).Doc(orderID)
+	eventRef := s.fb.DB.Collection(s.events).Doc(provider + "_" + ev.ID)
+	err = s.fb.DB.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		o, changed = nil, false
+		if _, err := tx.Get(eventRef); err == nil {
+			return nil
+		} else if status.Code(err) != codes.NotFound {
+			return err
+		}
+		doc, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return ErrOrderNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if o, err = s.orderFromDoc(doc); err != nil {
+			return err
+		}
+		note := s.advance(o, ev)
+		changed = note == ""
+		if err := tx.Create(eventRef, map[string]any{
+			"provider":   provider,
+			"orderId":    o.ID,
+			"type":       ev.Type,
+			"paymentId":  ev.PaymentID,
+			"amount":     ev.Amount,
+			"createdAt":  ev.CreatedAt,
+			"receivedAt": s.now().UTC(),
+			"applied":    changed,
+			"note":       note,
+		}); err != nil {
+			return err
+		}
+		if !changed {
+			log.Printf("shop: event %s for order %s not applied: %s", ev.ID, o.ID, note)
+			return nil
+		}
+		o.Sig = s.orderSig(o)
+		return tx.Set(ref, o)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return o, changed, nil
+}
+
+// advance applies ev to o and returns why it was not applied, or "".
+func (s *Store) advance(o *Order, ev *PaymentEvent) string {
+	now := s.now().UTC()
+	switch ev.Type {
+	case EventPaid:
+		if ev.Amount != o.Amount || (ev.Currency != "" && ev.Currency != o.Currency) {
+			return "amount mismatch: " + strconv.FormatInt(ev.Amount, 10) + " " + ev.Currency
+		}
+		if err := o.transition(OrderPaid, ev.ID, now); err != nil {
+			return err.Error()
+		}
+		if ev.PaymentID != "" {
+			o.PaymentID = ev.PaymentID
+		}
+		// Fulfilment digital langsung: tip selesai, produk mendapat akses unduhan
+		if o.Kind == KindProduct {
+			o.AccessUntil = now.Add(s.accessWindow)
+		}
+		_ = o.transition(OrderFulfilled, ev.ID, now)
+	case EventRefunded:
+		if err := o.transition(OrderRefunded, ev.ID, now); err != nil {
+			return err.Error()
+		}
+		o.AccessUntil = time.Time{}
+	case EventCanceled:
+		if err := o.transition(OrderCanceled, ev.ID, now); err != nil {
+			return err.Error()
+		}
+	default:
+		return "unknown event type " + ev.Type
+	}
+	return ""
+}
+
+func (s *Store) orderByPayment(ctx context.Context, paymentID string) (string, error) {
+	if paymentID == "" {
+		return "", ErrOrderNotFound
+	}
+	it := s.fb.DB.Collection(s.orders).Where("paymentId", "==", paymentID).Limit(1).Documents(ctx)
+	defer it.Stop()
+	doc, err := it.Next()
+	if err == iterator.Done {
+		return "", ErrOrderNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return doc.Ref.ID, nil
+}
+
+// TakeDownload counts one download of order id if it still grants access.
+func (s *Store) TakeDownload(ctx context.Context, id string) (*Order, error) {
+	ref := s.fb.DB.Collection(s.orders).Doc(id)
+	var o *Order
+	err := s.fb.DB.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return ErrOrderNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if o, err = s.orderFromDoc(doc); err != nil {
+			return err
+		}
+		if err := o.Downloadable(s.now()); err != nil {
+			return err
+		}
+		o.Downloads++
+		o.Sig = s.orderSig(o)
+		return tx.Update(ref, []firestore.Update{{Path: "downloads", Value: o.Downloads}, {Path: "sig", Value: o.Sig}})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// FileReader streams an order's file.
+type FileReader struct {
+	io.ReadCloser
+	File File
+	Size int64
+}
+
+// OpenFile opens the stored file of o.
+func (s *Store) OpenFile(ctx context.Context, o *Order) (*FileReader, error) {
+	if o.File == nil {
+		return nil, ErrNotDownloadable
+	}
+	r, size, err := s.files.Open(ctx, o.File.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &FileReader{ReadCloser: r, File: *o.File, Size: size}, nil
+}
+
+// AccessToken is the secret that lets the buyer view order id (dikirim lewat URL sukses/email).
+func (s *Store) AccessToken(id string) string {
+	return s.key.Token("order", id)
+}
+
+// CheckAccessToken reports whether token grants access to order id.
+func (s *Store) CheckAccessToken(id, token string) bool {
+	return token != "" && s.key.VerifyToken(token, "order", id)
+}
+
+// DownloadQuery returns the signed query of a download URL for o, valid for downloadTTL but
+// never past the order's access window.
+func (s *Store) DownloadQuery(o *Order) url.Values {
+	exp := s.now().Add(s.downloadTTL)
+	if o.AccessUntil.Before(exp) {
+		exp = o.AccessUntil
+	}
+	ts := strconv.FormatInt(exp.Unix(), 10)
+	return url.Values{"exp": {ts}, "sig": {s.key.Sign("download", o.ID, ts)}}
+}
+
+// CheckDownload verifies the exp and sig of a download URL for order id.
+func (s *Store) CheckDownload(id string, q url.Values) bool {
+	ts, sig := q.Get("exp"), q.Get("sig")
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || sig == "" || !s.now().Before(time.Unix(unix, 0)) {
+		return false
+	}
+	return s.key.Verify(sig, "download", id, ts)
+}
+
+// Run cancels orders that stayed pending for pendingTTL until ctx is cancelled.
+func (s *Store) Run(ctx context.Context) {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.sweep(ctx); err != nil {
+			log.Printf("shop sweep: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Store) sweep(ctx context.Context) error {
+	it := s.fb.DB.Collection(s.orders).Where("pendingUntil", "<=", s.now().UTC()).Limit(200).Documents(ctx)
+	defer it.Stop()
+	for {
+		doc, err := it.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.expire(ctx, doc.Ref); err != nil {
+			log.Printf("shop sweep %s: %v", doc.Ref.ID, err)
+		}
+	}
+}
+
+// expire cancels a pending order if it is still due (dicek ulang di dalam transaksi).
+func (s *Store) expire(ctx context.Context, ref *firestore.DocumentRef) error {
+	return s.fb.DB.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		o, err := s.orderFromDoc(doc)
+		if err != nil {
+			return err
+		}
+		now := s.now().UTC()
+		if o.Status != OrderPending || o.PendingUntil.IsZero() || o.PendingUntil.After(now) {
+			return nil
+		}
+		if err := o.transition(OrderCanceled, "", now); err != nil {
+			return err
+		}
+		o.Sig = s.orderSig(o)
+		return tx.Set(ref, o)
+	})
+}
+
+// orderSig covers everything that decides what the buyer paid and may still download.
+func (s *Store) orderSig(o *Order) string {
+	return s.key.Sign(orderParts(o)...)
+}
+
+func orderParts(o *Order) []string {
+	fileKey := ""
+	if o.File != nil {
+		fileKey = o.File.Key
+	}
+	return []string{"order state", o.ID, o.ProductID, o.SellerID, o.Kind, strconv.FormatInt(o.Amount, 10), o.Currency,
+		o.Status, fileKey, strconv.Itoa(o.MaxDownloads), strconv.Itoa(o.Downloads), strconv.FormatInt(o.AccessUntil.Unix(), 10)}
+}
+
+// orderFromDoc decodes an order; documents without a valid signature return ErrOrderNotFound.
+func (s *Store) orderFromDoc(doc *firestore.DocumentSnapshot) (*Order, error) {
+	var o Order
+	if err := doc.DataTo(&o); err != nil {
+		return nil, err
+	}
+	o.ID = doc.Ref.ID
+	if !s.key.Verify(o.Sig, orderParts(&o)...) {
+		return nil, ErrOrderNotFound
+	}
+	return &o, nil
+}
@@ -0,0 +1,199 @@
+package shop
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newTestStore(now time.Time) *Store {
+	s := NewStore(nil, "products", "orders", "shop_events", nil, []byte("secret"), "idr", time.Hour, 30*24*time.Hour)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestCanTransition(t *testing.T) {
+	states := []string{OrderPending, OrderPaid, OrderFulfilled, OrderRefunded, OrderCanceled}
+	allowed := map[[2]string]bool{
+		{OrderPending, OrderPaid}:       true,
+		{OrderPending, OrderCanceled}:   true,
+		{OrderPaid, OrderFulfilled}:     true,
+		{OrderPaid, OrderRefunded}:      true,
+		{OrderFulfilled, OrderRefunded}: true,
+	}
+	for _, from := range states {
+		for _, to := range states {
+			if got := CanTransition(from, to); got != allowed[[2]string{from, to}] {
+				t.Errorf("CanTransition(%s, %s) = %v", from, to, got)
+			}
+		}
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := newTestStore(now)
+
+	tests := []struct {
+		name       string
+		kind       string
+		status     string
+		ev         PaymentEvent
+		wantStatus string
+		wantNote   bool
+		wantAccess bool
+	}{
+		{"product paid", KindProduct, OrderPending, PaymentEvent{ID: "e1", Type: EventPaid, Amount: 50000, Currency: "idr", PaymentID: "pi_1"}, OrderFulfilled, false, true},
+		{"tip paid", KindTip, OrderPending, PaymentEvent{ID: "e1", Type: EventPaid, Amount: 50000}, OrderFulfilled, false, false},
+		{"amount mismatch", KindProduct, OrderPending, PaymentEvent{ID: "e1", Type: EventPaid, Amount: 1}, OrderPending, true, false},
+		{"currency mismatch", KindProduct, OrderPending, PaymentEvent{ID: "e1", Type: EventPaid, Amount: 50000, Currency: "usd"}, OrderPending, true, false},
+		{"paid after cancel", KindProduct, OrderCanceled, PaymentEvent{ID: "e1", Type: EventPaid, Amount: 50000}, OrderCanceled, true, false},
+		{"paid twice", KindProduct, OrderFulfilled, PaymentEvent{ID: "e2", Type: EventPaid, Amount: 50000}, OrderFulfilled, true, false},
+		{"refund fulfilled", KindProduct, OrderFulfilled, PaymentEvent{ID: "e2", Type: EventRefunded}, OrderRefunded, false, false},
+		{"refund pending", KindProduct, OrderPending, PaymentEvent{ID: "e2", Type: EventRefunded}, OrderPending, true, false},
+		{"cancel pending", KindProduct, OrderPending, PaymentEvent{ID: "e2", Type: EventCanceled}, OrderCanceled, false, false},
+		{"cancel fulfilled", KindProduct, OrderFulfilled, PaymentEvent{ID: "e2", Type: EventCanceled}, OrderFulfilled, true, false},
+		{"unknown event", KindProduct, OrderPending, PaymentEvent{ID: "e2", Type: "disputed"}, OrderPending, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &Order{ID: "o1", Kind: tt.kind, Amount: 50000, Currency: "idr", Status: tt.status, PendingUntil: now.Add(pendingTTL)}
+			if tt.status == OrderFulfilled {
+				o.AccessUntil = now.Add(time.Hour)
+			}
+			before := len(o.History)
+			note := s.advance(o, &tt.ev)
+			if (note != "") != tt.wantNote {
+				t.Fatalf("note = %q, wantNote %v", note, tt.wantNote)
+			}
+			if o.Status != tt.wantStatus {
+				t.Fatalf("status = %s, want %s", o.Status, tt.wantStatus)
+			}
+			if (!o.AccessUntil.IsZero() && tt.status != OrderFulfilled) != tt.wantAccess {
+				t.Fatalf("accessUntil = %v, wantAccess %v", o.AccessUntil, tt.wantAccess)
+			}
+			if tt.wantNote {
+				if len(o.History) != before {
+					t.Fatalf("rejected event changed history: %+v", o.History)
+				}
+				return
+			}
+			for _, h := range o.History[before:] {
+				if h.EventID != tt.ev.ID || !h.At.Equal(now) || !CanTransition(h.From, h.To) {
+					t.Fatalf("bad history entry %+v", h)
+				}
+			}
+			if o.Status != OrderPending && !o.PendingUntil.IsZero() {
+				t.Fatal("pendingUntil kept after leaving pending")
+			}
+		})
+	}
+}
+
+func TestAdvanceRefundRevokesAccess(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := newTestStore(now)
+	o := &Order{ID: "o1", Kind: KindProduct, Amount: 50000, Currency: "idr", Status: OrderPending, File: &File{Key: "f"}, MaxDownloads: 3}
+	if note := s.advance(o, &PaymentEvent{ID: "e1", Type: EventPaid, Amount: 50000}); note != "" {
+		t.Fatal(note)
+	}
+	if err := o.Downloadable(now); err != nil {
+		t.Fatalf("paid order not downloadable: %v", err)
+	}
+	if got := []string{o.History[0].To, o.History[1].To}; got[0] != OrderPaid || got[1] != OrderFulfilled {
+		t.Fatalf("history %v, want paid then fulfilled", got)
+	}
+	if note := s.advance(o, &PaymentEvent{ID: "e2", Type: EventRefunded}); note != "" {
+		t.Fatal(note)
+	}
+	if err := o.Downloadable(now); !errors.Is(err, ErrNotDownloadable) {
+		t.Fatalf("refunded order: %v, want ErrNotDownloadable", err)
+	}
+}
+
+func TestDownloadable(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	base := Order{Status: OrderFulfilled, File: &File{Key: "f"}, MaxDownloads: 3, Downloads: 1, AccessUntil: now.Add(time.Hour)}
+	tests := []struct {
+		name   string
+		change func(o *Order)
+		want   error
+	}{
+		{"ok", func(o *Order) {}, nil},
+		{"tip", func(o *Order) { o.File = nil }, ErrNotDownloadable},
+		{"paid only", func(o *Order) { o.Status = OrderPaid }, ErrNotDownloadable},
+		{"access expired", func(o *Order) { o.AccessUntil = now }, ErrAccessExpired},
+		{"limit reached", func(o *Order) { o.Downloads = 3 }, ErrDownloadLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := base
+			tt.change(&o)
+			if err := o.Downloadable(now); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignedURLs(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := newTestStore(now)
+	o := &Order{ID: "o1", AccessUntil: now.Add(30 * time.Minute)}
+
+	q := s.DownloadQuery(o)
+	if exp := q.Get("exp"); exp != strconv.FormatInt(o.AccessUntil.Unix(), 10) {
+		t.Fatalf("exp = %s, want capped at the access window", exp)
+	}
+	tampered := url.Values{"exp": {strconv.FormatInt(now.Add(time.Hour).Unix(), 10)}, "sig": {q.Get("sig")}}
+	tests := []struct {
+		name string
+		id   string
+		q    url.Values
+		at   time.Time
+		want bool
+	}{
+		{"valid", "o1", q, now, true},
+		{"other order", "o2", q, now, false},
+		{"extended expiry", "o1", tampered, now, false},
+		{"expired", "o1", q, o.AccessUntil, false},
+		{"no signature", "o1", url.Values{"exp": {q.Get("exp")}}, now, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := tt.at
+			s.now = func() time.Time { return at }
+			if got := s.CheckDownload(tt.id, tt.q); got != tt.want {
+				t.Fatalf("CheckDownload = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	token := s.AccessToken("o1")
+	if !s.CheckAccessToken("o1", token) || s.CheckAccessToken("o2", token) || s.CheckAccessToken("o1", "") {
+		t.Fatal("access token not bound to its order")
+	}
+}
+
+func TestOrderSignature(t *testing.T) {
+	s := newTestStore(time.Now())
+	o := &Order{ID: "o1", SellerID: "u1", Amount: 50000, Currency: "idr", Status: OrderPending}
+	o.Sig = s.orderSig(o)
+	if !s.key.Verify(o.Sig, orderParts(o)...) {
+		t.Fatal("signature of the order rejected")
+	}
+	for name, change := range map[string]func(o *Order){
+		"status":    func(o *Order) { o.Status = OrderFulfilled },
+		"amount":    func(o *Order) { o.Amount = 1 },
+		"downloads": func(o *Order) { o.Downloads = -100 },
+		"seller":    func(o *Order) { o.SellerID = "u2" },
+	} {
+		changed := *o
+		change(&changed)
+		if s.key.Verify(o.Sig, orderParts(&changed)...) {
+			t.Errorf("changed %s still verifies", name)
+		}
+	}
+}
@@ -0,0 +1,379 @@
+// Package shop lets creators sell digital products and accept tips from their bio page: produk
+// dan blok tip dengan harga, order dengan state machine (pending → paid → fulfilled, refunded),
+// webhook payment provider, dan URL unduhan bertanda tangan HMAC dengan batas waktu dan jumlah
+// unduhan. Product files live in a private blob store that is never served directly.
+package shop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"biomu/backend/internal/blob"
+	"biomu/backend/internal/firebase"
+	"biomu/backend/internal/signing"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Product kinds.
+const (
+	KindProduct = "product"
+	KindTip     = "tip"
+)
+
+const (
+	MaxProductsPerAccount = 50
+	MaxFileBytes          = 50 << 20
+	// MaxAmount: batas atas harga/tip dalam satuan terkecil mata uang
+	MaxAmount           = 100_000_000
+	maxTitleLen         = 80
+	maxDescriptionLen   = 500
+	maxSuggestedAmounts = 5
+	maxDownloadsDefault = 5
+	maxDownloadsLimit   = 100
+)
+
+var (
+	ErrNotFound      = errors.New("product not found")
+	ErrInvalid       = errors.New("invalid product")
+	ErrLimit         = errors.New("product limit reached")
+	ErrFileRequired  = errors.New("product has no file")
+	currencyRe       = regexp.MustCompile(`^[a-z]{3}$`)
+	fileNameUnsafeRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// Files is the private storage of product files (blob.Local di luar direktori media, atau
+// Firebase Storage tanpa URL publik yang pernah dibagikan).
+type Files interface {
+	blob.Store
+	blob.Opener
+}
+
+// File is the downloadable file of a product.
+type File struct {
+	Key         string `json:"-" firestore:"key"`
+	Name        string `json:"name" firestore:"name"`
+	ContentType string `json:"contentType" firestore:"contentType"`
+	Size        int64  `json:"size" firestore:"size"`
+}
+
+// Product is a digital product or a tip jar shown on the owner's bio page.
+type Product struct {
+	ID          string `json:"id" firestore:"-"`
+	OwnerID     string `json:"ownerId" firestore:"ownerId"`
+	Kind        string `json:"kind" firestore:"kind"`
+	Title       string `json:"title" firestore:"title"`
+	Description string `json:"description" firestore:"description"`
+	// Price dalam satuan terkecil mata uang; untuk tip = nominal minimum
+	Price    int64  `json:"price" firestore:"price"`
+	Currency string `json:"currency" firestore:"currency"`
+	// SuggestedAmounts: pilihan nominal tip di halaman bio
+	SuggestedAmounts []int64 `json:"suggestedAmounts,omitempty" firestore:"suggestedAmounts,omitempty"`
+	MaxDownloads     int     `json:"maxDownloads,omitempty" firestore:"maxDownloads,omitempty"`
+	File             *File   `json:"file,omitempty" firestore:"file,omitempty"`
+	Active           bool    `json:"active" firestore:"active"`
+	Position         int     `json:"position" firestore:"position"`
+	// Sig: HMAC field harga/file, supaya dokumen yang diubah di luar Store tidak bisa dijual
+	Sig       string    `json:"-" firestore:"sig"`
+	CreatedAt time.Time `json:"createdAt" firestore:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" firestore:"updatedAt"`
+}
+
+// Amounts returns the amounts a buyer may pick on the bio page.
+func (p *Product) Amounts() []int64 {
+	if p.Kind != KindTip {
+		return []int64{p.Price}
+	}
+	if len(p.SuggestedAmounts) == 0 {
+		return []int64{p.Price}
+	}
+	return p.SuggestedAmounts
+}
+
+// Sellable reports whether the product can be bought right now.
+func (p *Product) Sellable() bool {
+	return p.Active && (p.Kind == KindTip || p.File != nil)
+}
+
+// Normalize validates p in place; defaultCurrency is used when Currency is empty.
+func (p *Product) Normalize(defaultCurrency string) error {
+	p.Title = strings.TrimSpace(p.Title)
+	p.Description = strings.TrimSpace(p.Description)
+	p.Currency = strings.ToLower(strings.TrimSpace(p.Currency))
+	if p.Currency == "" {
+		p.Currency = defaultCurrency
+	}
+	switch {
+	case p.Kind != KindProduct && p.Kind != KindTip:
+		return fmt.Errorf("%w: kind must be product or tip", ErrInvalid)
+	case p.Title == "" || utf8.RuneCountInString(p.Title) > maxTitleLen:
+		return fmt.Errorf("%w: %s", ErrInvalid, "title is required (max "+strconv.Itoa(maxTitleLen)+" characters)")
+	case utf8.RuneCountInString(p.Description) > maxDescriptionLen:
+		return fmt.Errorf("%w: description is too long", ErrInvalid)
+	case !currencyRe.MatchString(p.Currency):
+		return fmt.Errorf("%w: currency must be an ISO 4217 code", ErrInvalid)
+	case p.Price < 1 || p.Price > MaxAmount:
+		return fmt.Errorf("%w: %s", ErrInvalid, "price must be between 1 and "+strconv.Itoa(MaxAmount))
+	case len(p.SuggestedAmounts) > maxSuggestedAmounts:
+		return fmt.Errorf("%w: %s", ErrInvalid, "at most "+strconv.Itoa(maxSuggestedAmounts)+" suggested amounts")
+	}
+	if p.Kind == KindTip {
+		for _, a := range p.SuggestedAmounts {
+			if a < p.Price || a > MaxAmount {
+				return fmt.Errorf("%w: %s", ErrInvalid, "suggested amounts must be between price and "+strconv.Itoa(MaxAmount))
+			}
+		}
+		sort.Slice(p.SuggestedAmounts, func(i, j int) bool { return p.SuggestedAmounts[i] < p.SuggestedAmounts[j] })
+		p.MaxDownloads = 0
+	} else {
+		p.SuggestedAmounts = nil
+		if p.MaxDownloads == 0 {
+			p.MaxDownloads = maxDownloadsDefault
+		}
+		if p.MaxDownloads < 1 || p.MaxDownloads > maxDownloadsLimit {
+			return fmt.Errorf("%w: %s", ErrInvalid, "maxDownloads must be between 1 and "+strconv.Itoa(maxDownloadsLimit))
+		}
+	}
+	return nil
+}
+
+// Store keeps products and orders and signs the URLs handed to buyers.
+type Store struct {
+	fb           *firebase.App
+	products     string
+	orders       string
+	events       string
+	files        Files
+	key          *signing.Key
+	currency     string
+	downloadTTL  time.Duration
+	accessWindow time.Duration
+	now          func() time.Time
+}
+
+// NewStore creates a shop store. Keys are derived from secret (SESSION_SECRET); downloadTTL is
+// the lifetime of one signed download URL and accessWindow how long after payment a buyer may
+// still request new ones.
+func NewStore(fb *firebase.App, products, orders, events string, files Files, secret []byte, currency string, downloadTTL, accessWindow time.Duration) *Store {
+	return &Store{
+		fb:           fb,
+		products:     products,
+		orders:       orders,
+		events:       events,
+		files:        files,
+		key:          signing.NewKey(secret, "biomu shop v1"),
+		currency:     currency,
+		downloadTTL:  downloadTTL,
+		accessWindow: accessWindow,
+		now:          time.Now,
+	}
+}
+
+// DefaultCurrency is used for products created without a currency.
+func (s *Store) DefaultCurrency() string { return s.currency }
+
+// productSig covers every field that decides what a buyer pays and receives.
+func (s *Store) productSig(p *Product) string {
+	return s.key.Sign(productParts(p)...)
+}
+
+func (s *Store) trusted(p *Product) bool {
+	return s.key.Verify(p.Sig, productParts(p)...)
+}
+
+func productParts(p *Product) []string {
+	amounts := make([]string, 0, len(p.SuggestedAmounts))
+	for _, a := range p.SuggestedAmounts {
+		amounts = append(amounts, strconv.FormatInt(a, 10))
+	}
+	fileKey := ""
+	if p.File != nil {
+		fileKey = p.File.Key
+	}
+	return []string{"product", p.ID, p.OwnerID, p.Kind, strconv.FormatInt(p.Price, 10), p.Currency,
+		strings.Join(amounts, ","), strconv.Itoa(p.MaxDownloads), fileKey}
+}
+
+// List returns every product of ownerID (termasuk yang nonaktif), in page order.
+func (s *Store) List(ctx context.Context, ownerID string) ([]*Product, error) {
+	it := s.fb.DB.Collection(s.products).Where("ownerId", "==", ownerID).Limit(MaxProductsPerAccount * 2).Documents(ctx)
+	defer it.Stop()
+	out := []*Product{}
+	for {
+		doc, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		p, err := s.fromDoc(doc)
+		if err != nil || !s.trusted(p) {
+			continue
+		}
+		out = append(out, p)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Position != out[j].Position {
+			return out[i].Position < out[j].Position
+		}
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+	return out, nil
+}
+
+// ForSale returns the products of ownerID shown on the bio page.
+func (s *Store) ForSale(ctx context.Context, ownerID string) ([]*Product, error) {
+	all, err := s.List(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	out := all[:0]
+	for _, p := range all {
+		if p.Sellable() {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// Get returns a trusted product, or ErrNotFound.
+func (s *Store) Get(ctx context.Context, id string) (*Product, error) {
+	if id == "" {
+		return nil, ErrNotFound
+	}
+	doc, err := s.fb.DB.Collection(s.products).Doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.fromDoc(doc)
+	if err != nil {
+		return nil, err
+	}
+	if !s.trusted(p) {
+		return nil, ErrNotFound
+	}
+	return p, nil
+}
+
+// Owned returns product id if it belongs to ownerID, or ErrNotFound.
+func (s *Store) Owned(ctx context.Context, ownerID, id string) (*Product, error) {
+	p, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if p.OwnerID != ownerID {
+		return nil, ErrNotFound
+	}
+	return p, nil
+}
+
+// Create stores a new, already normalized product for ownerID.
+func (s *Store) Create(ctx context.Context, ownerID string, p *Product) error {
+	existing, err := s.List(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	if len(existing) >= MaxProductsPerAccount {
+		return ErrLimit
+	}
+	ref := s.fb.DB.Collection(s.products).NewDoc()
+	now := s.now().UTC()
+	// Produk baru belum punya file; baru tampil di halaman bio setelah file diunggah
+	p.ID, p.OwnerID, p.File, p.CreatedAt, p.UpdatedAt = ref.ID, ownerID, nil, now, now
+	p.Sig = s.productSig(p)
+	_, err = ref.Create(ctx, p)
+	return err
+}
+
+// Save writes p after an update by its owner.
+func (s *Store) Save(ctx context.Context, p *Product) error {
+	p.UpdatedAt = s.now().UTC()
+	p.Sig = s.productSig(p)
+	_, err := s.fb.DB.Collection(s.products).Doc(p.ID).Set(ctx, p)
+	return err
+}
+
+// Delete removes a product. Its files are kept: pembeli sebelumnya masih bisa mengunduh.
+func (s *Store) Delete(ctx context.Context, ownerID, id string) error {
+	if _, err := s.Owned(ctx, ownerID, id); err != nil {
+		return err
+	}
+	_, err := s.fb.DB.Collection(s.products).Doc(id).Delete(ctx)
+	return err
+}
+
+// PutFile stores data as the product's file. Every upload gets a new key so orders that were
+// already paid keep downloading the file they bought.
+func (s *Store) PutFile(ctx context.Context, p *Product, name, contentType string, data []byte) error {
+	if p.Kind != KindProduct {
+		return fmt.Errorf("%w: only products have files", ErrInvalid)
+	}
+	name = SafeFileName(name)
+	key := path.Join("products", p.OwnerID, p.ID, randomHex(8), name)
+	if _, err := s.files.Put(ctx, key, contentType, data); err != nil {
+		return err
+	}
+	p.File = &File{Key: key, Name: name, ContentType: contentType, Size: int64(len(data))}
+	return s.Save(ctx, p)
+}
+
+// SafeFileName reduces name to a short ASCII file name usable as a blob key and in
+// Content-Disposition.
+func SafeFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Trim(fileNameUnsafeRe.ReplaceAllString(name, "-"), "-.")
+	if len(name) > 100 {
+		ext := path.Ext(name)
+		if len(ext) > 10 {
+			ext = ""
+		}
+		name = name[:100-len(ext)] + ext
+	}
+	if name == "" {
+		name = "download"
+	}
+	return name
+}
+
+func (s *Store) fromDoc(doc *firestore.DocumentSnapshot) (*Product, error) {
+	var p Product
+	if err := doc.DataTo(&p); err != nil {
+		return nil, err
+	}
+	p.ID = doc.Ref.ID
+	return &p, nil
+}
+
+// FormatAmount renders a minor-unit amount for display, mis. "IDR 50000" atau "USD 4.99".
+func FormatAmount(amount int64, currency string) string {
+	code := strings.ToUpper(currency)
+	if zeroDecimal[currency] {
+		return code + " " + strconv.FormatInt(amount, 10)
+	}
+	major, minor := amount/100, amount%100
+	if minor == 0 {
+		return code + " " + strconv.FormatInt(major, 10)
+	}
+	return code + " " + strconv.FormatInt(major, 10) + "." + strconv.FormatInt(minor/10, 10) + strconv.FormatInt(minor%10, 10)
+}
+
+// zeroDecimal lists currencies without minor units (amount = nominal apa adanya).
+var zeroDecimal = map[string]bool{
+	"bif": true, "clp": true, "djf": true, "gnf": true, "jpy": true, "kmf": true, "krw": true, "mga": true,
+	"pyg": true, "rwf": true, "ugx": true, "vnd": true, "vuv": true, "xaf": true, "xof": true, "xpf": true,
+}
@@ -0,0 +1,53 @@
+package shop
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Payment event types (dipetakan dari event masing-masing provider).
+const (
+	EventPaid     = "paid"
+	EventRefunded = "refunded"
+	EventCanceled = "canceled"
+)
+
+// Provider is a payment provider for one-off payments. Implementations: Stripe and Fake
+// (lokal/test). Webhook signatures use the same scheme as billing (billing.VerifySignature).
+type Provider interface {
+	Name() string
+	// CreatePayment starts a hosted checkout for one order.
+	CreatePayment(ctx context.Context, req PaymentRequest) (*Payment, error)
+	// ParseWebhook verifies the signature of a webhook request and converts it to a
+	// PaymentEvent. Event types the shop does not need return (nil, nil).
+	ParseWebhook(header http.Header, body []byte, now time.Time) (*PaymentEvent, error)
+}
+
+type PaymentRequest struct {
+	OrderID string
+	Title   string
+	// Amount dalam satuan terkecil mata uang (mis. sen)
+	Amount     int64
+	Currency   string
+	Email      string
+	SuccessURL string
+	CancelURL  string
+}
+
+type Payment struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// PaymentEvent is a payment state change delivered by a webhook. OrderID may be empty when the
+// provider only knows its own payment ID (mis. refund dari dashboard Stripe).
+type PaymentEvent struct {
+	ID        string
+	CreatedAt time.Time
+	Type      string
+	OrderID   string
+	PaymentID string
+	Amount    int64
+	Currency  string
+}
@@ -0,0 +1,153 @@
+package shop
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"biomu/backend/internal/billing"
+)
+
+const stripeAPIDefault = "https://api.stripe.com"
+
+// Stripe creates one-off payment Checkout Sessions (harga inline per order) and reads the
+// checkout.session.* and charge.refunded webhooks. The order ID travels as client_reference_id
+// and metadata "order_id" on both the session and its payment intent.
+type Stripe struct {
+	SecretKey     string
+	WebhookSecret string
+	// APIBase defaults to https://api.stripe.com (bisa diarahkan ke stripe-mock untuk test)
+	APIBase string
+	Client  *http.Client
+}
+
+func NewStripe(secretKey, webhookSecret string) (*Stripe, error) {
+	if secretKey == "" || webhookSecret == "" {
+		return nil, fmt.Errorf("stripe: secret key and webhook secret are required")
+	}
+	return &Stripe{
+		SecretKey:     secretKey,
+		WebhookSecret: webhookSecret,
+		APIBase:       stripeAPIDefault,
+		Client:        &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+func (s *Stripe) Name() string { return "stripe" }
+
+func (s *Stripe) CreatePayment(ctx context.Context, req PaymentRequest) (*Payment, error) {
+	form := url.Values{
+		"mode":                                          {"payment"},
+		"line_items[0][price_data][currency]":           {req.Currency},
+		"line_items[0][price_data][unit_amount]":        {strconv.FormatInt(req.Amount, 10)},
+		"line_items[0][price_data][product_data][name]": {req.Title},
+		"line_items[0][quantity]":                       {"1"},
+		"success_url":                                   {req.SuccessURL},
+		"cancel_url":                                    {req.CancelURL},
+		"client_reference_id":                           {req.OrderID},
+		"metadata[order_id]":                            {req.OrderID},
+		"payment_intent_data[metadata][order_id]":       {req.OrderID},
+	}
+	if req.Email != "" {
+		form.Set("customer_email", req.Email)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(s.APIBase, "/")+"/v1/checkout/sessions", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+s.SecretKey)
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// Satu order = satu session, walau request diulang
+	httpReq.Header.Set("Idempotency-Key", "order-"+req.OrderID)
+	resp, err := s.Client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(body, &e)
+		return nil, fmt.Errorf("stripe: payment: %s: %s", resp.Status, e.Error.Message)
+	}
+	var out Payment
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("stripe: payment: %w", err)
+	}
+	return &out, nil
+}
+
+type stripePaymentEvent struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		Object struct {
+			ID                string            `json:"id"`
+			ClientReferenceID string            `json:"client_reference_id"`
+			Metadata          map[string]string `json:"metadata"`
+			PaymentIntent     string            `json:"payment_intent"`
+			PaymentStatus     string            `json:"payment_status"`
+			AmountTotal       int64             `json:"amount_total"`
+			Amount            int64             `json:"amount"`
+			Currency          string            `json:"currency"`
+			Refunded          bool              `json:"refunded"`
+		} `json:"object"`
+	} `json:"data"`
+}
+
+func (s *Stripe) ParseWebhook(header http.Header, body []byte, now time.Time) (*PaymentEvent, error) {
+	if err := billing.VerifySignature([]byte(s.WebhookSecret), header.Get("Stripe-Signature"), body, now); err != nil {
+		return nil, err
+	}
+	var ev stripePaymentEvent
+	if err := json.Unmarshal(body, &ev); err != nil || ev.ID == "" {
+		return nil, billing.ErrBadPayload
+	}
+	obj := ev.Data.Object
+	out := &PaymentEvent{
+		ID:        ev.ID,
+		CreatedAt: time.Unix(ev.Created, 0).UTC(),
+		OrderID:   obj.Metadata["order_id"],
+		PaymentID: obj.PaymentIntent,
+		Currency:  obj.Currency,
+	}
+	switch ev.Type {
+	case "checkout.session.completed", "checkout.session.async_payment_succeeded":
+		// Metode pembayaran async (mis. transfer bank) selesai lewat async_payment_succeeded
+		if obj.PaymentStatus != "paid" {
+			return nil, nil
+		}
+		out.Type, out.Amount = EventPaid, obj.AmountTotal
+	case "checkout.session.expired", "checkout.session.async_payment_failed":
+		out.Type = EventCanceled
+	case "charge.refunded":
+		// Refund sebagian tidak mengubah status order
+		if !obj.Refunded {
+			return nil, nil
+		}
+		out.Type, out.Amount = EventRefunded, obj.Amount
+	default:
+		return nil, nil
+	}
+	if out.OrderID == "" && strings.HasPrefix(ev.Type, "checkout.session.") {
+		out.OrderID = obj.ClientReferenceID
+	}
+	if out.OrderID == "" && out.PaymentID == "" {
+		return nil, billing.ErrBadPayload
+	}
+	return out, nil
+}
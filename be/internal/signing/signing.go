@@ -0,0 +1,53 @@
+// Package signing membuat dan memeriksa HMAC untuk token yang dibagikan ke luar (token unlock,
+// link unsubscribe, URL download, token TXT domain). Setiap fitur memakai key turunan
+// SESSION_SECRET dengan purpose sendiri, jadi token satu fitur tidak pernah valid di fitur lain.
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// tokenLen is the length of the short hex tokens put in URLs and DNS records.
+const tokenLen = 32
+
+// Key is an HMAC-SHA256 key derived for one purpose.
+type Key struct {
+	key []byte
+}
+
+// NewKey derives the key of purpose (e.g. "biomu shop v1") from secret.
+func NewKey(secret []byte, purpose string) *Key {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	return &Key{key: mac.Sum(nil)}
+}
+
+// Sum returns the raw MAC of parts joined with NUL bytes.
+func (k *Key) Sum(parts ...string) []byte {
+	mac := hmac.New(sha256.New, k.key)
+	mac.Write([]byte(strings.Join(parts, "\x00")))
+	return mac.Sum(nil)
+}
+
+// Sign returns the hex MAC of parts.
+func (k *Key) Sign(parts ...string) string {
+	return hex.EncodeToString(k.Sum(parts...))
+}
+
+// Token returns the first 32 hex characters (128 bit) of Sign.
+func (k *Key) Token(parts ...string) string {
+	return k.Sign(parts...)[:tokenLen]
+}
+
+// Verify reports in constant time whether sig is Sign(parts...).
+func (k *Key) Verify(sig string, parts ...string) bool {
+	return hmac.Equal([]byte(sig), []byte(k.Sign(parts...)))
+}
+
+// VerifyToken reports in constant time whether token is Token(parts...).
+func (k *Key) VerifyToken(token string, parts ...string) bool {
+	return hmac.Equal([]byte(token), []byte(k.Token(parts...)))
+}
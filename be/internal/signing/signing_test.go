@@ -0,0 +1,81 @@
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+// legacy is how every feature signed before this package existed; tokens already handed out
+// (DNS TXT records, unsubscribe links) must keep verifying.
+func legacy(secret, purpose string, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	inner := hmac.New(sha256.New, mac.Sum(nil))
+	inner.Write([]byte(payload))
+	return hex.EncodeToString(inner.Sum(nil))
+}
+
+func TestKeyMatchesLegacyDerivation(t *testing.T) {
+	tests := []struct {
+		purpose string
+		parts   []string
+		payload string
+	}{
+		{"biomu custom domain v1", []string{"txt", "links.example.com", "u1"}, "txt\x00links.example.com\x00u1"},
+		{"biomu newsletter v1", []string{"unsubscribe", "abc"}, "unsubscribe\x00abc"},
+		{"biomu shop v1", []string{"download", "o1", "1700000000"}, "download\x00o1\x001700000000"},
+		{"biomu link unlock v1", []string{"l1.1700000000", "password", ""}, "l1.1700000000\x00password\x00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.purpose, func(t *testing.T) {
+			k := NewKey([]byte("secret"), tt.purpose)
+			want := legacy("secret", tt.purpose, tt.payload)
+			if got := k.Sign(tt.parts...); got != want {
+				t.Fatalf("Sign = %s, want %s", got, want)
+			}
+			if got := hex.EncodeToString(k.Sum(tt.parts...)); got != want {
+				t.Fatalf("Sum = %s, want %s", got, want)
+			}
+			if got := k.Token(tt.parts...); got != want[:32] {
+				t.Fatalf("Token = %s, want %s", got, want[:32])
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	k := NewKey([]byte("secret"), "biomu test v1")
+	other := NewKey([]byte("secret"), "biomu other v1")
+	sig, token := k.Sign("a", "b"), k.Token("a", "b")
+
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"signature", k.Verify(sig, "a", "b")},
+		{"token", k.VerifyToken(token, "a", "b")},
+	}
+	for _, tt := range tests {
+		if !tt.ok {
+			t.Errorf("%s rejected", tt.name)
+		}
+	}
+
+	rejected := []struct {
+		name string
+		ok   bool
+	}{
+		{"other parts", k.Verify(sig, "a", "c")},
+		{"other purpose", other.Verify(sig, "a", "b")},
+		{"token as signature", k.Verify(token, "a", "b")},
+		{"signature as token", k.VerifyToken(sig, "a", "b")},
+		{"empty", k.VerifyToken("", "a", "b")},
+	}
+	for _, tt := range rejected {
+		if tt.ok {
+			t.Errorf("%s accepted", tt.name)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package targeting
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"biomu/backend/internal/enrich"
+	"biomu/backend/internal/profile"
+)
+
+const previewMaxBytes = 64 << 10
+
+// Sessions resolves the signed-in caller (implemented by auth.Handler).
+type Sessions interface {
+	SessionUID(r *http.Request) string
+}
+
+type Handler struct {
+	profiles *profile.Store
+	sessions Sessions
+	enricher *enrich.Enricher
+}
+
+func NewHandler(profiles *profile.Store, sessions Sessions, enricher *enrich.Enricher) *Handler {
+	return &Handler{profiles: profiles, sessions: sessions, enricher: enricher}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+type previewRequest struct {
+	UserAgent      string `json:"userAgent"`
+	IP             string `json:"ip"`
+	AcceptLanguage string `json:"acceptLanguage"`
+	// Override hasil GeoIP/UA, mis. untuk mencoba negara tanpa punya IP-nya
+	Country string `json:"country"`
+	OS      string `json:"os"`
+	Device  string `json:"device"`
+	At      string `json:"at"` // RFC 3339, default sekarang
+	// Aturan yang belum disimpan; jika kosong dipakai aturan di dokumen link
+	Targets     []any   `json:"targets"`
+	FallbackURL *string `json:"fallbackUrl"`
+}
+
+type previewResponse struct {
+	Visitor Visitor `json:"visitor"`
+	Result
+}
+
+// POST /api/links/{linkId}/targeting/preview — pemilik/admin mencoba aturan untuk UA/IP tertentu
+func (h *Handler) Preview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	linkID := r.PathValue("linkId")
+	link, err := h.profiles.FindLink(ctx, linkID)
+	if err != nil {
+		log.Printf("targeting preview %s: %v", linkID, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load link"})
+		return
+	}
+	if link == nil {
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	if link.ProfileID != uid {
+		admin, err := h.profiles.IsAdmin(ctx, uid)
+		if err != nil {
+			log.Printf("targeting preview admin check %s: %v", uid, err)
+			h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load account"})
+			return
+		}
+		if !admin {
+			h.writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+			return
+		}
+	}
+
+	var body previewRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, previewMaxBytes)).Decode(&body); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+	at := time.Now()
+	if body.At != "" {
+		if at, err = time.Parse(time.RFC3339, body.At); err != nil {
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "at must be RFC 3339"})
+			return
+		}
+	}
+
+	info := h.enricher.Describe(strings.TrimSpace(body.IP), body.UserAgent)
+	if body.Country != "" {
+		info.Country = strings.ToUpper(strings.TrimSpace(body.Country))
+	}
+	if body.OS != "" {
+		info.OS = body.OS
+	}
+	if body.Device != "" {
+		info.Device = body.Device
+	}
+	v := Visitor{
+		Country:   info.Country,
+		OS:        info.OS,
+		Device:    info.Device,
+		Languages: ParseAcceptLanguage(body.AcceptLanguage),
+		Time:      at,
+	}
+
+	var result Result
+	if body.Targets != nil || body.FallbackURL != nil {
+		rules, err := ParseRules(body.Targets)
+		if err == nil {
+			err = Validate(rules)
+		}
+		if err != nil {
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if body.Targets == nil {
+			rules, _ = ParseRules(link.Data[FieldTargets])
+		}
+		fallback, _ := link.Data[FieldFallbackURL].(string)
+		if body.FallbackURL != nil {
+			fallback = *body.FallbackURL
+		}
+		tz, _ := link.Data[profile.FieldTimezone].(string)
+		result = Evaluate(rules, strings.TrimSpace(fallback), link.URL, tz, v)
+	} else {
+		result = Resolve(*link, v)
+	}
+	h.writeJSON(w, http.StatusOK, previewResponse{Visitor: v, Result: result})
+}
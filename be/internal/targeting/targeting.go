@@ -0,0 +1,366 @@
+// Package targeting memilih URL tujuan link berdasarkan aturan berurutan (negara, OS,
+// kelas device, bahasa browser, jendela waktu). Aturan pertama yang cocok menang; jika
+// tidak ada yang cocok dipakai fallbackUrl, lalu url biasa milik link.
+package targeting
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"biomu/backend/internal/enrich"
+	"biomu/backend/internal/profile"
+)
+
+// Field di dokumen link.
+const (
+	FieldTargets     = "targets"
+	FieldFallbackURL = "fallbackUrl"
+)
+
+const (
+	maxRules    = 20
+	maxValues   = 50
+	maxLanguage = 5
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Rule is one targeting rule. Empty criteria match everything; values within one
+// criterion are OR-ed and criteria are AND-ed.
+type Rule struct {
+	Name      string   `json:"name,omitempty"`
+	URL       string   `json:"url"`
+	Countries []string `json:"countries,omitempty"` // ISO 3166-1 alpha-2, mis. "ID"
+	OS        []string `json:"os,omitempty"`        // "iOS" juga mencakup iPadOS
+	Devices   []string `json:"devices,omitempty"`   // mobile, tablet, desktop, tv, console
+	Languages []string `json:"languages,omitempty"` // "id" cocok dengan "id-ID"
+	Days      []string `json:"days,omitempty"`      // mon..sun, waktu lokal Timezone
+	From      string   `json:"from,omitempty"`      // "HH:MM" lokal, inklusif
+	Until     string   `json:"until,omitempty"`     // "HH:MM" lokal, eksklusif; boleh lewat tengah malam
+	Timezone  string   `json:"timezone,omitempty"`  // nama IANA, default timezone link lalu UTC
+}
+
+// Visitor is what rules are evaluated against.
+type Visitor struct {
+	Country   string    `json:"country"`
+	OS        string    `json:"os"`
+	Device    string    `json:"device"`
+	Languages []string  `json:"languages"`
+	Time      time.Time `json:"time"`
+}
+
+// Result is the outcome of Resolve. Rule is -1 when no rule matched.
+type Result struct {
+	Rule     int    `json:"rule"`
+	RuleName string `json:"ruleName,omitempty"`
+	URL      string `json:"url"`
+	Fallback bool   `json:"fallback"`
+}
+
+// VisitorFromRequest builds the visitor from enrichment data and Accept-Language.
+func VisitorFromRequest(r *http.Request, info enrich.Info, now time.Time) Visitor {
+	return Visitor{
+		Country:   info.Country,
+		OS:        info.OS,
+		Device:    info.Device,
+		Languages: ParseAcceptLanguage(r.Header.Get("Accept-Language")),
+		Time:      now,
+	}
+}
+
+// ParseAcceptLanguage returns language tags ordered by q-value (lowercase, max 5, q=0 dropped).
+func ParseAcceptLanguage(header string) []string {
+	type tag struct {
+		lang string
+		q    float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		lang := strings.ToLower(strings.TrimSpace(fields[0]))
+		if lang == "" || lang == "*" {
+			continue
+		}
+		q := 1.0
+		for _, f := range fields[1:] {
+			f = strings.TrimSpace(f)
+			if strings.HasPrefix(f, "q=") {
+				if v, err := strconv.ParseFloat(f[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, tag{lang, q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		if len(out) == maxLanguage {
+			break
+		}
+		out = append(out, t.lang)
+	}
+	return out
+}
+
+// Resolve picks the destination for link. Rules without a timezone use the link's timezone.
+func Resolve(link profile.Link, v Visitor) Result {
+	rules, _ := ParseRules(link.Data[FieldTargets])
+	defaultTZ, _ := link.Data[profile.FieldTimezone].(string)
+	fallback, _ := link.Data[FieldFallbackURL].(string)
+	return Evaluate(rules, strings.TrimSpace(fallback), link.URL, defaultTZ, v)
+}
+
+// Targeted reports whether link has targeting rules or a fallbackUrl, i.e. whether it must be
+// opened through /go/{id} instead of /r/{id}.
+func Targeted(link profile.Link) bool {
+	if rules, err := ParseRules(link.Data[FieldTargets]); err == nil && len(rules) > 0 {
+		return true
+	}
+	fallback, _ := link.Data[FieldFallbackURL].(string)
+	return strings.TrimSpace(fallback) != ""
+}
+
+// Path returns the click-tracking path of link: /go/{id} for targeted links, else /r/{id}.
+func Path(link profile.Link) string {
+	if Targeted(link) {
+		return "/go/" + url.PathEscape(link.ID)
+	}
+	return "/r/" + url.PathEscape(link.ID)
+}
+
+// Evaluate returns the first matching rule, else fallbackURL, else linkURL.
+func Evaluate(rules []Rule, fallbackURL, linkURL, defaultTZ string, v Visitor) Result {
+	for i, rule := range rules {
+		if rule.Matches(v, defaultTZ) {
+			return Result{Rule: i, RuleName: rule.Name, URL: rule.URL}
+		}
+	}
+	if fallbackURL != "" {
+		return Result{Rule: -1, URL: fallbackURL, Fallback: true}
+	}
+	return Result{Rule: -1, URL: linkURL, Fallback: true}
+}
+
+// Matches reports whether every criterion of the rule accepts v.
+func (rule Rule) Matches(v Visitor, defaultTZ string) bool {
+	if len(rule.Countries) > 0 && !containsFold(rule.Countries, v.Country) {
+		return false
+	}
+	if len(rule.OS) > 0 && !matchOS(rule.OS, v.OS) {
+		return false
+	}
+	if len(rule.Devices) > 0 && !containsFold(rule.Devices, v.Device) {
+		return false
+	}
+	if len(rule.Languages) > 0 && !matchLanguage(rule.Languages, v.Languages) {
+		return false
+	}
+	if len(rule.Days) > 0 || rule.From != "" || rule.Until != "" {
+		tz := rule.Timezone
+		if tz == "" {
+			tz = defaultTZ
+		}
+		loc, err := profile.LoadTimezone(tz)
+		if err != nil {
+			return false
+		}
+		if !rule.inWindow(v.Time.In(loc)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (rule Rule) inWindow(local time.Time) bool {
+	if len(rule.Days) > 0 {
+		ok := false
+		for _, d := range rule.Days {
+			if wd, known := weekdays[strings.ToLower(d)]; known && wd == local.Weekday() {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	minute := local.Hour()*60 + local.Minute()
+	from, okFrom := clockMinutes(rule.From)
+	until, okUntil := clockMinutes(rule.Until)
+	switch {
+	case okFrom && okUntil && from > until:
+		// Jendela melewati tengah malam, mis. 22:00–06:00
+		return minute >= from || minute < until
+	case okFrom && okUntil:
+		return minute >= from && minute < until
+	case okFrom:
+		return minute >= from
+	case okUntil:
+		return minute < until
+	}
+	return true
+}
+
+func clockMinutes(s string) (int, bool) {
+	if s == "" {
+		return 0, false
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+func containsFold(values []string, v string) bool {
+	if v == "" {
+		return false
+	}
+	for _, x := range values {
+		if strings.EqualFold(strings.TrimSpace(x), v) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchOS(values []string, os string) bool {
+	if containsFold(values, os) {
+		return true
+	}
+	// App Store link untuk "iOS" juga berlaku di iPad
+	return strings.EqualFold(os, "iPadOS") && containsFold(values, "iOS")
+}
+
+func matchLanguage(values, accepted []string) bool {
+	for _, lang := range accepted {
+		primary, _, _ := strings.Cut(lang, "-")
+		for _, want := range values {
+			want = strings.ToLower(strings.TrimSpace(want))
+			if want == lang || want == primary {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParseRules reads the targets field of a link document ([]any of maps from Firestore,
+// or []any from decoded JSON).
+func ParseRules(v any) ([]Rule, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s must be an array", FieldTargets)
+	}
+	rules := make([]Rule, 0, len(raw))
+	for i, item := range raw {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s[%d] must be an object", FieldTargets, i)
+		}
+		str := func(k string) string {
+			s, _ := m[k].(string)
+			return strings.TrimSpace(s)
+		}
+		rules = append(rules, Rule{
+			Name:      str("name"),
+			URL:       str("url"),
+			Countries: stringList(m["countries"]),
+			OS:        stringList(m["os"]),
+			Devices:   stringList(m["devices"]),
+			Languages: stringList(m["languages"]),
+			Days:      stringList(m["days"]),
+			From:      str("from"),
+			Until:     str("until"),
+			Timezone:  str("timezone"),
+		})
+	}
+	return rules, nil
+}
+
+func stringList(v any) []string {
+	raw, _ := v.([]any)
+	out := make([]string, 0, len(raw))
+	for _, x := range raw {
+		if s, ok := x.(string); ok && strings.TrimSpace(s) != "" {
+			out = append(out, strings.TrimSpace(s))
+		}
+	}
+	return out
+}
+
+// Validate checks rules before they are stored.
+func Validate(rules []Rule) error {
+	if len(rules) > maxRules {
+		return fmt.Errorf("too many targeting rules (max %d)", maxRules)
+	}
+	for i, rule := range rules {
+		if !validURL(rule.URL) {
+			return fmt.Errorf("%s[%d].url must be an absolute http(s) URL", FieldTargets, i)
+		}
+		for _, list := range [][]string{rule.Countries, rule.OS, rule.Devices, rule.Languages, rule.Days} {
+			if len(list) > maxValues {
+				return fmt.Errorf("%s[%d]: too many values (max %d)", FieldTargets, i, maxValues)
+			}
+		}
+		for _, c := range rule.Countries {
+			if len(c) != 2 {
+				return fmt.Errorf("%s[%d]: country %q must be an ISO alpha-2 code", FieldTargets, i, c)
+			}
+		}
+		for _, d := range rule.Days {
+			if _, ok := weekdays[strings.ToLower(d)]; !ok {
+				return fmt.Errorf("%s[%d]: unknown day %q", FieldTargets, i, d)
+			}
+		}
+		for _, clock := range []string{rule.From, rule.Until} {
+			if _, err := time.Parse("15:04", clock); clock != "" && err != nil {
+				return fmt.Errorf("%s[%d]: time %q must be HH:MM", FieldTargets, i, clock)
+			}
+		}
+		if _, err := profile.LoadTimezone(rule.Timezone); err != nil {
+			return fmt.Errorf("%s[%d]: %v", FieldTargets, i, err)
+		}
+	}
+	return nil
+}
+
+// ValidateDoc validates the targeting fields present in a link create/update payload.
+func ValidateDoc(payload map[string]any) error {
+	if v, ok := payload[FieldTargets]; ok {
+		rules, err := ParseRules(v)
+		if err != nil {
+			return err
+		}
+		if err := Validate(rules); err != nil {
+			return err
+		}
+	}
+	if v, ok := payload[FieldFallbackURL]; ok && v != nil {
+		s, _ := v.(string)
+		if s != "" && !validURL(s) {
+			return fmt.Errorf("%s must be an absolute http(s) URL", FieldFallbackURL)
+		}
+	}
+	return nil
+}
+
+func validURL(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.User == nil
+}
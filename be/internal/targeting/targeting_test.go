@@ -0,0 +1,177 @@
+package targeting
+
+import (
+	"testing"
+	"time"
+
+	"biomu/backend/internal/profile"
+)
+
+func TestPath(t *testing.T) {
+	rules := []any{map[string]any{"url": "https://example.com/id", "countries": []any{"ID"}}}
+	tests := []struct {
+		name string
+		data map[string]any
+		want string
+	}{
+		{"plain", nil, "/r/l%2F1"},
+		{"targets", map[string]any{FieldTargets: rules}, "/go/l%2F1"},
+		{"fallback only", map[string]any{FieldFallbackURL: "https://example.com"}, "/go/l%2F1"},
+		{"empty targets", map[string]any{FieldTargets: []any{}, FieldFallbackURL: " "}, "/r/l%2F1"},
+		{"malformed targets", map[string]any{FieldTargets: "nope"}, "/r/l%2F1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Path(profile.Link{ID: "l/1", Data: tt.data}); got != tt.want {
+				t.Fatalf("Path = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	// Senin 2 Maret 2026, 12:00 WIB
+	noon := time.Date(2026, 3, 2, 5, 0, 0, 0, time.UTC)
+	visitor := Visitor{Country: "ID", OS: "iPadOS", Device: "tablet", Languages: []string{"id-id", "en"}, Time: noon}
+	tests := []struct {
+		name string
+		rule Rule
+		v    Visitor
+		want bool
+	}{
+		{"no criteria", Rule{}, visitor, true},
+		{"country", Rule{Countries: []string{"MY", "id"}}, visitor, true},
+		{"other country", Rule{Countries: []string{"MY"}}, visitor, false},
+		{"unknown country", Rule{Countries: []string{"ID"}}, Visitor{Time: noon}, false},
+		{"os", Rule{OS: []string{"Android", "ipados"}}, visitor, true},
+		// App Store link untuk iOS juga berlaku di iPad, tapi tidak sebaliknya
+		{"ios covers ipados", Rule{OS: []string{"iOS"}}, visitor, true},
+		{"ipados does not cover ios", Rule{OS: []string{"iPadOS"}}, Visitor{OS: "iOS", Time: noon}, false},
+		{"other os", Rule{OS: []string{"Windows"}}, visitor, false},
+		{"device", Rule{Devices: []string{"mobile", "Tablet"}}, visitor, true},
+		{"other device", Rule{Devices: []string{"desktop"}}, visitor, false},
+		{"language primary", Rule{Languages: []string{"id"}}, visitor, true},
+		{"language exact", Rule{Languages: []string{"ID-id"}}, visitor, true},
+		{"second language", Rule{Languages: []string{"en"}}, visitor, true},
+		{"regional rule, primary visitor", Rule{Languages: []string{"en-us"}}, visitor, false},
+		{"other language", Rule{Languages: []string{"ja"}}, visitor, false},
+		{"all criteria", Rule{Countries: []string{"ID"}, OS: []string{"iOS"}, Devices: []string{"tablet"}, Languages: []string{"id"}}, visitor, true},
+		{"one criterion fails", Rule{Countries: []string{"ID"}, Devices: []string{"mobile"}}, visitor, false},
+		{"weekday", Rule{Days: []string{"Mon", "tue"}, Timezone: "Asia/Jakarta"}, visitor, true},
+		{"weekend", Rule{Days: []string{"sat", "sun"}, Timezone: "Asia/Jakarta"}, visitor, false},
+		{"business hours", Rule{From: "09:00", Until: "17:00", Timezone: "Asia/Jakarta"}, visitor, true},
+		// 12:00 WIB = 05:00 UTC
+		{"business hours in UTC", Rule{From: "09:00", Until: "17:00"}, visitor, false},
+		{"from only", Rule{From: "12:00", Timezone: "Asia/Jakarta"}, visitor, true},
+		{"until only, exclusive", Rule{Until: "12:00", Timezone: "Asia/Jakarta"}, visitor, false},
+		{"unknown timezone", Rule{From: "00:00", Timezone: "Mars/Olympus"}, visitor, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Matches(tt.v, ""); got != tt.want {
+				t.Fatalf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Jendela 22:00–06:00 melewati tengah malam dan dihitung di timezone pemilik.
+func TestEvaluateOvernight(t *testing.T) {
+	night := Rule{URL: "https://example.com/night", From: "22:00", Until: "06:00"}
+	mondayNight := Rule{URL: "https://example.com/mon", Days: []string{"mon"}, From: "22:00", Until: "06:00"}
+	wib := time.FixedZone("WIB", 7*3600)
+	tests := []struct {
+		name  string
+		rule  Rule
+		local time.Time
+		want  bool
+	}{
+		{"before start", night, time.Date(2026, 3, 2, 21, 59, 0, 0, wib), false},
+		{"start inclusive", night, time.Date(2026, 3, 2, 22, 0, 0, 0, wib), true},
+		{"before midnight", night, time.Date(2026, 3, 2, 23, 59, 0, 0, wib), true},
+		{"midnight", night, time.Date(2026, 3, 3, 0, 0, 0, 0, wib), true},
+		{"after midnight", night, time.Date(2026, 3, 3, 5, 59, 0, 0, wib), true},
+		{"end exclusive", night, time.Date(2026, 3, 3, 6, 0, 0, 0, wib), false},
+		{"midday", night, time.Date(2026, 3, 3, 12, 0, 0, 0, wib), false},
+		// Hari dicek pada tanggal lokal saat klik: Selasa 01:00 bukan lagi "mon"
+		{"day before midnight", mondayNight, time.Date(2026, 3, 2, 23, 0, 0, 0, wib), true},
+		{"day after midnight", mondayNight, time.Date(2026, 3, 3, 1, 0, 0, 0, wib), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Waktu klik dalam UTC; rule tanpa timezone memakai timezone link
+			v := Visitor{Time: tt.local.UTC()}
+			got := Evaluate([]Rule{tt.rule}, "", "https://example.com", "Asia/Jakarta", v)
+			if (got.Rule == 0) != tt.want {
+				t.Fatalf("Evaluate = %+v, want match %v", got, tt.want)
+			}
+		})
+	}
+	// Timezone di rule menang atas timezone link: 23:00 WIB = 17:00 UTC
+	v := Visitor{Time: time.Date(2026, 3, 2, 23, 0, 0, 0, wib).UTC()}
+	night.Timezone = "UTC"
+	if got := Evaluate([]Rule{night}, "", "https://example.com", "Asia/Jakarta", v); got.Rule != -1 {
+		t.Fatalf("rule timezone ignored: %+v", got)
+	}
+}
+
+func TestEvaluateOrder(t *testing.T) {
+	v := Visitor{Country: "ID", Device: "mobile", Time: time.Date(2026, 3, 2, 5, 0, 0, 0, time.UTC)}
+	rules := []Rule{
+		{Name: "us", URL: "https://example.com/us", Countries: []string{"US"}},
+		{Name: "id mobile", URL: "https://example.com/id-mobile", Countries: []string{"ID"}, Devices: []string{"mobile"}},
+		{Name: "id", URL: "https://example.com/id", Countries: []string{"ID"}},
+	}
+	tests := []struct {
+		name     string
+		rules    []Rule
+		fallback string
+		v        Visitor
+		want     Result
+	}{
+		{"first match wins", rules, "", v, Result{Rule: 1, RuleName: "id mobile", URL: "https://example.com/id-mobile"}},
+		{"later rule", rules, "", Visitor{Country: "ID", Device: "desktop"}, Result{Rule: 2, RuleName: "id", URL: "https://example.com/id"}},
+		{"fallback url", rules, "https://example.com/other", Visitor{Country: "FR"}, Result{Rule: -1, URL: "https://example.com/other", Fallback: true}},
+		{"link url", rules, "", Visitor{Country: "FR"}, Result{Rule: -1, URL: "https://example.com", Fallback: true}},
+		{"no rules", nil, "https://example.com/other", v, Result{Rule: -1, URL: "https://example.com/other", Fallback: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Evaluate(tt.rules, tt.fallback, "https://example.com", "", tt.v); got != tt.want {
+				t.Fatalf("Evaluate = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	link := profile.Link{ID: "l1", URL: "https://example.com", Data: map[string]any{
+		profile.FieldTimezone: "Asia/Jakarta",
+		FieldFallbackURL:      " https://example.com/fallback ",
+		FieldTargets: []any{
+			map[string]any{"name": "android", "url": "https://play.example.com", "os": []any{"Android"}},
+			map[string]any{"name": "office", "url": " https://example.com/office ", "from": "09:00", "until": "17:00", "days": []any{"mon", "tue", "wed", "thu", "fri"}},
+		},
+	}}
+	// Senin 10:00 WIB
+	monday := time.Date(2026, 3, 2, 3, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		link profile.Link
+		v    Visitor
+		want Result
+	}{
+		{"os", link, Visitor{OS: "Android", Time: monday}, Result{Rule: 0, RuleName: "android", URL: "https://play.example.com"}},
+		// Jam kantor dihitung di timezone link
+		{"link timezone", link, Visitor{OS: "iOS", Time: monday}, Result{Rule: 1, RuleName: "office", URL: "https://example.com/office"}},
+		{"fallback", link, Visitor{OS: "iOS", Time: monday.Add(10 * time.Hour)}, Result{Rule: -1, URL: "https://example.com/fallback", Fallback: true}},
+		{"malformed targets", profile.Link{URL: "https://example.com", Data: map[string]any{FieldTargets: "nope"}}, Visitor{Time: monday}, Result{Rule: -1, URL: "https://example.com", Fallback: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Resolve(tt.link, tt.v); got != tt.want {
+				t.Fatalf("Resolve = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
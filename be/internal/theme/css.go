@@ -0,0 +1,128 @@
+package theme
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+var (
+	shapeRadius = map[string]string{"square": "0px", "rounded": "12px", "pill": "999px"}
+	// layoutVars: lebar konten dan jumlah kolom link per layout
+	layoutVars = map[string][2]string{
+		"classic": {"560px", "1"},
+		"card":    {"480px", "1"},
+		"grid":    {"720px", "2"},
+		"minimal": {"520px", "1"},
+	}
+	imageFit = map[string][2]string{
+		"cover":   {"cover", "no-repeat"},
+		"contain": {"contain", "no-repeat"},
+		"tile":    {"auto", "repeat"},
+	}
+)
+
+// CSS compiles a validated spec into a :root block of CSS variables. The variable names
+// match the bio page template (--bg, --fg, --accent, --btn-bg, ...).
+func CSS(s Spec) []byte {
+	p := s.Palette
+	vars := [][2]string{
+		{"bg", p.Background},
+		{"fg", p.Text},
+		{"muted", p.Muted},
+		{"accent", p.Accent},
+		{"font", FontStacks[s.Fonts.Body]},
+		{"font-heading", FontStacks[s.Fonts.Heading]},
+		{"radius", shapeRadius[s.Button.Shape]},
+	}
+
+	btnBg, btnFg, border, shadow := p.ButtonBackground, p.ButtonText, "transparent", "none"
+	switch s.Button.Style {
+	case "outline":
+		btnBg, btnFg, border = "transparent", p.Text, p.ButtonBackground
+	case "soft":
+		btnBg, btnFg = withAlpha(p.ButtonBackground, 0x33), p.Text
+	case "shadow":
+		border, shadow = p.Text, "4px 4px 0 "+p.Accent
+	}
+	vars = append(vars,
+		[2]string{"btn-bg", btnBg},
+		[2]string{"btn-fg", btnFg},
+		[2]string{"btn-border", border},
+		[2]string{"btn-shadow", shadow},
+	)
+
+	bgImage, bgSize, bgRepeat := "none", "cover", "no-repeat"
+	switch s.Background.Type {
+	case "gradient":
+		bgImage = gradientCSS(s.Background.Gradient)
+	case "image":
+		img := s.Background.Image
+		bgImage = `url("` + img.URL + `")`
+		if img.Overlay != "" {
+			bgImage = "linear-gradient(" + img.Overlay + "," + img.Overlay + ")," + bgImage
+		}
+		fit := imageFit[img.Fit]
+		bgSize, bgRepeat = fit[0], fit[1]
+	}
+	layout := layoutVars[s.Layout]
+	vars = append(vars,
+		[2]string{"bg-image", bgImage},
+		[2]string{"bg-size", bgSize},
+		[2]string{"bg-repeat", bgRepeat},
+		[2]string{"layout", s.Layout},
+		[2]string{"max-width", layout[0]},
+		[2]string{"columns", layout[1]},
+	)
+
+	var b strings.Builder
+	b.WriteString(":root{")
+	for i, v := range vars {
+		if i > 0 {
+			b.WriteByte(';')
+		}
+		b.WriteString("--" + v[0] + ":" + v[1])
+	}
+	b.WriteString("}\n")
+	return []byte(b.String())
+}
+
+// Version is a short digest of the compiled CSS, used as the ?v= cache-busting parameter.
+func Version(s Spec) string {
+	sum := sha256.Sum256(CSS(s))
+	return hex.EncodeToString(sum[:6])
+}
+
+// CSSPath is the stylesheet path of a theme including its current version.
+func CSSPath(t *Theme) string {
+	return "/api/themes/" + t.ID + ".css?v=" + Version(t.Spec)
+}
+
+func gradientCSS(g *Gradient) string {
+	stops := make([]string, 0, len(g.Stops))
+	for _, st := range g.Stops {
+		s := st.Color
+		if st.Position != nil {
+			s += " " + strconv.Itoa(*st.Position) + "%"
+		}
+		stops = append(stops, s)
+	}
+	if g.Kind == "radial" {
+		return "radial-gradient(circle," + strings.Join(stops, ",") + ")"
+	}
+	return "linear-gradient(" + strconv.Itoa(g.Angle) + "deg," + strings.Join(stops, ",") + ")"
+}
+
+// withAlpha returns a validated hex color as #rrggbbaa with the given alpha.
+func withAlpha(c string, a byte) string {
+	h := strings.TrimPrefix(c, "#")
+	if len(h) <= 4 {
+		var full strings.Builder
+		for _, r := range h[:3] {
+			full.WriteString(string(r) + string(r))
+		}
+		h = full.String()
+	}
+	return "#" + h[:6] + hex.EncodeToString([]byte{a})
+}
@@ -0,0 +1,219 @@
+package theme
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"biomu/backend/internal/public"
+)
+
+const specMaxBytes = 16 << 10
+
+// Sessions resolves the signed-in caller (implemented by auth.Handler).
+type Sessions interface {
+	SessionUID(r *http.Request) string
+}
+
+type Handler struct {
+	store    *Store
+	sessions Sessions
+}
+
+func NewHandler(store *Store, sessions Sessions) *Handler {
+	return &Handler{store: store, sessions: sessions}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// themeView is a theme plus the versioned URL of its stylesheet.
+type themeView struct {
+	*Theme
+	CSSURL string `json:"cssUrl"`
+}
+
+func view(t *Theme) themeView {
+	return themeView{Theme: t, CSSURL: CSSPath(t)}
+}
+
+// GET /api/themes — preset bawaan, ditambah tema milik caller jika ada session
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	out := map[string][]themeView{"presets": {}, "themes": {}}
+	for _, t := range Presets() {
+		out["presets"] = append(out["presets"], view(t))
+	}
+	if uid := h.sessions.SessionUID(r); uid != "" {
+		themes, err := h.store.List(r.Context(), uid)
+		if err != nil {
+			log.Printf("theme list %s: %v", uid, err)
+			h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load themes"})
+			return
+		}
+		for _, t := range themes {
+			out["themes"] = append(out["themes"], view(t))
+		}
+	}
+	h.writeJSON(w, http.StatusOK, out)
+}
+
+// GET /api/themes/{id} — tema dalam JSON; GET /api/themes/{id}.css — tema sebagai CSS variables.
+// Dengan ?v= yang cocok dengan versi saat ini respons di-cache selamanya (immutable).
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, css := strings.CutSuffix(r.PathValue("id"), ".css")
+	t, err := h.store.Get(r.Context(), id)
+	if err != nil {
+		log.Printf("theme get %s: %v", id, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load theme"})
+		return
+	}
+	if t == nil {
+		w.Header().Set("Cache-Control", "public, max-age=30")
+		if css {
+			http.NotFound(w, r)
+			return
+		}
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "theme not found"})
+		return
+	}
+	if !css {
+		body, err := json.Marshal(view(t))
+		if err != nil {
+			log.Printf("theme get %s encode: %v", id, err)
+			h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load theme"})
+			return
+		}
+		public.WriteCached(w, r, "application/json", body)
+		return
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if v := r.URL.Query().Get("v"); v != "" && v == Version(t.Spec) {
+		public.WriteImmutable(w, r, "text/css; charset=utf-8", CSS(t.Spec))
+		return
+	}
+	public.WriteCached(w, r, "text/css; charset=utf-8", CSS(t.Spec))
+}
+
+// POST /api/themes — buat tema baru milik caller
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	spec, ok := h.decode(w, r)
+	if !ok {
+		return
+	}
+	t, err := h.store.Create(r.Context(), uid, spec)
+	if err != nil {
+		h.writeStoreError(w, "create", uid, err)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, view(t))
+}
+
+// PUT /api/themes/{id} — ganti seluruh isi tema milik caller
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	spec, ok := h.decode(w, r)
+	if !ok {
+		return
+	}
+	t, err := h.store.Update(r.Context(), uid, r.PathValue("id"), spec)
+	if err != nil {
+		h.writeStoreError(w, "update", r.PathValue("id"), err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, view(t))
+}
+
+// DELETE /api/themes/{id}
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	if err := h.store.Delete(r.Context(), uid, r.PathValue("id")); err != nil {
+		h.writeStoreError(w, "delete", r.PathValue("id"), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /api/themes/{id}/apply — pakai tema (preset atau milik sendiri) untuk halaman bio caller
+func (h *Handler) Apply(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uid := h.sessions.SessionUID(r)
+	if uid == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	t, err := h.store.Apply(r.Context(), uid, r.PathValue("id"))
+	if err != nil {
+		h.writeStoreError(w, "apply", r.PathValue("id"), err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, view(t))
+}
+
+func (h *Handler) decode(w http.ResponseWriter, r *http.Request) (Spec, bool) {
+	spec, err := DecodeSpec(http.MaxBytesReader(w, r.Body, specMaxBytes))
+	var invalid *ValidationError
+	if errors.As(err, &invalid) {
+		h.writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": "invalid_theme", "fields": invalid.Fields})
+		return Spec{}, false
+	}
+	if err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body: " + err.Error()})
+		return Spec{}, false
+	}
+	return spec, true
+}
+
+func (h *Handler) writeStoreError(w http.ResponseWriter, op, id string, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "theme not found"})
+	case errors.Is(err, ErrPreset):
+		h.writeJSON(w, http.StatusForbidden, map[string]string{"error": err.Error()})
+	case errors.Is(err, ErrLimit):
+		h.writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		log.Printf("theme %s %s: %v", op, id, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to " + op + " theme"})
+	}
+}
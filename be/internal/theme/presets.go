@@ -0,0 +1,94 @@
+package theme
+
+import "sort"
+
+// DefaultPresetID is used when an account has no (valid) themeId.
+const DefaultPresetID = "midnight"
+
+func pos(n int) *int { return &n }
+
+// presets: tema bawaan, read-only dan bisa dipakai semua akun. "midnight" sama dengan
+// tampilan default halaman bio sebelum ada sistem tema.
+var presets = map[string]Spec{
+	"midnight": {
+		Name:       "Midnight",
+		Palette:    Palette{Background: "#0f172a", Text: "#f1f5f9", Muted: "#94a3b8", Accent: "#38bdf8", ButtonBackground: "#1e293b", ButtonText: "#f1f5f9"},
+		Fonts:      Fonts{Body: "system", Heading: "system"},
+		Button:     Button{Style: "fill", Shape: "rounded"},
+		Background: Background{Type: "solid"},
+		Layout:     "classic",
+	},
+	"daylight": {
+		Name:       "Daylight",
+		Palette:    Palette{Background: "#f8fafc", Text: "#0f172a", Muted: "#64748b", Accent: "#2563eb", ButtonBackground: "#ffffff", ButtonText: "#0f172a"},
+		Fonts:      Fonts{Body: "sans", Heading: "sans"},
+		Button:     Button{Style: "shadow", Shape: "rounded"},
+		Background: Background{Type: "solid"},
+		Layout:     "classic",
+	},
+	"sunset": {
+		Name:    "Sunset",
+		Palette: Palette{Background: "#7c2d12", Text: "#fff7ed", Muted: "#fed7aa", Accent: "#fde047", ButtonBackground: "#ffffff", ButtonText: "#7c2d12"},
+		Fonts:   Fonts{Body: "rounded", Heading: "display"},
+		Button:  Button{Style: "fill", Shape: "pill"},
+		Background: Background{Type: "gradient", Gradient: &Gradient{Kind: "linear", Angle: 160, Stops: []GradientStop{
+			{Color: "#f97316", Position: pos(0)},
+			{Color: "#db2777", Position: pos(55)},
+			{Color: "#7c3aed", Position: pos(100)},
+		}}},
+		Layout: "card",
+	},
+	"forest": {
+		Name:       "Forest",
+		Palette:    Palette{Background: "#052e16", Text: "#ecfdf5", Muted: "#86efac", Accent: "#4ade80", ButtonBackground: "#4ade80", ButtonText: "#052e16"},
+		Fonts:      Fonts{Body: "sans", Heading: "slab"},
+		Button:     Button{Style: "outline", Shape: "rounded"},
+		Background: Background{Type: "gradient", Gradient: &Gradient{Kind: "radial", Stops: []GradientStop{{Color: "#166534"}, {Color: "#052e16"}}}},
+		Layout:     "classic",
+	},
+	"paper": {
+		Name:       "Paper",
+		Palette:    Palette{Background: "#fdfbf7", Text: "#292524", Muted: "#78716c", Accent: "#b45309", ButtonBackground: "#b45309", ButtonText: "#fdfbf7"},
+		Fonts:      Fonts{Body: "serif", Heading: "serif"},
+		Button:     Button{Style: "soft", Shape: "square"},
+		Background: Background{Type: "solid"},
+		Layout:     "minimal",
+	},
+	"mono": {
+		Name:       "Mono",
+		Palette:    Palette{Background: "#000000", Text: "#ffffff", Muted: "#a3a3a3", Accent: "#ffffff", ButtonBackground: "#ffffff", ButtonText: "#000000"},
+		Fonts:      Fonts{Body: "mono", Heading: "mono"},
+		Button:     Button{Style: "fill", Shape: "square"},
+		Background: Background{Type: "solid"},
+		Layout:     "grid",
+	},
+}
+
+func init() {
+	// Preset juga harus lolos validasi yang sama dengan tema user
+	for id, s := range presets {
+		if _, err := s.Normalize(); err != nil {
+			panic("theme: invalid preset " + id + ": " + err.Error())
+		}
+	}
+}
+
+// Preset returns a built-in theme by ID.
+func Preset(id string) (*Theme, bool) {
+	s, ok := presets[id]
+	if !ok {
+		return nil, false
+	}
+	return &Theme{ID: id, Preset: true, Spec: s}, true
+}
+
+// Presets returns every built-in theme sorted by ID.
+func Presets() []*Theme {
+	out := make([]*Theme, 0, len(presets))
+	for id := range presets {
+		t, _ := Preset(id)
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
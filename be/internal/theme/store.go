@@ -0,0 +1,237 @@
+package theme
+
+import (
+	"context"
+	"errors"
+	"log"
+	"regexp"
+	"sort"
+	"time"
+
+	"biomu/backend/internal/firebase"
+	"biomu/backend/internal/profile"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MaxPerAccount limits user-defined themes per account.
+const MaxPerAccount = 20
+
+var (
+	ErrNotFound = errors.New("theme not found")
+	ErrPreset   = errors.New("preset themes are read-only")
+	ErrLimit    = errors.New("theme limit reached")
+)
+
+var idRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Store keeps user themes in a top-level collection (field ownerId = ID akun) and resolves
+// the themeId referenced by an account document.
+type Store struct {
+	fb           *firebase.App
+	coll         string
+	accountsColl string
+}
+
+func NewStore(fb *firebase.App, coll, accountsColl string) *Store {
+	return &Store{fb: fb, coll: coll, accountsColl: accountsColl}
+}
+
+// Get returns a preset or user theme by ID, or nil when it does not exist.
+func (s *Store) Get(ctx context.Context, id string) (*Theme, error) {
+	if t, ok := Preset(id); ok {
+		return t, nil
+	}
+	if !idRe.MatchString(id) {
+		return nil, nil
+	}
+	doc, err := s.fb.DB.Collection(s.coll).Doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t, err := fromDoc(doc)
+	if err != nil {
+		return nil, err
+	}
+	return validOrNil(t), nil
+}
+
+// List returns the themes owned by ownerID, newest first.
+func (s *Store) List(ctx context.Context, ownerID string) ([]*Theme, error) {
+	docs, err := s.fb.DB.Collection(s.coll).Where("ownerId", "==", ownerID).Limit(MaxPerAccount).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*Theme, 0, len(docs))
+	for _, doc := range docs {
+		t, err := fromDoc(doc)
+		if err != nil {
+			return nil, err
+		}
+		if t = validOrNil(t); t != nil {
+			out = append(out, t)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+// Create stores a new theme for ownerID. spec must already be normalized.
+func (s *Store) Create(ctx context.Context, ownerID string, spec Spec) (*Theme, error) {
+	ref := s.fb.DB.Collection(s.coll).NewDoc()
+	now := time.Now().UTC()
+	t := &Theme{ID: ref.ID, OwnerID: ownerID, Spec: spec, CreatedAt: now, UpdatedAt: now}
+	err := s.fb.DB.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		// Hitung di dalam transaksi supaya request paralel tidak melewati batas
+		docs, err := tx.Documents(s.fb.DB.Collection(s.coll).Where("ownerId", "==", ownerID).Limit(MaxPerAccount)).GetAll()
+		if err != nil {
+			return err
+		}
+		if len(docs) >= MaxPerAccount {
+			return ErrLimit
+		}
+		return tx.Create(ref, t)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Update replaces the spec of a theme owned by ownerID.
+func (s *Store) Update(ctx context.Context, ownerID, id string, spec Spec) (*Theme, error) {
+	if _, ok := Preset(id); ok {
+		return nil, ErrPreset
+	}
+	if !idRe.MatchString(id) {
+		return nil, ErrNotFound
+	}
+	ref := s.fb.DB.Collection(s.coll).Doc(id)
+	var out *Theme
+	err := s.fb.DB.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		t, err := s.owned(tx, ref, ownerID)
+		if err != nil {
+			return err
+		}
+		t.Spec, t.UpdatedAt = spec, time.Now().UTC()
+		out = t
+		return tx.Set(ref, t)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Delete removes a theme owned by ownerID; an account still referencing it falls back to
+// the default preset.
+func (s *Store) Delete(ctx context.Context, ownerID, id string) error {
+	if _, ok := Preset(id); ok {
+		return ErrPreset
+	}
+	if !idRe.MatchString(id) {
+		return ErrNotFound
+	}
+	ref := s.fb.DB.Collection(s.coll).Doc(id)
+	account := s.fb.DB.Collection(s.accountsColl).Doc(ownerID)
+	return s.fb.DB.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if _, err := s.owned(tx, ref, ownerID); err != nil {
+			return err
+		}
+		acc, err := tx.Get(account)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		if err == nil {
+			if current, _ := acc.Data()["themeId"].(string); current == id {
+				if err := tx.Update(account, []firestore.Update{
+					{Path: "themeId", Value: firestore.Delete},
+					{Path: "updatedAt", Value: firestore.ServerTimestamp},
+				}); err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Delete(ref)
+	})
+}
+
+// Apply sets the account's themeId after checking the theme is a preset or owned by uid.
+func (s *Store) Apply(ctx context.Context, uid, id string) (*Theme, error) {
+	t, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil || (!t.Preset && t.OwnerID != uid) {
+		return nil, ErrNotFound
+	}
+	_, err = s.fb.DB.Collection(s.accountsColl).Doc(uid).Update(ctx, []firestore.Update{
+		{Path: "themeId", Value: t.ID},
+		{Path: "updatedAt", Value: firestore.ServerTimestamp},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ForProfile resolves the theme referenced by an account. Missing themes, or themes owned by
+// another account (themeId ditulis langsung lewat /api/db), resolve to nil.
+func (s *Store) ForProfile(ctx context.Context, p *profile.Profile) (*Theme, error) {
+	if p.ThemeID == "" {
+		return nil, nil
+	}
+	t, err := s.Get(ctx, p.ThemeID)
+	if err != nil || t == nil {
+		return nil, err
+	}
+	if !t.Preset && t.OwnerID != p.ID {
+		return nil, nil
+	}
+	return t, nil
+}
+
+func (s *Store) owned(tx *firestore.Transaction, ref *firestore.DocumentRef, ownerID string) (*Theme, error) {
+	doc, err := tx.Get(ref)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	t, err := fromDoc(doc)
+	if err != nil {
+		return nil, err
+	}
+	// Tema milik akun lain diperlakukan sama dengan tidak ada
+	if t.OwnerID != ownerID {
+		return nil, ErrNotFound
+	}
+	return t, nil
+}
+
+func fromDoc(doc *firestore.DocumentSnapshot) (*Theme, error) {
+	var t Theme
+	if err := doc.DataTo(&t); err != nil {
+		return nil, err
+	}
+	t.ID = doc.Ref.ID
+	return &t, nil
+}
+
+// validOrNil re-validates a stored spec: the collection is also reachable through the generic
+// /api/db endpoint, so documents are not trusted to be valid before they are compiled to CSS.
+func validOrNil(t *Theme) *Theme {
+	spec, err := t.Spec.Normalize()
+	if err != nil {
+		log.Printf("theme %s: ignoring invalid stored theme: %v", t.ID, err)
+		return nil
+	}
+	t.Spec = spec
+	return t
+}
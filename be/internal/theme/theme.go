@@ -0,0 +1,285 @@
+// Package theme menyimpan tema halaman bio (palet, font, gaya tombol, background, layout)
+// sebagai dokumen tervalidasi dan meng-compile-nya menjadi CSS variables.
+package theme
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxNameLen       = 60
+	maxGradientStops = 5
+	maxImageURLLen   = 1024
+)
+
+// Spec is the user-editable part of a theme. Every field is required unless noted; unknown
+// fields are rejected so typos do not silently fall back to defaults.
+type Spec struct {
+	Name       string     `json:"name" firestore:"name"`
+	Palette    Palette    `json:"palette" firestore:"palette"`
+	Fonts      Fonts      `json:"fonts" firestore:"fonts"`
+	Button     Button     `json:"button" firestore:"button"`
+	Background Background `json:"background" firestore:"background"`
+	Layout     string     `json:"layout" firestore:"layout"`
+}
+
+// Palette: warna hex (#rgb, #rgba, #rrggbb, #rrggbbaa), disimpan lowercase.
+type Palette struct {
+	Background       string `json:"background" firestore:"background"`
+	Text             string `json:"text" firestore:"text"`
+	Muted            string `json:"muted" firestore:"muted"`
+	Accent           string `json:"accent" firestore:"accent"`
+	ButtonBackground string `json:"buttonBackground" firestore:"buttonBackground"`
+	ButtonText       string `json:"buttonText" firestore:"buttonText"`
+}
+
+// Fonts are keys of FontStacks; no remote font is loaded.
+type Fonts struct {
+	Body    string `json:"body" firestore:"body"`
+	Heading string `json:"heading" firestore:"heading"`
+}
+
+type Button struct {
+	// Style: fill, outline, soft, shadow
+	Style string `json:"style" firestore:"style"`
+	// Shape: square, rounded, pill
+	Shape string `json:"shape" firestore:"shape"`
+}
+
+type Background struct {
+	// Type: solid (Palette.Background), gradient atau image
+	Type     string    `json:"type" firestore:"type"`
+	Gradient *Gradient `json:"gradient,omitempty" firestore:"gradient,omitempty"`
+	Image    *Image    `json:"image,omitempty" firestore:"image,omitempty"`
+}
+
+type Gradient struct {
+	// Kind: linear atau radial; Angle (derajat) hanya untuk linear
+	Kind  string         `json:"kind" firestore:"kind"`
+	Angle int            `json:"angle" firestore:"angle"`
+	Stops []GradientStop `json:"stops" firestore:"stops"`
+}
+
+type GradientStop struct {
+	Color string `json:"color" firestore:"color"`
+	// Position 0–100 (%); nil = dibagi rata oleh browser
+	Position *int `json:"position,omitempty" firestore:"position,omitempty"`
+}
+
+type Image struct {
+	// URL https atau path media lokal (/media/...)
+	URL string `json:"url" firestore:"url"`
+	// Fit: cover, contain, tile
+	Fit string `json:"fit" firestore:"fit"`
+	// Overlay: warna hex (biasanya dengan alpha) di atas gambar supaya teks tetap terbaca
+	Overlay string `json:"overlay,omitempty" firestore:"overlay,omitempty"`
+}
+
+// Theme is a stored theme: a built-in preset or a user theme owned by one account.
+type Theme struct {
+	ID      string `json:"id" firestore:"-"`
+	OwnerID string `json:"ownerId,omitempty" firestore:"ownerId"`
+	Preset  bool   `json:"preset" firestore:"-"`
+	Spec
+	CreatedAt time.Time `json:"createdAt,omitempty" firestore:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty" firestore:"updatedAt"`
+}
+
+var (
+	Layouts       = []string{"classic", "card", "grid", "minimal"}
+	ButtonStyles  = []string{"fill", "outline", "soft", "shadow"}
+	ButtonShapes  = []string{"square", "rounded", "pill"}
+	ImageFits     = []string{"cover", "contain", "tile"}
+	GradientKinds = []string{"linear", "radial"}
+)
+
+// FontStacks maps font keys to CSS font stacks of locally installed fonts.
+var FontStacks = map[string]string{
+	"system":  "-apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif",
+	"sans":    "'Helvetica Neue', Arial, 'Noto Sans', sans-serif",
+	"serif":   "Georgia, 'Times New Roman', 'Noto Serif', serif",
+	"mono":    "ui-monospace, SFMono-Regular, Menlo, Consolas, monospace",
+	"rounded": "ui-rounded, 'SF Pro Rounded', 'Varela Round', 'Nunito', sans-serif",
+	"slab":    "'Roboto Slab', 'Rockwell', 'Courier New', serif",
+	"display": "'Avenir Next', 'Futura', 'Trebuchet MS', sans-serif",
+}
+
+var (
+	hexColorRe = regexp.MustCompile(`^#([0-9a-f]{3}|[0-9a-f]{4}|[0-9a-f]{6}|[0-9a-f]{8})$`)
+	// Karakter URL yang aman di dalam url("...") tanpa escaping
+	imageURLRe = regexp.MustCompile(`^(https://[a-zA-Z0-9.-]+(:[0-9]{1,5})?|/media)/[a-zA-Z0-9._~/%?&=+-]*$`)
+)
+
+// FieldError describes one invalid field, e.g. {"palette.accent", "must be a hex color"}.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every invalid field of a spec.
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Message)
+	}
+	return "invalid theme: " + strings.Join(parts, "; ")
+}
+
+// DecodeSpec strictly decodes a JSON spec (unknown fields are an error) and validates it.
+// Malformed JSON is returned as is; invalid values as *ValidationError.
+func DecodeSpec(r io.Reader) (Spec, error) {
+	var s Spec
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return Spec{}, err
+	}
+	if dec.More() {
+		return Spec{}, errors.New("unexpected data after JSON object")
+	}
+	return s.Normalize()
+}
+
+// Normalize trims and lowercases values and validates every field, returning a
+// *ValidationError listing all problems.
+func (s Spec) Normalize() (Spec, error) {
+	v := &validator{}
+	s.Name = strings.TrimSpace(s.Name)
+	switch n := utf8.RuneCountInString(s.Name); {
+	case n == 0:
+		v.add("name", "is required")
+	case n > maxNameLen:
+		v.add("name", "must be at most "+strconv.Itoa(maxNameLen)+" characters")
+	case strings.IndexFunc(s.Name, unicode.IsControl) >= 0:
+		v.add("name", "must not contain control characters")
+	}
+
+	p := &s.Palette
+	v.color("palette.background", &p.Background, true)
+	v.color("palette.text", &p.Text, true)
+	v.color("palette.muted", &p.Muted, true)
+	v.color("palette.accent", &p.Accent, true)
+	v.color("palette.buttonBackground", &p.ButtonBackground, true)
+	v.color("palette.buttonText", &p.ButtonText, true)
+
+	v.font("fonts.body", &s.Fonts.Body)
+	v.font("fonts.heading", &s.Fonts.Heading)
+	v.oneOf("button.style", &s.Button.Style, ButtonStyles)
+	v.oneOf("button.shape", &s.Button.Shape, ButtonShapes)
+	v.oneOf("layout", &s.Layout, Layouts)
+
+	bg := &s.Background
+	v.oneOf("background.type", &bg.Type, []string{"solid", "gradient", "image"})
+	// Hanya sub-objek yang sesuai type yang disimpan
+	switch bg.Type {
+	case "solid":
+		bg.Gradient, bg.Image = nil, nil
+	case "gradient":
+		bg.Image = nil
+		if bg.Gradient == nil {
+			v.add("background.gradient", "is required for type gradient")
+			break
+		}
+		g := bg.Gradient
+		v.oneOf("background.gradient.kind", &g.Kind, GradientKinds)
+		if g.Kind == "radial" {
+			g.Angle = 0
+		}
+		if g.Angle < 0 || g.Angle > 360 {
+			v.add("background.gradient.angle", "must be between 0 and 360")
+		}
+		if len(g.Stops) < 2 || len(g.Stops) > maxGradientStops {
+			v.add("background.gradient.stops", "must have 2 to "+strconv.Itoa(maxGradientStops)+" stops")
+		}
+		last := -1
+		for i := range g.Stops {
+			st := &g.Stops[i]
+			field := "background.gradient.stops[" + strconv.Itoa(i) + "]"
+			v.color(field+".color", &st.Color, true)
+			if st.Position != nil {
+				if *st.Position < 0 || *st.Position > 100 {
+					v.add(field+".position", "must be between 0 and 100")
+				} else if *st.Position < last {
+					v.add(field+".position", "must not be lower than the previous stop")
+				} else {
+					last = *st.Position
+				}
+			}
+		}
+	case "image":
+		bg.Gradient = nil
+		if bg.Image == nil {
+			v.add("background.image", "is required for type image")
+			break
+		}
+		img := bg.Image
+		img.URL = strings.TrimSpace(img.URL)
+		if len(img.URL) > maxImageURLLen || !imageURLRe.MatchString(img.URL) || strings.Contains(img.URL, "..") {
+			v.add("background.image.url", "must be an https URL or a /media/ path")
+		}
+		v.oneOf("background.image.fit", &img.Fit, ImageFits)
+		v.color("background.image.overlay", &img.Overlay, false)
+	}
+
+	if len(v.errs) > 0 {
+		return Spec{}, &ValidationError{Fields: v.errs}
+	}
+	return s, nil
+}
+
+type validator struct {
+	errs []FieldError
+}
+
+func (v *validator) add(field, msg string) {
+	v.errs = append(v.errs, FieldError{Field: field, Message: msg})
+}
+
+func (v *validator) color(field string, dst *string, required bool) {
+	*dst = strings.ToLower(strings.TrimSpace(*dst))
+	if *dst == "" && !required {
+		return
+	}
+	if !hexColorRe.MatchString(*dst) {
+		v.add(field, "must be a hex color (#rgb, #rrggbb or #rrggbbaa)")
+	}
+}
+
+func (v *validator) font(field string, dst *string) {
+	*dst = strings.ToLower(strings.TrimSpace(*dst))
+	if _, ok := FontStacks[*dst]; !ok {
+		v.add(field, "must be one of "+strings.Join(fontKeys(), ", "))
+	}
+}
+
+func (v *validator) oneOf(field string, dst *string, allowed []string) {
+	*dst = strings.ToLower(strings.TrimSpace(*dst))
+	for _, a := range allowed {
+		if *dst == a {
+			return
+		}
+	}
+	v.add(field, "must be one of "+strings.Join(allowed, ", "))
+}
+
+func fontKeys() []string {
+	keys := make([]string, 0, len(FontStacks))
+	for k := range FontStacks {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
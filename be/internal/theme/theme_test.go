@@ -0,0 +1,168 @@
+package theme
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func validSpec() Spec {
+	return Spec{
+		Name:       "  Senja ",
+		Palette:    Palette{Background: "#FFF", Text: "#111111", Muted: "#666", Accent: "#FF6600", ButtonBackground: "#f60", ButtonText: "#ffffffcc"},
+		Fonts:      Fonts{Body: "Sans", Heading: " serif "},
+		Button:     Button{Style: "Soft", Shape: "pill"},
+		Background: Background{Type: "solid", Image: &Image{URL: "https://example.com/x.png", Fit: "cover"}},
+		Layout:     "GRID",
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	s, err := validSpec().Normalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Name != "Senja" || s.Palette.Background != "#fff" || s.Fonts.Heading != "serif" || s.Button.Style != "soft" || s.Layout != "grid" {
+		t.Fatalf("not normalized: %+v", s)
+	}
+	if s.Background.Image != nil {
+		t.Fatal("image kept for a solid background")
+	}
+	again, err := s.Normalize()
+	if err != nil || string(CSS(again)) != string(CSS(s)) {
+		t.Fatalf("Normalize is not idempotent: %v", err)
+	}
+}
+
+func TestNormalizeErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(s *Spec)
+		fields []string
+	}{
+		{"empty name", func(s *Spec) { s.Name = " " }, []string{"name"}},
+		{"control character", func(s *Spec) { s.Name = "a\x1b[31m" }, []string{"name"}},
+		{"long name", func(s *Spec) { s.Name = strings.Repeat("é", maxNameLen+1) }, []string{"name"}},
+		{"colors", func(s *Spec) { s.Palette.Accent = "red"; s.Palette.Text = "#12345" }, []string{"palette.text", "palette.accent"}},
+		{"css in color", func(s *Spec) { s.Palette.Muted = "#fff;}body{display:none" }, []string{"palette.muted"}},
+		{"font", func(s *Spec) { s.Fonts.Body = "Comic Sans" }, []string{"fonts.body"}},
+		{"enums", func(s *Spec) { s.Button.Shape = "circle"; s.Layout = "" }, []string{"button.shape", "layout"}},
+		{"gradient missing", func(s *Spec) { s.Background.Type = "gradient" }, []string{"background.gradient"}},
+		{"gradient stops", func(s *Spec) {
+			p0, p50, p20 := 0, 50, 20
+			s.Background = Background{Type: "gradient", Gradient: &Gradient{Kind: "linear", Angle: 400, Stops: []GradientStop{
+				{Color: "#000", Position: &p0}, {Color: "#fff", Position: &p50}, {Color: "#f00", Position: &p20},
+			}}}
+		}, []string{"background.gradient.angle", "background.gradient.stops[2].position"}},
+		{"one stop", func(s *Spec) {
+			s.Background = Background{Type: "gradient", Gradient: &Gradient{Kind: "radial", Stops: []GradientStop{{Color: "#000"}}}}
+		}, []string{"background.gradient.stops"}},
+		{"image url breakout", func(s *Spec) {
+			s.Background = Background{Type: "image", Image: &Image{URL: `https://example.com/x.png");}body{x:url("`, Fit: "cover"}}
+		}, []string{"background.image.url"}},
+		{"image http", func(s *Spec) {
+			s.Background = Background{Type: "image", Image: &Image{URL: "http://example.com/x.png", Fit: "cover"}}
+		}, []string{"background.image.url"}},
+		{"image traversal", func(s *Spec) {
+			s.Background = Background{Type: "image", Image: &Image{URL: "/media/../api/x", Fit: "tile", Overlay: "dark"}}
+		}, []string{"background.image.url", "background.image.overlay"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := validSpec()
+			tt.change(&s)
+			_, err := s.Normalize()
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("err = %v, want *ValidationError", err)
+			}
+			var got []string
+			for _, f := range verr.Fields {
+				got = append(got, f.Field)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.fields, ",") {
+				t.Fatalf("fields %v, want %v", got, tt.fields)
+			}
+		})
+	}
+}
+
+func TestDecodeSpec(t *testing.T) {
+	body := `{"name":"A","palette":{"background":"#000","text":"#fff","muted":"#999","accent":"#0af","buttonBackground":"#0af","buttonText":"#000"},` +
+		`"fonts":{"body":"system","heading":"system"},"button":{"style":"fill","shape":"square"},"background":{"type":"solid"},"layout":"classic"}`
+	if _, err := DecodeSpec(strings.NewReader(body)); err != nil {
+		t.Fatalf("valid spec: %v", err)
+	}
+	for name, in := range map[string]string{
+		"unknown field": strings.Replace(body, `"layout"`, `"layuot"`, 1),
+		"trailing data": body + `{}`,
+		"not json":      "name=A",
+	} {
+		if _, err := DecodeSpec(strings.NewReader(in)); err == nil {
+			t.Errorf("%s accepted", name)
+		}
+	}
+}
+
+func TestCSS(t *testing.T) {
+	p25 := 25
+	s, err := Spec{
+		Name:       "A",
+		Palette:    Palette{Background: "#000", Text: "#fff", Muted: "#999", Accent: "#0af", ButtonBackground: "#0af", ButtonText: "#000"},
+		Fonts:      Fonts{Body: "mono", Heading: "serif"},
+		Button:     Button{Style: "soft", Shape: "rounded"},
+		Background: Background{Type: "gradient", Gradient: &Gradient{Kind: "linear", Angle: 90, Stops: []GradientStop{{Color: "#000"}, {Color: "#fff", Position: &p25}}}},
+		Layout:     "grid",
+	}.Normalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	css := string(CSS(s))
+	for _, want := range []string{
+		":root{--bg:#000;--fg:#fff;",
+		"--font:" + FontStacks["mono"] + ";",
+		"--radius:12px;",
+		"--btn-bg:#00aaff33;--btn-fg:#fff;",
+		"--bg-image:linear-gradient(90deg,#000,#fff 25%);",
+		"--max-width:720px;--columns:2}",
+	} {
+		if !strings.Contains(css, want) {
+			t.Errorf("CSS missing %q:\n%s", want, css)
+		}
+	}
+	if strings.Count(css, "{") != 1 || strings.Count(css, "}") != 1 {
+		t.Errorf("CSS must be a single :root block:\n%s", css)
+	}
+
+	v := Version(s)
+	s.Palette.Accent = "#0ab"
+	if len(v) != 12 || Version(s) == v {
+		t.Errorf("version %q does not follow the spec", v)
+	}
+}
+
+func TestWithAlpha(t *testing.T) {
+	for in, want := range map[string]string{"#0af": "#00aaff80", "#0af8": "#00aaff80", "#12345678": "#12345680", "#123456": "#12345680"} {
+		if got := withAlpha(in, 0x80); got != want {
+			t.Errorf("withAlpha(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestPresets(t *testing.T) {
+	if _, ok := Preset(DefaultPresetID); !ok {
+		t.Fatalf("default preset %q missing", DefaultPresetID)
+	}
+	for _, p := range Presets() {
+		n, err := p.Spec.Normalize()
+		if err != nil {
+			t.Fatalf("preset %s: %v", p.ID, err)
+		}
+		if Version(n) != Version(p.Spec) {
+			t.Errorf("preset %s is not stored normalized", p.ID)
+		}
+		if !p.Preset || CSSPath(p) != "/api/themes/"+p.ID+".css?v="+Version(p.Spec) {
+			t.Errorf("preset %s: %+v", p.ID, p)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+// Package unfurl mengambil metadata halaman (title, deskripsi, OG/Twitter image, favicon,
+// oEmbed) dari URL yang ditempel user. Request keluar dibatasi ketat: timeout, ukuran
+// respons, jumlah redirect, dan proteksi SSRF yang memeriksa setiap alamat IP yang
+// benar-benar di-dial (termasuk setelah redirect dan DNS rebinding).
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultTimeout   = 8 * time.Second
+	dialTimeout      = 3 * time.Second
+	maxRedirects     = 5
+	maxHTMLBytes     = 1 << 20
+	maxOEmbedBytes   = 256 << 10
+	defaultUserAgent = "Mozilla/5.0 (compatible; biomu-unfurl/1.0)"
+)
+
+var (
+	// ErrBlocked is returned when the target resolves to a non-public address.
+	ErrBlocked = errors.New("destination is not allowed")
+	// ErrInvalidURL is returned for anything but absolute http(s) URLs.
+	ErrInvalidURL = errors.New("url must be an absolute http(s) URL")
+)
+
+// Fetcher performs the outbound requests.
+type Fetcher struct {
+	client *http.Client
+	// noFollow memakai transport yang sama tapi tidak mengikuti redirect (untuk Expand)
+	noFollow  *http.Client
+	userAgent string
+	// AllowAddr decides which IPs may be dialed. Default: only public unicast addresses.
+	// Tests against httptest (127.0.0.1) replace it.
+	AllowAddr func(netip.Addr) bool
+}
+
+// NewFetcher creates a fetcher with SSRF protection enabled.
+func NewFetcher() *Fetcher {
+	f := &Fetcher{userAgent: defaultUserAgent, AllowAddr: PublicAddr}
+	dialer := &net.Dialer{
+		Timeout: dialTimeout,
+		// Control jalan tepat sebelum connect untuk setiap alamat hasil resolve, jadi
+		// DNS rebinding maupun redirect ke host internal tetap tertahan di sini.
+		Control: func(network, address string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return ErrBlocked
+			}
+			if !f.AllowAddr(ap.Addr().Unmap()) {
+				return ErrBlocked
+			}
+			return nil
+		},
+	}
+	transport := &http.Transport{
+		// Jangan pernah lewat proxy dari environment: proxy bisa menjangkau jaringan internal
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   dialTimeout,
+		ResponseHeaderTimeout: 5 * time.Second,
+		MaxIdleConns:          20,
+		IdleConnTimeout:       30 * time.Second,
+	}
+	f.client = &http.Client{
+		Transport: transport,
+		Timeout:   defaultTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("too many redirects")
+			}
+			if _, err := ValidateURL(req.URL.String()); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+	f.noFollow = &http.Client{
+		Transport: transport,
+		Timeout:   defaultTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return f
+}
+
+// PublicAddr reports whether ip is a public unicast address (no loopback, private, link-local,
+// CGNAT, multicast, unspecified, documentation or benchmarking ranges).
+func PublicAddr(ip netip.Addr) bool {
+	if !ip.IsValid() || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, p := range blockedPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // CGNAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64 bisa memetakan ke IPv4 privat
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// ValidateURL parses raw and checks scheme, host and credentials (it does not resolve DNS;
+// address checks happen when dialing).
+func ValidateURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return nil, ErrInvalidURL
+	}
+	u.Fragment = ""
+	return u, nil
+}
+
+// Fetch downloads target (same SSRF protection, redirect and timeout rules as Unfurl) and
+// returns at most limit bytes of the body plus the response Content-Type.
+func (f *Fetcher) Fetch(ctx context.Context, target, accept string, limit int64) ([]byte, string, error) {
+	if _, err := ValidateURL(target); err != nil {
+		return nil, "", err
+	}
+	body, resp, err := f.get(ctx, target, accept, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, resp.Header.Get("Content-Type"), nil
+}
+
+// get fetches target and returns at most limit bytes of the body plus the final URL.
+func (f *Fetcher) get(ctx context.Context, target, accept string, limit int64) ([]byte, *http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	req.Header.Set("Accept", accept)
+	req.Header.Set("Accept-Language", "id,en;q=0.8")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrBlocked) {
+			return nil, nil, ErrBlocked
+		}
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, resp, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, resp, err
+	}
+	return body, resp, nil
+}
+
+// Expand follows the redirect chain of raw hop by hop (for URL shorteners) and returns every
+// URL visited, starting with raw. On error the chain up to the failing hop is returned, so a
+// redirect into a blocked address still reveals its target.
+func (f *Fetcher) Expand(ctx context.Context, raw string) ([]string, error) {
+	u, err := ValidateURL(raw)
+	if err != nil {
+		return nil, err
+	}
+	chain := []string{u.String()}
+	for hop := 0; ; hop++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return chain, err
+		}
+		req.Header.Set("User-Agent", f.userAgent)
+		resp, err := f.noFollow.Do(req)
+		if err != nil {
+			if errors.Is(err, ErrBlocked) {
+				return chain, ErrBlocked
+			}
+			return chain, err
+		}
+		resp.Body.Close()
+		loc := resp.Header.Get("Location")
+		if resp.StatusCode < 300 || resp.StatusCode > 399 || loc == "" {
+			return chain, nil
+		}
+		if hop >= maxRedirects {
+			return chain, errors.New("too many redirects")
+		}
+		next, err := u.Parse(loc)
+		if err != nil {
+			return chain, ErrInvalidURL
+		}
+		if u, err = ValidateURL(next.String()); err != nil {
+			return append(chain, next.String()), err
+		}
+		chain = append(chain, u.String())
+	}
+}
@@ -0,0 +1,206 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+// newTestFetcher allows exactly the given addresses, so httptest servers on other loopback
+// addresses stand in for private hosts.
+func newTestFetcher(allowed ...string) *Fetcher {
+	f := NewFetcher()
+	f.AllowAddr = func(ip netip.Addr) bool {
+		for _, a := range allowed {
+			if ip == netip.MustParseAddr(a) {
+				return true
+			}
+		}
+		return false
+	}
+	return f
+}
+
+// newServerOn starts an httptest server listening on addr (mis. "127.0.0.2").
+func newServerOn(t *testing.T, addr string, h http.Handler) *httptest.Server {
+	t.Helper()
+	l, err := net.Listen("tcp", addr+":0")
+	if err != nil {
+		t.Skipf("cannot listen on %s: %v", addr, err)
+	}
+	s := httptest.NewUnstartedServer(h)
+	s.Listener.Close()
+	s.Listener = l
+	s.Start()
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"198.51.100.7", false},
+		{"::1", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"64:ff9b::a00:1", false},
+		{"ff02::1", false},
+	}
+	for _, tt := range tests {
+		if got := PublicAddr(netip.MustParseAddr(tt.ip)); got != tt.want {
+			t.Errorf("PublicAddr(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	for raw, ok := range map[string]bool{
+		"https://example.com/a#frag":  true,
+		" http://example.com ":        true,
+		"ftp://example.com":           false,
+		"javascript:alert(1)":         false,
+		"https://user:pw@example.com": false,
+		"/relative":                   false,
+		"https://":                    false,
+	} {
+		u, err := ValidateURL(raw)
+		if ok != (err == nil) {
+			t.Errorf("ValidateURL(%q) err = %v", raw, err)
+		}
+		if err == nil && u.Fragment != "" {
+			t.Errorf("ValidateURL(%q) kept the fragment", raw)
+		}
+	}
+}
+
+// Default fetcher tidak boleh men-dial loopback.
+func TestFetchBlocksPrivateAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("blocked server was reached")
+	}))
+	defer srv.Close()
+
+	_, _, err := NewFetcher().Fetch(context.Background(), srv.URL, "*/*", 1024)
+	if !errors.Is(err, ErrBlocked) {
+		t.Fatalf("err = %v, want ErrBlocked", err)
+	}
+	if httpStatus(err) != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403", httpStatus(err))
+	}
+}
+
+func TestFetchSizeCap(t *testing.T) {
+	srv := newServerOn(t, "127.0.0.1", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(strings.Repeat("x", 4096)))
+	}))
+	body, contentType, err := newTestFetcher("127.0.0.1").Fetch(context.Background(), srv.URL, "*/*", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(body) != 100 || contentType != "text/plain" {
+		t.Fatalf("got %d bytes (%q), want 100", len(body), contentType)
+	}
+}
+
+// Setiap hop redirect divalidasi ulang: URL-nya oleh CheckRedirect dan alamat IP-nya saat dial.
+func TestRedirectHops(t *testing.T) {
+	internal := newServerOn(t, "127.0.0.2", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("internal server was reached through a redirect")
+	}))
+	var public *httptest.Server
+	public = newServerOn(t, "127.0.0.1", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/to-internal":
+			http.Redirect(w, r, internal.URL+"/admin", http.StatusFound)
+		case "/to-ftp":
+			http.Redirect(w, r, "ftp://example.com/file", http.StatusFound)
+		case "/to-credentials":
+			http.Redirect(w, r, "http://user:pw@"+strings.TrimPrefix(public.URL, "http://")+"/ok", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/hop":
+			http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+		case "/ok":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<title>ok</title>"))
+		}
+	}))
+	f := newTestFetcher("127.0.0.1")
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/to-internal", http.StatusForbidden},
+		{"/to-ftp", http.StatusBadRequest},
+		{"/to-credentials", http.StatusBadRequest},
+		{"/loop", http.StatusBadGateway},
+		{"/hop", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			_, _, err := f.Fetch(context.Background(), public.URL+tt.path, "*/*", 1024)
+			if tt.status == 0 {
+				if err != nil {
+					t.Fatalf("err = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("redirect was followed")
+			}
+			if got := httpStatus(err); got != tt.status {
+				t.Fatalf("status = %d (%v), want %d", got, err, tt.status)
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	internal := newServerOn(t, "127.0.0.2", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("internal server was reached through a redirect")
+	}))
+	srv := newServerOn(t, "127.0.0.1", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/s/abc":
+			http.Redirect(w, r, "/landing?ref=short", http.StatusMovedPermanently)
+		case "/s/internal":
+			http.Redirect(w, r, internal.URL+"/metadata", http.StatusFound)
+		}
+	}))
+	f := newTestFetcher("127.0.0.1")
+
+	chain, err := f.Expand(context.Background(), srv.URL+"/s/abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain) != 2 || chain[1] != srv.URL+"/landing?ref=short" {
+		t.Fatalf("chain = %v", chain)
+	}
+
+	// Rantai sampai hop yang diblokir tetap dikembalikan
+	chain, err = f.Expand(context.Background(), srv.URL+"/s/internal")
+	if !errors.Is(err, ErrBlocked) {
+		t.Fatalf("err = %v, want ErrBlocked", err)
+	}
+	if len(chain) != 2 || chain[1] != internal.URL+"/metadata" {
+		t.Fatalf("chain = %v", chain)
+	}
+}
@@ -0,0 +1,67 @@
+package unfurl
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+)
+
+const requestMaxBytes = 4 << 10
+
+// Sessions resolves the signed-in caller (implemented by auth.Handler).
+type Sessions interface {
+	SessionUID(r *http.Request) string
+}
+
+type Handler struct {
+	unfurler *Unfurler
+	sessions Sessions
+}
+
+func NewHandler(unfurler *Unfurler, sessions Sessions) *Handler {
+	return &Handler{unfurler: unfurler, sessions: sessions}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// POST /api/links/unfurl — ambil title, deskripsi, gambar OG, favicon dan oEmbed dari URL.
+// Hanya untuk user yang login supaya endpoint ini tidak jadi open proxy.
+func (h *Handler) Unfurl(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.sessions.SessionUID(r) == "" {
+		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	var body struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, requestMaxBytes)).Decode(&body); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+	meta, err := h.unfurler.Unfurl(r.Context(), body.URL)
+	if err != nil {
+		status := httpStatus(err)
+		if status == http.StatusBadGateway {
+			log.Printf("unfurl %s: %v", body.URL, err)
+			h.writeJSON(w, status, map[string]string{"error": "failed to fetch url"})
+			return
+		}
+		// Pesan sentinel saja: error terbungkus berisi URL hop redirect dan detail dial
+		msg := ErrInvalidURL.Error()
+		if status == http.StatusForbidden {
+			msg = ErrBlocked.Error()
+		}
+		h.writeJSON(w, status, map[string]string{"error": msg})
+		return
+	}
+	h.writeJSON(w, http.StatusOK, meta)
+}
@@ -0,0 +1,171 @@
+package unfurl
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	maxTitleLen       = 200
+	maxDescriptionLen = 500
+)
+
+// page is what parseHTML extracts from the document head.
+type page struct {
+	title       string
+	description string
+	siteName    string
+	image       string
+	icons       []icon
+	oembedJSON  string
+}
+
+type icon struct {
+	href  string
+	rel   string
+	sizes string
+}
+
+// parseHTML reads the <head> of an HTML document. Relative URLs are resolved against base.
+func parseHTML(body []byte, contentType string, base *url.URL) page {
+	var p page
+	r, err := charset.NewReader(bytes.NewReader(body), contentType)
+	if err != nil {
+		return p
+	}
+	meta := map[string]string{}
+	var titleText strings.Builder
+	inTitle := false
+	z := html.NewTokenizer(r)
+loop:
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			break loop
+		case html.TextToken:
+			if inTitle {
+				titleText.Write(z.Text())
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				break loop
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			attrs := map[string]string{}
+			for hasAttr {
+				var k, v []byte
+				k, v, hasAttr = z.TagAttr()
+				attrs[strings.ToLower(string(k))] = string(v)
+			}
+			switch string(name) {
+			case "body":
+				break loop
+			case "title":
+				inTitle = tt == html.StartTagToken && titleText.Len() == 0
+			case "meta":
+				key := strings.ToLower(attrs["property"])
+				if key == "" {
+					key = strings.ToLower(attrs["name"])
+				}
+				if key != "" && attrs["content"] != "" {
+					if _, seen := meta[key]; !seen {
+						meta[key] = attrs["content"]
+					}
+				}
+			case "link":
+				rel := strings.ToLower(attrs["rel"])
+				href := resolve(base, attrs["href"])
+				if href == "" {
+					continue
+				}
+				switch {
+				case strings.Contains(rel, "icon"):
+					p.icons = append(p.icons, icon{href: href, rel: rel, sizes: attrs["sizes"]})
+				case rel == "alternate" && strings.EqualFold(attrs["type"], "application/json+oembed") && p.oembedJSON == "":
+					p.oembedJSON = href
+				}
+			}
+		}
+	}
+
+	p.title = firstNonEmpty(meta["og:title"], meta["twitter:title"], titleText.String())
+	p.description = firstNonEmpty(meta["og:description"], meta["twitter:description"], meta["description"])
+	p.siteName = firstNonEmpty(meta["og:site_name"], meta["application-name"])
+	p.image = resolve(base, firstNonEmpty(meta["og:image:secure_url"], meta["og:image"], meta["og:image:url"], meta["twitter:image"], meta["twitter:image:src"]))
+	return p
+}
+
+// bestIcon prefers apple-touch-icon (larger), then the largest declared icon, then the first one.
+func bestIcon(icons []icon) string {
+	best, bestScore := "", -1
+	for _, ic := range icons {
+		score := 1
+		if strings.Contains(ic.rel, "apple-touch-icon") {
+			score = 500
+		}
+		if w, _, ok := strings.Cut(strings.ToLower(ic.sizes), "x"); ok {
+			n := 0
+			for _, c := range w {
+				if c < '0' || c > '9' {
+					n = 0
+					break
+				}
+				n = n*10 + int(c-'0')
+			}
+			if n > score {
+				score = n
+			}
+		}
+		if score > bestScore {
+			best, bestScore = ic.href, score
+		}
+	}
+	return best
+}
+
+// resolve makes ref absolute against base and only keeps http(s) results.
+func resolve(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	abs := base.ResolveReference(u)
+	if abs.Scheme != "http" && abs.Scheme != "https" {
+		return ""
+	}
+	return abs.String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// clean collapses whitespace, drops invalid UTF-8 and truncates to max runes.
+func clean(s string, max int) string {
+	s = strings.Join(strings.Fields(strings.ToValidUTF8(s, "")), " ")
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	r := []rune(s)
+	return strings.TrimSpace(string(r[:max-1])) + "…"
+}
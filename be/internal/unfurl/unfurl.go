@@ -0,0 +1,218 @@
+package unfurl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	cacheTTL        = 24 * time.Hour
+	failureCacheTTL = 10 * time.Minute
+	maxCacheEntries = 2000
+)
+
+// Metadata is the unfurl result returned to the client.
+type Metadata struct {
+	URL         string  `json:"url"`
+	FinalURL    string  `json:"finalUrl"`
+	Title       string  `json:"title,omitempty"`
+	Description string  `json:"description,omitempty"`
+	SiteName    string  `json:"siteName,omitempty"`
+	Image       string  `json:"image,omitempty"`
+	Favicon     string  `json:"favicon,omitempty"`
+	ContentType string  `json:"contentType,omitempty"`
+	OEmbed      *OEmbed `json:"oembed,omitempty"`
+}
+
+// OEmbed holds the useful fields of a discovered oEmbed response. The embed HTML is
+// deliberately not passed on.
+type OEmbed struct {
+	Endpoint     string `json:"endpoint"`
+	Type         string `json:"type,omitempty"`
+	Title        string `json:"title,omitempty"`
+	AuthorName   string `json:"authorName,omitempty"`
+	ProviderName string `json:"providerName,omitempty"`
+	ThumbnailURL string `json:"thumbnailUrl,omitempty"`
+}
+
+type cacheEntry struct {
+	meta    Metadata
+	err     error
+	expires time.Time
+}
+
+// Unfurler fetches metadata and caches results (failures for a shorter time) in memory.
+type Unfurler struct {
+	fetcher *Fetcher
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+	now   func() time.Time
+}
+
+func NewUnfurler(fetcher *Fetcher) *Unfurler {
+	return &Unfurler{fetcher: fetcher, cache: map[string]cacheEntry{}, now: time.Now}
+}
+
+// Unfurl returns metadata for raw, from cache when possible.
+func (u *Unfurler) Unfurl(ctx context.Context, raw string) (Metadata, error) {
+	target, err := ValidateURL(raw)
+	if err != nil {
+		return Metadata{}, err
+	}
+	key := target.String()
+	if e, ok := u.cached(key); ok {
+		return e.meta, e.err
+	}
+	meta, err := u.fetch(ctx, target)
+	ttl := cacheTTL
+	if err != nil {
+		ttl = failureCacheTTL
+	}
+	// Request yang dibatalkan client tidak ikut di-cache
+	if ctx.Err() == nil {
+		u.store(key, cacheEntry{meta: meta, err: err, expires: u.now().Add(ttl)})
+	}
+	return meta, err
+}
+
+func (u *Unfurler) cached(key string) (cacheEntry, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	e, ok := u.cache[key]
+	if !ok || u.now().After(e.expires) {
+		return cacheEntry{}, false
+	}
+	return e, true
+}
+
+func (u *Unfurler) store(key string, e cacheEntry) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if len(u.cache) >= maxCacheEntries {
+		now := u.now()
+		for k, old := range u.cache {
+			if now.After(old.expires) {
+				delete(u.cache, k)
+			}
+		}
+		// Masih penuh: buang entri sembarang (urutan map Go acak)
+		for k := range u.cache {
+			if len(u.cache) < maxCacheEntries {
+				break
+			}
+			delete(u.cache, k)
+		}
+	}
+	u.cache[key] = e
+}
+
+func (u *Unfurler) fetch(ctx context.Context, target *url.URL) (Metadata, error) {
+	meta := Metadata{URL: target.String(), FinalURL: target.String()}
+	body, resp, err := u.fetcher.get(ctx, target.String(), "text/html,application/xhtml+xml;q=0.9,*/*;q=0.5", maxHTMLBytes)
+	if err != nil {
+		return Metadata{}, err
+	}
+	final := resp.Request.URL
+	meta.FinalURL = final.String()
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	meta.ContentType = mediaType
+
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		// Bukan HTML (mis. PDF/gambar): cukup nama file sebagai judul
+		meta.Title = clean(lastPathSegment(final), maxTitleLen)
+		if strings.HasPrefix(mediaType, "image/") {
+			meta.Image = meta.FinalURL
+		}
+		meta.Favicon = defaultFavicon(final)
+		return meta, nil
+	}
+
+	p := parseHTML(body, contentType, final)
+	meta.Title = clean(p.title, maxTitleLen)
+	meta.Description = clean(p.description, maxDescriptionLen)
+	meta.SiteName = clean(p.siteName, maxTitleLen)
+	meta.Image = p.image
+	meta.Favicon = bestIcon(p.icons)
+	if meta.Favicon == "" {
+		meta.Favicon = defaultFavicon(final)
+	}
+	if p.oembedJSON != "" {
+		if oe, ok := u.fetchOEmbed(ctx, p.oembedJSON); ok {
+			meta.OEmbed = &oe
+			if meta.Title == "" {
+				meta.Title = oe.Title
+			}
+			if meta.Image == "" {
+				meta.Image = oe.ThumbnailURL
+			}
+			if meta.SiteName == "" {
+				meta.SiteName = oe.ProviderName
+			}
+		}
+	}
+	if meta.Title == "" {
+		meta.Title = final.Hostname()
+	}
+	return meta, nil
+}
+
+// fetchOEmbed loads a discovered oEmbed JSON endpoint through the same guarded client.
+func (u *Unfurler) fetchOEmbed(ctx context.Context, endpoint string) (OEmbed, bool) {
+	body, resp, err := u.fetcher.get(ctx, endpoint, "application/json", maxOEmbedBytes)
+	if err != nil {
+		return OEmbed{}, false
+	}
+	var raw struct {
+		Type         string `json:"type"`
+		Title        string `json:"title"`
+		AuthorName   string `json:"author_name"`
+		ProviderName string `json:"provider_name"`
+		ThumbnailURL string `json:"thumbnail_url"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return OEmbed{}, false
+	}
+	return OEmbed{
+		Endpoint:     endpoint,
+		Type:         clean(raw.Type, 20),
+		Title:        clean(raw.Title, maxTitleLen),
+		AuthorName:   clean(raw.AuthorName, maxTitleLen),
+		ProviderName: clean(raw.ProviderName, maxTitleLen),
+		ThumbnailURL: resolve(resp.Request.URL, raw.ThumbnailURL),
+	}, true
+}
+
+func defaultFavicon(u *url.URL) string {
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/favicon.ico"}).String()
+}
+
+func lastPathSegment(u *url.URL) string {
+	p := strings.TrimRight(u.Path, "/")
+	if i := strings.LastIndex(p, "/"); i >= 0 && i < len(p)-1 {
+		if s, err := url.PathUnescape(p[i+1:]); err == nil {
+			return s
+		}
+	}
+	return u.Hostname()
+}
+
+// httpStatus maps unfurl errors to the status code returned by the handler. Error dari
+// CheckRedirect dan dial Control datang terbungkus *url.Error, jadi dicocokkan dengan errors.Is.
+func httpStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidURL):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrBlocked):
+		return http.StatusForbidden
+	}
+	return http.StatusBadGateway
+}
@@ -0,0 +1,197 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const articleHTML = `<!doctype html>
+<html><head>
+<meta charset="utf-8">
+<title>Judul dari title</title>
+<meta property="og:title" content="  Judul   OG ">
+<meta name="twitter:title" content="Judul Twitter">
+<meta name="description" content="Deskripsi meta">
+<meta property="og:description" content="Deskripsi OG">
+<meta property="og:site_name" content="Blog Aether">
+<meta property="og:image" content="/img/cover.jpg">
+<link rel="icon" href="/favicon-16.png" sizes="16x16">
+<link rel="icon" href="/favicon-64.png" sizes="64x64">
+<link rel="apple-touch-icon" href="/apple.png">
+<link rel="alternate" type="application/json+oembed" href="/oembed.json">
+</head><body><meta property="og:title" content="Di dalam body"></body></html>`
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"invalid url", ErrInvalidURL, http.StatusBadRequest},
+		{"blocked", ErrBlocked, http.StatusForbidden},
+		// CheckRedirect: redirect ke URL yang tidak valid
+		{"redirect to invalid url", &url.Error{Op: "Get", URL: "ftp://x", Err: ErrInvalidURL}, http.StatusBadRequest},
+		// Dial Control: alamat privat
+		{"blocked dial", &url.Error{Op: "Get", URL: "http://10.0.0.1/", Err: &net.OpError{Op: "dial", Net: "tcp", Err: ErrBlocked}}, http.StatusForbidden},
+		{"wrapped", fmt.Errorf("unfurl: %w", ErrBlocked), http.StatusForbidden},
+		{"upstream", errors.New("unexpected status 500"), http.StatusBadGateway},
+		{"timeout", &url.Error{Op: "Get", URL: "http://x", Err: errors.New("i/o timeout")}, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		if got := httpStatus(tt.err); got != tt.want {
+			t.Errorf("%s: httpStatus = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// newTestUnfurler serves pages from an httptest server on 127.0.0.1 and lets the fetcher reach it.
+func newTestUnfurler(t *testing.T, h http.Handler) (*Unfurler, string) {
+	t.Helper()
+	srv := newServerOn(t, "127.0.0.1", h)
+	return NewUnfurler(newTestFetcher("127.0.0.1")), srv.URL
+}
+
+func TestUnfurlExtraction(t *testing.T) {
+	u, base := newTestUnfurler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/article":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(articleHTML))
+		case "/untitled":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><head><link rel="alternate" type="application/json+oembed" href="oembed.json"><link rel="shortcut icon" href="/fav.ico"></head></html>`))
+		case "/plain":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><head><title>
+				Hanya   title
+			</title></head></html>`))
+		case "/oembed.json":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"type":"video","title":"Judul oEmbed","author_name":"Aether","provider_name":"VideoHost","thumbnail_url":"/thumb.jpg","html":"<iframe></iframe>"}`))
+		case "/files/Laporan 2026.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			_, _ = w.Write([]byte("%PDF-1.7"))
+		case "/photo.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte("\x89PNG"))
+		}
+	}))
+
+	oembed := &OEmbed{Endpoint: base + "/oembed.json", Type: "video", Title: "Judul oEmbed", AuthorName: "Aether", ProviderName: "VideoHost", ThumbnailURL: base + "/thumb.jpg"}
+	tests := []struct {
+		path string
+		want Metadata
+	}{
+		// og:* menang atas twitter:* dan <title>; apple-touch-icon menang atas ikon lain;
+		// meta di dalam <body> diabaikan
+		{"/article", Metadata{
+			Title:       "Judul OG",
+			Description: "Deskripsi OG",
+			SiteName:    "Blog Aether",
+			Image:       base + "/img/cover.jpg",
+			Favicon:     base + "/apple.png",
+			ContentType: "text/html",
+			OEmbed:      oembed,
+		}},
+		// Tanpa judul dan gambar: diambil dari oEmbed
+		{"/untitled", Metadata{
+			Title:       "Judul oEmbed",
+			SiteName:    "VideoHost",
+			Image:       base + "/thumb.jpg",
+			Favicon:     base + "/fav.ico",
+			ContentType: "text/html",
+			OEmbed:      oembed,
+		}},
+		{"/plain", Metadata{Title: "Hanya title", Favicon: base + "/favicon.ico", ContentType: "text/html"}},
+		{"/files/Laporan%202026.pdf", Metadata{Title: "Laporan 2026.pdf", Favicon: base + "/favicon.ico", ContentType: "application/pdf"}},
+		{"/photo.png", Metadata{Title: "photo.png", Image: base + "/photo.png", Favicon: base + "/favicon.ico", ContentType: "image/png"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := u.Unfurl(context.Background(), base+tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.want.URL, tt.want.FinalURL = base+tt.path, base+tt.path
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got  %+v %+v\nwant %+v %+v", got, got.OEmbed, tt.want, tt.want.OEmbed)
+			}
+		})
+	}
+}
+
+func TestBestIcon(t *testing.T) {
+	tests := []struct {
+		icons []icon
+		want  string
+	}{
+		{nil, ""},
+		{[]icon{{href: "a", rel: "icon"}, {href: "b", rel: "icon"}}, "a"},
+		{[]icon{{href: "a", rel: "icon", sizes: "16x16"}, {href: "b", rel: "icon", sizes: "192X192"}}, "b"},
+		{[]icon{{href: "a", rel: "icon", sizes: "192x192"}, {href: "b", rel: "apple-touch-icon"}}, "b"},
+		{[]icon{{href: "a", rel: "apple-touch-icon"}, {href: "b", rel: "icon", sizes: "512x512"}}, "b"},
+		{[]icon{{href: "a", rel: "icon", sizes: "any"}, {href: "b", rel: "icon", sizes: "32x32"}}, "b"},
+	}
+	for _, tt := range tests {
+		if got := bestIcon(tt.icons); got != tt.want {
+			t.Errorf("bestIcon(%+v) = %q, want %q", tt.icons, got, tt.want)
+		}
+	}
+}
+
+// Hanya maxHTMLBytes pertama yang dibaca: <title> setelah batas itu tidak terlihat.
+func TestUnfurlSizeCap(t *testing.T) {
+	u, base := newTestUnfurler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><head><!--" + strings.Repeat("x", maxHTMLBytes) + "--><title>Terlambat</title></head></html>"))
+	}))
+	got, err := u.Unfurl(context.Background(), base+"/big")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "127.0.0.1" {
+		t.Fatalf("title = %q, want hostname fallback", got.Title)
+	}
+}
+
+// Endpoint oEmbed di alamat privat tidak di-fetch; halaman tetap ter-unfurl tanpa oEmbed.
+func TestUnfurlOEmbedBlocked(t *testing.T) {
+	internal := newServerOn(t, "127.0.0.2", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("internal oEmbed endpoint was reached")
+	}))
+	u, base := newTestUnfurler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<head><title>Halo</title><link rel="alternate" type="application/json+oembed" href="` + internal.URL + `/oembed"></head>`))
+	}))
+	got, err := u.Unfurl(context.Background(), base+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Halo" || got.OEmbed != nil {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+// Unfurl yang diblokir mengembalikan ErrBlocked dan hasilnya ikut di-cache.
+func TestUnfurlBlockedCached(t *testing.T) {
+	hits := 0
+	srv := newServerOn(t, "127.0.0.1", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	u := NewUnfurler(NewFetcher())
+	for i := 0; i < 2; i++ {
+		if _, err := u.Unfurl(context.Background(), srv.URL+"/"); !errors.Is(err, ErrBlocked) {
+			t.Fatalf("err = %v, want ErrBlocked", err)
+		}
+	}
+	if hits != 0 || len(u.cache) != 1 {
+		t.Fatalf("hits %d, cache %d", hits, len(u.cache))
+	}
+}
@@ -0,0 +1,155 @@
+package vcard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"biomu/backend/internal/domain"
+	"biomu/backend/internal/profile"
+	"biomu/backend/internal/public"
+	"biomu/backend/internal/unfurl"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	photoMaxBytes  = 2 << 20
+	photoMaxPixels = 4096 * 4096
+	photoTimeout   = 4 * time.Second
+	// photoSize: sisi terpanjang avatar di vCard; cukup untuk foto kontak, file tetap kecil
+	photoSize    = 256
+	photoQuality = 85
+)
+
+type Handler struct {
+	profiles *profile.Store
+	domains  *domain.Store
+	fetcher  *unfurl.Fetcher
+	baseURL  string
+}
+
+// NewHandler creates the vCard handler. fetcher downloads avatars to embed; baseURL is the
+// public origin of profiles without a verified custom domain.
+func NewHandler(profiles *profile.Store, domains *domain.Store, fetcher *unfurl.Fetcher, baseURL string) *Handler {
+	return &Handler{profiles: profiles, domains: domains, fetcher: fetcher, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// GET /api/public/{handle}/contact.vcf — kartu kontak (vCard 4.0) kreator, hanya jika pemilik
+// mengaktifkan "contactCard"
+func (h *Handler) Card(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	handle := profile.NormalizeHandle(r.PathValue("handle"))
+	if handle == "" {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "handle is required"})
+		return
+	}
+	p, err := h.profiles.FindByHandle(ctx, handle)
+	if err != nil {
+		log.Printf("vcard %s: %v", handle, err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load profile"})
+		return
+	}
+	settings := Settings{}
+	if p != nil {
+		settings = FromProfile(p)
+	}
+	if !settings.Enabled {
+		w.Header().Set("Cache-Control", "public, max-age=30")
+		h.writeJSON(w, http.StatusNotFound, map[string]string{"error": "contact card not found"})
+		return
+	}
+
+	profileURL := h.domains.CanonicalURL(ctx, p)
+	if profileURL == "" {
+		profileURL = h.baseURL + "/" + p.Handle
+	}
+	c := Card{
+		Name:     p.DisplayName,
+		Nickname: p.Handle,
+		Note:     p.Bio,
+		URL:      profileURL,
+		Email:    settings.Email,
+		Phone:    settings.Phone,
+		Org:      settings.Org,
+		Title:    settings.Title,
+		Rev:      p.UpdatedAt,
+	}
+	if c.Name == "" {
+		c.Name = "@" + p.Handle
+	}
+	if photo := h.loadPhoto(ctx, p); photo != nil {
+		c.Photo, c.PhotoType = photo, "image/jpeg"
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="`+p.Handle+`.vcf"`)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	public.WriteCached(w, r, "text/vcard; charset=utf-8", c.Encode())
+}
+
+// loadPhoto downloads the owner's avatar and re-encodes it as a small JPEG (format yang
+// didukung semua aplikasi kontak); failures only drop the photo.
+func (h *Handler) loadPhoto(ctx context.Context, owner *profile.Profile) []byte {
+	src := strings.TrimSpace(owner.Image)
+	if src == "" {
+		return nil
+	}
+	// Avatar hasil upload disimpan sebagai path relatif terhadap origin publik
+	if strings.HasPrefix(src, "/") && !strings.HasPrefix(src, "//") {
+		src = h.baseURL + src
+	}
+	ctx, cancel := context.WithTimeout(ctx, photoTimeout)
+	defer cancel()
+	body, _, err := h.fetcher.Fetch(ctx, src, "image/*", photoMaxBytes)
+	if err != nil {
+		log.Printf("vcard photo %s: %v", owner.ID, err)
+		return nil
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(body))
+	if err != nil || cfg.Width*cfg.Height > photoMaxPixels {
+		log.Printf("vcard photo %s: unsupported image", owner.ID)
+		return nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(body))
+	if err != nil {
+		log.Printf("vcard photo %s: %v", owner.ID, err)
+		return nil
+	}
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width > photoSize || height > photoSize {
+		if width >= height {
+			width, height = photoSize, max(1, height*photoSize/width)
+		} else {
+			width, height = max(1, width*photoSize/height), photoSize
+		}
+	}
+	// Latar putih untuk avatar transparan (JPEG tidak punya alpha)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Over, nil)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: photoQuality}); err != nil {
+		log.Printf("vcard photo %s: %v", owner.ID, err)
+		return nil
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,156 @@
+// Package vcard builds an RFC 6350 (vCard 4.0) contact card from a profile so visitors can save
+// the creator as a contact: nama, handle, bio, URL halaman bio, avatar yang di-embed sebagai
+// base64, plus email/telepon/organisasi yang sengaja dibuka pemilik di field "contactCard".
+package vcard
+
+import (
+	"encoding/base64"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"biomu/backend/internal/profile"
+)
+
+// maxLineOctets: panjang baris maksimal sebelum di-fold (RFC 6350 3.2), tanpa CRLF
+const maxLineOctets = 75
+
+const maxFieldLen = 200
+
+// Settings is the contact card of an account (field "contactCard" di dokumen akun). Email and
+// phone are only included when the owner sets them here; email akun tidak pernah dipakai.
+type Settings struct {
+	Enabled bool
+	Email   string
+	Phone   string
+	Org     string
+	Title   string
+}
+
+// FromProfile reads the contact card settings of p.
+func FromProfile(p *profile.Profile) Settings {
+	raw, _ := p.Data["contactCard"].(map[string]any)
+	if raw == nil {
+		return Settings{}
+	}
+	field := func(key string) string {
+		v, _ := raw[key].(string)
+		v = strings.TrimSpace(v)
+		if utf8.RuneCountInString(v) > maxFieldLen {
+			v = string([]rune(v)[:maxFieldLen])
+		}
+		return v
+	}
+	out := Settings{Email: field("email"), Phone: field("phone"), Org: field("org"), Title: field("title")}
+	out.Enabled, _ = raw["enabled"].(bool)
+	return out
+}
+
+// Card is the data written to a vCard.
+type Card struct {
+	Name     string
+	Nickname string
+	Note     string
+	URL      string
+	Email    string
+	Phone    string
+	Org      string
+	Title    string
+	// Photo: gambar JPEG/PNG yang di-embed sebagai data URI
+	Photo     []byte
+	PhotoType string
+	Rev       time.Time
+}
+
+// Encode returns the vCard 4.0 text of c with CRLF line endings and folded lines.
+func (c Card) Encode() []byte {
+	var b strings.Builder
+	line := func(name, value string) {
+		writeFolded(&b, name+":"+value)
+	}
+	line("BEGIN", "VCARD")
+	line("VERSION", "4.0")
+	line("PRODID", "-//Biomu//Bio Page//ID")
+	line("KIND", "individual")
+	line("FN", escape(c.Name))
+	// N wajib di vCard 3 dan masih dibaca banyak aplikasi kontak; nama lengkap sebagai given name
+	line("N", ";"+escape(c.Name)+";;;")
+	if c.Nickname != "" {
+		line("NICKNAME", escape(c.Nickname))
+	}
+	if c.Org != "" {
+		line("ORG", escape(c.Org))
+	}
+	if c.Title != "" {
+		line("TITLE", escape(c.Title))
+	}
+	if c.Email != "" {
+		line("EMAIL;TYPE=work", escape(c.Email))
+	}
+	if c.Phone != "" {
+		line("TEL;VALUE=uri;TYPE=cell", "tel:"+telURI(c.Phone))
+	}
+	if c.URL != "" {
+		line("URL", c.URL)
+	}
+	if c.Note != "" {
+		line("NOTE", escape(c.Note))
+	}
+	if len(c.Photo) > 0 {
+		line("PHOTO", "data:"+c.PhotoType+";base64,"+base64.StdEncoding.EncodeToString(c.Photo))
+	}
+	if c.URL != "" {
+		line("UID", c.URL)
+	}
+	if !c.Rev.IsZero() {
+		line("REV", c.Rev.UTC().Format("20060102T150405Z"))
+	}
+	line("END", "VCARD")
+	return []byte(b.String())
+}
+
+// escape escapes a TEXT value (RFC 6350 3.4).
+func escape(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\n", `\n`, "\r", `\n`).Replace(s)
+}
+
+// telURI keeps the characters allowed in a global tel: URI number (RFC 3966).
+func telURI(s string) string {
+	var b strings.Builder
+	sep := false
+	for _, r := range strings.TrimSpace(s) {
+		switch {
+		case r >= '0' && r <= '9':
+			if sep && b.Len() > 0 && b.String() != "+" {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			sep = false
+		case r == '+' && b.Len() == 0:
+			b.WriteRune(r)
+		case r == '-' || r == ' ' || r == '.' || r == '(' || r == ')':
+			sep = true
+		}
+	}
+	return b.String()
+}
+
+// writeFolded writes one content line, folded at maxLineOctets octets without splitting a
+// UTF-8 sequence; continuation lines start with a single space (RFC 6350 3.2).
+func writeFolded(b *strings.Builder, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Spasi di awal baris lanjutan ikut dihitung
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
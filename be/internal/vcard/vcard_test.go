@@ -0,0 +1,120 @@
+package vcard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"biomu/backend/internal/profile"
+)
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Aether", "Aether"},
+		{"Studio, Inc; Jakarta", `Studio\, Inc\; Jakarta`},
+		{`a\b`, `a\\b`},
+		{"baris 1\r\nbaris 2\rbaris 3", `baris 1\nbaris 2\nbaris 3`},
+	}
+	for _, tt := range tests {
+		if got := escape(tt.in); got != tt.want {
+			t.Errorf("escape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTelURI(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"+62 812-3456-7890", "+62-812-3456-7890"},
+		{"(021) 555.0199", "021-555-0199"},
+		{"+1 (555) 010 9999", "+1-555-010-9999"},
+		{" 0812 3456 ", "0812-3456"},
+		// Karakter lain (huruf, ;, +) dibuang supaya URI tetap valid
+		{"0812;ext=1+2", "081212"},
+		{"abc", ""},
+	}
+	for _, tt := range tests {
+		if got := telURI(tt.in); got != tt.want {
+			t.Errorf("telURI(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFromProfile(t *testing.T) {
+	long := strings.Repeat("é", maxFieldLen+10)
+	p := &profile.Profile{Data: map[string]any{"contactCard": map[string]any{
+		"enabled": true,
+		"email":   " fan@example.com ",
+		"phone":   "+62 812",
+		"org":     long,
+		"title":   42,
+	}}}
+	got := FromProfile(p)
+	want := Settings{Enabled: true, Email: "fan@example.com", Phone: "+62 812", Org: strings.Repeat("é", maxFieldLen)}
+	if got != want {
+		t.Fatalf("FromProfile = %+v, want %+v", got, want)
+	}
+	if got := FromProfile(&profile.Profile{Data: map[string]any{}}); got.Enabled {
+		t.Fatal("card enabled without contactCard")
+	}
+}
+
+func TestEncode(t *testing.T) {
+	photo := bytes.Repeat([]byte{0xff, 0xd8, 0x00, 0x7f}, 100)
+	c := Card{
+		Name:      "Aether, Studio",
+		Nickname:  "aether",
+		Note:      "Kreator musik;\nkolaborasi? DM",
+		URL:       "https://aether.bio/aether",
+		Email:     "hi@aether.bio",
+		Phone:     "+62 812-3456",
+		Org:       "Aether; Co",
+		Photo:     photo,
+		PhotoType: "image/jpeg",
+		Rev:       time.Date(2026, 3, 1, 12, 0, 0, 0, time.FixedZone("WIB", 7*3600)),
+	}
+	raw := string(c.Encode())
+	if !strings.HasSuffix(raw, "\r\n") {
+		t.Fatal("vCard does not end with CRLF")
+	}
+	lines := strings.Split(strings.TrimSuffix(raw, "\r\n"), "\r\n")
+	for i, l := range lines {
+		if len(l) > maxLineOctets {
+			t.Errorf("line %d has %d octets", i, len(l))
+		}
+		if strings.Contains(l, "\n") {
+			t.Errorf("line %d contains a bare LF", i)
+		}
+	}
+	card := strings.ReplaceAll(raw, "\r\n ", "")
+	for _, want := range []string{
+		"BEGIN:VCARD\r\nVERSION:4.0\r\n",
+		"FN:Aether\\, Studio\r\n",
+		"N:;Aether\\, Studio;;;\r\n",
+		"NICKNAME:aether\r\n",
+		"ORG:Aether\\; Co\r\n",
+		"EMAIL;TYPE=work:hi@aether.bio\r\n",
+		"TEL;VALUE=uri;TYPE=cell:tel:+62-812-3456\r\n",
+		"NOTE:Kreator musik\\;\\nkolaborasi? DM\r\n",
+		"PHOTO:data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(photo) + "\r\n",
+		"UID:https://aether.bio/aether\r\n",
+		"REV:20260301T050000Z\r\n",
+		"END:VCARD\r\n",
+	} {
+		if !strings.Contains(card, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	// Field kosong tidak ditulis
+	minimal := string(Card{Name: "A"}.Encode())
+	for _, absent := range []string{"EMAIL", "TEL", "PHOTO", "UID", "REV", "TITLE"} {
+		if strings.Contains(minimal, absent) {
+			t.Errorf("minimal card contains %s", absent)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package visitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"biomu/backend/internal/firebase"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// saltTTL: dokumen salt kedaluwarsa sehari setelah harinya selesai (aktifkan TTL policy
+// Firestore pada field expireAt); salt hari sebelumnya juga dihapus saat rotasi.
+const saltTTL = 48 * time.Hour
+
+// FirestoreSaltStore shares the daily salt between backend instances through a
+// short-TTL Firestore document, so unique visitors are consistent across replicas.
+type FirestoreSaltStore struct {
+	fb   *firebase.App
+	coll string
+}
+
+func NewFirestoreSaltStore(fb *firebase.App, coll string) *FirestoreSaltStore {
+	return &FirestoreSaltStore{fb: fb, coll: coll}
+}
+
+func (s *FirestoreSaltStore) Salt(ctx context.Context, day string) ([]byte, error) {
+	dayStart, err := time.Parse("2006-01-02", day)
+	if err != nil {
+		return nil, fmt.Errorf("invalid day %q: %w", day, err)
+	}
+	ref := s.fb.DB.Collection(s.coll).Doc(day)
+	salt, err := newSalt()
+	if err != nil {
+		return nil, err
+	}
+	_, err = ref.Create(ctx, map[string]any{
+		"salt":     salt,
+		"expireAt": dayStart.Add(saltTTL),
+	})
+	if err == nil {
+		// Instance pertama yang membuat salt hari ini juga membuang salt kemarin
+		_, _ = s.fb.DB.Collection(s.coll).Doc(dayStart.AddDate(0, 0, -1).Format("2006-01-02")).Delete(ctx)
+		return salt, nil
+	}
+	if status.Code(err) != codes.AlreadyExists {
+		return nil, err
+	}
+	snap, err := ref.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stored, ok := snap.Data()["salt"].([]byte)
+	if !ok || len(stored) != saltSize {
+		return nil, fmt.Errorf("salt document %s is malformed", day)
+	}
+	return stored, nil
+}
@@ -0,0 +1,132 @@
+package visitor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Proxies is the set of reverse proxies (CDN, load balancer) in front of the backend whose
+// forwarding headers are trusted. Tanpa proxy terpercaya, header seperti X-Forwarded-For dan
+// CF-Connecting-IP dikirim bebas oleh client dan tidak boleh dipakai untuk rate limit.
+type Proxies struct {
+	nets []*net.IPNet
+	// header: header berisi IP client yang ditulis ulang oleh proxy (mis. CF-Connecting-IP).
+	// Kosong berarti X-Forwarded-For.
+	header string
+}
+
+// ParseProxies parses a comma-separated list of IPs/CIDRs. header optionally names the header
+// the outermost proxy sets to the client IP; without it X-Forwarded-For is used.
+func ParseProxies(spec, header string) (*Proxies, error) {
+	p := &Proxies{header: http.CanonicalHeaderKey(strings.TrimSpace(header))}
+	for _, v := range strings.Split(spec, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if !strings.Contains(v, "/") {
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid proxy address %q", v)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			p.nets = append(p.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy range %q: %w", v, err)
+		}
+		p.nets = append(p.nets, n)
+	}
+	return p, nil
+}
+
+func (p *Proxies) trusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if p == nil || ip == nil {
+		return false
+	}
+	for _, n := range p.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the client IP of r. Forwarding headers are only read when the peer is a
+// trusted proxy; X-Forwarded-For is walked from the right and the first hop that is not a
+// trusted proxy wins, so entries prepended by the client are ignored.
+func (p *Proxies) ClientIP(r *http.Request) string {
+	ip := peerIP(r)
+	if !p.trusted(ip) {
+		return ip
+	}
+	if p.header != "" {
+		if v := strings.TrimSpace(r.Header.Get(p.header)); net.ParseIP(v) != nil {
+			return v
+		}
+		return ip
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			// Rantai rusak: berhenti di hop terpercaya terakhir
+			break
+		}
+		ip = hop
+		if !p.trusted(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+type clientIPKey struct{}
+
+// Middleware resolves the client IP of every request once, for ClientIP.
+func (p *Proxies) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), clientIPKey{}, p.ClientIP(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ClientIP returns the visitor IP resolved by Proxies.Middleware, or the peer address when
+// the request did not pass through it.
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+	return peerIP(r)
+}
+
+func peerIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// RateKey returns the client IP of r as a rate limit key. IPv6 clients are grouped by /64,
+// karena satu pelanggan biasanya mendapat seluruh prefix dan bisa berganti alamat sesukanya.
+func RateKey(r *http.Request) string {
+	ip := ClientIP(r)
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.String()
+	}
+	return parsed.Mask(net.CIDRMask(64, 128)).String() + "/64"
+}
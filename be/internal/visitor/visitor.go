@@ -0,0 +1,139 @@
+// Package visitor menghitung ID pengunjung anonim tanpa cookie dan tanpa menyimpan IP.
+//
+// ID = HMAC-SHA256(salt harian, IP terpotong | User-Agent | profileID). Salt dibuat acak
+// per hari (UTC) dan hanya disimpan di memori atau di storage dengan TTL pendek, lalu
+// dibuang saat hari berganti. Akibatnya ID stabil dalam satu hari (unique visitor harian
+// bisa dihitung) tetapi tidak bisa dihubungkan antar hari maupun antar profil, dan IP asli
+// tidak pernah bisa direkonstruksi. Sesuai UU PDP dan GDPR: tidak ada cookie, tidak ada IP.
+package visitor
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const saltSize = 32
+
+// SaltStore provides the secret salt for a UTC day ("2006-01-02").
+// Implementations must return the same salt for the same day and must not keep old salts.
+type SaltStore interface {
+	Salt(ctx context.Context, day string) ([]byte, error)
+}
+
+// Identifier computes privacy-preserving visitor IDs.
+type Identifier struct {
+	store SaltStore
+	now   func() time.Time
+
+	mu   sync.Mutex
+	day  string
+	salt []byte
+}
+
+func New(store SaltStore) *Identifier {
+	if store == nil {
+		store = NewMemorySaltStore()
+	}
+	return &Identifier{store: store, now: time.Now}
+}
+
+// VisitorID returns the anonymous visitor ID for r on profileID. It returns "" when the
+// visitor opted out via Do-Not-Track / Global Privacy Control or no salt is available,
+// so the event is still counted but never attributed to a visitor.
+func (id *Identifier) VisitorID(r *http.Request, profileID string) string {
+	if OptedOut(r) {
+		return ""
+	}
+	return id.hash(r.Context(), TruncateIP(ClientIP(r)), r.UserAgent(), profileID)
+}
+
+func (id *Identifier) hash(ctx context.Context, ip, ua, profileID string) string {
+	salt := id.currentSalt(ctx)
+	if salt == nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(ip))
+	mac.Write([]byte{0})
+	mac.Write([]byte(ua))
+	mac.Write([]byte{0})
+	mac.Write([]byte(profileID))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+func (id *Identifier) currentSalt(ctx context.Context) []byte {
+	day := id.now().UTC().Format("2006-01-02")
+	id.mu.Lock()
+	defer id.mu.Unlock()
+	if id.day == day && id.salt != nil {
+		return id.salt
+	}
+	salt, err := id.store.Salt(ctx, day)
+	if err != nil {
+		log.Printf("visitor salt %s: %v", day, err)
+		return nil
+	}
+	// Salt hari sebelumnya langsung dilupakan
+	id.day, id.salt = day, salt
+	return salt
+}
+
+// OptedOut reports whether the visitor sent Do-Not-Track or Global Privacy Control.
+func OptedOut(r *http.Request) bool {
+	return r.Header.Get("DNT") == "1" || r.Header.Get("Sec-GPC") == "1"
+}
+
+// TruncateIP zeroes the host part of an address: IPv4 to /24 and IPv6 to /48.
+// Unparseable input yields "".
+func TruncateIP(ip string) string {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// MemorySaltStore keeps only the salt of the current day in memory.
+type MemorySaltStore struct {
+	mu   sync.Mutex
+	day  string
+	salt []byte
+}
+
+func NewMemorySaltStore() *MemorySaltStore {
+	return &MemorySaltStore{}
+}
+
+func (s *MemorySaltStore) Salt(_ context.Context, day string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.day == day {
+		return s.salt, nil
+	}
+	salt, err := newSalt()
+	if err != nil {
+		return nil, err
+	}
+	s.day, s.salt = day, salt
+	return salt, nil
+}
+
+func newSalt() ([]byte, error) {
+	b := make([]byte, saltSize)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,162 @@
+package visitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestVisitorIDDaily(t *testing.T) {
+	day1 := time.Date(2026, 3, 1, 0, 0, 1, 0, time.UTC)
+	tests := []struct {
+		name      string
+		a, b      time.Time
+		ipA, ipB  string
+		profB     string
+		wantEqual bool
+	}{
+		{"same day", day1, day1.Add(23 * time.Hour), "203.0.113.7", "203.0.113.7", "p1", true},
+		{"same /24", day1, day1.Add(time.Hour), "203.0.113.7", "203.0.113.200", "p1", true},
+		{"other /24", day1, day1, "203.0.113.7", "198.51.100.7", "p1", false},
+		{"next day", day1, day1.Add(24 * time.Hour), "203.0.113.7", "203.0.113.7", "p1", false},
+		{"midnight UTC", day1.Add(24*time.Hour - 2*time.Second), day1.Add(24*time.Hour - time.Second), "203.0.113.7", "203.0.113.7", "p1", false},
+		{"other profile", day1, day1, "203.0.113.7", "203.0.113.7", "p2", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := tt.a
+			id := New(NewMemorySaltStore())
+			id.now = func() time.Time { return now }
+
+			a := id.VisitorID(request(tt.ipA), "p1")
+			now = tt.b
+			b := id.VisitorID(request(tt.ipB), tt.profB)
+			if a == "" || b == "" {
+				t.Fatalf("empty visitor ID: %q %q", a, b)
+			}
+			if (a == b) != tt.wantEqual {
+				t.Fatalf("ids %q and %q, want equal = %v", a, b, tt.wantEqual)
+			}
+		})
+	}
+}
+
+func TestVisitorIDOptOut(t *testing.T) {
+	id := New(nil)
+	for _, h := range []string{"DNT", "Sec-GPC"} {
+		r := request("203.0.113.7")
+		r.Header.Set(h, "1")
+		if v := id.VisitorID(r, "p1"); v != "" {
+			t.Errorf("%s: visitor ID %q, want none", h, v)
+		}
+	}
+}
+
+func TestMemorySaltStoreForgetsOldDays(t *testing.T) {
+	s := NewMemorySaltStore()
+	ctx := context.Background()
+	first, _ := s.Salt(ctx, "2026-03-01")
+	if again, _ := s.Salt(ctx, "2026-03-01"); string(again) != string(first) {
+		t.Fatal("salt changed within a day")
+	}
+	s.Salt(ctx, "2026-03-02")
+	if back, _ := s.Salt(ctx, "2026-03-01"); string(back) == string(first) {
+		t.Fatal("salt of a past day was kept")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseProxies("10.0.0.0/8, 192.0.2.1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloudflare, err := ParseProxies("192.0.2.1", "CF-Connecting-IP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		proxies *Proxies
+		remote  string
+		headers map[string][]string
+		want    string
+	}{
+		{"no proxies ignores headers", nil, "198.51.100.9:1234",
+			map[string][]string{"X-Forwarded-For": {"203.0.113.7"}, "Cf-Connecting-Ip": {"203.0.113.8"}}, "198.51.100.9"},
+		{"untrusted peer ignores headers", proxies, "198.51.100.9:1234",
+			map[string][]string{"X-Forwarded-For": {"203.0.113.7"}}, "198.51.100.9"},
+		{"trusted peer", proxies, "10.1.2.3:1234",
+			map[string][]string{"X-Forwarded-For": {"203.0.113.7"}}, "203.0.113.7"},
+		{"spoofed left hops", proxies, "10.1.2.3:1234",
+			map[string][]string{"X-Forwarded-For": {"1.1.1.1, 2.2.2.2, 203.0.113.7, 192.0.2.1"}}, "203.0.113.7"},
+		{"multiple headers", proxies, "10.1.2.3:1234",
+			map[string][]string{"X-Forwarded-For": {"1.1.1.1", "203.0.113.7"}}, "203.0.113.7"},
+		{"all hops trusted", proxies, "10.1.2.3:1234",
+			map[string][]string{"X-Forwarded-For": {"10.9.9.9, 192.0.2.1"}}, "10.9.9.9"},
+		{"garbage hop", proxies, "10.1.2.3:1234",
+			map[string][]string{"X-Forwarded-For": {"203.0.113.7, nope"}}, "10.1.2.3"},
+		{"no header", proxies, "10.1.2.3:1234", nil, "10.1.2.3"},
+		{"client header from trusted peer", cloudflare, "192.0.2.1:443",
+			map[string][]string{"Cf-Connecting-Ip": {"203.0.113.7"}, "X-Forwarded-For": {"1.1.1.1"}}, "203.0.113.7"},
+		{"client header from untrusted peer", cloudflare, "198.51.100.9:443",
+			map[string][]string{"Cf-Connecting-Ip": {"203.0.113.7"}}, "198.51.100.9"},
+		{"ipv6 peer", nil, "[2001:db8::1]:443", nil, "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+			for k, vs := range tt.headers {
+				for _, v := range vs {
+					r.Header.Add(k, v)
+				}
+			}
+			if got := tt.proxies.ClientIP(r); got != tt.want {
+				t.Fatalf("Proxies.ClientIP = %q, want %q", got, tt.want)
+			}
+			var got string
+			tt.proxies.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				got = ClientIP(r)
+			})).ServeHTTP(httptest.NewRecorder(), r)
+			if got != tt.want {
+				t.Fatalf("ClientIP behind middleware = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateKey(t *testing.T) {
+	tests := []struct {
+		remote string
+		want   string
+	}{
+		{"203.0.113.7:1234", "203.0.113.7"},
+		{"[2001:db8:1:2:3:4:5:6]:443", "2001:db8:1:2::/64"},
+		{"[2001:db8:1:2::ffff]:443", "2001:db8:1:2::/64"},
+		{"[::ffff:203.0.113.7]:443", "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tt.remote
+		if got := RateKey(r); got != tt.want {
+			t.Errorf("RateKey(%s) = %q, want %q", tt.remote, got, tt.want)
+		}
+	}
+}
+
+func TestParseProxiesInvalid(t *testing.T) {
+	for _, spec := range []string{"not-an-ip", "10.0.0.0/33"} {
+		if _, err := ParseProxies(spec, ""); err == nil {
+			t.Errorf("ParseProxies(%q) accepted", spec)
+		}
+	}
+}
+
+func request(ip string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = ip + ":1234"
+	r.Header.Set("User-Agent", "Mozilla/5.0 test")
+	return r
+}
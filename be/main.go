@@ -4,14 +4,43 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"biomu/backend/internal/analytics"
 	"biomu/backend/internal/auth"
+	"biomu/backend/internal/billing"
+	"biomu/backend/internal/blob"
+	"biomu/backend/internal/botfilter"
+	"biomu/backend/internal/contact"
 	"biomu/backend/internal/db"
+	"biomu/backend/internal/domain"
 	"biomu/backend/internal/email"
+	"biomu/backend/internal/enrich"
+	"biomu/backend/internal/entitlement"
+	"biomu/backend/internal/events"
+	"biomu/backend/internal/experiment"
 	"biomu/backend/internal/firebase"
+	"biomu/backend/internal/media"
+	"biomu/backend/internal/newsletter"
+	"biomu/backend/internal/page"
+	"biomu/backend/internal/profile"
+	"biomu/backend/internal/protect"
+	"biomu/backend/internal/public"
+	"biomu/backend/internal/qr"
+	"biomu/backend/internal/redirect"
+	"biomu/backend/internal/sanitize"
+	"biomu/backend/internal/schedule"
+	"biomu/backend/internal/screen"
+	"biomu/backend/internal/shop"
+	"biomu/backend/internal/targeting"
+	"biomu/backend/internal/theme"
+	"biomu/backend/internal/unfurl"
+	"biomu/backend/internal/vcard"
+	"biomu/backend/internal/visitor"
 
 	"github.com/joho/godotenv"
 )
@@ -20,6 +49,31 @@ const (
 	sessionCookieName = "session"
 	sessionDuration   = 7 * 24 * time.Hour
 	portDefault       = "8080"
+	publicBaseDefault = "https://aether.bio"
+	siteNameDefault   = "aether.bio"
+
+	rollupIntervalDefault = 5 * time.Minute
+	retentionDaysDefault  = 90
+	retentionInterval     = 24 * time.Hour
+	geoIPReloadInterval   = time.Minute
+	linkScheduleInterval  = time.Minute
+
+	experimentPromoteInterval = 10 * time.Minute
+	unlockTokenTTL            = 10 * time.Minute
+
+	mediaDirDefault       = "./data/media"
+	mediaPublicURLDefault = "/media"
+	mediaQuality          = 82
+
+	billingGraceDefault = 72 * time.Hour
+
+	shopFilesDirDefault     = "./data/products"
+	shopCurrencyDefault     = "idr"
+	shopDownloadTTLDefault  = 24 * time.Hour
+	shopAccessWindowDefault = 30 * 24 * time.Hour
+
+	acmeCacheDirDefault = "./data/acme"
+	httpsPortDefault    = "443"
 )
 
 func main() {
@@ -48,6 +102,207 @@ func main() {
 		log.Fatal("COLLECTION_ACCOUNTS or NEXT_PUBLIC_COLLECTIONS_ACCOUNTS must be set")
 	}
 
+	linksColl := os.Getenv("COLLECTION_LINKS")
+	if linksColl == "" {
+		linksColl = os.Getenv("NEXT_PUBLIC_COLLECTIONS_LINKS")
+	}
+	if linksColl == "" {
+		linksColl = "links"
+	}
+
+	eventsColl := os.Getenv("COLLECTION_EVENTS")
+	if eventsColl == "" {
+		eventsColl = "events"
+	}
+
+	rollupsColl := os.Getenv("COLLECTION_ANALYTICS")
+	if rollupsColl == "" {
+		rollupsColl = "analytics_rollups"
+	}
+	rollupInterval := rollupIntervalDefault
+	if v := os.Getenv("ANALYTICS_ROLLUP_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("invalid ANALYTICS_ROLLUP_INTERVAL %q", v)
+		}
+		rollupInterval = d
+	}
+
+	retentionDays := retentionDaysDefault
+	if v := os.Getenv("ANALYTICS_RETENTION_DAYS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			log.Fatalf("invalid ANALYTICS_RETENTION_DAYS %q", v)
+		}
+		retentionDays = n
+	}
+
+	settingsColl := os.Getenv("COLLECTION_SETTINGS")
+	if settingsColl == "" {
+		settingsColl = "settings"
+	}
+	countBots := os.Getenv("ANALYTICS_COUNT_BOTS") == "true"
+	// Koleksi yang URL-nya discreening saat Create/Update lewat /api/db (dipisah koma)
+	screenedColls := []string{linksColl}
+	if v := os.Getenv("URL_SCREENING_COLLECTIONS"); v != "" {
+		screenedColls = strings.Split(v, ",")
+	}
+
+	themesColl := os.Getenv("COLLECTION_THEMES")
+	if themesColl == "" {
+		themesColl = "themes"
+	}
+
+	subscriptionsColl := os.Getenv("COLLECTION_SUBSCRIPTIONS")
+	if subscriptionsColl == "" {
+		subscriptionsColl = "subscriptions"
+	}
+	billingGrace := billingGraceDefault
+	if v := os.Getenv("BILLING_GRACE_PERIOD"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			log.Fatalf("invalid BILLING_GRACE_PERIOD %q", v)
+		}
+		billingGrace = d
+	}
+
+	productsColl := os.Getenv("COLLECTION_PRODUCTS")
+	if productsColl == "" {
+		productsColl = "products"
+	}
+	ordersColl := os.Getenv("COLLECTION_ORDERS")
+	if ordersColl == "" {
+		ordersColl = "orders"
+	}
+	shopCurrency := strings.ToLower(os.Getenv("SHOP_CURRENCY"))
+	if shopCurrency == "" {
+		shopCurrency = shopCurrencyDefault
+	}
+	shopDownloadTTL := shopDownloadTTLDefault
+	if v := os.Getenv("SHOP_DOWNLOAD_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("invalid SHOP_DOWNLOAD_TTL %q", v)
+		}
+		shopDownloadTTL = d
+	}
+	shopAccessWindow := shopAccessWindowDefault
+	if v := os.Getenv("SHOP_ACCESS_WINDOW"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("invalid SHOP_ACCESS_WINDOW %q", v)
+		}
+		shopAccessWindow = d
+	}
+
+	subscribersColl := os.Getenv("COLLECTION_SUBSCRIBERS")
+	if subscribersColl == "" {
+		subscribersColl = "subscribers"
+	}
+
+	contactColl := os.Getenv("COLLECTION_CONTACT_MESSAGES")
+	if contactColl == "" {
+		contactColl = "contact_messages"
+	}
+	// Kata kunci spam tambahan untuk form kontak (dipisah koma), ditambahkan ke daftar bawaan
+	contactKeywords := contact.DefaultKeywords
+	if v := os.Getenv("CONTACT_SPAM_KEYWORDS"); v != "" {
+		contactKeywords = append(append([]string{}, contactKeywords...), strings.Split(v, ",")...)
+	}
+
+	domainsColl := os.Getenv("COLLECTION_DOMAINS")
+	if domainsColl == "" {
+		domainsColl = "domains"
+	}
+
+	// Media upload: "local" (default, disk; dev/test) atau "firebase" (Firebase Storage)
+	mediaColl := os.Getenv("COLLECTION_MEDIA")
+	if mediaColl == "" {
+		mediaColl = "media"
+	}
+	var mediaStore blob.Store
+	var localMedia *blob.Local
+	switch os.Getenv("MEDIA_STORE") {
+	case "", "local":
+		mediaDir := os.Getenv("MEDIA_DIR")
+		if mediaDir == "" {
+			mediaDir = mediaDirDefault
+		}
+		mediaPublicURL := os.Getenv("MEDIA_PUBLIC_URL")
+		if mediaPublicURL == "" {
+			mediaPublicURL = mediaPublicURLDefault
+		}
+		localMedia, err = blob.NewLocal(mediaDir, mediaPublicURL)
+		if err != nil {
+			log.Fatalf("media store: %v", err)
+		}
+		mediaStore = localMedia
+	case "firebase":
+		mediaStore, err = blob.NewFirebase(fb, os.Getenv("FIREBASE_STORAGE_BUCKET"))
+		if err != nil {
+			log.Fatalf("media store: %v", err)
+		}
+	default:
+		log.Fatalf("invalid MEDIA_STORE %q (local or firebase)", os.Getenv("MEDIA_STORE"))
+	}
+	// File produk digital: privat (tidak pernah dilayani langsung), backend mengikuti MEDIA_STORE
+	var shopFiles shop.Files
+	if localMedia != nil {
+		shopFilesDir := os.Getenv("SHOP_FILES_DIR")
+		if shopFilesDir == "" {
+			shopFilesDir = shopFilesDirDefault
+		}
+		shopFiles, err = blob.NewLocal(shopFilesDir, "")
+	} else {
+		shopFiles, err = blob.NewFirebase(fb, os.Getenv("FIREBASE_STORAGE_BUCKET"))
+	}
+	if err != nil {
+		log.Fatalf("shop files: %v", err)
+	}
+	// WebP hanya dihasilkan jika binary cwebp (libwebp) ada di PATH; JPEG selalu ada
+	mediaEncoders := []media.Encoder{media.JPEG{Quality: mediaQuality}}
+	if webp, ok := media.NewCWebP(mediaQuality); ok {
+		mediaEncoders = append(mediaEncoders, webp)
+	} else {
+		log.Printf("warning: cwebp not found, media variants are JPEG only")
+	}
+
+	// Salt visitor ID: "memory" (default, per instance) atau "firestore" (dibagi antar instance, TTL pendek)
+	var saltStore visitor.SaltStore = visitor.NewMemorySaltStore()
+	if os.Getenv("VISITOR_SALT_STORE") == "firestore" {
+		saltsColl := os.Getenv("COLLECTION_VISITOR_SALTS")
+		if saltsColl == "" {
+			saltsColl = "visitor_salts"
+		}
+		saltStore = visitor.NewFirestoreSaltStore(fb, saltsColl)
+	}
+
+	// IP client: header forwarding (X-Forwarded-For atau CLIENT_IP_HEADER) hanya dipercaya dari TRUSTED_PROXIES
+	proxies, err := visitor.ParseProxies(os.Getenv("TRUSTED_PROXIES"), os.Getenv("CLIENT_IP_HEADER"))
+	if err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	// GeoIP offline (.mmdb format MaxMind); tanpa file, negara diambil dari header CDN
+	// File yang belum ada saat start tetap dipantau; Watch membukanya begitu tersedia
+	geoDB, err := enrich.OpenGeoDB(os.Getenv("GEOIP_DB_PATH"))
+	if err != nil {
+		log.Printf("warning: geoip database not loaded yet, retrying: %v", err)
+	}
+	if geoDB != nil {
+		go geoDB.Watch(ctx, geoIPReloadInterval)
+	}
+	enricher := enrich.NewEnricher(geoDB)
+
+	publicBaseURL := os.Getenv("PUBLIC_BASE_URL")
+	if publicBaseURL == "" {
+		publicBaseURL = publicBaseDefault
+	}
+	siteName := os.Getenv("SITE_NAME")
+	if siteName == "" {
+		siteName = siteNameDefault
+	}
+
 	sessionSecret := os.Getenv("SESSION_SECRET")
 	if sessionSecret == "" {
 		sessionSecret = "dev-session-secret-change-in-production"
@@ -55,7 +310,150 @@ func main() {
 	}
 
 	authHandler := auth.NewHandler(fb, emailSender, accountsColl, sessionCookieName, sessionDuration, []byte(sessionSecret))
-	dbHandler := db.NewHandler(fb)
+	profileStore := profile.NewStore(fb, accountsColl, linksColl)
+
+	// Link terkunci: unlock token ditandatangani dengan key turunan SESSION_SECRET
+	linkGuard := protect.NewGuard(profileStore, authHandler, protect.NewUnlocker([]byte(sessionSecret), unlockTokenTTL))
+	go linkGuard.Run(ctx)
+	protectHandler := protect.NewHandler(profileStore, linkGuard)
+	// Screening URL anti phishing: blocklist bawaan + aturan admin, shortener diikuti lewat fetcher SSRF-safe
+	unfurlFetcher := unfurl.NewFetcher()
+	urlScreener := screen.NewScreener(fb, settingsColl, screenedColls, unfurlFetcher)
+	go urlScreener.Run(ctx)
+	screenHandler := screen.NewHandler(fb, urlScreener, profileStore, authHandler)
+	// Batas per tier (reguler/membership) untuk tulis lewat /api/db
+	entitlements := entitlement.NewChecker(fb, profileStore, entitlement.DefaultPolicy(accountsColl, linksColl))
+	go entitlements.Run(ctx)
+	entitlementHandler := entitlement.NewHandler(entitlements, authHandler)
+	dbHandler := db.NewHandler(fb, authHandler, linksColl, linkGuard, urlScreener, entitlements)
+	// Visitor ID ter-hash (salt harian), dipakai analytics dan assignment A/B test
+	visitors := visitor.New(saltStore)
+	// A/B test link: counter impression/klik ber-shard, pemenang dipromosikan otomatis
+	variantCounters := experiment.NewCounters(fb, linksColl)
+	go variantCounters.Run(ctx)
+	promoter := experiment.NewPromoter(fb, linksColl, variantCounters, experimentPromoteInterval)
+	go promoter.Run(ctx)
+	experimentHandler := experiment.NewHandler(fb, profileStore, authHandler, variantCounters)
+
+	publicHandler := public.NewHandler(profileStore, visitors)
+	themeStore := theme.NewStore(fb, themesColl, accountsColl)
+	themeHandler := theme.NewHandler(themeStore, authHandler)
+	customHandler := sanitize.NewHandler(fb, profileStore, authHandler)
+	// Billing membership: "" (nonaktif), "fake" (dev/test) atau "stripe"
+	var billingProvider billing.Provider
+	switch os.Getenv("BILLING_PROVIDER") {
+	case "":
+	case "fake":
+		secret := os.Getenv("BILLING_WEBHOOK_SECRET")
+		if secret == "" {
+			secret = "dev-billing-secret"
+			log.Printf("warning: BILLING_WEBHOOK_SECRET not set, using default (dev only)")
+		}
+		billingProvider = billing.NewFake([]byte(secret))
+	case "stripe":
+		billingProvider, err = billing.NewStripe(os.Getenv("STRIPE_SECRET_KEY"), os.Getenv("STRIPE_WEBHOOK_SECRET"), os.Getenv("STRIPE_PRICE_ID"))
+		if err != nil {
+			log.Fatalf("billing: %v", err)
+		}
+	default:
+		log.Fatalf("invalid BILLING_PROVIDER %q (fake or stripe)", os.Getenv("BILLING_PROVIDER"))
+	}
+	var billingHandler *billing.Handler
+	if billingProvider != nil {
+		billingStore := billing.NewStore(fb, subscriptionsColl, subscriptionsColl+"_events", accountsColl, billingGrace)
+		go billingStore.Run(ctx)
+		returnURL := os.Getenv("BILLING_RETURN_URL")
+		if returnURL == "" {
+			returnURL = strings.TrimRight(publicBaseURL, "/") + "/settings/billing"
+		}
+		billingHandler = billing.NewHandler(billingStore, billingProvider, profileStore, authHandler, returnURL)
+	}
+
+	// Produk digital dan tip: "" (nonaktif), "fake" (dev/test) atau "stripe"
+	var shopProvider shop.Provider
+	switch os.Getenv("SHOP_PROVIDER") {
+	case "":
+	case "fake":
+		secret := os.Getenv("SHOP_WEBHOOK_SECRET")
+		if secret == "" {
+			secret = "dev-shop-secret"
+			log.Printf("warning: SHOP_WEBHOOK_SECRET not set, using default (dev only)")
+		}
+		shopProvider = shop.NewFake([]byte(secret))
+	case "stripe":
+		shopProvider, err = shop.NewStripe(os.Getenv("STRIPE_SECRET_KEY"), os.Getenv("STRIPE_SHOP_WEBHOOK_SECRET"))
+		if err != nil {
+			log.Fatalf("shop: %v", err)
+		}
+	default:
+		log.Fatalf("invalid SHOP_PROVIDER %q (fake or stripe)", os.Getenv("SHOP_PROVIDER"))
+	}
+	var shopStore *shop.Store
+	var shopHandler *shop.Handler
+	if shopProvider != nil {
+		shopStore = shop.NewStore(fb, productsColl, ordersColl, ordersColl+"_events", shopFiles, []byte(sessionSecret), shopCurrency, shopDownloadTTL, shopAccessWindow)
+		go shopStore.Run(ctx)
+		shopHandler = shop.NewHandler(shopStore, shopProvider, profileStore, authHandler, emailSender, publicBaseURL, siteName)
+	}
+
+	// Custom domain: verifikasi TXT, dicek ulang berkala; host platform tidak bisa diklaim
+	platformHosts := []string{}
+	if u, err := url.Parse(publicBaseURL); err == nil && u.Host != "" {
+		platformHosts = append(platformHosts, u.Host)
+	}
+	if v := os.Getenv("PLATFORM_HOSTS"); v != "" {
+		platformHosts = append(platformHosts, strings.Split(v, ",")...)
+	}
+	// Newsletter: signup double opt-in dari halaman bio, subscriber pending dihapus setelah 7 hari
+	newsletterStore := newsletter.NewStore(fb, subscribersColl, []byte(sessionSecret))
+	go newsletterStore.Run(ctx)
+	newsletterHandler := newsletter.NewHandler(newsletterStore, profileStore, authHandler, emailSender, publicBaseURL, siteName)
+	go newsletterHandler.Run(ctx)
+
+	// Form kontak: pesan disimpan di inbox pemilik dan diteruskan ke email akun (kecuali spam)
+	contactStore := contact.NewStore(fb, contactColl, []byte(sessionSecret))
+	go contactStore.Run(ctx)
+	contactHandler := contact.NewHandler(contactStore, contact.NewScorer(contactKeywords), profileStore, authHandler, authHandler, emailSender, publicBaseURL, siteName)
+	go contactHandler.Run(ctx)
+
+	domainStore := domain.NewStore(fb, domainsColl, profileStore, domain.NewResolver(os.Getenv("DNS_RESOLVER")), []byte(sessionSecret), platformHosts)
+	go domainStore.Run(ctx)
+	domainHandler := domain.NewHandler(domainStore, profileStore, authHandler)
+	pageHandler := page.NewHandler(profileStore, visitors, themeStore, domainStore, shopStore, publicBaseURL, siteName)
+	// QR code profil/link (PNG/SVG), logo avatar diambil lewat fetcher SSRF-safe
+	qrHandler := qr.NewHandler(profileStore, unfurlFetcher, publicBaseURL)
+	// Kartu kontak vCard (avatar di-embed lewat fetcher SSRF-safe) dan blok event (.ics)
+	vcardHandler := vcard.NewHandler(profileStore, domainStore, unfurlFetcher, publicBaseURL)
+	eventsHandler := events.NewHandler(fb, profileStore, authHandler, publicBaseURL, siteName)
+
+	// Link terjadwal: event "tayang"/"kedaluwarsa" dikirim ke pemilik lewat email
+	linkScheduler := schedule.NewScheduler(fb, linksColl, linkScheduleInterval)
+	linkScheduler.OnEvent(schedule.EmailNotifier(emailSender, profileStore))
+	go linkScheduler.Run(ctx)
+
+	// Event analytics ditulis async dalam batch (redirect tidak menunggu Firestore)
+	eventRecorder := analytics.NewRecorder(fb, eventsColl, linksColl)
+	go eventRecorder.Run(ctx)
+
+	// Filter bot/crawler: pola UA bawaan, bisa ditimpa admin lewat Firestore tanpa redeploy
+	botClassifier := botfilter.NewClassifier(fb, settingsColl)
+	go botClassifier.Run(ctx)
+	botHandler := botfilter.NewHandler(botClassifier, profileStore, authHandler)
+	redirectHandler := redirect.NewHandler(profileStore, eventRecorder, visitors, botClassifier, variantCounters, linkGuard, urlScreener, countBots)
+	targetingHandler := targeting.NewHandler(profileStore, authHandler, enricher)
+	// Unfurl URL saat user menambah link (fetch keluar dengan proteksi SSRF, hasil di-cache di memori)
+	unfurlHandler := unfurl.NewHandler(unfurl.NewUnfurler(unfurlFetcher), authHandler)
+	mediaHandler := media.NewHandler(fb, authHandler, mediaStore, media.NewProcessor(mediaEncoders...), mediaColl, accountsColl)
+
+	// Retensi: raw event dihapus setelah ANALYTICS_RETENTION_DAYS hari
+	purger := analytics.NewPurger(fb, eventsColl, time.Duration(retentionDays)*24*time.Hour, retentionInterval)
+	go purger.Run(ctx)
+
+	// Rollup analytics per jam/hari (idempotent, bisa di-backfill)
+	aggregator := analytics.NewAggregator(fb, eventsColl, rollupsColl, rollupInterval)
+	go aggregator.Run(ctx)
+	analyticsHandler := analytics.NewHandler(fb, profileStore, authHandler, eventRecorder, aggregator, visitors, botClassifier, variantCounters)
+	go analyticsHandler.Run(ctx)
 
 	mux := http.NewServeMux()
 
@@ -66,6 +464,46 @@ func main() {
 	mux.HandleFunc("OPTIONS /api/auth/verify-otp", opt)
 	mux.HandleFunc("OPTIONS /api/auth/session", opt)
 	mux.HandleFunc("OPTIONS /api/auth/logout", opt)
+	mux.HandleFunc("OPTIONS /api/account/handle", opt)
+	mux.HandleFunc("OPTIONS /api/account/email", opt)
+	mux.HandleFunc("OPTIONS /api/account/email/verify", opt)
+	mux.HandleFunc("OPTIONS /api/public/events", opt)
+	mux.HandleFunc("OPTIONS /api/admin/bot-patterns", opt)
+	mux.HandleFunc("OPTIONS /api/links/{linkId}/targeting/preview", opt)
+	mux.HandleFunc("OPTIONS /api/links/{linkId}/experiment/promote", opt)
+	mux.HandleFunc("OPTIONS /api/links/{linkId}/unlock", opt)
+	mux.HandleFunc("OPTIONS /api/links/unfurl", opt)
+	mux.HandleFunc("OPTIONS /api/admin/moderation/{collection}/{id}", opt)
+	mux.HandleFunc("OPTIONS /api/moderation/{collection}/{id}/appeal", opt)
+	mux.HandleFunc("OPTIONS /api/admin/url-blocklist", opt)
+	mux.HandleFunc("OPTIONS /api/media", opt)
+	mux.HandleFunc("OPTIONS /api/media/{id}", opt)
+	mux.HandleFunc("OPTIONS /api/themes", opt)
+	mux.HandleFunc("OPTIONS /api/themes/{id}", opt)
+	mux.HandleFunc("OPTIONS /api/themes/{id}/apply", opt)
+	mux.HandleFunc("OPTIONS /api/profile/custom", opt)
+	mux.HandleFunc("OPTIONS /api/domains", opt)
+	mux.HandleFunc("OPTIONS /api/entitlements", opt)
+	mux.HandleFunc("OPTIONS /api/billing/checkout", opt)
+	mux.HandleFunc("OPTIONS /api/billing/subscription", opt)
+	mux.HandleFunc("OPTIONS /api/billing/fake/events", opt)
+	mux.HandleFunc("OPTIONS /api/shop/products", opt)
+	mux.HandleFunc("OPTIONS /api/shop/products/{id}", opt)
+	mux.HandleFunc("OPTIONS /api/shop/products/{id}/file", opt)
+	mux.HandleFunc("OPTIONS /api/shop/orders", opt)
+	mux.HandleFunc("OPTIONS /api/shop/orders/{orderId}", opt)
+	mux.HandleFunc("OPTIONS /api/shop/checkout", opt)
+	mux.HandleFunc("OPTIONS /api/shop/fake/events", opt)
+	mux.HandleFunc("OPTIONS /api/public/{handle}/subscribe", opt)
+	mux.HandleFunc("OPTIONS /api/newsletter/subscribers", opt)
+	mux.HandleFunc("OPTIONS /api/newsletter/subscribers.csv", opt)
+	mux.HandleFunc("OPTIONS /api/newsletter/subscribers/{id}", opt)
+	mux.HandleFunc("OPTIONS /api/public/{handle}/contact", opt)
+	mux.HandleFunc("OPTIONS /api/contact/messages", opt)
+	mux.HandleFunc("OPTIONS /api/profile/events", opt)
+	mux.HandleFunc("OPTIONS /api/contact/messages/{id}", opt)
+	mux.HandleFunc("OPTIONS /api/domains/{domain}", opt)
+	mux.HandleFunc("OPTIONS /api/domains/{domain}/verify", opt)
 
 	mux.HandleFunc("POST /api/auth/verification", authHandler.Verification)
 	mux.HandleFunc("POST /api/auth/signup", authHandler.Signup)
@@ -73,6 +511,9 @@ func main() {
 	mux.HandleFunc("POST /api/auth/session", authHandler.Session)
 	mux.HandleFunc("GET /api/auth/session", authHandler.SessionGet)
 	mux.HandleFunc("POST /api/auth/logout", authHandler.Logout)
+	mux.HandleFunc("PUT /api/account/handle", authHandler.UpdateHandle)
+	mux.HandleFunc("POST /api/account/email", authHandler.RequestEmailChange)
+	mux.HandleFunc("POST /api/account/email/verify", authHandler.VerifyEmailChange)
 
 	// Generic Firestore CRUD (Go 1.22 pattern matching)
 	mux.HandleFunc("GET /api/db/{collection}", dbHandler.List)
@@ -81,13 +522,171 @@ func main() {
 	mux.HandleFunc("PATCH /api/db/{collection}/{id}", dbHandler.Update)
 	mux.HandleFunc("PUT /api/db/{collection}/{id}", dbHandler.Update)
 	mux.HandleFunc("DELETE /api/db/{collection}/{id}", dbHandler.Delete)
+	mux.HandleFunc("GET /api/entitlements", entitlementHandler.Get)
+
+	// Public (tanpa session, cache-friendly untuk CDN)
+	mux.HandleFunc("GET /api/public/{handle}", publicHandler.Profile)
+	mux.HandleFunc("POST /api/public/events", analyticsHandler.Beacon)
+	mux.HandleFunc("GET /api/qr", qrHandler.QR)
+
+	// Analytics (pemilik profil atau admin)
+	mux.HandleFunc("GET /api/analytics/{profileId}", analyticsHandler.Query)
+	mux.HandleFunc("POST /api/analytics/backfill", analyticsHandler.Backfill)
+
+	// Admin
+	mux.HandleFunc("GET /api/admin/bot-patterns", botHandler.Get)
+	mux.HandleFunc("PUT /api/admin/bot-patterns", botHandler.Put)
+
+	// Moderasi URL: antrean review, keputusan admin, blocklist, dan banding oleh pemilik
+	mux.HandleFunc("GET /api/admin/moderation", screenHandler.Queue)
+	mux.HandleFunc("POST /api/admin/moderation/{collection}/{id}", screenHandler.Review)
+	mux.HandleFunc("GET /api/admin/url-blocklist", screenHandler.GetBlocklist)
+	mux.HandleFunc("PUT /api/admin/url-blocklist", screenHandler.PutBlocklist)
+	mux.HandleFunc("POST /api/moderation/{collection}/{id}/appeal", screenHandler.Appeal)
+
+	// Preview metadata URL (title, OG image, favicon, oEmbed) untuk form tambah link
+	mux.HandleFunc("POST /api/links/unfurl", unfurlHandler.Unfurl)
+
+	// Tema halaman bio: preset + tema milik akun; {id}.css = CSS variables (cache lama dengan ?v=)
+	mux.HandleFunc("GET /api/themes", themeHandler.List)
+	mux.HandleFunc("POST /api/themes", themeHandler.Create)
+	mux.HandleFunc("GET /api/themes/{id}", themeHandler.Get)
+	mux.HandleFunc("PUT /api/themes/{id}", themeHandler.Update)
+	mux.HandleFunc("DELETE /api/themes/{id}", themeHandler.Delete)
+	mux.HandleFunc("POST /api/themes/{id}/apply", themeHandler.Apply)
+
+	// Custom CSS dan blok rich text (status membership), disanitasi sebelum disimpan
+	mux.HandleFunc("GET /api/profile/custom", customHandler.Get)
+	mux.HandleFunc("PUT /api/profile/custom", customHandler.Put)
+
+	// Billing: checkout langganan, webhook provider (HMAC), state langganan caller
+	if billingHandler != nil {
+		mux.HandleFunc("POST /api/billing/checkout", billingHandler.Checkout)
+		mux.HandleFunc("GET /api/billing/subscription", billingHandler.Subscription)
+		mux.HandleFunc("POST /api/billing/webhook", billingHandler.Webhook)
+		if billingProvider.Name() == "fake" {
+			mux.HandleFunc("POST /api/billing/fake/events", billingHandler.FakeEvent)
+		}
+	}
+
+	// Produk digital dan tip: checkout dari halaman bio, webhook provider (HMAC), tanda terima
+	// pembeli dan unduhan bertanda tangan dengan batas waktu dan jumlah
+	if shopHandler != nil {
+		mux.HandleFunc("GET /api/shop/products", shopHandler.Products)
+		mux.HandleFunc("POST /api/shop/products", shopHandler.CreateProduct)
+		mux.HandleFunc("PUT /api/shop/products/{id}", shopHandler.UpdateProduct)
+		mux.HandleFunc("DELETE /api/shop/products/{id}", shopHandler.DeleteProduct)
+		mux.HandleFunc("POST /api/shop/products/{id}/file", shopHandler.UploadFile)
+		mux.HandleFunc("GET /api/shop/orders", shopHandler.Sales)
+		mux.HandleFunc("GET /api/shop/orders/{orderId}", shopHandler.Order)
+		mux.HandleFunc("POST /api/shop/checkout", shopHandler.Checkout)
+		mux.HandleFunc("POST /api/shop/webhook", shopHandler.Webhook)
+		if shopProvider.Name() == "fake" {
+			mux.HandleFunc("POST /api/shop/fake/events", shopHandler.FakeEvent)
+		}
+		mux.HandleFunc("GET /orders/{orderId}", shopHandler.Receipt)
+		mux.HandleFunc("GET /d/{orderId}", shopHandler.Download)
+	}
+
+	// Newsletter: signup dari halaman bio (double opt-in), konfirmasi/unsubscribe lewat link
+	// bertanda tangan, daftar dan ekspor CSV untuk pemilik
+	mux.HandleFunc("POST /api/public/{handle}/subscribe", newsletterHandler.Subscribe)
+	mux.HandleFunc("GET /newsletter/confirm", newsletterHandler.Confirm)
+	mux.HandleFunc("POST /newsletter/confirm", newsletterHandler.Confirm)
+	mux.HandleFunc("GET /newsletter/unsubscribe", newsletterHandler.Unsubscribe)
+	mux.HandleFunc("POST /newsletter/unsubscribe", newsletterHandler.Unsubscribe)
+	mux.HandleFunc("GET /api/newsletter/subscribers", newsletterHandler.Subscribers)
+	mux.HandleFunc("GET /api/newsletter/subscribers.csv", newsletterHandler.Export)
+	mux.HandleFunc("DELETE /api/newsletter/subscribers/{id}", newsletterHandler.Delete)
+
+	// Form kontak: pesan pengunjung tanpa membuka email kreator; inbox untuk pemilik
+	mux.HandleFunc("POST /api/public/{handle}/contact", contactHandler.Contact)
+	mux.HandleFunc("GET /api/contact/messages", contactHandler.Messages)
+	mux.HandleFunc("PATCH /api/contact/messages/{id}", contactHandler.Update)
+	mux.HandleFunc("DELETE /api/contact/messages/{id}", contactHandler.Delete)
+
+	// Kartu kontak dan event: vCard 4.0, file .ics per event dan feed kalender per profil
+	mux.HandleFunc("GET /api/public/{handle}/contact.vcf", vcardHandler.Card)
+	mux.HandleFunc("GET /api/profile/events", eventsHandler.Get)
+	mux.HandleFunc("PUT /api/profile/events", eventsHandler.Put)
+	mux.HandleFunc("GET /api/public/{handle}/events.ics", eventsHandler.Feed)
+	mux.HandleFunc("GET /api/public/{handle}/events/{file}", eventsHandler.Event)
+
+	// Custom domain (status membership): klaim → pasang record TXT → verifikasi
+	mux.HandleFunc("GET /api/domains", domainHandler.List)
+	mux.HandleFunc("POST /api/domains", domainHandler.Create)
+	mux.HandleFunc("POST /api/domains/{domain}/verify", domainHandler.Verify)
+	mux.HandleFunc("DELETE /api/domains/{domain}", domainHandler.Delete)
+
+	// Upload gambar (avatar/thumbnail) → variant JPEG/WebP + blurhash
+	mux.HandleFunc("POST /api/media", mediaHandler.Upload)
+	mux.HandleFunc("DELETE /api/media/{id}", mediaHandler.Delete)
+	if localMedia != nil {
+		mux.Handle("GET /media/", http.StripPrefix("/media/", localMedia.Handler()))
+	}
+
+	// Targeting link: uji aturan untuk UA/IP tertentu (pemilik link atau admin)
+	mux.HandleFunc("POST /api/links/{linkId}/targeting/preview", targetingHandler.Preview)
+
+	// A/B test link (pemilik link atau admin)
+	mux.HandleFunc("GET /api/links/{linkId}/experiment", experimentHandler.Results)
+	mux.HandleFunc("POST /api/links/{linkId}/experiment/promote", experimentHandler.Promote)
+
+	// Link terkunci: unlock (password/konfirmasi 18+/session) dan interstitial-nya
+	mux.HandleFunc("POST /api/links/{linkId}/unlock", protectHandler.Unlock)
+	mux.HandleFunc("GET /unlock/{linkId}", pageHandler.Unlock)
+
+	// oEmbed provider dan widget iframe profil untuk dipasang di situs lain
+	mux.HandleFunc("GET /oembed", pageHandler.OEmbed)
+	mux.HandleFunc("GET /embed/{handle}", pageHandler.Embed)
+	mux.HandleFunc("GET /embed.js", pageHandler.EmbedScript)
+
+	// Redirect link dengan click tracking
+	mux.HandleFunc("GET /r/{linkId}", redirectHandler.Link)
+	mux.HandleFunc("GET /go/{linkId}", redirectHandler.Targeted)
+
+	// Halaman bio server-rendered (Open Graph untuk crawler sosial media)
+	mux.HandleFunc("GET /{handle}", pageHandler.Bio)
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = portDefault
 	}
+	// Custom domain verified → halaman bio pemiliknya di "/"
+	handler := corsMiddleware(proxies.Middleware(enricher.Middleware(domain.NewRouter(domainStore).Middleware(mux))))
+
+	// Sertifikat TLS otomatis (ACME) untuk custom domain; ACME_DIRECTORY_URL bisa diarahkan ke Pebble
+	if os.Getenv("ACME_ENABLED") == "true" {
+		cacheDir := os.Getenv("ACME_CACHE_DIR")
+		if cacheDir == "" {
+			cacheDir = acmeCacheDirDefault
+		}
+		certManager, err := domain.NewCertManager(domainStore, domain.ACMEConfig{
+			DirectoryURL: os.Getenv("ACME_DIRECTORY_URL"),
+			CACertFile:   os.Getenv("ACME_CA_CERT"),
+			Email:        os.Getenv("ACME_EMAIL"),
+			CacheDir:     cacheDir,
+		})
+		if err != nil {
+			log.Fatalf("acme: %v", err)
+		}
+		httpsPort := os.Getenv("HTTPS_PORT")
+		if httpsPort == "" {
+			httpsPort = httpsPortDefault
+		}
+		tlsServer := &http.Server{Addr: ":" + httpsPort, Handler: handler, TLSConfig: certManager.TLSConfig()}
+		go func() {
+			log.Printf("backend listening (TLS) on :%s", httpsPort)
+			if err := tlsServer.ListenAndServeTLS("", ""); err != nil {
+				log.Fatalf("tls server: %v", err)
+			}
+		}()
+		// Challenge http-01 dijawab di port HTTP, request lain diteruskan seperti biasa
+		handler = certManager.HTTPHandler(handler)
+	}
+
 	log.Printf("backend listening on :%s", port)
-	if err := http.ListenAndServe(":"+port, corsMiddleware(mux)); err != nil {
+	if err := http.ListenAndServe(":"+port, handler); err != nil {
 		log.Fatalf("server: %v", err)
 	}
 }
@@ -105,7 +704,7 @@ func corsMiddleware(next http.Handler) http.Handler {
 		// Selalu pakai satu origin dari env.
 		w.Header().Set("Access-Control-Allow-Origin", originEnv)
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Unlock-Token")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)